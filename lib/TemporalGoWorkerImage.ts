@@ -212,8 +212,12 @@ COPY . .
 ARG CGO_ENABLED=0
 ARG GOOS=linux
 ARG GOARCH=amd64
+ARG VERSION=v1.0.0
+ARG GIT_COMMIT=
 RUN CGO_ENABLED=\${CGO_ENABLED} GOOS=\${GOOS} GOARCH=\${GOARCH} \\
-    go build -a -installsuffix cgo -ldflags '-extldflags "-static"' -o worker .
+    go build -a -installsuffix cgo \\
+    -ldflags "-extldflags '-static' -X main.version=\${VERSION} -X main.gitCommit=\${GIT_COMMIT}" \\
+    -o worker .
 
 # Runtime stage
 ARG RUNTIME_IMAGE=alpine:latest
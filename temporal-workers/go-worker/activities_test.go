@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// cacheCall is one call made to mockCache
+type cacheCall struct {
+	Method string
+	Key    string
+	Data   interface{}
+	TTL    time.Duration
+}
+
+// mockCache records its calls and fails them all with err, if set
+type mockCache struct {
+	calls []cacheCall
+	err   error
+}
+
+func (c *mockCache) Store(ctx context.Context, key string, data interface{}, ttl time.Duration) error {
+	c.calls = append(c.calls, cacheCall{Method: "Store", Key: key, Data: data, TTL: ttl})
+	return c.err
+}
+
+func (c *mockCache) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	c.calls = append(c.calls, cacheCall{Method: "Retrieve", Key: key})
+	return "cached", c.err
+}
+
+func (c *mockCache) Delete(ctx context.Context, key string) error {
+	c.calls = append(c.calls, cacheCall{Method: "Delete", Key: key})
+	return c.err
+}
+
+func TestCacheOperationUsesCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     CacheOperationInput
+		cacheErr  error
+		wantCalls []cacheCall
+		wantErr   string
+	}{
+		{
+			name:      "store",
+			input:     CacheOperationInput{Operation: "store", Key: "dataset-1", Data: "payload", TTL: 60},
+			wantCalls: []cacheCall{{Method: "Store", Key: "dataset-1", Data: "payload", TTL: time.Minute}},
+		},
+		{
+			name:      "retrieve",
+			input:     CacheOperationInput{Operation: "retrieve", Key: "dataset-1"},
+			wantCalls: []cacheCall{{Method: "Retrieve", Key: "dataset-1"}},
+		},
+		{
+			name:      "delete",
+			input:     CacheOperationInput{Operation: "delete", Key: "dataset-1"},
+			wantCalls: []cacheCall{{Method: "Delete", Key: "dataset-1"}},
+		},
+		{
+			name:      "cache error",
+			input:     CacheOperationInput{Operation: "delete", Key: "dataset-1"},
+			cacheErr:  errors.New("cache unavailable"),
+			wantCalls: []cacheCall{{Method: "Delete", Key: "dataset-1"}},
+			wantErr:   "cache unavailable",
+		},
+		{
+			name:    "unsupported operation",
+			input:   CacheOperationInput{Operation: "flush", Key: "dataset-1"},
+			wantErr: "unsupported cache operation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mockCache{err: tt.cacheErr}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(&Activities{Cache: cache})
+
+			_, err := env.ExecuteActivity(activities.CacheOperation, tt.input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, cache.calls)
+		})
+	}
+}
+
+// TestProcessingMetricsJSON checks ProcessingMetrics against the
+// map[string]float64 it replaced: payloads either one wrote decode to the
+// same figures under the other
+func TestProcessingMetricsJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		// old is how the map encoded the metrics
+		old  map[string]float64
+		want ProcessingMetrics
+	}{
+		{
+			name: "processing",
+			old:  map[string]float64{"throughput": 1250, "cpu_utilization": 0.8, "memory_usage": 0.65},
+			want: ProcessingMetrics{Throughput: 1250, CPUUtilization: 0.8, MemoryUsage: 0.65},
+		},
+		{
+			name: "optimization",
+			old:  map[string]float64{"throughput": 1500, "cpu_utilization": 0.72, "memory_usage": 0.55, "cache_hit_rate": 0.9},
+			want: ProcessingMetrics{Throughput: 1500, CPUUtilization: 0.72, MemoryUsage: 0.55, CacheHitRate: 0.9},
+		},
+		{
+			name: "optimization without cache hits",
+			old:  map[string]float64{"throughput": 1500, "cpu_utilization": 0.72, "memory_usage": 0.55, "cache_hit_rate": 0},
+			want: ProcessingMetrics{Throughput: 1500, CPUUtilization: 0.72, MemoryUsage: 0.55},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldJSON, err := json.Marshal(tt.old)
+			require.NoError(t, err)
+
+			var decoded ProcessingMetrics
+			require.NoError(t, json.Unmarshal(oldJSON, &decoded))
+			assert.Equal(t, tt.want, decoded)
+
+			newJSON, err := json.Marshal(tt.want)
+			require.NoError(t, err)
+			if _, ok := tt.old["cache_hit_rate"]; ok {
+				assert.JSONEq(t, string(oldJSON), string(newJSON))
+			}
+			var asMap map[string]float64
+			require.NoError(t, json.Unmarshal(newJSON, &asMap))
+			for _, key := range []string{"throughput", "cpu_utilization", "memory_usage", "cache_hit_rate"} {
+				assert.Equal(t, tt.old[key], asMap[key], key)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// parseActivityConcurrency parses ACTIVITY_CONCURRENCY, a comma-separated
+// list of ActivityName=limit pairs
+func parseActivityConcurrency(spec string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("activity concurrency %q: want Name=limit", entry)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("activity concurrency %q: limit must be a positive integer", entry)
+		}
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits, nil
+}
+
+// activityConcurrencyInterceptor caps how many executions of an activity
+// type run at once, independently of the worker-wide limit, so a heavy
+// activity can't take every slot. Executions over the cap wait for a
+// running one to finish; they hold a worker slot while waiting, so keep the
+// worker-wide limit above the sum of the per-type caps.
+type activityConcurrencyInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	slots map[string]chan struct{}
+}
+
+func newActivityConcurrencyInterceptor(limits map[string]int) *activityConcurrencyInterceptor {
+	slots := make(map[string]chan struct{}, len(limits))
+	for name, limit := range limits {
+		slots[name] = make(chan struct{}, limit)
+	}
+	return &activityConcurrencyInterceptor{slots: slots}
+}
+
+func (i *activityConcurrencyInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	a := &activityConcurrencyActivityInterceptor{root: i}
+	a.Next = next
+	return a
+}
+
+type activityConcurrencyActivityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	root *activityConcurrencyInterceptor
+}
+
+func (a *activityConcurrencyActivityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	name := activity.GetInfo(ctx).ActivityType.Name
+	slots, limited := a.root.slots[name]
+	if !limited {
+		return a.Next.ExecuteActivity(ctx, in)
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-slots }()
+	return a.Next.ExecuteActivity(ctx, in)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestParseActivityConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]int
+		wantErr string
+	}{
+		{name: "empty", want: map[string]int{}},
+		{name: "pairs", spec: " ProcessLargeDataset=2, NormalizeEncoding = 1 ,", want: map[string]int{"ProcessLargeDataset": 2, "NormalizeEncoding": 1}},
+		{name: "missing limit", spec: "ProcessLargeDataset", wantErr: `activity concurrency "ProcessLargeDataset": want Name=limit`},
+		{name: "zero limit", spec: "ProcessLargeDataset=0", wantErr: "limit must be a positive integer"},
+		{name: "not a number", spec: "ProcessLargeDataset=two", wantErr: "limit must be a positive integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseActivityConcurrency(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// nextActivity stands in for the rest of the interceptor chain
+type nextActivity struct {
+	interceptor.ActivityInboundInterceptorBase
+	execute func(ctx context.Context) (interface{}, error)
+}
+
+func (n *nextActivity) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	return n.execute(ctx)
+}
+
+// runInActivity runs fn inside an activity of the given type, where
+// activity.GetInfo works
+func runInActivity(t *testing.T, activityType string, fn func(ctx context.Context)) {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(func(ctx context.Context) error {
+		fn(ctx)
+		return nil
+	}, activity.RegisterOptions{Name: activityType})
+	_, err := env.ExecuteActivity(activityType)
+	require.NoError(t, err)
+}
+
+func TestActivityConcurrencyInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  map[string]int
+		wantMax int32
+	}{
+		{name: "limited", limits: map[string]int{"Heavy": 2}, wantMax: 2},
+		{name: "other types unlimited", limits: map[string]int{"Other": 1}, wantMax: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newActivityConcurrencyInterceptor(tt.limits)
+			var running, maxRunning atomic.Int32
+			next := &nextActivity{execute: func(ctx context.Context) (interface{}, error) {
+				n := running.Add(1)
+				for {
+					max := maxRunning.Load()
+					if n <= max || maxRunning.CompareAndSwap(max, n) {
+						break
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+				running.Add(-1)
+				return "done", nil
+			}}
+
+			runInActivity(t, "Heavy", func(ctx context.Context) {
+				a := limiter.InterceptActivity(ctx, next)
+				var wg sync.WaitGroup
+				for i := 0; i < 5; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						result, err := a.ExecuteActivity(ctx, &interceptor.ExecuteActivityInput{})
+						assert.NoError(t, err)
+						assert.Equal(t, "done", result)
+					}()
+				}
+				wg.Wait()
+			})
+			assert.Equal(t, tt.wantMax, maxRunning.Load())
+		})
+	}
+}
+
+// TestActivityConcurrencyInterceptorCancelled checks an execution waiting
+// for a slot gives up when its context ends
+func TestActivityConcurrencyInterceptorCancelled(t *testing.T) {
+	limiter := newActivityConcurrencyInterceptor(map[string]int{"Heavy": 1})
+	limiter.slots["Heavy"] <- struct{}{}
+	called := false
+	next := &nextActivity{execute: func(ctx context.Context) (interface{}, error) {
+		called = true
+		return nil, nil
+	}}
+
+	runInActivity(t, "Heavy", func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := limiter.InterceptActivity(ctx, next).ExecuteActivity(ctx, &interceptor.ExecuteActivityInput{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	assert.False(t, called)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// TimeoutStrategy says which of an activity's timeouts bounds it. Workflows
+// must pick one explicitly; setting StartToClose when the whole retry loop
+// should be bounded, or the reverse, is an easy mistake.
+type TimeoutStrategy string
+
+const (
+	// TimeoutBoundedTotal bounds all attempts and the backoff between them
+	// (ScheduleToCloseTimeout). Use it when the caller needs an answer by
+	// a deadline.
+	TimeoutBoundedTotal TimeoutStrategy = "bounded-total"
+	// TimeoutBoundedAttempt bounds each attempt (StartToCloseTimeout); the
+	// retry policy's MaximumAttempts bounds the total. Use it for work
+	// whose attempts may hang but that should keep retrying.
+	TimeoutBoundedAttempt TimeoutStrategy = "bounded-attempt"
+)
+
+// ActivityTimeoutConfig chooses an activity's timeout strategy and the
+// timeout it applies to
+type ActivityTimeoutConfig struct {
+	Strategy TimeoutStrategy `json:"strategy"`
+	Timeout  time.Duration   `json:"timeout"`
+}
+
+// newActivityOptions builds activity options from a timeout config and
+// retry policy, rejecting configs that leave an activity unbounded
+func newActivityOptions(config ActivityTimeoutConfig, retryPolicy *temporal.RetryPolicy) (workflow.ActivityOptions, error) {
+	options := workflow.ActivityOptions{RetryPolicy: retryPolicy}
+	if config.Timeout <= 0 {
+		return options, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("activity timeout must be positive, got %s", config.Timeout),
+			ErrTypeInvalidActivityOptions, nil)
+	}
+	switch config.Strategy {
+	case TimeoutBoundedTotal:
+		options.ScheduleToCloseTimeout = config.Timeout
+	case TimeoutBoundedAttempt:
+		if retryPolicy == nil || retryPolicy.MaximumAttempts <= 0 {
+			return options, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("%s needs a retry policy with MaximumAttempts; without one retries never end", TimeoutBoundedAttempt),
+				ErrTypeInvalidActivityOptions, nil)
+		}
+		options.StartToCloseTimeout = config.Timeout
+	case "":
+		return options, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("activity timeout strategy is required (%s or %s)", TimeoutBoundedTotal, TimeoutBoundedAttempt),
+			ErrTypeInvalidActivityOptions, nil)
+	default:
+		return options, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unknown activity timeout strategy %q (want %s or %s)", config.Strategy, TimeoutBoundedTotal, TimeoutBoundedAttempt),
+			ErrTypeInvalidActivityOptions, nil)
+	}
+	return options, nil
+}
+
+// newHeartbeatActivityOptions is newActivityOptions for activities that
+// heartbeat at the given interval
+func newHeartbeatActivityOptions(config ActivityTimeoutConfig, retryPolicy *temporal.RetryPolicy, interval time.Duration) (workflow.ActivityOptions, error) {
+	options, err := newActivityOptions(config, retryPolicy)
+	if err != nil {
+		return options, err
+	}
+	options = withHeartbeatInterval(options, interval)
+	return options, validateHeartbeatOptions(options, interval)
+}
+
+// activityBudget is the longest an activity run with options can take: its
+// ScheduleToCloseTimeout, or else every attempt plus the backoff between
+// them, with the SDK's retry defaults for unset fields
+func activityBudget(options workflow.ActivityOptions) time.Duration {
+	if options.ScheduleToCloseTimeout > 0 {
+		return options.ScheduleToCloseTimeout
+	}
+	policy := temporal.RetryPolicy{}
+	if options.RetryPolicy != nil {
+		policy = *options.RetryPolicy
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = time.Second
+	}
+	if policy.BackoffCoefficient < 1 {
+		policy.BackoffCoefficient = 2
+	}
+	if policy.MaximumInterval <= 0 {
+		policy.MaximumInterval = 100 * policy.InitialInterval
+	}
+	attempts := int(policy.MaximumAttempts)
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	budget := time.Duration(attempts) * options.StartToCloseTimeout
+	backoff := policy.InitialInterval
+	for i := 1; i < attempts; i++ {
+		budget += backoff
+		backoff = time.Duration(float64(backoff) * policy.BackoffCoefficient)
+		if backoff > policy.MaximumInterval {
+			backoff = policy.MaximumInterval
+		}
+	}
+	return budget
+}
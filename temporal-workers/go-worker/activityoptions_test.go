@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestNewActivityOptions(t *testing.T) {
+	retries := &temporal.RetryPolicy{MaximumAttempts: 3}
+	tests := []struct {
+		name        string
+		config      ActivityTimeoutConfig
+		retryPolicy *temporal.RetryPolicy
+		want        workflow.ActivityOptions
+		wantErr     string
+	}{
+		{
+			name:   "bounded total",
+			config: ActivityTimeoutConfig{Strategy: TimeoutBoundedTotal, Timeout: 10 * time.Minute},
+			want:   workflow.ActivityOptions{ScheduleToCloseTimeout: 10 * time.Minute},
+		},
+		{
+			name:        "bounded attempt",
+			config:      ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Minute},
+			retryPolicy: retries,
+			want:        workflow.ActivityOptions{StartToCloseTimeout: time.Minute, RetryPolicy: retries},
+		},
+		{
+			name:        "bounded attempt without attempt limit",
+			config:      ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Minute},
+			retryPolicy: &temporal.RetryPolicy{InitialInterval: time.Second},
+			wantErr:     "bounded-attempt needs a retry policy with MaximumAttempts; without one retries never end",
+		},
+		{name: "bounded attempt without retry policy", config: ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Minute}, wantErr: "bounded-attempt needs a retry policy"},
+		{name: "no timeout", config: ActivityTimeoutConfig{Strategy: TimeoutBoundedTotal}, wantErr: "activity timeout must be positive, got 0s"},
+		{name: "no strategy", config: ActivityTimeoutConfig{Timeout: time.Minute}, wantErr: "activity timeout strategy is required (bounded-total or bounded-attempt)"},
+		{name: "unknown strategy", config: ActivityTimeoutConfig{Strategy: "forever", Timeout: time.Minute}, wantErr: `unknown activity timeout strategy "forever"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := newActivityOptions(tt.config, tt.retryPolicy)
+			if tt.wantErr != "" {
+				requireApplicationError(t, err, ErrTypeInvalidActivityOptions)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, options)
+		})
+	}
+}
+
+func TestNewHeartbeatActivityOptions(t *testing.T) {
+	retries := &temporal.RetryPolicy{MaximumAttempts: 3}
+	tests := []struct {
+		name        string
+		config      ActivityTimeoutConfig
+		interval    time.Duration
+		wantTimeout time.Duration
+		wantErr     string
+	}{
+		{name: "heartbeats within the attempt", config: ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Minute}, interval: 10 * time.Second, wantTimeout: 30 * time.Second},
+		{name: "heartbeats within the total", config: ActivityTimeoutConfig{Strategy: TimeoutBoundedTotal, Timeout: time.Hour}, interval: time.Minute, wantTimeout: 3 * time.Minute},
+		{
+			name:     "heartbeat timeout outlasts the attempt",
+			config:   ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Minute},
+			interval: 30 * time.Second,
+			wantErr:  "heartbeat timeout 1m30s is not shorter than the 1m0s activity timeout",
+		},
+		{name: "no interval", config: ActivityTimeoutConfig{Strategy: TimeoutBoundedTotal, Timeout: time.Hour}, wantErr: "heartbeat interval must be positive, got 0s"},
+		{name: "invalid timeout config", config: ActivityTimeoutConfig{Timeout: time.Hour}, interval: time.Minute, wantErr: "activity timeout strategy is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := newHeartbeatActivityOptions(tt.config, retries, tt.interval)
+			if tt.wantErr != "" {
+				requireApplicationError(t, err, ErrTypeInvalidActivityOptions)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTimeout, options.HeartbeatTimeout)
+			assert.Equal(t, retries, options.RetryPolicy)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync/atomic"
+)
+
+// adminServer serves health and maintenance endpoints alongside the worker
+type adminServer struct {
+	token     string
+	workers   *workerManager
+	retention *retentionReport
+	server    *http.Server
+	// started and stopping drive /readyz: ready once the worker has
+	// started, until shutdown begins
+	started  atomic.Bool
+	stopping atomic.Bool
+}
+
+// newAdminServer builds the admin server. With enablePprof, the runtime
+// profiles are served under /debug/pprof/, behind the admin token. A nil
+// retention leaves it out of /healthz.
+func newAdminServer(addr, token string, workers *workerManager, enablePprof bool, retention *retentionReport) *adminServer {
+	s := &adminServer{token: token, workers: workers, retention: retention}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.Handle("/admin/pause", s.requireToken(http.HandlerFunc(s.handlePause)))
+	mux.Handle("/admin/resume", s.requireToken(http.HandlerFunc(s.handleResume)))
+	mux.Handle("/admin/concurrency", s.requireToken(http.HandlerFunc(s.handleConcurrency)))
+	mux.Handle("/admin/results", s.requireToken(http.HandlerFunc(s.handleResult)))
+	if enablePprof {
+		mux.Handle("/debug/pprof/", s.requireToken(http.HandlerFunc(pprof.Index)))
+		mux.Handle("/debug/pprof/cmdline", s.requireToken(http.HandlerFunc(pprof.Cmdline)))
+		mux.Handle("/debug/pprof/profile", s.requireToken(http.HandlerFunc(pprof.Profile)))
+		mux.Handle("/debug/pprof/symbol", s.requireToken(http.HandlerFunc(pprof.Symbol)))
+		mux.Handle("/debug/pprof/trace", s.requireToken(http.HandlerFunc(pprof.Trace)))
+	}
+
+	s.server = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// ListenAndServe blocks serving requests until the server is closed
+func (s *adminServer) ListenAndServe() error {
+	err := s.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting requests and waits for in-flight ones
+func (s *adminServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *adminServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	body := map[string]interface{}{
+		"status": "ok",
+		"paused": s.workers.Paused(),
+	}
+	if s.retention != nil {
+		body["retention"] = s.retention
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// MarkStarted reports the worker started, making it ready
+func (s *adminServer) MarkStarted() {
+	s.started.Store(true)
+}
+
+// MarkStopping reports shutdown has begun; the worker stays unready even
+// if MarkStarted comes later
+func (s *adminServer) MarkStopping() {
+	s.stopping.Store(true)
+}
+
+// handleReadyz answers 200 once the client has connected and the worker
+// has started, and 503 before that and from the start of shutdown
+func (s *adminServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !s.started.Load() || s.stopping.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ready",
+		"paused": s.workers.Paused(),
+	})
+}
+
+func (s *adminServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.workers.Pause()
+	log.Printf("⏸️ Worker polling paused via admin endpoint")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"paused": true})
+}
+
+func (s *adminServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.workers.Resume(); err != nil {
+		log.Printf("❌ Unable to resume worker: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, errWorkerStopped) {
+			status = http.StatusConflict
+		}
+		http.Error(w, "unable to resume worker: "+err.Error(), status)
+		return
+	}
+	log.Printf("▶️ Worker polling resumed via admin endpoint")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"paused": false})
+}
+
+// resizeResponse is the body of POST /admin/concurrency. DrainTimeout is
+// how long the replaced worker's in-flight activities may run before they
+// are cancelled and retried.
+type resizeResponse struct {
+	ConcurrencyLimits
+	DrainTimeout string `json:"drain_timeout"`
+}
+
+func (s *adminServer) handleConcurrency(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.workers.Limits())
+	case http.MethodPost:
+		var limits ConcurrencyLimits
+		if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := limits.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		applied, err := s.workers.Resize(limits)
+		if err != nil {
+			log.Printf("❌ Unable to resize worker: %v", err)
+			http.Error(w, "unable to resize worker: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		drain := s.workers.DrainTimeout()
+		log.Printf("🎚️ Worker concurrency set via admin endpoint: %d activity slots, %d workflow task slots; the previous worker drains for up to %s", applied.Activities, applied.WorkflowTasks, drain)
+		writeJSON(w, http.StatusOK, resizeResponse{ConcurrencyLimits: applied, DrainTimeout: drain.String()})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *adminServer) handleResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	workflowID := r.URL.Query().Get("workflow_id")
+	if workflowID == "" {
+		http.Error(w, "workflow_id is required", http.StatusBadRequest)
+		return
+	}
+	stored, err := LoadResult(r.Context(), objectStore, workflowID)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "result not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Unable to load result: %v", err)
+		http.Error(w, "unable to load result", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, stored)
+}
+
+// requireToken guards admin endpoints with a bearer token. Admin endpoints
+// are disabled entirely when no ADMIN_TOKEN is configured.
+func (s *adminServer) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" {
+			http.Error(w, "admin endpoints disabled", http.StatusForbidden)
+			return
+		}
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("❌ Unable to encode response: %v", err)
+	}
+}
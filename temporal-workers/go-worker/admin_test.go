@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/worker"
+)
+
+func TestAdminConcurrency(t *testing.T) {
+	workers := newWorkerManager(nil, "go-workers", worker.Options{
+		MaxConcurrentActivityExecutionSize:     10,
+		MaxConcurrentWorkflowTaskExecutionSize: 4,
+		WorkerStopTimeout:                      45 * time.Second,
+	}, nil)
+	handler := newAdminServer(":0", "secret", workers, false, nil).server.Handler
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       resizeResponse
+	}{
+		{
+			name:       "activities only",
+			body:       `{"activities": 20}`,
+			wantStatus: http.StatusOK,
+			want:       resizeResponse{ConcurrencyLimits: ConcurrencyLimits{Activities: 20, WorkflowTasks: 4}, DrainTimeout: "45s"},
+		},
+		{
+			name:       "both",
+			body:       `{"activities": 5, "workflow_tasks": 8}`,
+			wantStatus: http.StatusOK,
+			want:       resizeResponse{ConcurrencyLimits: ConcurrencyLimits{Activities: 5, WorkflowTasks: 8}, DrainTimeout: "45s"},
+		},
+		{name: "single workflow task slot", body: `{"workflow_tasks": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/concurrency", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got resizeResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.ConcurrencyLimits, workers.Limits())
+		})
+	}
+}
+
+func TestAdminConcurrencyRunningWorker(t *testing.T) {
+	workers, started := fakeWorkerManager(worker.Options{
+		MaxConcurrentActivityExecutionSize:     10,
+		MaxConcurrentWorkflowTaskExecutionSize: 4,
+		WorkerStopTimeout:                      45 * time.Second,
+	})
+	require.NoError(t, workers.Start())
+	handler := newAdminServer(":0", "secret", workers, false, nil).server.Handler
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/concurrency", strings.NewReader(`{"activities": 20, "workflow_tasks": 8}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var got resizeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	want := ConcurrencyLimits{Activities: 20, WorkflowTasks: 8}
+	assert.Equal(t, resizeResponse{ConcurrencyLimits: want, DrainTimeout: "45s"}, got)
+
+	// The old worker was drained and a replacement with the returned
+	// limits polls in its place
+	startedWorkers := started()
+	require.Len(t, startedWorkers, 2)
+	old, replacement := startedWorkers[0], startedWorkers[1]
+	assert.True(t, old.Stopped())
+	assert.False(t, replacement.Stopped())
+	assert.Same(t, replacement, workers.worker)
+	assert.Equal(t, want.Activities, replacement.options.MaxConcurrentActivityExecutionSize)
+	assert.Equal(t, want.WorkflowTasks, replacement.options.MaxConcurrentWorkflowTaskExecutionSize)
+	assert.Equal(t, 45*time.Second, replacement.options.WorkerStopTimeout)
+	assert.Equal(t, want, workers.Limits())
+}
+
+func TestAdminResults(t *testing.T) {
+	store := useCountingStore(t)
+	stored := StoredResult{WorkflowID: "wf-1", RunID: "run-1", WorkflowType: "ComplexProcessingWorkflow", CompletedAt: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC), Result: []byte(`{"processed_items":10}`)}
+	_, err := newActivities().PersistResult(context.Background(), stored)
+	require.NoError(t, err)
+	_, err = store.Put(context.Background(), resultKey("wf-corrupt"), strings.NewReader("{"))
+	require.NoError(t, err)
+	handler := newAdminServer(":0", "secret", newWorkerManager(nil, "go-workers", worker.Options{}, nil), false, nil).server.Handler
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		token      string
+		wantStatus int
+	}{
+		{name: "found", method: http.MethodGet, target: "/admin/results?workflow_id=wf-1", token: "secret", wantStatus: http.StatusOK},
+		{name: "not found", method: http.MethodGet, target: "/admin/results?workflow_id=wf-2", token: "secret", wantStatus: http.StatusNotFound},
+		{name: "unreadable", method: http.MethodGet, target: "/admin/results?workflow_id=wf-corrupt", token: "secret", wantStatus: http.StatusInternalServerError},
+		{name: "no workflow id", method: http.MethodGet, target: "/admin/results", token: "secret", wantStatus: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodPost, target: "/admin/results?workflow_id=wf-1", token: "secret", wantStatus: http.StatusMethodNotAllowed},
+		{name: "wrong token", method: http.MethodGet, target: "/admin/results?workflow_id=wf-1", token: "guess", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got StoredResult
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, stored.RunID, got.RunID)
+			assert.True(t, stored.CompletedAt.Equal(got.CompletedAt))
+			assert.JSONEq(t, string(stored.Result), string(got.Result))
+		})
+	}
+}
+
+func TestAdminPprof(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		adminToken string
+		target     string
+		token      string
+		wantStatus int
+	}{
+		{name: "index", enabled: true, adminToken: "secret", target: "/debug/pprof/", token: "secret", wantStatus: http.StatusOK},
+		{name: "heap profile", enabled: true, adminToken: "secret", target: "/debug/pprof/heap", token: "secret", wantStatus: http.StatusOK},
+		{name: "cmdline", enabled: true, adminToken: "secret", target: "/debug/pprof/cmdline", token: "secret", wantStatus: http.StatusOK},
+		{name: "wrong token", enabled: true, adminToken: "secret", target: "/debug/pprof/heap", token: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "no admin token", enabled: true, target: "/debug/pprof/heap", wantStatus: http.StatusForbidden},
+		{name: "disabled", adminToken: "secret", target: "/debug/pprof/heap", token: "secret", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newAdminServer(":0", tt.adminToken, newWorkerManager(nil, "go-workers", worker.Options{}, nil), tt.enabled, nil).server.Handler
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
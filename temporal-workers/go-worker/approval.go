@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Approval signal and query names
+const (
+	ApproveSignalName        = "approve"
+	RejectSignalName         = "reject"
+	PendingApprovalQueryName = "pendingApproval"
+)
+
+// defaultApprovalTimeout applies when callers omit approval_timeout_seconds
+const defaultApprovalTimeout = 24 * time.Hour
+
+// sensitiveOperations always require approval before they run
+var sensitiveOperations = map[string]bool{
+	"delete":   true,
+	"drop":     true,
+	"truncate": true,
+}
+
+// ApprovalDecision is the payload of the approve and reject signals
+type ApprovalDecision struct {
+	Approver string `json:"approver"`
+	Reason   string `json:"reason"`
+}
+
+// ApprovalState represents the approval gate as exposed by the query
+type ApprovalState struct {
+	Required  bool      `json:"required"`
+	Pending   bool      `json:"pending"`
+	Status    string    `json:"status"` // not_required, pending, approved, rejected, timed_out
+	Deadline  time.Time `json:"deadline,omitempty"`
+	Approver  string    `json:"approver,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	Operation string    `json:"operation"`
+	Target    string    `json:"target"`
+}
+
+// requiresApproval reports whether the operation must wait for a human:
+// when it is one of sensitiveOperations, when the statement it runs is, or
+// when the caller opts in with the require_approval parameter. The
+// parameter can only add the gate, never lift it.
+func requiresApproval(input SystemOperationInput) bool {
+	if required, _ := input.Parameters.Bool("require_approval"); required {
+		return true
+	}
+	if sensitiveOperations[strings.ToLower(input.Operation)] {
+		return true
+	}
+	statement, err := sqlStatement(DatabaseOperationInput{Operation: input.Operation, Target: input.Target})
+	if err != nil {
+		// DatabaseOperation rejects the target before running anything
+		return false
+	}
+	verb, _, _ := strings.Cut(statement, " ")
+	return sensitiveOperations[strings.ToLower(verb)]
+}
+
+// legacyRequiresApproval is the rule runs started before require_approval
+// became opt-in only were gated by
+func legacyRequiresApproval(input SystemOperationInput) bool {
+	if required, ok := input.Parameters.Bool("require_approval"); ok {
+		return required
+	}
+	return sensitiveOperations[strings.ToLower(input.Operation)]
+}
+
+// awaitApproval blocks until an approve or reject signal arrives, or rejects
+// automatically once the timeout elapses. The current state is always
+// available through the pendingApproval query.
+func awaitApproval(ctx workflow.Context, input SystemOperationInput) (ApprovalState, error) {
+	logger := workflow.GetLogger(ctx)
+
+	// Versioned so runs that opted out of the gate before that was removed
+	// replay unchanged
+	required := requiresApproval(input)
+	if workflow.GetVersion(ctx, "approval-opt-in-only", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		required = legacyRequiresApproval(input)
+	}
+	state := ApprovalState{
+		Required:  required,
+		Status:    "not_required",
+		Operation: input.Operation,
+		Target:    input.Target,
+	}
+	if err := workflow.SetQueryHandler(ctx, PendingApprovalQueryName, func() (ApprovalState, error) {
+		return state, nil
+	}); err != nil {
+		return state, err
+	}
+	if !state.Required {
+		return state, nil
+	}
+
+	timeout := defaultApprovalTimeout
+	if seconds, ok := input.Parameters.Int64("approval_timeout_seconds"); ok && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	state.Pending = true
+	state.Status = "pending"
+	state.Deadline = workflow.Now(ctx).Add(timeout)
+	logger.Info("⏳ Waiting for approval", "operation", input.Operation, "target", input.Target, "timeout", timeout)
+
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+
+	var decision ApprovalDecision
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, ApproveSignalName), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &decision)
+		state.Status = "approved"
+	})
+	selector.AddReceive(workflow.GetSignalChannel(ctx, RejectSignalName), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &decision)
+		state.Status = "rejected"
+	})
+	selector.AddFuture(workflow.NewTimer(timerCtx, timeout), func(f workflow.Future) {
+		state.Status = "timed_out"
+		decision.Reason = fmt.Sprintf("no decision within %s", timeout)
+	})
+	selector.Select(ctx)
+
+	state.Pending = false
+	state.Approver = decision.Approver
+	state.Reason = decision.Reason
+
+	if state.Status != "approved" {
+		logger.Warn("🚫 Operation not approved", "status", state.Status, "approver", state.Approver, "reason", state.Reason)
+		return state, fmt.Errorf("operation %s on %s %s: %s", input.Operation, input.Target, strings.ReplaceAll(state.Status, "_", " "), state.Reason)
+	}
+	logger.Info("✅ Operation approved", "approver", state.Approver)
+	return state, nil
+}
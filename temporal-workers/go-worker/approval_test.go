@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestRequiresApproval(t *testing.T) {
+	tests := []struct {
+		name  string
+		input SystemOperationInput
+		want  bool
+	}{
+		{name: "select", input: SystemOperationInput{Operation: "select", Target: "users"}, want: false},
+		{name: "delete", input: SystemOperationInput{Operation: "delete", Target: "users"}, want: true},
+		{name: "drop", input: SystemOperationInput{Operation: "DROP", Target: "users"}, want: true},
+		{name: "truncate", input: SystemOperationInput{Operation: "truncate", Target: "users"}, want: true},
+		{name: "opt in", input: SystemOperationInput{Operation: "update", Target: "users", Parameters: Parameters{"require_approval": true}}, want: true},
+		{name: "opt out ignored", input: SystemOperationInput{Operation: "delete", Target: "users", Parameters: Parameters{"require_approval": false}}, want: true},
+		{name: "query parameter ignored", input: SystemOperationInput{Operation: "select", Target: "users", Parameters: Parameters{"query": "DROP TABLE users"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, requiresApproval(tt.input))
+		})
+	}
+}
+
+func TestSystemOperationWorkflowRejectsOptedOutDelete(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities)
+
+	env.RegisterDelayedCallback(func() {
+		value, err := env.QueryWorkflow(PendingApprovalQueryName)
+		require.NoError(t, err)
+		var state ApprovalState
+		require.NoError(t, value.Get(&state))
+		assert.True(t, state.Pending)
+		env.SignalWorkflow(RejectSignalName, ApprovalDecision{Approver: "ops", Reason: "not today"})
+	}, time.Minute)
+
+	env.ExecuteWorkflow(SystemOperationWorkflow, SystemOperationInput{
+		Operation:  "delete",
+		Target:     "users",
+		Parameters: Parameters{"require_approval": false},
+	})
+
+	require.True(t, env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected")
+	env.AssertNotCalled(t, "DatabaseOperation")
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+
+	"temporal-go-worker/internal/avro"
+)
+
+// Application error types returned by EncodeAvro. Registry outages are
+// retryable; a rejected schema or a result that doesn't fit it isn't.
+const (
+	ErrTypeSchemaRegistryUnavailable = "SchemaRegistryUnavailable"
+	ErrTypeAvroSchemaRejected        = "AvroSchemaRejected"
+	ErrTypeAvroEncodingFailed        = "AvroEncodingFailed"
+)
+
+// registryAttempts is how many times a registry call is tried within one
+// activity attempt before the activity's own retries take over
+const registryAttempts = 3
+
+// AvroRegistry returns the ID of a schema under a subject, registering it
+// if the subject doesn't have it yet
+type AvroRegistry interface {
+	SchemaID(ctx context.Context, subject, schema string) (int, error)
+}
+
+// avroSchemaRegistry is used by EncodeAvro. It defaults to the Confluent
+// Schema Registry API at SCHEMA_REGISTRY_URL.
+var avroSchemaRegistry AvroRegistry = confluentRegistry{}
+
+// avroSchemaIDs caches schema IDs by subject and schema, since a registered
+// schema's ID never changes
+var avroSchemaIDs sync.Map
+
+// EncodeAvroInput represents input for encoding a result as Avro. Subject
+// defaults to "<dataset_id>-value".
+type EncodeAvroInput struct {
+	DatasetID string                 `json:"dataset_id"`
+	Subject   string                 `json:"subject,omitempty"`
+	Schema    string                 `json:"schema"` // Avro schema JSON
+	Result    map[string]interface{} `json:"result"`
+}
+
+// EncodeAvroResult represents an Avro-encoded result. Data is in the
+// Confluent wire format (magic byte, schema ID, Avro body).
+type EncodeAvroResult struct {
+	Subject  string `json:"subject"`
+	SchemaID int    `json:"schema_id"`
+	Data     []byte `json:"data"`
+}
+
+// EncodeAvro encodes a result with an Avro schema registered in the schema
+// registry, registering the schema first if the subject lacks it
+func (a *Activities) EncodeAvro(ctx context.Context, input EncodeAvroInput) (EncodeAvroResult, error) {
+	subject := input.Subject
+	if subject == "" {
+		subject = input.DatasetID + "-value"
+	}
+	activityLog.Infof("🧬 Encoding result of %s as Avro (subject: %s)", input.DatasetID, subject)
+
+	schema, err := avro.Parse([]byte(input.Schema))
+	if err != nil {
+		return EncodeAvroResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeAvroEncodingFailed, err)
+	}
+	body, err := avro.Encode(schema, input.Result)
+	if err != nil {
+		return EncodeAvroResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeAvroEncodingFailed, err)
+	}
+
+	cacheKey := subject + "\x00" + input.Schema
+	id, cached := avroSchemaIDs.Load(cacheKey)
+	if !cached {
+		schemaID, err := avroSchemaRegistry.SchemaID(ctx, subject, input.Schema)
+		if err != nil {
+			return EncodeAvroResult{}, err
+		}
+		avroSchemaIDs.Store(cacheKey, schemaID)
+		id = schemaID
+	}
+
+	result := EncodeAvroResult{Subject: subject, SchemaID: id.(int), Data: avro.ConfluentFrame(id.(int), body)}
+	activityLog.Infof("✅ Encoded %d bytes with schema %d", len(result.Data), result.SchemaID)
+	return result, nil
+}
+
+// confluentRegistry speaks the Confluent Schema Registry REST API
+type confluentRegistry struct{}
+
+// errSubjectLacksSchema means a lookup found no matching schema
+var errSubjectLacksSchema = errors.New("schema not registered")
+
+func (confluentRegistry) SchemaID(ctx context.Context, subject, schema string) (int, error) {
+	if schemaRegistryURL == "" {
+		return 0, temporal.NewNonRetryableApplicationError("SCHEMA_REGISTRY_URL is not configured", ErrTypeSchemaRegistryUnavailable, nil)
+	}
+	base := strings.TrimSuffix(schemaRegistryURL, "/") + "/subjects/" + url.PathEscape(subject)
+
+	id, err := registryCall(ctx, base, schema)
+	if errors.Is(err, errSubjectLacksSchema) {
+		activityLog.Infof("📝 Registering Avro schema under %s", subject)
+		id, err = registryCall(ctx, base+"/versions", schema)
+	}
+	return id, err
+}
+
+// registryCall posts the schema to a lookup or register endpoint and returns
+// the schema ID, retrying while the registry is unavailable
+func registryCall(ctx context.Context, endpoint, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+	delay := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		id, err := postSchema(ctx, endpoint, body)
+		var appErr *temporal.ApplicationError
+		unavailable := errors.As(err, &appErr) && appErr.Type() == ErrTypeSchemaRegistryUnavailable
+		if !unavailable || attempt == registryAttempts {
+			return id, err
+		}
+		activityLog.Errorf("⚠️ Schema registry unavailable (attempt %d/%d): %v", attempt, registryAttempts, err)
+		select {
+		case <-ctx.Done():
+			return 0, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func postSchema(ctx context.Context, endpoint string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	resp, err := schemaRegistryClient.Do(req)
+	if err != nil {
+		return 0, temporal.NewApplicationError(err.Error(), ErrTypeSchemaRegistryUnavailable)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return 0, errSubjectLacksSchema
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return 0, temporal.NewApplicationError(fmt.Sprintf("schema registry returned %s", resp.Status), ErrTypeSchemaRegistryUnavailable)
+	case resp.StatusCode != http.StatusOK:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return 0, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("schema registry rejected the schema: %s: %s", resp.Status, bytes.TrimSpace(msg)),
+			ErrTypeAvroSchemaRejected, nil)
+	}
+
+	var parsed struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return 0, fmt.Errorf("decoding schema registry response: %w", err)
+	}
+	return parsed.ID, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+const countSchema = `{"type": "record", "name": "Result", "fields": [{"name": "processed_items", "type": "long"}]}`
+
+func TestEncodeAvro(t *testing.T) {
+	tests := []struct {
+		name string
+		// statuses are returned by the registry in turn; 200 replies with
+		// schema ID 7
+		statuses    []int
+		noRegistry  bool
+		result      map[string]interface{}
+		wantPaths   []string
+		wantErrType string
+		wantRetry   bool
+	}{
+		{name: "already registered", statuses: []int{200}, wantPaths: []string{"/subjects/ds-1-value"}},
+		{name: "registered on a miss", statuses: []int{404, 200}, wantPaths: []string{"/subjects/ds-1-value", "/subjects/ds-1-value/versions"}},
+		{name: "unavailable then recovers", statuses: []int{503, 200}, wantPaths: []string{"/subjects/ds-1-value", "/subjects/ds-1-value"}},
+		{
+			name:        "stays unavailable",
+			statuses:    []int{503, 503, 503},
+			wantPaths:   []string{"/subjects/ds-1-value", "/subjects/ds-1-value", "/subjects/ds-1-value"},
+			wantErrType: ErrTypeSchemaRegistryUnavailable,
+			wantRetry:   true,
+		},
+		{
+			name:        "schema rejected",
+			statuses:    []int{404, 422},
+			wantPaths:   []string{"/subjects/ds-1-value", "/subjects/ds-1-value/versions"},
+			wantErrType: ErrTypeAvroSchemaRejected,
+		},
+		{name: "result doesn't fit", result: map[string]interface{}{"processed_items": "ten"}, wantErrType: ErrTypeAvroEncodingFailed},
+		{name: "no registry configured", noRegistry: true, wantErrType: ErrTypeSchemaRegistryUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				status := tt.statuses[len(paths)]
+				paths = append(paths, r.URL.Path)
+				mu.Unlock()
+				var body map[string]string
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.JSONEq(t, countSchema, body["schema"])
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(`{"id":7}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+			prev := schemaRegistryURL
+			t.Cleanup(func() { schemaRegistryURL = prev })
+			schemaRegistryURL = server.URL
+			if tt.noRegistry {
+				schemaRegistryURL = ""
+			}
+			t.Cleanup(func() { avroSchemaIDs.Delete("ds-1-value\x00" + countSchema) })
+
+			result := tt.result
+			if result == nil {
+				result = map[string]interface{}{"processed_items": 10}
+			}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.EncodeAvro, EncodeAvroInput{DatasetID: "ds-1", Schema: countSchema, Result: result})
+			assert.Equal(t, tt.wantPaths, paths)
+			if tt.wantErrType != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				assert.Equal(t, tt.wantRetry, !appErr.NonRetryable())
+				return
+			}
+			require.NoError(t, err)
+			var encoded EncodeAvroResult
+			require.NoError(t, value.Get(&encoded))
+			assert.Equal(t, "ds-1-value", encoded.Subject)
+			assert.Equal(t, 7, encoded.SchemaID)
+			require.Len(t, encoded.Data, 6)
+			assert.Equal(t, byte(0), encoded.Data[0])
+			assert.Equal(t, uint32(7), binary.BigEndian.Uint32(encoded.Data[1:5]))
+			assert.Equal(t, byte(20), encoded.Data[5], "zig-zag encoded 10")
+
+			// The schema ID is cached, so encoding again doesn't hit the registry
+			_, err = env.ExecuteActivity(activities.EncodeAvro, EncodeAvroInput{DatasetID: "ds-1", Schema: countSchema, Result: result})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPaths, paths)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Backpressure defaults: no delay below the lag threshold, then a delay
+// growing with lag/threshold, capped at the maximum
+const (
+	defaultLagThreshold      = 10000
+	backpressureBaseDelay    = 5 * time.Second
+	backpressureMaxDelay     = 5 * time.Minute
+	lagThresholdParameter    = "backpressure_lag_threshold"
+	maxDelaySecondsParameter = "backpressure_max_delay_seconds"
+)
+
+// LagSource reports how far consumers of a topic are behind
+type LagSource interface {
+	ConsumerLag(ctx context.Context, topic string) (int64, error)
+}
+
+// consumerLagSource is used by CheckDownstreamPressure. It defaults to a
+// source reporting no lag; assign a Kafka admin client to measure for real.
+var consumerLagSource LagSource = noLagSource{}
+
+type noLagSource struct{}
+
+func (noLagSource) ConsumerLag(ctx context.Context, topic string) (int64, error) {
+	return 0, nil
+}
+
+// CheckDownstreamPressureInput represents input for checking consumer lag
+type CheckDownstreamPressureInput struct {
+	Topics          []string `json:"topics"`
+	LagThreshold    int64    `json:"lag_threshold,omitempty"`
+	MaxDelaySeconds int64    `json:"max_delay_seconds,omitempty"`
+}
+
+// CheckDownstreamPressureResult reports lag per topic and how long to wait
+// before publishing
+type CheckDownstreamPressureResult struct {
+	Lag                map[string]int64 `json:"lag"`
+	MaxLag             int64            `json:"max_lag"`
+	RecommendedDelayMs int64            `json:"recommended_delay_ms"`
+}
+
+// CheckDownstreamPressure reads consumer lag of the topics results are
+// about to be published to and recommends a delay
+func (a *Activities) CheckDownstreamPressure(ctx context.Context, input CheckDownstreamPressureInput) (CheckDownstreamPressureResult, error) {
+	activityLog.Infof("🚦 Checking downstream pressure for %d topics", len(input.Topics))
+
+	result := CheckDownstreamPressureResult{Lag: make(map[string]int64, len(input.Topics))}
+	for _, topic := range input.Topics {
+		lag, err := consumerLagSource.ConsumerLag(ctx, topic)
+		if err != nil {
+			activityLog.Errorf("❌ Unable to read consumer lag for %s: %v", topic, err)
+			return result, fmt.Errorf("reading consumer lag for %s: %w", topic, err)
+		}
+		result.Lag[topic] = lag
+		if lag > result.MaxLag {
+			result.MaxLag = lag
+		}
+	}
+
+	maxDelay := backpressureMaxDelay
+	if input.MaxDelaySeconds > 0 {
+		maxDelay = time.Duration(input.MaxDelaySeconds) * time.Second
+	}
+	result.RecommendedDelayMs = recommendedDelay(result.MaxLag, input.LagThreshold, maxDelay).Milliseconds()
+
+	activityLog.Infof("✅ Downstream max lag %d, recommended delay %dms", result.MaxLag, result.RecommendedDelayMs)
+	return result, nil
+}
+
+// recommendedDelay is zero up to the threshold and then grows linearly with
+// how many thresholds the lag is at, up to maxDelay
+func recommendedDelay(lag, threshold int64, maxDelay time.Duration) time.Duration {
+	if threshold <= 0 {
+		threshold = defaultLagThreshold
+	}
+	if lag <= threshold {
+		return 0
+	}
+	delay := time.Duration(float64(backpressureBaseDelay) * float64(lag) / float64(threshold))
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// awaitDownstreamCapacity waits out the delay recommended for the kafka
+// sinks' topics. Failing to measure lag doesn't hold up delivery.
+func awaitDownstreamCapacity(ctx workflow.Context, sinks []SinkConfig, parameters Parameters) {
+	var topics []string
+	for _, sink := range sinks {
+		if sink.Type == SinkTypeKafka {
+			topics = append(topics, sink.Target)
+		}
+	}
+	if len(topics) == 0 {
+		return
+	}
+
+	logger := workflow.GetLogger(ctx)
+	input := CheckDownstreamPressureInput{Topics: topics}
+	input.LagThreshold, _ = parameters.Int64(lagThresholdParameter)
+	input.MaxDelaySeconds, _ = parameters.Int64(maxDelaySecondsParameter)
+
+	var pressure CheckDownstreamPressureResult
+	if err := workflow.ExecuteActivity(ctx, activities.CheckDownstreamPressure, input).Get(ctx, &pressure); err != nil {
+		logger.Error("❌ Unable to check downstream pressure", "error", err)
+		return
+	}
+	if pressure.RecommendedDelayMs <= 0 {
+		return
+	}
+	delay := time.Duration(pressure.RecommendedDelayMs) * time.Millisecond
+	logger.Info("🚦 Downstream backed up, delaying delivery", "max_lag", pressure.MaxLag, "delay", delay)
+	if err := workflow.Sleep(ctx, delay); err != nil {
+		logger.Error("❌ Backpressure delay interrupted", "error", err)
+	}
+}
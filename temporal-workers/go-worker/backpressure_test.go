@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// fakeLagSource reports lag per topic and fails the topics in errs
+type fakeLagSource struct {
+	lag  map[string]int64
+	errs map[string]error
+}
+
+func (s fakeLagSource) ConsumerLag(ctx context.Context, topic string) (int64, error) {
+	return s.lag[topic], s.errs[topic]
+}
+
+func TestRecommendedDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		lag       int64
+		threshold int64
+		maxDelay  time.Duration
+		want      time.Duration
+	}{
+		{name: "below threshold", lag: 500, threshold: 1000, maxDelay: time.Minute},
+		{name: "at threshold", lag: 1000, threshold: 1000, maxDelay: time.Minute},
+		{name: "grows with lag", lag: 3000, threshold: 1000, maxDelay: time.Minute, want: 15 * time.Second},
+		{name: "capped", lag: 100000, threshold: 1000, maxDelay: time.Minute, want: time.Minute},
+		{name: "default threshold", lag: 2 * defaultLagThreshold, maxDelay: time.Minute, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, recommendedDelay(tt.lag, tt.threshold, tt.maxDelay))
+		})
+	}
+}
+
+func TestCheckDownstreamPressure(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     CheckDownstreamPressureInput
+		source    fakeLagSource
+		wantMax   int64
+		wantDelay int64
+		wantErr   string
+	}{
+		{
+			name:    "no pressure",
+			input:   CheckDownstreamPressureInput{Topics: []string{"results", "audit"}},
+			source:  fakeLagSource{lag: map[string]int64{"results": 10, "audit": 20}},
+			wantMax: 20,
+		},
+		{
+			name:      "backed up",
+			input:     CheckDownstreamPressureInput{Topics: []string{"results", "audit"}, LagThreshold: 1000, MaxDelaySeconds: 10},
+			source:    fakeLagSource{lag: map[string]int64{"results": 1500, "audit": 20}},
+			wantMax:   1500,
+			wantDelay: 7500,
+		},
+		{
+			name:    "lag unavailable",
+			input:   CheckDownstreamPressureInput{Topics: []string{"results"}},
+			source:  fakeLagSource{errs: map[string]error{"results": errors.New("broker down")}},
+			wantErr: "reading consumer lag for results: broker down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := consumerLagSource
+			t.Cleanup(func() { consumerLagSource = prev })
+			consumerLagSource = tt.source
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.CheckDownstreamPressure, tt.input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var result CheckDownstreamPressureResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantMax, result.MaxLag)
+			assert.Equal(t, tt.wantDelay, result.RecommendedDelayMs)
+			assert.Len(t, result.Lag, len(tt.input.Topics))
+		})
+	}
+}
+
+func TestAwaitDownstreamCapacity(t *testing.T) {
+	tests := []struct {
+		name      string
+		sinks     []SinkConfig
+		delayMs   int64
+		err       error
+		wantCheck bool
+		wantWait  time.Duration
+	}{
+		{name: "delayed", sinks: []SinkConfig{{Type: SinkTypeKafka, Target: "results"}}, delayMs: 30000, wantCheck: true, wantWait: 30 * time.Second},
+		{name: "no pressure", sinks: []SinkConfig{{Type: SinkTypeKafka, Target: "results"}}, wantCheck: true},
+		{name: "check fails", sinks: []SinkConfig{{Type: SinkTypeKafka, Target: "results"}}, err: errors.New("broker down"), wantCheck: true},
+		{name: "no kafka sinks", sinks: []SinkConfig{{Type: SinkTypeDatabase, Target: "analytics.results"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			checked := false
+			env.OnActivity(activities.CheckDownstreamPressure, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input CheckDownstreamPressureInput) (CheckDownstreamPressureResult, error) {
+					checked = true
+					assert.Equal(t, []string{"results"}, input.Topics)
+					assert.Equal(t, int64(500), input.LagThreshold)
+					return CheckDownstreamPressureResult{RecommendedDelayMs: tt.delayMs}, tt.err
+				})
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) (time.Duration, error) {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute, RetryPolicy: &temporal.RetryPolicy{MaximumAttempts: 1}})
+				started := workflow.Now(ctx)
+				awaitDownstreamCapacity(ctx, tt.sinks, Parameters{lagThresholdParameter: 500})
+				return workflow.Now(ctx).Sub(started), nil
+			}, workflow.RegisterOptions{Name: "backpressureWorkflow"})
+
+			env.ExecuteWorkflow("backpressureWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var waited time.Duration
+			require.NoError(t, env.GetWorkflowResult(&waited))
+			assert.Equal(t, tt.wantCheck, checked)
+			assert.Equal(t, tt.wantWait, waited)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const defaultBatchChildTimeout = 30 * time.Minute
+
+// BatchProgressQueryName returns the batch result as it stands
+const BatchProgressQueryName = "batchProgress"
+
+// Batch child statuses. Children still running when the batch stops early
+// are cancelled.
+const (
+	BatchChildCompleted = "completed"
+	BatchChildFailed    = "failed"
+	BatchChildTimedOut  = "timed_out"
+	BatchChildCancelled = "cancelled"
+)
+
+// BatchProcessingInput represents input for the batch processing workflow.
+// Each item runs as a ComplexProcessingWorkflow child.
+type BatchProcessingInput struct {
+	Items []ComplexProcessingInput `json:"items"`
+	// ChildTimeoutSeconds is a soft deadline per child (default: 30m).
+	// Children still running when it passes are terminated.
+	ChildTimeoutSeconds int `json:"child_timeout_seconds,omitempty"`
+	// TimeoutSeconds, when set, bounds the whole batch. When it passes, the
+	// running children are cancelled and the batch returns what completed.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// StartsPerSecond, when set, caps how fast children are started, so a
+	// large batch doesn't start them all in one burst
+	StartsPerSecond float64 `json:"starts_per_second,omitempty"`
+}
+
+// BatchChildStatus reports how one child went
+type BatchChildStatus struct {
+	WorkflowID string `json:"workflow_id"`
+	DatasetID  string `json:"dataset_id"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	// Result is the child's result, once it has completed
+	Result *ComplexProcessingResult `json:"result,omitempty"`
+}
+
+// BatchProcessingResult represents the result of a batch. Stopped says why
+// a batch ended before every child did: "cancelled" or "timed_out".
+type BatchProcessingResult struct {
+	Status    string             `json:"status"`
+	Completed int                `json:"completed"`
+	Failed    int                `json:"failed"`
+	TimedOut  int                `json:"timed_out"`
+	Cancelled int                `json:"cancelled"`
+	Stopped   string             `json:"stopped,omitempty"`
+	Children  []BatchChildStatus `json:"children"`
+}
+
+// batchChild tracks one running child
+type batchChild struct {
+	future      workflow.ChildWorkflowFuture
+	cancelTimer workflow.CancelFunc
+	decided     bool
+}
+
+// BatchProcessingWorkflow runs every item as a child workflow in parallel.
+// With StartsPerSecond set, starts are spaced out by workflow timers.
+// A child that outlives the soft deadline is terminated and recorded as
+// timed out, and the batch carries on with the others. Child results are
+// kept as they arrive, so a batch cancelled or past its overall timeout
+// still completes, returning them as a partial result.
+func BatchProcessingWorkflow(ctx workflow.Context, input BatchProcessingInput) (BatchProcessingResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("📚 Starting batch processing workflow", "items", len(input.Items))
+	logWorkflowInput(ctx, input)
+
+	childTimeout := defaultBatchChildTimeout
+	if input.ChildTimeoutSeconds > 0 {
+		childTimeout = time.Duration(input.ChildTimeoutSeconds) * time.Second
+	}
+
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 30 * time.Second}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    30 * time.Second,
+		MaximumAttempts:    5,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return BatchProcessingResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	result := BatchProcessingResult{Children: make([]BatchChildStatus, len(input.Items))}
+	if err := workflow.SetQueryHandler(ctx, BatchProgressQueryName, func() (BatchProcessingResult, error) {
+		return result, nil
+	}); err != nil {
+		return result, err
+	}
+	children := make([]*batchChild, len(input.Items))
+	selector := workflow.NewSelector(ctx)
+	parentID := workflow.GetInfo(ctx).WorkflowExecution.ID
+
+	// Versioned so batches started before early stopping replay unchanged
+	stopEarly := workflow.GetVersion(ctx, "batch-partial-results", workflow.DefaultVersion, 1) == 1
+	childrenCtx, cancelChildren := workflow.WithCancel(ctx)
+	if !stopEarly {
+		childrenCtx = ctx
+	}
+
+	addStopCases := func() {
+		selector.AddReceive(ctx.Done(), func(c workflow.ReceiveChannel, more bool) {
+			result.Stopped = "cancelled"
+		})
+		if input.TimeoutSeconds > 0 {
+			timeout := time.Duration(input.TimeoutSeconds) * time.Second
+			selector.AddFuture(workflow.NewTimer(ctx, timeout), func(f workflow.Future) {
+				if f.Get(ctx, nil) == nil {
+					result.Stopped = "timed_out"
+				}
+			})
+		}
+	}
+
+	// Throttled starts can take a while, so the batch may stop part way
+	// through them and children that finish meanwhile are recorded
+	var startInterval time.Duration
+	if input.StartsPerSecond > 0 {
+		startInterval = time.Duration(float64(time.Second) / input.StartsPerSecond)
+		if stopEarly {
+			addStopCases()
+		}
+	}
+
+	for i, item := range input.Items {
+		result.Children[i] = BatchChildStatus{WorkflowID: fmt.Sprintf("%s-child-%d", parentID, i), DatasetID: item.DatasetID}
+	}
+
+	for i, item := range input.Items {
+		i := i
+		if i > 0 && startInterval > 0 {
+			waited := false
+			selector.AddFuture(workflow.NewTimer(ctx, startInterval), func(f workflow.Future) {
+				waited = true
+				if f.Get(ctx, nil) != nil {
+					result.Stopped = "cancelled"
+				}
+			})
+			for !waited && result.Stopped == "" {
+				selector.Select(ctx)
+			}
+			if result.Stopped != "" {
+				break
+			}
+		}
+		childID := result.Children[i].WorkflowID
+		childCtx := workflow.WithChildOptions(childrenCtx, workflow.ChildWorkflowOptions{WorkflowID: childID})
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		child := &batchChild{
+			future:      workflow.ExecuteChildWorkflow(childCtx, ComplexProcessingWorkflow, item),
+			cancelTimer: cancelTimer,
+		}
+		children[i] = child
+
+		selector.AddFuture(child.future, func(f workflow.Future) {
+			if child.decided {
+				// Already timed out; this is the termination landing
+				return
+			}
+			child.decided = true
+			child.cancelTimer()
+			var childResult ComplexProcessingResult
+			if err := f.Get(ctx, &childResult); err != nil {
+				if temporal.IsCanceledError(err) && ctx.Err() != nil {
+					// The batch is being cancelled and took the child with it
+					result.Children[i].Status = BatchChildCancelled
+					result.Children[i].Error = "batch cancelled"
+					return
+				}
+				result.Children[i].Status = BatchChildFailed
+				result.Children[i].Error = err.Error()
+				logger.Error("❌ Batch child failed", "workflow_id", childID, "error", err)
+				return
+			}
+			result.Children[i].Status = BatchChildCompleted
+			result.Children[i].Result = &childResult
+		})
+		selector.AddFuture(workflow.NewTimer(timerCtx, childTimeout), func(f workflow.Future) {
+			if child.decided || f.Get(ctx, nil) != nil {
+				// Child finished first and cancelled the timer
+				return
+			}
+			child.decided = true
+			logger.Warn("⏰ Batch child exceeded its deadline, terminating", "workflow_id", childID, "timeout", childTimeout)
+			result.Children[i].Status = BatchChildTimedOut
+			result.Children[i].Error = fmt.Sprintf("exceeded soft deadline of %s", childTimeout)
+			terminateChild(ctx, child, childID, result.Children[i].Error)
+		})
+	}
+
+	if stopEarly && startInterval == 0 {
+		addStopCases()
+	}
+
+	history := watchHistoryLength(ctx)
+	for decided := 0; decided < len(children) && result.Stopped == ""; {
+		selector.Select(ctx)
+		history.check()
+		decided = 0
+		for _, child := range children {
+			if child != nil && child.decided {
+				decided++
+			}
+		}
+	}
+
+	if result.Stopped != "" {
+		logger.Warn("🛑 Batch stopped early, returning completed children", "reason", result.Stopped)
+		cancelChildren()
+		for i, child := range children {
+			if child == nil || !child.decided {
+				// Children never started are cancelled too
+				if child != nil {
+					child.decided = true
+					child.cancelTimer()
+				}
+				result.Children[i].Status = BatchChildCancelled
+				result.Children[i].Error = "batch " + strings.ReplaceAll(result.Stopped, "_", " ")
+			}
+		}
+	}
+
+	for _, child := range result.Children {
+		switch child.Status {
+		case BatchChildCompleted:
+			result.Completed++
+		case BatchChildFailed:
+			result.Failed++
+		case BatchChildTimedOut:
+			result.TimedOut++
+		case BatchChildCancelled:
+			result.Cancelled++
+		}
+	}
+	result.Status = "completed"
+	if result.Failed > 0 || result.TimedOut > 0 || result.Cancelled > 0 {
+		result.Status = "partial"
+	}
+	logger.Info("✅ Batch processing workflow completed", "completed", result.Completed, "failed", result.Failed, "timed_out", result.TimedOut, "cancelled", result.Cancelled)
+	return result, nil
+}
+
+// terminateChild stops a child that ran past its deadline. Termination
+// works even when the child doesn't respond to cancellation.
+func terminateChild(ctx workflow.Context, child *batchChild, childID, reason string) {
+	logger := workflow.GetLogger(ctx)
+	var execution workflow.Execution
+	if err := child.future.GetChildWorkflowExecution().Get(ctx, &execution); err != nil {
+		// Never started, so there is nothing to terminate
+		return
+	}
+	err := workflow.ExecuteActivity(ctx, activities.TerminateWorkflow, TerminateWorkflowInput{
+		WorkflowID: execution.ID,
+		RunID:      execution.RunID,
+		Reason:     reason,
+	}).Get(ctx, nil)
+	if err != nil {
+		logger.Error("❌ Unable to terminate batch child", "workflow_id", childID, "error", err)
+	}
+}
+
+// TerminateWorkflowInput represents input for terminating a workflow
+type TerminateWorkflowInput struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	Reason     string `json:"reason"`
+}
+
+// TerminateWorkflow terminates a workflow run. A run that already closed
+// counts as terminated.
+func (a *Activities) TerminateWorkflow(ctx context.Context, input TerminateWorkflowInput) error {
+	if temporalClient == nil {
+		return temporal.NewNonRetryableApplicationError("no Temporal client configured", "TemporalClientUnavailable", nil)
+	}
+	activityLog.Infof("🛑 Terminating workflow %s: %s", input.WorkflowID, input.Reason)
+	err := temporalClient.TerminateWorkflow(ctx, input.WorkflowID, input.RunID, input.Reason)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
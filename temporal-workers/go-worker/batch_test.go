@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// batchChildRun is how a mocked batch child runs: it takes Duration, then
+// fails with Err if set
+type batchChildRun struct {
+	Duration time.Duration
+	Err      error
+}
+
+// newBatchTestEnv mocks ComplexProcessingWorkflow children by dataset ID
+// and records when each started, relative to the start of the batch
+func newBatchTestEnv(t *testing.T, runs map[string]batchChildRun) (*testsuite.TestWorkflowEnvironment, map[string]time.Duration) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "parent"})
+	env.RegisterWorkflow(ComplexProcessingWorkflow)
+	env.RegisterActivity(activities)
+	start := env.Now()
+
+	starts := map[string]time.Duration{}
+	env.OnWorkflow(ComplexProcessingWorkflow, mock.Anything, mock.Anything).Return(
+		func(ctx workflow.Context, input ComplexProcessingInput) (ComplexProcessingResult, error) {
+			starts[input.DatasetID] = workflow.Now(ctx).Sub(start)
+			run := runs[input.DatasetID]
+			if err := workflow.Sleep(ctx, run.Duration); err != nil {
+				return ComplexProcessingResult{}, err
+			}
+			if run.Err != nil {
+				return ComplexProcessingResult{}, run.Err
+			}
+			return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "completed", ProcessedItems: 10}, nil
+		})
+	return env, starts
+}
+
+func batchItems(datasetIDs ...string) []ComplexProcessingInput {
+	items := make([]ComplexProcessingInput, len(datasetIDs))
+	for i, id := range datasetIDs {
+		items[i] = ComplexProcessingInput{DatasetID: id}
+	}
+	return items
+}
+
+// childStatuses returns each child's status and error, dropping results
+func childStatuses(result BatchProcessingResult) [][2]string {
+	out := make([][2]string, len(result.Children))
+	for i, child := range result.Children {
+		out[i] = [2]string{child.Status, child.Error}
+	}
+	return out
+}
+
+func TestBatchProcessingWorkflowSoftDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		terminateErr error
+		wantStatuses [][2]string
+	}{
+		{
+			name: "stuck child terminated",
+			wantStatuses: [][2]string{
+				{BatchChildCompleted, ""},
+				{BatchChildTimedOut, "exceeded soft deadline of 30m0s"},
+				{BatchChildFailed, ""},
+			},
+		},
+		{
+			// The child is still reported as timed out; its own timeouts
+			// end it eventually
+			name:         "termination fails",
+			terminateErr: temporal.NewNonRetryableApplicationError("permission denied", "PermissionDenied", nil),
+			wantStatuses: [][2]string{
+				{BatchChildCompleted, ""},
+				{BatchChildTimedOut, "exceeded soft deadline of 30m0s"},
+				{BatchChildFailed, ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _ := newBatchTestEnv(t, map[string]batchChildRun{
+				"fast":   {Duration: time.Minute},
+				"stuck":  {Duration: 2 * time.Hour},
+				"broken": {Duration: time.Minute, Err: errors.New("processing failed")},
+			})
+			var terminated []TerminateWorkflowInput
+			env.OnActivity(activities.TerminateWorkflow, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input TerminateWorkflowInput) error {
+					terminated = append(terminated, input)
+					return tt.terminateErr
+				})
+
+			env.ExecuteWorkflow(BatchProcessingWorkflow, BatchProcessingInput{
+				Items:               batchItems("fast", "stuck", "broken"),
+				ChildTimeoutSeconds: 1800,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result BatchProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			require.Len(t, terminated, 1)
+			assert.Equal(t, "parent-child-1", terminated[0].WorkflowID)
+			assert.NotEmpty(t, terminated[0].RunID)
+			assert.Equal(t, "exceeded soft deadline of 30m0s", terminated[0].Reason)
+
+			assert.Contains(t, result.Children[2].Error, "processing failed")
+			result.Children[2].Error = ""
+			assert.Equal(t, tt.wantStatuses, childStatuses(result))
+			assert.Equal(t, "partial", result.Status)
+			assert.Equal(t, []int{1, 1, 1, 0}, []int{result.Completed, result.Failed, result.TimedOut, result.Cancelled})
+			require.NotNil(t, result.Children[0].Result)
+			assert.Equal(t, 10, result.Children[0].Result.ProcessedItems)
+		})
+	}
+}
+
+// terminatingClient is a Temporal client that only terminates workflows
+type terminatingClient struct {
+	client.Client
+	terminated []TerminateWorkflowInput
+	err        error
+}
+
+func (c *terminatingClient) TerminateWorkflow(ctx context.Context, workflowID, runID, reason string, details ...interface{}) error {
+	c.terminated = append(c.terminated, TerminateWorkflowInput{WorkflowID: workflowID, RunID: runID, Reason: reason})
+	return c.err
+}
+
+func TestTerminateWorkflow(t *testing.T) {
+	input := TerminateWorkflowInput{WorkflowID: "parent-child-1", RunID: "run-1", Reason: "exceeded soft deadline of 30m0s"}
+	tests := []struct {
+		name        string
+		client      *terminatingClient
+		wantErr     string
+		wantErrType string
+	}{
+		{name: "terminated", client: &terminatingClient{}},
+		{name: "already closed", client: &terminatingClient{err: serviceerror.NewNotFound("workflow not found")}},
+		{name: "server error", client: &terminatingClient{err: serviceerror.NewUnavailable("frontend down")}, wantErr: "frontend down"},
+		{name: "no client", wantErrType: "TemporalClientUnavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := temporalClient
+			t.Cleanup(func() { temporalClient = prev })
+			temporalClient = nil
+			if tt.client != nil {
+				temporalClient = tt.client
+			}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			_, err := env.ExecuteActivity(activities.TerminateWorkflow, input)
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			default:
+				require.NoError(t, err)
+			}
+			if tt.client != nil {
+				assert.Equal(t, []TerminateWorkflowInput{input}, tt.client.terminated)
+			}
+		})
+	}
+}
+
+func TestBatchProcessingWorkflowStopsEarly(t *testing.T) {
+	runs := map[string]batchChildRun{
+		"a": {Duration: time.Minute},
+		"b": {Duration: 20 * time.Minute},
+		"c": {Duration: 25 * time.Minute},
+	}
+	tests := []struct {
+		name string
+		// cancelAt cancels the batch, if set
+		cancelAt time.Duration
+		// legacy runs as if started before early stopping
+		legacy       bool
+		wantStopped  string
+		wantStatuses [][2]string
+	}{
+		{
+			name:        "timed out",
+			wantStopped: "timed_out",
+			wantStatuses: [][2]string{
+				{BatchChildCompleted, ""}, {BatchChildCancelled, "batch timed out"}, {BatchChildCancelled, "batch timed out"},
+			},
+		},
+		{
+			name:        "cancelled",
+			cancelAt:    5 * time.Minute,
+			wantStopped: "cancelled",
+			wantStatuses: [][2]string{
+				{BatchChildCompleted, ""}, {BatchChildCancelled, "batch cancelled"}, {BatchChildCancelled, "batch cancelled"},
+			},
+		},
+		{
+			name:   "started before early stopping",
+			legacy: true,
+			wantStatuses: [][2]string{
+				{BatchChildCompleted, ""}, {BatchChildCompleted, ""}, {BatchChildCompleted, ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _ := newBatchTestEnv(t, runs)
+			if tt.legacy {
+				env.OnGetVersion("batch-partial-results", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+			}
+			if tt.cancelAt > 0 {
+				env.RegisterDelayedCallback(env.CancelWorkflow, tt.cancelAt)
+			}
+			var progress BatchProcessingResult
+			env.RegisterDelayedCallback(func() {
+				value, err := env.QueryWorkflow(BatchProgressQueryName)
+				require.NoError(t, err)
+				require.NoError(t, value.Get(&progress))
+			}, 2*time.Minute)
+
+			env.ExecuteWorkflow(BatchProcessingWorkflow, BatchProcessingInput{Items: batchItems("a", "b", "c"), TimeoutSeconds: 600})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result BatchProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			assert.Equal(t, [][2]string{{BatchChildCompleted, ""}, {"", ""}, {"", ""}}, childStatuses(progress))
+			assert.Equal(t, tt.wantStopped, result.Stopped)
+			assert.Equal(t, tt.wantStatuses, childStatuses(result))
+			// Completed children keep their results
+			require.NotNil(t, result.Children[0].Result)
+			assert.Equal(t, "a", result.Children[0].Result.DatasetID)
+			if tt.wantStopped == "" {
+				assert.Equal(t, "completed", result.Status)
+				assert.Equal(t, 3, result.Completed)
+				return
+			}
+			assert.Equal(t, "partial", result.Status)
+			assert.Equal(t, 1, result.Completed)
+			assert.Equal(t, 2, result.Cancelled)
+			assert.Nil(t, result.Children[1].Result)
+		})
+	}
+}
+
+func TestBatchProcessingWorkflowThrottlesStarts(t *testing.T) {
+	tests := []struct {
+		name            string
+		startsPerSecond float64
+		timeoutSeconds  int
+		cancelAt        time.Duration
+		wantStarts      map[string]time.Duration
+		wantStatuses    [][2]string
+	}{
+		{
+			name:         "unthrottled",
+			wantStarts:   map[string]time.Duration{"a": 0, "b": 0, "c": 0},
+			wantStatuses: [][2]string{{BatchChildCompleted, ""}, {BatchChildCompleted, ""}, {BatchChildCompleted, ""}},
+		},
+		{
+			name:            "throttled",
+			startsPerSecond: 0.01,
+			wantStarts:      map[string]time.Duration{"a": 0, "b": 100 * time.Second, "c": 200 * time.Second},
+			wantStatuses:    [][2]string{{BatchChildCompleted, ""}, {BatchChildCompleted, ""}, {BatchChildCompleted, ""}},
+		},
+		{
+			name:            "timed out between starts",
+			startsPerSecond: 1.0 / 600,
+			timeoutSeconds:  900,
+			wantStarts:      map[string]time.Duration{"a": 0, "b": 10 * time.Minute},
+			wantStatuses:    [][2]string{{BatchChildCompleted, ""}, {BatchChildCompleted, ""}, {BatchChildCancelled, "batch timed out"}},
+		},
+		{
+			name:            "cancelled between starts",
+			startsPerSecond: 1.0 / 600,
+			cancelAt:        5 * time.Minute,
+			wantStarts:      map[string]time.Duration{"a": 0},
+			wantStatuses:    [][2]string{{BatchChildCompleted, ""}, {BatchChildCancelled, "batch cancelled"}, {BatchChildCancelled, "batch cancelled"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, starts := newBatchTestEnv(t, map[string]batchChildRun{
+				"a": {Duration: time.Minute}, "b": {Duration: time.Minute}, "c": {Duration: time.Minute},
+			})
+			if tt.cancelAt > 0 {
+				env.RegisterDelayedCallback(env.CancelWorkflow, tt.cancelAt)
+			}
+
+			env.ExecuteWorkflow(BatchProcessingWorkflow, BatchProcessingInput{
+				Items:           batchItems("a", "b", "c"),
+				StartsPerSecond: tt.startsPerSecond,
+				TimeoutSeconds:  tt.timeoutSeconds,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result BatchProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			assert.Equal(t, tt.wantStarts, starts)
+			assert.Equal(t, tt.wantStatuses, childStatuses(result))
+		})
+	}
+}
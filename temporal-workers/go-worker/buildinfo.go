@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version and gitCommit are injected at build time:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.gitCommit=$(git rev-parse HEAD)"
+var (
+	version   = "v1.0.0"
+	gitCommit = ""
+)
+
+// defaultBuildID derives the worker build ID from the version and git commit
+// so every build gets a unique versioning ID without anyone editing it. The
+// commit comes from ldflags, falling back to the VCS stamp the Go toolchain
+// embeds when building inside a git checkout. Without either, the ID is just
+// "go-<version>".
+func defaultBuildID() string {
+	commit, dirty := gitCommit, false
+	if commit == "" {
+		commit, dirty = vcsRevision()
+	}
+	if commit == "" {
+		return "go-" + version
+	}
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	id := fmt.Sprintf("go-%s-%s", version, commit)
+	if dirty {
+		id += "-dirty"
+	}
+	return id
+}
+
+// readBuildInfo is debug.ReadBuildInfo, swapped out in tests since test
+// binaries carry no VCS stamp
+var readBuildInfo = debug.ReadBuildInfo
+
+func vcsRevision() (revision string, modified bool) {
+	info, ok := readBuildInfo()
+	if !ok {
+		return "", false
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	return revision, modified
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultBuildID(t *testing.T) {
+	stamped := func(settings ...debug.BuildSetting) func() (*debug.BuildInfo, bool) {
+		return func() (*debug.BuildInfo, bool) { return &debug.BuildInfo{Settings: settings}, true }
+	}
+	tests := []struct {
+		name      string
+		gitCommit string
+		buildInfo func() (*debug.BuildInfo, bool)
+		want      string
+	}{
+		{name: "ldflags commit", gitCommit: "0123456789abcdef0123", buildInfo: stamped(), want: "go-v1.2.0-0123456789ab"},
+		{name: "short ldflags commit", gitCommit: "abc123", buildInfo: stamped(), want: "go-v1.2.0-abc123"},
+		{
+			name:      "ldflags win over the stamp",
+			gitCommit: "abc123",
+			buildInfo: stamped(debug.BuildSetting{Key: "vcs.revision", Value: "fedcba9876543210"}, debug.BuildSetting{Key: "vcs.modified", Value: "true"}),
+			want:      "go-v1.2.0-abc123",
+		},
+		{
+			name:      "vcs stamp",
+			buildInfo: stamped(debug.BuildSetting{Key: "vcs.revision", Value: "fedcba9876543210"}, debug.BuildSetting{Key: "vcs.modified", Value: "false"}),
+			want:      "go-v1.2.0-fedcba987654",
+		},
+		{
+			name:      "dirty checkout",
+			buildInfo: stamped(debug.BuildSetting{Key: "vcs.revision", Value: "fedcba9876543210"}, debug.BuildSetting{Key: "vcs.modified", Value: "true"}),
+			want:      "go-v1.2.0-fedcba987654-dirty",
+		},
+		{name: "no stamp", buildInfo: stamped(), want: "go-v1.2.0"},
+		{name: "no build info", buildInfo: func() (*debug.BuildInfo, bool) { return nil, false }, want: "go-v1.2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevVersion, prevCommit, prevRead := version, gitCommit, readBuildInfo
+			t.Cleanup(func() { version, gitCommit, readBuildInfo = prevVersion, prevCommit, prevRead })
+			version, gitCommit, readBuildInfo = "v1.2.0", tt.gitCommit, tt.buildInfo
+
+			assert.Equal(t, tt.want, defaultBuildID())
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeCanaryFailed is returned, without retries, when canary metrics
+// fall outside their bounds. Its details carry the CanaryResult.
+const ErrTypeCanaryFailed = "CanaryFailed"
+
+const defaultCanarySampleFraction = 0.01
+
+// MetricBounds is the accepted range of a canary metric; either end may be
+// open
+type MetricBounds struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// CanaryInput represents input for a canary-gated run. Bounds are keyed by
+// canary metric: the processing metrics (throughput, cpu_utilization,
+// memory_usage), numeric processing results (success_rate, error_count),
+// items_processed, quarantined and quarantine_rate.
+type CanaryInput struct {
+	Input          ComplexProcessingInput  `json:"input"`
+	SampleFraction float64                 `json:"sample_fraction,omitempty"` // default: 0.01
+	Bounds         map[string]MetricBounds `json:"bounds"`
+}
+
+// CanaryViolation is a canary metric outside its bounds, or missing
+type CanaryViolation struct {
+	Metric string       `json:"metric"`
+	Value  *float64     `json:"value,omitempty"`
+	Bounds MetricBounds `json:"bounds"`
+}
+
+// CanaryResult represents the outcome of a canary-gated run. Full is set
+// once the canary passed and the full run finished.
+type CanaryResult struct {
+	Passed         bool                     `json:"passed"`
+	SampleFraction float64                  `json:"sample_fraction"`
+	Metrics        map[string]float64       `json:"metrics"`
+	Violations     []CanaryViolation        `json:"violations,omitempty"`
+	Full           *ComplexProcessingResult `json:"full,omitempty"`
+}
+
+// CanaryWorkflow processes a sample of the dataset first and checks its
+// metrics against the expected bounds. Only a passing canary goes on to the
+// full ComplexProcessingWorkflow run; a failing one aborts before any of
+// the full dataset is touched.
+func CanaryWorkflow(ctx workflow.Context, input CanaryInput) (CanaryResult, error) {
+	logger := workflow.GetLogger(ctx)
+	fraction := input.SampleFraction
+	if fraction <= 0 || fraction >= 1 {
+		fraction = defaultCanarySampleFraction
+	}
+	logger.Info("🐤 Starting canary workflow", "dataset_id", input.Input.DatasetID, "sample_fraction", fraction)
+	logWorkflowInput(ctx, input)
+
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 5 * time.Minute}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    30 * time.Second,
+		MaximumAttempts:    3,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return CanaryResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	parameters := mergeParameters(Parameters{}, input.Input.Parameters)
+	parameters["sample_fraction"] = fraction
+	var sample ProcessLargeDatasetResult
+	err = workflow.ExecuteActivity(ctx, activities.ProcessLargeDataset, ProcessLargeDatasetInput{
+		DatasetID:   input.Input.DatasetID,
+		ProcessType: input.Input.ProcessType,
+		Parameters:  parameters,
+	}).Get(ctx, &sample)
+	if err != nil {
+		logger.Error("❌ Canary processing failed", "error", err)
+		return CanaryResult{SampleFraction: fraction}, err
+	}
+
+	result := CanaryResult{SampleFraction: fraction, Metrics: canaryMetrics(sample)}
+	result.Violations = checkCanaryBounds(result.Metrics, input.Bounds)
+	result.Passed = len(result.Violations) == 0
+	if !result.Passed {
+		names := make([]string, len(result.Violations))
+		for i, v := range result.Violations {
+			names[i] = v.Metric
+		}
+		logger.Error("❌ Canary failed, aborting full run", "violations", result.Violations)
+		return result, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("canary of %s out of bounds: %s", input.Input.DatasetID, strings.Join(names, ", ")),
+			ErrTypeCanaryFailed, nil, result)
+	}
+	logger.Info("✅ Canary passed, starting full run", "metrics", result.Metrics)
+
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID: workflow.GetInfo(ctx).WorkflowExecution.ID + "-full",
+	})
+	var full ComplexProcessingResult
+	err = workflow.ExecuteChildWorkflow(childCtx, ComplexProcessingWorkflow, input.Input).Get(ctx, &full)
+	result.Full = &full
+	if err != nil {
+		logger.Error("❌ Full run failed", "error", err)
+		return result, err
+	}
+	logger.Info("✅ Canary workflow completed", "processed_items", full.ProcessedItems)
+	return result, nil
+}
+
+// canaryMetrics flattens a sample's result into the metrics bounds apply to
+func canaryMetrics(sample ProcessLargeDatasetResult) map[string]float64 {
+	metrics := sample.Metrics.vars()
+	results := Parameters(sample.Results)
+	for name := range sample.Results {
+		if value, ok := results.Float64(name); ok {
+			metrics[name] = value
+		}
+	}
+	metrics["items_processed"] = float64(sample.ItemsProcessed)
+	metrics["quarantined"] = float64(sample.Quarantined)
+	if total := sample.ItemsProcessed + sample.Quarantined; total > 0 {
+		metrics["quarantine_rate"] = float64(sample.Quarantined) / float64(total)
+	}
+	return metrics
+}
+
+// checkCanaryBounds returns the metrics outside their bounds, by name. A
+// bounded metric the canary didn't report is a violation too.
+func checkCanaryBounds(metrics map[string]float64, bounds map[string]MetricBounds) []CanaryViolation {
+	names := make([]string, 0, len(bounds))
+	for name := range bounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []CanaryViolation
+	for _, name := range names {
+		b := bounds[name]
+		value, ok := metrics[name]
+		switch {
+		case !ok:
+			violations = append(violations, CanaryViolation{Metric: name, Bounds: b})
+		case b.Min != nil && value < *b.Min, b.Max != nil && value > *b.Max:
+			value := value
+			violations = append(violations, CanaryViolation{Metric: name, Value: &value, Bounds: b})
+		}
+	}
+	return violations
+}
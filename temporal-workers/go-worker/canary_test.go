@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestCanaryWorkflow(t *testing.T) {
+	sample := ProcessLargeDatasetResult{
+		ItemsProcessed: 9,
+		Quarantined:    1,
+		Metrics:        ProcessingMetrics{Throughput: 500, CPUUtilization: 0.4},
+		Results:        map[string]interface{}{"success_rate": 0.97, "label": "sample"},
+	}
+	tests := []struct {
+		name           string
+		bounds         map[string]MetricBounds
+		sampleErr      error
+		wantViolations []string
+		wantErr        string
+	}{
+		{
+			name: "passes",
+			bounds: map[string]MetricBounds{
+				"success_rate":    {Min: float64Ptr(0.95)},
+				"quarantine_rate": {Max: float64Ptr(0.2)},
+				"throughput":      {Min: float64Ptr(100), Max: float64Ptr(1000)},
+			},
+		},
+		{
+			name: "out of bounds",
+			bounds: map[string]MetricBounds{
+				"success_rate":    {Min: float64Ptr(0.99)},
+				"quarantine_rate": {Max: float64Ptr(0.05)},
+				"throughput":      {Min: float64Ptr(100)},
+			},
+			wantViolations: []string{"quarantine_rate", "success_rate"},
+			wantErr:        "canary of ds-1 out of bounds: quarantine_rate, success_rate",
+		},
+		{
+			name:           "metric not reported",
+			bounds:         map[string]MetricBounds{"error_count": {Max: float64Ptr(0)}},
+			wantViolations: []string{"error_count"},
+			wantErr:        "canary of ds-1 out of bounds: error_count",
+		},
+		{name: "canary processing fails", sampleErr: temporal.NewNonRetryableApplicationError("source unavailable", "SourceUnavailable", nil), wantErr: "source unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var fractions []float64
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(ComplexProcessingWorkflow)
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(func(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				fraction, _ := input.Parameters.Float64("sample_fraction")
+				fractions = append(fractions, fraction)
+				if fraction > 0 {
+					return sample, tt.sampleErr
+				}
+				return ProcessLargeDatasetResult{ItemsProcessed: 1000, ProcessingTime: "1s"}, nil
+			})
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			env.ExecuteWorkflow(CanaryWorkflow, CanaryInput{
+				Input:  ComplexProcessingInput{Version: CurrentComplexProcessingInputVersion, DatasetID: "ds-1"},
+				Bounds: tt.bounds,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+
+			if tt.wantErr != "" {
+				err := env.GetWorkflowError()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, []float64{defaultCanarySampleFraction}, fractions, "the full run started")
+				if tt.wantViolations == nil {
+					return
+				}
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, ErrTypeCanaryFailed, appErr.Type())
+				var result CanaryResult
+				require.NoError(t, appErr.Details(&result))
+				assert.False(t, result.Passed)
+				var violations []string
+				for _, v := range result.Violations {
+					violations = append(violations, v.Metric)
+				}
+				assert.Equal(t, tt.wantViolations, violations)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result CanaryResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.True(t, result.Passed)
+			assert.Equal(t, defaultCanarySampleFraction, result.SampleFraction)
+			assert.Equal(t, 0.1, result.Metrics["quarantine_rate"])
+			assert.Equal(t, 0.97, result.Metrics["success_rate"])
+			assert.NotContains(t, result.Metrics, "label")
+			require.NotNil(t, result.Full)
+			assert.Equal(t, 1000, result.Full.ProcessedItems)
+			assert.Equal(t, []float64{defaultCanarySampleFraction, 0}, fractions)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// CancelReasonSignalName carries why a workflow is being cancelled.
+// Temporal's cancel request has no reason of its own, so callers signal
+// one before cancelling.
+const CancelReasonSignalName = "cancelReason"
+
+// CancelReason is the payload of the cancelReason signal
+type CancelReason struct {
+	Reason      string `json:"reason"`
+	RequestedBy string `json:"requested_by,omitempty"`
+}
+
+// cancellationAudit writes an AuditLog entry when its workflow is
+// cancelled, recording the signalled reason and the step it was at
+type cancellationAudit struct {
+	ctx       workflow.Context
+	datasetID string
+	step      func() string
+}
+
+// auditCancellation prepares the audit of ctx's workflow. step reports
+// where the workflow currently is; call record when the workflow returns.
+func auditCancellation(ctx workflow.Context, datasetID string, step func() string) *cancellationAudit {
+	return &cancellationAudit{ctx: ctx, datasetID: datasetID, step: step}
+}
+
+// record writes the audit entry if the workflow was cancelled. It runs in a
+// disconnected context, since the workflow's own context is already done.
+func (a *cancellationAudit) record() {
+	if a.ctx.Err() != workflow.ErrCanceled {
+		return
+	}
+	ctx, _ := workflow.NewDisconnectedContext(a.ctx)
+	if workflow.GetVersion(ctx, "cancellation-audit", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return
+	}
+	// Signals wait in the channel until read; the latest reason wins
+	var reason, latest CancelReason
+	for workflow.GetSignalChannel(ctx, CancelReasonSignalName).ReceiveAsync(&latest) {
+		reason = latest
+	}
+	if reason.Reason == "" {
+		reason.Reason = "no reason given"
+	}
+
+	step := a.step()
+	logger := workflow.GetLogger(ctx)
+	logger.Warn("🛑 Workflow cancelled", "step", step, "reason", reason.Reason)
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: 30 * time.Second})
+	err := workflow.ExecuteActivity(ctx, activities.AuditLog, AuditLogInput{
+		Action:    "workflow_cancelled",
+		DatasetID: a.datasetID,
+		Details: map[string]interface{}{
+			"workflow_type": workflow.GetInfo(ctx).WorkflowType.Name,
+			"step":          step,
+			"reason":        reason.Reason,
+			"requested_by":  reason.RequestedBy,
+		},
+	}).Get(ctx, nil)
+	if err != nil {
+		logger.Error("❌ Unable to audit cancellation", "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestComplexProcessingCancellationAudit(t *testing.T) {
+	tests := []struct {
+		name string
+		// reasons are signalled before cancelling, in turn
+		reasons    []CancelReason
+		cancelAt   time.Duration
+		auditErr   error
+		wantAudit  bool
+		wantStep   string
+		wantReason string
+	}{
+		{
+			name:       "cancelled mid-processing",
+			reasons:    []CancelReason{{Reason: "superseded"}, {Reason: "bad input", RequestedBy: "ops"}},
+			cancelAt:   2 * time.Minute,
+			wantAudit:  true,
+			wantStep:   "process_dataset",
+			wantReason: "bad input",
+		},
+		{name: "cancelled without a reason", cancelAt: 2 * time.Minute, wantAudit: true, wantStep: "process_dataset", wantReason: "no reason given"},
+		{name: "audit fails", cancelAt: 2 * time.Minute, auditErr: temporal.NewNonRetryableApplicationError("audit store down", "AuditUnavailable", nil), wantAudit: true, wantStep: "process_dataset", wantReason: "no reason given"},
+		{name: "not cancelled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var audits []AuditLogInput
+			logger := &recordingLogger{}
+			var suite testsuite.WorkflowTestSuite
+			suite.SetLogger(logger)
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).After(5*time.Minute).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "5m"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(func(ctx context.Context, input AuditLogInput) error {
+				mu.Lock()
+				defer mu.Unlock()
+				audits = append(audits, input)
+				if input.Action == "workflow_cancelled" {
+					return tt.auditErr
+				}
+				return nil
+			})
+			if tt.cancelAt > 0 {
+				for _, reason := range tt.reasons {
+					reason := reason
+					env.RegisterDelayedCallback(func() {
+						env.SignalWorkflow(CancelReasonSignalName, reason)
+					}, time.Minute)
+				}
+				env.RegisterDelayedCallback(env.CancelWorkflow, tt.cancelAt)
+			}
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:   CurrentComplexProcessingInputVersion,
+				DatasetID: "ds-1",
+			})
+			require.True(t, env.IsWorkflowCompleted())
+
+			var cancelled []AuditLogInput
+			for _, audit := range audits {
+				if audit.Action == "workflow_cancelled" {
+					cancelled = append(cancelled, audit)
+				}
+			}
+			if !tt.wantAudit {
+				require.NoError(t, env.GetWorkflowError())
+				assert.Empty(t, cancelled)
+				return
+			}
+			require.Error(t, env.GetWorkflowError())
+			assert.True(t, temporal.IsCanceledError(env.GetWorkflowError()), env.GetWorkflowError())
+			require.Len(t, cancelled, 1)
+			audit := cancelled[0]
+			assert.Equal(t, "ds-1", audit.DatasetID)
+			assert.Equal(t, "ComplexProcessingWorkflow", audit.Details["workflow_type"])
+			assert.Equal(t, tt.wantStep, audit.Details["step"])
+			assert.Equal(t, tt.wantReason, audit.Details["reason"])
+			assert.NotNil(t, logger.warns["🛑 Workflow cancelled"])
+			if tt.auditErr != nil {
+				assert.Contains(t, logger.errors, "❌ Unable to audit cancellation")
+			} else {
+				assert.NotContains(t, logger.errors, "❌ Unable to audit cancellation")
+			}
+		})
+	}
+}
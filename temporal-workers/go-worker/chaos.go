@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeChaosInjected is the type of failures injected in chaos mode. They
+// are retryable, like the transient errors they stand in for.
+const ErrTypeChaosInjected = "ChaosInjected"
+
+// chaosConfig controls fault injection. Rates are probabilities per
+// activity attempt; an empty Activities set targets every activity.
+type chaosConfig struct {
+	FailureRate float64
+	LatencyRate float64
+	MinLatency  time.Duration
+	MaxLatency  time.Duration
+	Activities  map[string]bool
+	Seed        int64
+}
+
+// chaosConfigFromEnv reads chaos mode settings. It reports false unless
+// CHAOS=true, and in a production ENVIRONMENT unless CHAOS_ALLOW_PRODUCTION
+// is also true.
+func chaosConfigFromEnv() (chaosConfig, bool, error) {
+	if os.Getenv("CHAOS") != "true" {
+		return chaosConfig{}, false, nil
+	}
+	switch strings.ToLower(os.Getenv("ENVIRONMENT")) {
+	case "prod", "production":
+		if os.Getenv("CHAOS_ALLOW_PRODUCTION") != "true" {
+			log.Printf("⚠️ CHAOS is ignored in %s; set CHAOS_ALLOW_PRODUCTION=true to override", os.Getenv("ENVIRONMENT"))
+			return chaosConfig{}, false, nil
+		}
+	}
+
+	config := chaosConfig{
+		MinLatency: getEnvDuration("CHAOS_MIN_LATENCY", 0),
+		MaxLatency: getEnvDuration("CHAOS_MAX_LATENCY", 5*time.Second),
+		Seed:       time.Now().UnixNano(),
+	}
+	var err error
+	if config.FailureRate, err = chaosRate("CHAOS_FAILURE_RATE", 0.1); err != nil {
+		return config, false, err
+	}
+	if config.LatencyRate, err = chaosRate("CHAOS_LATENCY_RATE", 0.2); err != nil {
+		return config, false, err
+	}
+	if config.MaxLatency < config.MinLatency {
+		return config, false, fmt.Errorf("CHAOS_MAX_LATENCY %s is below CHAOS_MIN_LATENCY %s", config.MaxLatency, config.MinLatency)
+	}
+	if seed := os.Getenv("CHAOS_SEED"); seed != "" {
+		if config.Seed, err = strconv.ParseInt(seed, 10, 64); err != nil {
+			return config, false, fmt.Errorf("CHAOS_SEED %q: must be an integer", seed)
+		}
+	}
+	for _, name := range strings.Split(os.Getenv("CHAOS_ACTIVITIES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if config.Activities == nil {
+				config.Activities = make(map[string]bool)
+			}
+			config.Activities[name] = true
+		}
+	}
+	return config, true, nil
+}
+
+func chaosRate(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%s %q: must be a probability between 0 and 1", key, value)
+	}
+	return rate, nil
+}
+
+// chaosInterceptor delays and fails activity attempts at random so retry
+// policies and timeouts can be exercised outside production. Latency is
+// injected before the activity runs and counts against its timeouts.
+type chaosInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	config chaosConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newChaosInterceptor(config chaosConfig) *chaosInterceptor {
+	return &chaosInterceptor{config: config, rng: rand.New(rand.NewSource(config.Seed))}
+}
+
+// next draws the fault for one attempt
+func (i *chaosInterceptor) next() (delay time.Duration, fail bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.rng.Float64() < i.config.LatencyRate {
+		delay = i.config.MinLatency
+		if spread := i.config.MaxLatency - i.config.MinLatency; spread > 0 {
+			delay += time.Duration(i.rng.Int63n(int64(spread)))
+		}
+	}
+	return delay, i.rng.Float64() < i.config.FailureRate
+}
+
+func (i *chaosInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	a := &chaosActivityInterceptor{root: i}
+	a.Next = next
+	return a
+}
+
+type chaosActivityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	root *chaosInterceptor
+}
+
+func (a *chaosActivityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	info := activity.GetInfo(ctx)
+	if targets := a.root.config.Activities; len(targets) > 0 && !targets[info.ActivityType.Name] {
+		return a.Next.ExecuteActivity(ctx, in)
+	}
+
+	delay, fail := a.root.next()
+	if delay > 0 {
+		activityLog.Infof("🐒 Chaos: delaying %s by %s", info.ActivityType.Name, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if fail {
+		activityLog.Errorf("🐒 Chaos: failing %s (attempt %d)", info.ActivityType.Name, info.Attempt)
+		return nil, temporal.NewApplicationError(fmt.Sprintf("chaos: injected failure in %s", info.ActivityType.Name), ErrTypeChaosInjected)
+	}
+	return a.Next.ExecuteActivity(ctx, in)
+}
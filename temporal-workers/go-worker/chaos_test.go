@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestChaosConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantEnabled bool
+		want        chaosConfig
+		wantErr     string
+	}{
+		{name: "disabled by default"},
+		{
+			name:        "enabled",
+			env:         map[string]string{"CHAOS": "true", "CHAOS_FAILURE_RATE": "0.3", "CHAOS_MAX_LATENCY": "1s", "CHAOS_SEED": "42", "CHAOS_ACTIVITIES": " AuditLog, CacheOperation "},
+			wantEnabled: true,
+			want: chaosConfig{
+				FailureRate: 0.3,
+				LatencyRate: 0.2,
+				MaxLatency:  time.Second,
+				Activities:  map[string]bool{"AuditLog": true, "CacheOperation": true},
+				Seed:        42,
+			},
+		},
+		{name: "ignored in production", env: map[string]string{"CHAOS": "true", "ENVIRONMENT": "Production"}},
+		{
+			name:        "allowed in production",
+			env:         map[string]string{"CHAOS": "true", "ENVIRONMENT": "prod", "CHAOS_ALLOW_PRODUCTION": "true", "CHAOS_SEED": "1"},
+			wantEnabled: true,
+			want:        chaosConfig{FailureRate: 0.1, LatencyRate: 0.2, MaxLatency: 5 * time.Second, Seed: 1},
+		},
+		{name: "rate out of range", env: map[string]string{"CHAOS": "true", "CHAOS_FAILURE_RATE": "1.5"}, wantErr: `CHAOS_FAILURE_RATE "1.5": must be a probability between 0 and 1`},
+		{name: "latency bounds reversed", env: map[string]string{"CHAOS": "true", "CHAOS_MIN_LATENCY": "2s", "CHAOS_MAX_LATENCY": "1s"}, wantErr: "CHAOS_MAX_LATENCY 1s is below CHAOS_MIN_LATENCY 2s"},
+		{name: "invalid seed", env: map[string]string{"CHAOS": "true", "CHAOS_SEED": "abc"}, wantErr: `CHAOS_SEED "abc": must be an integer`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"CHAOS", "ENVIRONMENT", "CHAOS_ALLOW_PRODUCTION", "CHAOS_FAILURE_RATE", "CHAOS_LATENCY_RATE", "CHAOS_MIN_LATENCY", "CHAOS_MAX_LATENCY", "CHAOS_SEED", "CHAOS_ACTIVITIES"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			config, enabled, err := chaosConfigFromEnv()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.EqualError(t, err, tt.wantErr)
+				assert.False(t, enabled)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnabled, enabled)
+			if tt.wantEnabled {
+				assert.Equal(t, tt.want, config)
+			}
+		})
+	}
+}
+
+// TestChaosInterceptorRates checks that a seeded interceptor injects faults
+// at roughly the configured rates, within the configured latency bounds
+func TestChaosInterceptorRates(t *testing.T) {
+	const draws = 10000
+	chaos := newChaosInterceptor(chaosConfig{FailureRate: 0.3, LatencyRate: 0.5, MinLatency: time.Second, MaxLatency: 2 * time.Second, Seed: 7})
+	var failures, delays int
+	for i := 0; i < draws; i++ {
+		delay, fail := chaos.next()
+		if fail {
+			failures++
+		}
+		if delay > 0 {
+			delays++
+			assert.GreaterOrEqual(t, delay, time.Second)
+			assert.Less(t, delay, 2*time.Second)
+		}
+	}
+	assert.InDelta(t, 0.3, float64(failures)/draws, 0.02)
+	assert.InDelta(t, 0.5, float64(delays)/draws, 0.02)
+
+	// The same seed injects the same faults
+	again := newChaosInterceptor(chaosConfig{FailureRate: 0.3, LatencyRate: 0.5, MinLatency: time.Second, MaxLatency: 2 * time.Second, Seed: 7})
+	replay := newChaosInterceptor(chaosConfig{FailureRate: 0.3, LatencyRate: 0.5, MinLatency: time.Second, MaxLatency: 2 * time.Second, Seed: 7})
+	for i := 0; i < 100; i++ {
+		delay, fail := again.next()
+		replayDelay, replayFail := replay.next()
+		require.Equal(t, delay, replayDelay)
+		require.Equal(t, fail, replayFail)
+	}
+}
+
+func TestChaosInterceptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     chaosConfig
+		wantFailed bool
+	}{
+		{name: "no faults", config: chaosConfig{}},
+		{name: "injected failure", config: chaosConfig{FailureRate: 1}, wantFailed: true},
+		{name: "other activities spared", config: chaosConfig{FailureRate: 1, Activities: map[string]bool{"AuditLog": true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{newChaosInterceptor(tt.config)}})
+			env.RegisterActivityWithOptions(func(ctx context.Context) error {
+				calls++
+				return nil
+			}, activity.RegisterOptions{Name: "flaky"})
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) error {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+					StartToCloseTimeout: time.Minute,
+					RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+				})
+				return workflow.ExecuteActivity(ctx, "flaky").Get(ctx, nil)
+			}, workflow.RegisterOptions{Name: "chaosWorkflow"})
+
+			env.ExecuteWorkflow("chaosWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			if !tt.wantFailed {
+				require.NoError(t, env.GetWorkflowError())
+				assert.Equal(t, 1, calls)
+				return
+			}
+			err := env.GetWorkflowError()
+			var appErr *temporal.ApplicationError
+			require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+			assert.Equal(t, ErrTypeChaosInjected, appErr.Type())
+			assert.False(t, appErr.NonRetryable())
+			assert.Contains(t, appErr.Error(), "chaos: injected failure in flaky")
+			assert.Zero(t, calls)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Command bulk-start starts one workflow per line of a JSONL file.
+//
+//	go run ./cmd/bulk-start -file inputs.jsonl -workflow ComplexProcessingWorkflow
+//
+// Each line is passed as the workflow's single argument. Workflow IDs are
+// derived from the line's content, so rerunning a partially failed file
+// only starts what is missing; lines already started are counted as
+// skipped. It dials Temporal with the worker's TEMPORAL_* settings and data
+// converter (DATA_CONVERTER, PAYLOAD_ENCRYPTION_KEY), so inputs are encrypted
+// like the worker's.
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"temporal-go-worker/internal/payload"
+	"temporal-go-worker/internal/temporalconn"
+)
+
+const (
+	maxStartAttempts = 5
+	maxLineBytes     = 4 << 20
+)
+
+// workflowStarter is the part of client.Client used to start workflows
+type workflowStarter interface {
+	ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error)
+}
+
+type bulkOptions struct {
+	workflow    string
+	taskQueue   string
+	idPrefix    string
+	concurrency int
+	retryDelay  time.Duration
+	// rateLimit paces retries of starts the server rejects with
+	// ResourceExhausted; retryDelay covers the other transient errors
+	rateLimit temporalconn.RateLimitBackoff
+}
+
+type bulkSummary struct {
+	started atomic.Int64
+	skipped atomic.Int64
+	failed  atomic.Int64
+}
+
+func (s *bulkSummary) done() int64 {
+	return s.started.Load() + s.skipped.Load() + s.failed.Load()
+}
+
+func main() {
+	file := flag.String("file", "", "JSONL file with one workflow input per line (- for stdin)")
+	workflowType := flag.String("workflow", "ComplexProcessingWorkflow", "workflow type to start")
+	taskQueue := flag.String("task-queue", getEnv("TASK_QUEUE", "go-workers"), "task queue to start workflows on")
+	idPrefix := flag.String("id-prefix", "bulk", "prefix of the derived workflow IDs")
+	concurrency := flag.Int("concurrency", 10, "maximum starts in flight")
+	flag.Parse()
+
+	if *file == "" || *concurrency < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("❌ Unable to open input file: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	clientOptions, err := temporalconn.ClientOptionsFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Invalid Temporal configuration: %v", err)
+	}
+	if clientOptions.DataConverter, err = payload.DataConverterFromEnv(client.MetricsNopHandler); err != nil {
+		log.Fatalf("❌ Invalid data converter configuration: %v", err)
+	}
+	c, err := client.Dial(clientOptions)
+	if err != nil {
+		log.Fatalf("❌ Unable to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	summary, err := bulkStart(context.Background(), c, in, os.Stderr, bulkOptions{
+		workflow:    *workflowType,
+		taskQueue:   temporalconn.TaskQueue(*taskQueue),
+		idPrefix:    *idPrefix,
+		concurrency: *concurrency,
+		retryDelay:  time.Second,
+		rateLimit:   temporalconn.DefaultRateLimitBackoff,
+	})
+	if err != nil {
+		log.Fatalf("❌ Unable to read input file: %v", err)
+	}
+
+	log.Printf("📊 Bulk start finished: %d started, %d skipped (already started), %d failed",
+		summary.started.Load(), summary.skipped.Load(), summary.failed.Load())
+	if summary.failed.Load() > 0 {
+		os.Exit(1)
+	}
+}
+
+// bulkStart starts a workflow for every non-empty line of in, at most
+// opts.concurrency at a time, reporting progress to progress
+func bulkStart(ctx context.Context, starter workflowStarter, in io.Reader, progress io.Writer, opts bulkOptions) (*bulkSummary, error) {
+	var summary bulkSummary
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.concurrency)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	total := 0
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		total++
+		if !json.Valid([]byte(line)) {
+			log.Printf("❌ Line %d: invalid JSON", lineNo)
+			summary.failed.Add(1)
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(lineNo int, line string) {
+			defer func() {
+				<-sem
+				wg.Done()
+				fmt.Fprintf(progress, "\r⏳ %d done: %d started, %d skipped, %d failed",
+					summary.done(), summary.started.Load(), summary.skipped.Load(), summary.failed.Load())
+			}()
+			id := workflowID(opts.idPrefix, line)
+			err := startWithRetry(ctx, starter, opts, id, json.RawMessage(line))
+			var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+			switch {
+			case err == nil:
+				summary.started.Add(1)
+			case errors.As(err, &alreadyStarted):
+				summary.skipped.Add(1)
+			default:
+				log.Printf("❌ Line %d (%s): %v", lineNo, id, err)
+				summary.failed.Add(1)
+			}
+		}(lineNo, line)
+	}
+	wg.Wait()
+	if total > 0 {
+		fmt.Fprintln(progress)
+	}
+	return &summary, scanner.Err()
+}
+
+// workflowID derives a stable ID from the input, so the same line always
+// maps to the same workflow
+func workflowID(prefix, line string) string {
+	sum := sha256.Sum256([]byte(line))
+	return prefix + "-" + hex.EncodeToString(sum[:8])
+}
+
+func startWithRetry(ctx context.Context, starter workflowStarter, opts bulkOptions, id string, input json.RawMessage) error {
+	startOptions := client.StartWorkflowOptions{
+		ID:                                       id,
+		TaskQueue:                                opts.taskQueue,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+	delay := opts.retryDelay
+	var err error
+	for attempt := 1; attempt <= maxStartAttempts; attempt++ {
+		err = opts.rateLimit.Do(ctx, func() error {
+			_, err := starter.ExecuteWorkflow(ctx, startOptions, opts.workflow, input)
+			return err
+		})
+		if err == nil || !transient(err) {
+			return err
+		}
+		if attempt < maxStartAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", maxStartAttempts, err)
+}
+
+// transient reports whether a start error may succeed on retry.
+// ResourceExhausted isn't: rateLimit has already retried it with backoff.
+func transient(err error) bool {
+	var unavailable *serviceerror.Unavailable
+	var deadline *serviceerror.DeadlineExceeded
+	return errors.As(err, &unavailable) || errors.As(err, &deadline) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
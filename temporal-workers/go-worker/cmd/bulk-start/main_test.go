@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// fakeStarter fails the starts of each input with its errors in turn and
+// records the options of every start that succeeds
+type fakeStarter struct {
+	mu       sync.Mutex
+	errs     map[string][]error
+	attempts map[string]int
+	started  []client.StartWorkflowOptions
+}
+
+func (s *fakeStarter) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	input := string(args[0].(json.RawMessage))
+	attempt := s.attempts[input]
+	s.attempts[input]++
+	if errs := s.errs[input]; attempt < len(errs) {
+		return nil, errs[attempt]
+	}
+	s.started = append(s.started, options)
+	return nil, nil
+}
+
+func TestBulkStart(t *testing.T) {
+	unavailable := serviceerror.NewUnavailable("frontend restarting")
+	tests := []struct {
+		name        string
+		input       string
+		errs        map[string][]error
+		wantStarted int64
+		wantSkipped int64
+		wantFailed  int64
+	}{
+		{name: "all started", input: "{\"id\":1}\n\n{\"id\":2}\n", wantStarted: 2},
+		{
+			name:        "already started skipped",
+			input:       "{\"id\":1}\n{\"id\":2}\n",
+			errs:        map[string][]error{`{"id":2}`: {serviceerror.NewWorkflowExecutionAlreadyStarted("started", "", "")}},
+			wantStarted: 1,
+			wantSkipped: 1,
+		},
+		{name: "transient error retried", input: `{"id":1}`, errs: map[string][]error{`{"id":1}`: {unavailable, unavailable}}, wantStarted: 1},
+		{name: "invalid JSON", input: "{\"id\":1}\n{\"id\":\n", wantStarted: 1, wantFailed: 1},
+		{name: "permanent error", input: `{"id":1}`, errs: map[string][]error{`{"id":1}`: {serviceerror.NewInvalidArgument("bad input")}}, wantFailed: 1},
+		{
+			name:       "transient error gives up",
+			input:      `{"id":1}`,
+			errs:       map[string][]error{`{"id":1}`: {unavailable, unavailable, unavailable, unavailable, unavailable}},
+			wantFailed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starter := &fakeStarter{errs: tt.errs, attempts: make(map[string]int)}
+			var progress bytes.Buffer
+
+			summary, err := bulkStart(context.Background(), starter, strings.NewReader(tt.input), &progress, bulkOptions{
+				workflow:    "ComplexProcessingWorkflow",
+				taskQueue:   "queue-1",
+				idPrefix:    "bulk",
+				concurrency: 2,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStarted, summary.started.Load())
+			assert.Equal(t, tt.wantSkipped, summary.skipped.Load())
+			assert.Equal(t, tt.wantFailed, summary.failed.Load())
+			for _, options := range starter.started {
+				assert.Equal(t, "queue-1", options.TaskQueue)
+				assert.True(t, strings.HasPrefix(options.ID, "bulk-"), options.ID)
+				assert.True(t, options.WorkflowExecutionErrorWhenAlreadyStarted)
+			}
+		})
+	}
+}
+
+func TestBulkStartLineTooLong(t *testing.T) {
+	starter := &fakeStarter{attempts: make(map[string]int)}
+	input := `{"id":"` + strings.Repeat("x", maxLineBytes) + `"}`
+
+	_, err := bulkStart(context.Background(), starter, strings.NewReader(input), &bytes.Buffer{}, bulkOptions{concurrency: 1})
+	require.Error(t, err)
+	assert.Empty(t, starter.started)
+}
+
+func TestWorkflowID(t *testing.T) {
+	assert.Equal(t, workflowID("bulk", `{"id":1}`), workflowID("bulk", `{"id":1}`))
+	assert.NotEqual(t, workflowID("bulk", `{"id":1}`), workflowID("bulk", `{"id":2}`))
+	assert.Len(t, workflowID("bulk", `{"id":1}`), len("bulk-")+16)
+}
+
+func TestTransient(t *testing.T) {
+	assert.True(t, transient(serviceerror.NewUnavailable("down")))
+	assert.True(t, transient(context.DeadlineExceeded))
+	assert.False(t, transient(serviceerror.NewInvalidArgument("bad")))
+	assert.False(t, transient(errors.New("boom")))
+}
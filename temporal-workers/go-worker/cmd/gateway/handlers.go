@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+
+	"temporal-go-worker/internal/temporalconn"
+)
+
+// workflowClient is the part of client.Client the gateway uses
+type workflowClient interface {
+	ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error)
+	DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
+	QueryWorkflow(ctx context.Context, workflowID, runID, queryType string, args ...interface{}) (converter.EncodedValue, error)
+	CancelWorkflow(ctx context.Context, workflowID, runID string) error
+}
+
+// startRequest is the body of POST /workflows/{type}
+type startRequest struct {
+	WorkflowID string          `json:"workflow_id"`
+	TaskQueue  string          `json:"task_queue"`
+	Input      json.RawMessage `json:"input"`
+}
+
+// workflowDescription is the body of GET /workflows/{id}
+type workflowDescription struct {
+	WorkflowID   string      `json:"workflow_id"`
+	RunID        string      `json:"run_id"`
+	WorkflowType string      `json:"workflow_type"`
+	Status       string      `json:"status"`
+	StartTime    *time.Time  `json:"start_time,omitempty"`
+	CloseTime    *time.Time  `json:"close_time,omitempty"`
+	HistoryLen   int64       `json:"history_length"`
+	Query        interface{} `json:"query,omitempty"`
+}
+
+// startRateLimit retries rate-limited starts briefly; callers get a 429
+// once it gives up, so a request isn't held for long
+var startRateLimit = temporalconn.RateLimitBackoff{
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     2 * time.Second,
+	MaxAttempts:     4,
+}
+
+// DeadlineHeader bounds a started workflow: an RFC 3339 time or a duration
+// such as "30s" from now. It becomes the workflow execution timeout and
+// bounds the start request itself.
+const DeadlineHeader = "X-Deadline"
+
+// Deadlines are clamped to this range; maxDeadline is set from
+// GATEWAY_MAX_DEADLINE
+var (
+	minDeadline = time.Second
+	maxDeadline = 24 * time.Hour
+)
+
+// defaultWorkflows are the workflow types the gateway starts when
+// GATEWAY_WORKFLOWS is unset
+var defaultWorkflows = []string{"ComplexProcessingWorkflow", "HighPerformanceWorkflow", "BatchProcessingWorkflow"}
+
+// gatewayOptions configures a gateway
+type gatewayOptions struct {
+	// TaskQueue is where workflows start unless a request names another
+	TaskQueue string
+	// Token is the bearer token requests must carry; empty accepts any
+	// request, which main only allows with GATEWAY_INSECURE
+	Token string
+	// Workflows are the workflow types that may be started
+	Workflows []string
+	// TaskQueues are the task queues, besides TaskQueue, requests may name
+	TaskQueues []string
+}
+
+type gateway struct {
+	client     workflowClient
+	taskQueue  string
+	token      string
+	workflows  map[string]bool
+	taskQueues map[string]bool
+	rateLimit  temporalconn.RateLimitBackoff
+}
+
+func newGateway(c workflowClient, options gatewayOptions) http.Handler {
+	g := &gateway{
+		client:     c,
+		taskQueue:  options.TaskQueue,
+		token:      options.Token,
+		workflows:  make(map[string]bool, len(options.Workflows)),
+		taskQueues: map[string]bool{options.TaskQueue: true},
+		rateLimit:  startRateLimit,
+	}
+	for _, name := range options.Workflows {
+		g.workflows[name] = true
+	}
+	for _, queue := range options.TaskQueues {
+		g.taskQueues[queue] = true
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/workflows/", g.requireToken(http.HandlerFunc(g.handleWorkflows)))
+	return mux
+}
+
+func (g *gateway) handleWorkflows(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/workflows/")
+	if name == "" || strings.Contains(name, "/") {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		g.start(w, r, name)
+	case http.MethodGet:
+		g.describe(w, r, name)
+	case http.MethodDelete:
+		g.cancel(w, r, name)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (g *gateway) start(w http.ResponseWriter, r *http.Request, workflowType string) {
+	if !g.workflows[workflowType] {
+		http.Error(w, fmt.Sprintf("workflow type %q can't be started through the gateway", workflowType), http.StatusForbidden)
+		return
+	}
+	var req startRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	taskQueue := g.taskQueue
+	if req.TaskQueue != "" {
+		taskQueue = temporalconn.TaskQueue(req.TaskQueue)
+	}
+	if !g.taskQueues[taskQueue] {
+		http.Error(w, fmt.Sprintf("task queue %q isn't allowed", req.TaskQueue), http.StatusForbidden)
+		return
+	}
+	var args []interface{}
+	if len(req.Input) > 0 {
+		args = append(args, req.Input)
+	}
+	options := client.StartWorkflowOptions{
+		ID:                                       req.WorkflowID,
+		TaskQueue:                                taskQueue,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+	ctx := r.Context()
+	if header := r.Header.Get(DeadlineHeader); header != "" {
+		timeout, err := parseDeadline(header, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		options.WorkflowExecutionTimeout = timeout
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var run client.WorkflowRun
+	err := g.rateLimit.Do(ctx, func() (err error) {
+		run, err = g.client.ExecuteWorkflow(ctx, options, workflowType, args...)
+		return err
+	})
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			http.Error(w, "deadline exceeded before the workflow started", http.StatusGatewayTimeout)
+			return
+		}
+		writeError(w, "start", err)
+		return
+	}
+	log.Printf("🚀 Started %s %s (run %s)", workflowType, run.GetID(), run.GetRunID())
+	writeJSON(w, http.StatusCreated, map[string]string{"workflow_id": run.GetID(), "run_id": run.GetRunID()})
+}
+
+func (g *gateway) describe(w http.ResponseWriter, r *http.Request, workflowID string) {
+	resp, err := g.client.DescribeWorkflowExecution(r.Context(), workflowID, "")
+	if err != nil {
+		writeError(w, "describe", err)
+		return
+	}
+	info := resp.GetWorkflowExecutionInfo()
+	desc := workflowDescription{
+		WorkflowID:   info.GetExecution().GetWorkflowId(),
+		RunID:        info.GetExecution().GetRunId(),
+		WorkflowType: info.GetType().GetName(),
+		Status:       info.GetStatus().String(),
+		HistoryLen:   info.GetHistoryLength(),
+	}
+	if info.GetStartTime() != nil {
+		t := info.GetStartTime().AsTime()
+		desc.StartTime = &t
+	}
+	if info.GetCloseTime() != nil {
+		t := info.GetCloseTime().AsTime()
+		desc.CloseTime = &t
+	}
+
+	if queryType := r.URL.Query().Get("query"); queryType != "" {
+		var args []interface{}
+		if arg := r.URL.Query().Get("arg"); arg != "" {
+			if !json.Valid([]byte(arg)) {
+				http.Error(w, "arg must be JSON", http.StatusBadRequest)
+				return
+			}
+			args = append(args, json.RawMessage(arg))
+		}
+		value, err := g.client.QueryWorkflow(r.Context(), workflowID, "", queryType, args...)
+		if err != nil {
+			writeError(w, "query", err)
+			return
+		}
+		var result interface{}
+		if value != nil && value.HasValue() {
+			if err := value.Get(&result); err != nil {
+				writeError(w, "query", err)
+				return
+			}
+		}
+		desc.Query = result
+	}
+	writeJSON(w, http.StatusOK, desc)
+}
+
+func (g *gateway) cancel(w http.ResponseWriter, r *http.Request, workflowID string) {
+	if err := g.client.CancelWorkflow(r.Context(), workflowID, ""); err != nil {
+		writeError(w, "cancel", err)
+		return
+	}
+	log.Printf("🛑 Requested cancellation of %s", workflowID)
+	writeJSON(w, http.StatusAccepted, map[string]string{"workflow_id": workflowID, "status": "cancel_requested"})
+}
+
+// parseDeadline returns how long from now a deadline header allows,
+// clamped to [minDeadline, maxDeadline]. A deadline already past is an
+// error rather than clamped up.
+func parseDeadline(header string, now time.Time) (time.Duration, error) {
+	var timeout time.Duration
+	if at, err := time.Parse(time.RFC3339Nano, header); err == nil {
+		timeout = at.Sub(now)
+	} else if d, err := time.ParseDuration(header); err == nil {
+		timeout = d
+	} else {
+		return 0, fmt.Errorf("invalid %s %q: want an RFC 3339 time or a duration", DeadlineHeader, header)
+	}
+	switch {
+	case timeout <= 0:
+		return 0, fmt.Errorf("%s %q has already passed", DeadlineHeader, header)
+	case timeout < minDeadline:
+		timeout = minDeadline
+	case timeout > maxDeadline:
+		timeout = maxDeadline
+	}
+	return timeout, nil
+}
+
+// requireToken checks the bearer token. Without one every request is let
+// through, which main refuses unless GATEWAY_INSECURE is set.
+func (g *gateway) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.token != "" {
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(g.token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeError maps Temporal errors to HTTP statuses
+func writeError(w http.ResponseWriter, op string, err error) {
+	var (
+		notFound       *serviceerror.NotFound
+		alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+		invalid        *serviceerror.InvalidArgument
+		queryFailed    *serviceerror.QueryFailed
+		unavailable    *serviceerror.Unavailable
+		exhausted      *serviceerror.ResourceExhausted
+		timedOut       *serviceerror.DeadlineExceeded
+	)
+	status := http.StatusInternalServerError
+	switch {
+	case errors.As(err, &notFound):
+		status = http.StatusNotFound
+	case errors.As(err, &alreadyStarted):
+		status = http.StatusConflict
+	case errors.As(err, &invalid), errors.As(err, &queryFailed):
+		status = http.StatusBadRequest
+	case errors.As(err, &exhausted):
+		status = http.StatusTooManyRequests
+	case errors.As(err, &unavailable):
+		status = http.StatusServiceUnavailable
+	case errors.As(err, &timedOut), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("❌ Unable to %s workflow: %v", op, err)
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("❌ Unable to encode response: %v", err)
+	}
+}
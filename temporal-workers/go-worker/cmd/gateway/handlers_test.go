@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+
+	"temporal-go-worker/internal/temporalconn"
+)
+
+// fakeClient records the calls the gateway makes
+type fakeClient struct {
+	started  []client.StartWorkflowOptions
+	types    []interface{}
+	startErr error
+	// startErrs fail the first starts in turn, before startErr applies
+	startErrs []error
+	attempts  int
+	cancelled []string
+	queryArgs []interface{}
+}
+
+func (f *fakeClient) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	f.attempts++
+	if len(f.startErrs) > 0 {
+		err := f.startErrs[0]
+		f.startErrs = f.startErrs[1:]
+		return nil, err
+	}
+	if f.startErr != nil {
+		return nil, f.startErr
+	}
+	f.started = append(f.started, options)
+	f.types = append(f.types, workflow)
+	return fakeRun{id: options.ID}, nil
+}
+
+func (f *fakeClient) DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error) {
+	if workflowID == "missing" {
+		return nil, serviceerror.NewNotFound("workflow not found")
+	}
+	return &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflow.WorkflowExecutionInfo{
+			Execution:     &common.WorkflowExecution{WorkflowId: workflowID, RunId: "run-1"},
+			Type:          &common.WorkflowType{Name: "ComplexProcessingWorkflow"},
+			Status:        enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING,
+			HistoryLength: 12,
+		},
+	}, nil
+}
+
+func (f *fakeClient) QueryWorkflow(ctx context.Context, workflowID, runID, queryType string, args ...interface{}) (converter.EncodedValue, error) {
+	f.queryArgs = args
+	payload, err := converter.GetDefaultDataConverter().ToPayloads(map[string]string{"query": queryType})
+	if err != nil {
+		return nil, err
+	}
+	return client.NewValue(payload), nil
+}
+
+func (f *fakeClient) CancelWorkflow(ctx context.Context, workflowID, runID string) error {
+	f.cancelled = append(f.cancelled, workflowID)
+	return nil
+}
+
+type fakeRun struct {
+	id string
+}
+
+func (r fakeRun) GetID() string    { return r.id }
+func (r fakeRun) GetRunID() string { return "run-1" }
+func (r fakeRun) Get(ctx context.Context, valuePtr interface{}) error {
+	return nil
+}
+func (r fakeRun) GetWithOptions(ctx context.Context, valuePtr interface{}, options client.WorkflowRunGetOptions) error {
+	return nil
+}
+
+func newTestGateway(c *fakeClient) http.Handler {
+	return newGateway(c, gatewayOptions{
+		TaskQueue:  "go-workers",
+		Token:      "secret",
+		Workflows:  []string{"ComplexProcessingWorkflow"},
+		TaskQueues: []string{"go-workers-eu"},
+	})
+}
+
+func serve(h http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGatewayStart(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		header     map[string]string
+		startErr   error
+		wantStatus int
+		wantQueue  string
+	}{
+		{name: "started", target: "/workflows/ComplexProcessingWorkflow", body: `{"workflow_id": "wf-1", "input": {"dataset_id": "d"}}`, wantStatus: http.StatusCreated, wantQueue: "go-workers"},
+		{name: "allowed task queue", target: "/workflows/ComplexProcessingWorkflow", body: `{"workflow_id": "wf-1", "task_queue": "go-workers-eu"}`, wantStatus: http.StatusCreated, wantQueue: "go-workers-eu"},
+		{name: "other task queue", target: "/workflows/ComplexProcessingWorkflow", body: `{"workflow_id": "wf-1", "task_queue": "admin-workers"}`, wantStatus: http.StatusForbidden},
+		{name: "workflow type not allowed", target: "/workflows/SystemOperationWorkflow", body: `{"workflow_id": "wf-1"}`, wantStatus: http.StatusForbidden},
+		{name: "invalid body", target: "/workflows/ComplexProcessingWorkflow", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "already started", target: "/workflows/ComplexProcessingWorkflow", body: `{"workflow_id": "wf-1"}`, startErr: serviceerror.NewWorkflowExecutionAlreadyStarted("started", "", "run-0"), wantStatus: http.StatusConflict},
+		{name: "deadline", target: "/workflows/ComplexProcessingWorkflow", body: `{"workflow_id": "wf-1"}`, header: map[string]string{DeadlineHeader: "90s"}, wantStatus: http.StatusCreated, wantQueue: "go-workers"},
+		{name: "deadline passed", target: "/workflows/ComplexProcessingWorkflow", body: `{"workflow_id": "wf-1"}`, header: map[string]string{DeadlineHeader: "2000-01-01T00:00:00Z"}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeClient{startErr: tt.startErr}
+			rec := serve(newTestGateway(c), http.MethodPost, tt.target, tt.body, tt.header)
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusCreated {
+				assert.Empty(t, c.started)
+				return
+			}
+			require.Len(t, c.started, 1)
+			assert.Equal(t, "wf-1", c.started[0].ID)
+			assert.Equal(t, tt.wantQueue, c.started[0].TaskQueue)
+			assert.Equal(t, "ComplexProcessingWorkflow", c.types[0])
+			if tt.header[DeadlineHeader] != "" {
+				assert.Equal(t, 90*time.Second, c.started[0].WorkflowExecutionTimeout)
+			}
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, map[string]string{"workflow_id": "wf-1", "run_id": "run-1"}, body)
+		})
+	}
+}
+
+// TestGatewayStartEnvironmentQueues checks request task queues get the
+// ENVIRONMENT prefix before they are checked against the allowed ones
+func TestGatewayStartEnvironmentQueues(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "prod")
+	tests := []struct {
+		name       string
+		queue      string
+		wantStatus int
+		wantQueue  string
+	}{
+		{name: "default queue", wantStatus: http.StatusCreated, wantQueue: "prod-go-workers"},
+		{name: "bare name prefixed", queue: "go-workers-eu", wantStatus: http.StatusCreated, wantQueue: "prod-go-workers-eu"},
+		{name: "prefixed name kept", queue: "prod-go-workers-eu", wantStatus: http.StatusCreated, wantQueue: "prod-go-workers-eu"},
+		{name: "queue not allowed", queue: "admin-workers", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeClient{}
+			h := newGateway(c, gatewayOptions{
+				TaskQueue:  temporalconn.TaskQueue("go-workers"),
+				Token:      "secret",
+				Workflows:  []string{"ComplexProcessingWorkflow"},
+				TaskQueues: []string{temporalconn.TaskQueue("go-workers-eu")},
+			})
+			body, err := json.Marshal(map[string]string{"workflow_id": "wf-1", "task_queue": tt.queue})
+			require.NoError(t, err)
+
+			rec := serve(h, http.MethodPost, "/workflows/ComplexProcessingWorkflow", string(body), nil)
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusCreated {
+				assert.Empty(t, c.started)
+				return
+			}
+			require.Len(t, c.started, 1)
+			assert.Equal(t, tt.wantQueue, c.started[0].TaskQueue)
+		})
+	}
+}
+
+func TestGatewayStartRateLimited(t *testing.T) {
+	prev := startRateLimit
+	t.Cleanup(func() { startRateLimit = prev })
+	startRateLimit.InitialInterval = time.Millisecond
+	startRateLimit.MaxInterval = time.Millisecond
+
+	exhausted := serviceerror.NewResourceExhausted(enumspb.RESOURCE_EXHAUSTED_CAUSE_RPS_LIMIT, "rate limit exceeded")
+	tests := []struct {
+		name         string
+		startErrs    []error
+		startErr     error
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "retried until started", startErrs: []error{exhausted, exhausted}, wantStatus: http.StatusCreated, wantAttempts: 3},
+		{name: "still rate limited", startErr: exhausted, wantStatus: http.StatusTooManyRequests, wantAttempts: startRateLimit.MaxAttempts},
+		{name: "unavailable", startErr: serviceerror.NewUnavailable("frontend down"), wantStatus: http.StatusServiceUnavailable, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeClient{startErrs: tt.startErrs, startErr: tt.startErr}
+			rec := serve(newTestGateway(c), http.MethodPost, "/workflows/ComplexProcessingWorkflow", `{"workflow_id": "wf-1"}`, nil)
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.wantAttempts, c.attempts)
+		})
+	}
+}
+
+func TestGatewayRequiresToken(t *testing.T) {
+	c := &fakeClient{}
+	h := newTestGateway(c)
+	for _, auth := range []string{"", "Bearer wrong"} {
+		req := httptest.NewRequest(http.MethodPost, "/workflows/ComplexProcessingWorkflow", strings.NewReader(`{"workflow_id": "wf-1"}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	assert.Empty(t, c.started)
+}
+
+func TestGatewayDescribe(t *testing.T) {
+	c := &fakeClient{}
+	h := newTestGateway(c)
+
+	rec := serve(h, http.MethodGet, `/workflows/wf-1?query=checkpoint&arg={"step":"x"}`, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var desc workflowDescription
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &desc))
+	assert.Equal(t, "wf-1", desc.WorkflowID)
+	assert.Equal(t, "Running", desc.Status)
+	assert.Equal(t, int64(12), desc.HistoryLen)
+	assert.Equal(t, map[string]interface{}{"query": "checkpoint"}, desc.Query)
+	assert.Equal(t, []interface{}{json.RawMessage(`{"step":"x"}`)}, c.queryArgs)
+
+	assert.Equal(t, http.StatusBadRequest, serve(h, http.MethodGet, "/workflows/wf-1?query=checkpoint&arg={", "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serve(h, http.MethodGet, "/workflows/missing", "", nil).Code)
+}
+
+func TestGatewayCancel(t *testing.T) {
+	c := &fakeClient{}
+	rec := serve(newTestGateway(c), http.MethodDelete, "/workflows/wf-1", "", nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, []string{"wf-1"}, c.cancelled)
+}
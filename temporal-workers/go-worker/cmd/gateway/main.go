@@ -0,0 +1,91 @@
+// Command gateway exposes workflow start, describe and cancel over HTTP for
+// clients that don't speak Temporal:
+//
+//	POST   /workflows/{type}  start a workflow; body {"workflow_id", "task_queue", "input"},
+//	                          optional X-Deadline header bounding the run
+//	GET    /workflows/{id}    describe a run; ?query=<name>[&arg=<json>] also queries it
+//	DELETE /workflows/{id}    request cancellation
+//
+// It dials Temporal with the worker's TEMPORAL_* settings and data converter
+// (DATA_CONVERTER, PAYLOAD_ENCRYPTION_KEY). Requests need
+// GATEWAY_TOKEN as a bearer token, and only the workflow types in
+// GATEWAY_WORKFLOWS can be started.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/client"
+
+	"temporal-go-worker/internal/payload"
+	"temporal-go-worker/internal/temporalconn"
+)
+
+func main() {
+	port := getEnv("GATEWAY_PORT", "8081")
+	taskQueue := temporalconn.TaskQueue(getEnv("TASK_QUEUE", "go-workers"))
+	token := os.Getenv("GATEWAY_TOKEN")
+	insecure := os.Getenv("GATEWAY_INSECURE") == "true"
+	if token == "" && !insecure {
+		log.Fatalf("❌ GATEWAY_TOKEN is unset; set it, or GATEWAY_INSECURE=true to accept unauthenticated requests")
+	}
+	workflows := defaultWorkflows
+	if v := os.Getenv("GATEWAY_WORKFLOWS"); v != "" {
+		workflows = splitList(v)
+	}
+	var taskQueues []string
+	for _, queue := range splitList(os.Getenv("GATEWAY_TASK_QUEUES")) {
+		taskQueues = append(taskQueues, temporalconn.TaskQueue(queue))
+	}
+	if v := os.Getenv("GATEWAY_MAX_DEADLINE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("❌ Invalid GATEWAY_MAX_DEADLINE %q", v)
+		}
+		maxDeadline = d
+	}
+
+	clientOptions, err := temporalconn.ClientOptionsFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Invalid Temporal configuration: %v", err)
+	}
+	if clientOptions.DataConverter, err = payload.DataConverterFromEnv(client.MetricsNopHandler); err != nil {
+		log.Fatalf("❌ Invalid data converter configuration: %v", err)
+	}
+	c, err := client.Dial(clientOptions)
+	if err != nil {
+		log.Fatalf("❌ Unable to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	if token == "" {
+		log.Printf("⚠️ GATEWAY_INSECURE is set; the gateway accepts unauthenticated requests")
+	}
+	log.Printf("🌐 Workflow gateway listening on :%s (default task queue %s, workflows %s)", port, taskQueue, strings.Join(workflows, ", "))
+	handler := newGateway(c, gatewayOptions{TaskQueue: taskQueue, Token: token, Workflows: workflows, TaskQueues: taskQueues})
+	if err := http.ListenAndServe(":"+port, handler); err != nil {
+		log.Fatalf("❌ Gateway failed: %v", err)
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"go.temporal.io/sdk/temporal"
+
+	"temporal-go-worker/internal/predicate"
+)
+
+// ErrTypeInvalidPredicate marks a predicate that can't be parsed or
+// evaluated; rerunning the same input can't fix it
+const ErrTypeInvalidPredicate = "InvalidPredicate"
+
+// optimizeWhenParameter holds a predicate over the processing metrics, e.g.
+// "throughput < 50000", that decides whether the optimize step runs
+const optimizeWhenParameter = "optimize_when"
+
+// parseCondition parses the predicate stored under key. It returns nil when
+// no predicate is set, and a non-retryable error when it is invalid.
+func parseCondition(params Parameters, key string) (*predicate.Expr, error) {
+	src, ok := params[key]
+	if !ok {
+		return nil, nil
+	}
+	s, isString := src.(string)
+	if !isString {
+		return nil, temporal.NewNonRetryableApplicationError(key+" must be a string", ErrTypeInvalidPredicate, nil)
+	}
+	if s == "" {
+		return nil, nil
+	}
+	expr, err := predicate.Parse(s)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidPredicate, err)
+	}
+	return expr, nil
+}
+
+// evalCondition evaluates a parsed predicate; a nil predicate is true
+func evalCondition(expr *predicate.Expr, vars map[string]float64) (bool, error) {
+	if expr == nil {
+		return true, nil
+	}
+	ok, err := expr.Eval(vars)
+	if err != nil {
+		return false, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidPredicate, err)
+	}
+	return ok, nil
+}
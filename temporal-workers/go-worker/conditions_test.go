@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Parameters
+		wantNil bool
+		wantErr string
+	}{
+		{name: "unset", params: Parameters{}, wantNil: true},
+		{name: "empty", params: Parameters{optimizeWhenParameter: ""}, wantNil: true},
+		{name: "valid", params: Parameters{optimizeWhenParameter: "throughput < 50000"}},
+		{name: "not a string", params: Parameters{optimizeWhenParameter: 5}, wantErr: "optimize_when must be a string"},
+		{name: "invalid", params: Parameters{optimizeWhenParameter: "throughput <"}, wantErr: `predicate "throughput <"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseCondition(tt.params, optimizeWhenParameter)
+			if tt.wantErr != "" {
+				requireApplicationError(t, err, ErrTypeInvalidPredicate)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNil, expr == nil)
+		})
+	}
+}
+
+func TestComplexProcessingOptimizeWhen(t *testing.T) {
+	tests := []struct {
+		name         string
+		optimizeWhen string
+		wantOptimize bool
+		wantErr      string
+	}{
+		{name: "no predicate", wantOptimize: true},
+		{name: "predicate holds", optimizeWhen: "throughput < 50000 && cpu_utilization > 0.5", wantOptimize: true},
+		{name: "predicate fails", optimizeWhen: "throughput >= 50000"},
+		{name: "unknown value", optimizeWhen: "latency > 100", wantErr: `unknown value "latency"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{
+				ItemsProcessed: 10, ProcessingTime: "1s", Metrics: ProcessingMetrics{Throughput: 40000, CPUUtilization: 0.8},
+			}, nil)
+			optimized := false
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input OptimizePerformanceInput) (OptimizePerformanceResult, error) {
+					optimized = true
+					return OptimizePerformanceResult{PerformanceGain: 0.25}, nil
+				})
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			params := Parameters{}
+			if tt.optimizeWhen != "" {
+				params[optimizeWhenParameter] = tt.optimizeWhen
+			}
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:    CurrentComplexProcessingInputVersion,
+				DatasetID:  "ds-1",
+				Parameters: params,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			assert.Equal(t, tt.wantOptimize, optimized)
+
+			if tt.wantErr != "" {
+				requireApplicationError(t, env.GetWorkflowError(), ErrTypeInvalidPredicate)
+				assert.Contains(t, env.GetWorkflowError().Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result ComplexProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			if tt.wantOptimize {
+				assert.Equal(t, 0.25, result.OptimizationGain)
+			} else {
+				assert.Zero(t, result.OptimizationGain)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	contractsv1 "temporal-go-worker/contracts/v1"
+)
+
+// The *ProtoWorkflow variants accept the protobuf contracts from
+// proto/contracts/v1 so callers in other languages can start them with
+// "json/protobuf" or "binary/protobuf" payloads, which the data converter
+// decodes into the generated types. Each converts to the JSON struct and
+// runs the corresponding workflow.
+
+// ComplexProcessingProtoWorkflow runs ComplexProcessingWorkflow from a proto input
+func ComplexProcessingProtoWorkflow(ctx workflow.Context, input *contractsv1.ComplexProcessingInputProto) (ComplexProcessingResult, error) {
+	return ComplexProcessingWorkflow(ctx, ComplexProcessingInput{
+		DatasetID:   input.GetDatasetId(),
+		ProcessType: ProcessType(input.GetProcessType()),
+		Parameters:  input.GetParameters().AsMap(),
+		Priority:    Priority(input.GetPriority()),
+	})
+}
+
+// SystemOperationProtoWorkflow runs SystemOperationWorkflow from a proto input
+func SystemOperationProtoWorkflow(ctx workflow.Context, input *contractsv1.SystemOperationInputProto) (map[string]interface{}, error) {
+	return SystemOperationWorkflow(ctx, SystemOperationInput{
+		Operation:  input.GetOperation(),
+		Target:     input.GetTarget(),
+		Parameters: input.GetParameters().AsMap(),
+		Timeout:    int(input.GetTimeout()),
+	})
+}
+
+// HighPerformanceProtoWorkflow runs HighPerformanceWorkflow from a proto input
+func HighPerformanceProtoWorkflow(ctx workflow.Context, input *contractsv1.HighPerformanceInputProto) (map[string]interface{}, error) {
+	return HighPerformanceWorkflow(ctx, HighPerformanceInput{
+		TaskType:    input.GetTaskType(),
+		Concurrency: int(input.GetConcurrency()),
+		Data:        input.GetData().AsMap(),
+	})
+}
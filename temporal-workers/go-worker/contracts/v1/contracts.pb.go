@@ -0,0 +1,368 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v4.25.3
+// source: contracts/v1/contracts.proto
+
+package contractsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ComplexProcessingInputProto is the cross-language contract for ComplexProcessingInput.
+type ComplexProcessingInputProto struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DatasetId   string           `protobuf:"bytes,1,opt,name=dataset_id,json=datasetId,proto3" json:"dataset_id,omitempty"`
+	ProcessType string           `protobuf:"bytes,2,opt,name=process_type,json=processType,proto3" json:"process_type,omitempty"`
+	Parameters  *structpb.Struct `protobuf:"bytes,3,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	Priority    string           `protobuf:"bytes,4,opt,name=priority,proto3" json:"priority,omitempty"`
+}
+
+func (x *ComplexProcessingInputProto) Reset() {
+	*x = ComplexProcessingInputProto{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_contracts_v1_contracts_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComplexProcessingInputProto) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComplexProcessingInputProto) ProtoMessage() {}
+
+func (x *ComplexProcessingInputProto) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_v1_contracts_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComplexProcessingInputProto.ProtoReflect.Descriptor instead.
+func (*ComplexProcessingInputProto) Descriptor() ([]byte, []int) {
+	return file_contracts_v1_contracts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ComplexProcessingInputProto) GetDatasetId() string {
+	if x != nil {
+		return x.DatasetId
+	}
+	return ""
+}
+
+func (x *ComplexProcessingInputProto) GetProcessType() string {
+	if x != nil {
+		return x.ProcessType
+	}
+	return ""
+}
+
+func (x *ComplexProcessingInputProto) GetParameters() *structpb.Struct {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *ComplexProcessingInputProto) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+// SystemOperationInputProto is the cross-language contract for SystemOperationInput.
+type SystemOperationInputProto struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Operation  string           `protobuf:"bytes,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	Target     string           `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Parameters *structpb.Struct `protobuf:"bytes,3,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	Timeout    int32            `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+}
+
+func (x *SystemOperationInputProto) Reset() {
+	*x = SystemOperationInputProto{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_contracts_v1_contracts_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SystemOperationInputProto) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemOperationInputProto) ProtoMessage() {}
+
+func (x *SystemOperationInputProto) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_v1_contracts_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SystemOperationInputProto.ProtoReflect.Descriptor instead.
+func (*SystemOperationInputProto) Descriptor() ([]byte, []int) {
+	return file_contracts_v1_contracts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SystemOperationInputProto) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *SystemOperationInputProto) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SystemOperationInputProto) GetParameters() *structpb.Struct {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *SystemOperationInputProto) GetTimeout() int32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+// HighPerformanceInputProto is the cross-language contract for HighPerformanceInput.
+type HighPerformanceInputProto struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TaskType    string           `protobuf:"bytes,1,opt,name=task_type,json=taskType,proto3" json:"task_type,omitempty"`
+	Concurrency int32            `protobuf:"varint,2,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	Data        *structpb.Struct `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *HighPerformanceInputProto) Reset() {
+	*x = HighPerformanceInputProto{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_contracts_v1_contracts_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HighPerformanceInputProto) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HighPerformanceInputProto) ProtoMessage() {}
+
+func (x *HighPerformanceInputProto) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_v1_contracts_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HighPerformanceInputProto.ProtoReflect.Descriptor instead.
+func (*HighPerformanceInputProto) Descriptor() ([]byte, []int) {
+	return file_contracts_v1_contracts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HighPerformanceInputProto) GetTaskType() string {
+	if x != nil {
+		return x.TaskType
+	}
+	return ""
+}
+
+func (x *HighPerformanceInputProto) GetConcurrency() int32 {
+	if x != nil {
+		return x.Concurrency
+	}
+	return 0
+}
+
+func (x *HighPerformanceInputProto) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_contracts_v1_contracts_proto protoreflect.FileDescriptor
+
+var file_contracts_v1_contracts_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x14,
+	0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74,
+	0x73, 0x2e, 0x76, 0x31, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0xb4, 0x01, 0x0a, 0x1b, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x78, 0x50, 0x72,
+	0x6f, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x50, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x73, 0x65, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x61, 0x74, 0x61, 0x73, 0x65, 0x74, 0x49,
+	0x64, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x37, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65,
+	0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63,
+	0x74, 0x52, 0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x22, 0xa4, 0x01, 0x0a, 0x19, 0x53, 0x79,
+	0x73, 0x74, 0x65, 0x6d, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x70,
+	0x75, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x37, 0x0a,
+	0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x0a, 0x70, 0x61, 0x72, 0x61,
+	0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x22, 0x87, 0x01, 0x0a, 0x19, 0x48, 0x69, 0x67, 0x68, 0x50, 0x65, 0x72, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1b,
+	0x0a, 0x09, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x61, 0x73, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x63,
+	0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x2b, 0x0a,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74,
+	0x72, 0x75, 0x63, 0x74, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x42, 0x2d, 0x5a, 0x2b, 0x74, 0x65,
+	0x6d, 0x70, 0x6f, 0x72, 0x61, 0x6c, 0x2d, 0x67, 0x6f, 0x2d, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72,
+	0x2f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x73, 0x2f, 0x76, 0x31, 0x3b, 0x63, 0x6f,
+	0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x73, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_contracts_v1_contracts_proto_rawDescOnce sync.Once
+	file_contracts_v1_contracts_proto_rawDescData = file_contracts_v1_contracts_proto_rawDesc
+)
+
+func file_contracts_v1_contracts_proto_rawDescGZIP() []byte {
+	file_contracts_v1_contracts_proto_rawDescOnce.Do(func() {
+		file_contracts_v1_contracts_proto_rawDescData = protoimpl.X.CompressGZIP(file_contracts_v1_contracts_proto_rawDescData)
+	})
+	return file_contracts_v1_contracts_proto_rawDescData
+}
+
+var file_contracts_v1_contracts_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_contracts_v1_contracts_proto_goTypes = []any{
+	(*ComplexProcessingInputProto)(nil), // 0: workers.contracts.v1.ComplexProcessingInputProto
+	(*SystemOperationInputProto)(nil),   // 1: workers.contracts.v1.SystemOperationInputProto
+	(*HighPerformanceInputProto)(nil),   // 2: workers.contracts.v1.HighPerformanceInputProto
+	(*structpb.Struct)(nil),             // 3: google.protobuf.Struct
+}
+var file_contracts_v1_contracts_proto_depIdxs = []int32{
+	3, // 0: workers.contracts.v1.ComplexProcessingInputProto.parameters:type_name -> google.protobuf.Struct
+	3, // 1: workers.contracts.v1.SystemOperationInputProto.parameters:type_name -> google.protobuf.Struct
+	3, // 2: workers.contracts.v1.HighPerformanceInputProto.data:type_name -> google.protobuf.Struct
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_contracts_v1_contracts_proto_init() }
+func file_contracts_v1_contracts_proto_init() {
+	if File_contracts_v1_contracts_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_contracts_v1_contracts_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ComplexProcessingInputProto); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_contracts_v1_contracts_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*SystemOperationInputProto); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_contracts_v1_contracts_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*HighPerformanceInputProto); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_contracts_v1_contracts_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_contracts_v1_contracts_proto_goTypes,
+		DependencyIndexes: file_contracts_v1_contracts_proto_depIdxs,
+		MessageInfos:      file_contracts_v1_contracts_proto_msgTypes,
+	}.Build()
+	File_contracts_v1_contracts_proto = out.File
+	file_contracts_v1_contracts_proto_rawDesc = nil
+	file_contracts_v1_contracts_proto_goTypes = nil
+	file_contracts_v1_contracts_proto_depIdxs = nil
+}
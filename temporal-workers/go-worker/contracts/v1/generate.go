@@ -0,0 +1,5 @@
+// Package contractsv1 holds the protobuf workflow input contracts shared with
+// the Python and TypeScript workers.
+package contractsv1
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=paths=source_relative contracts/v1/contracts.proto
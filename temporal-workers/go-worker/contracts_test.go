@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	contractsv1 "temporal-go-worker/contracts/v1"
+)
+
+func TestComplexProcessingProtoWorkflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		parameters  map[string]interface{}
+		wantBatch   interface{}
+		wantErrType string
+	}{
+		{name: "converted", parameters: map[string]interface{}{"batch_size": 500}, wantBatch: json.Number("500")},
+		{name: "invalid predicate", parameters: map[string]interface{}{optimizeWhenParameter: "throughput >"}, wantErrType: ErrTypeInvalidPredicate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			var processed ProcessLargeDatasetInput
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+					processed = input
+					return ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil
+				})
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			parameters, err := structpb.NewStruct(tt.parameters)
+			require.NoError(t, err)
+			env.ExecuteWorkflow(ComplexProcessingProtoWorkflow, &contractsv1.ComplexProcessingInputProto{
+				DatasetId:   "ds-1",
+				ProcessType: "Batch",
+				Parameters:  parameters,
+				Priority:    "high",
+			})
+			require.True(t, env.IsWorkflowCompleted())
+
+			if tt.wantErrType != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(env.GetWorkflowError(), &appErr), "want an application error, got %v", env.GetWorkflowError())
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				assert.Empty(t, processed.DatasetID)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result ComplexProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, "ds-1", result.DatasetID)
+			assert.Equal(t, ProcessTypeBatch, result.Routing.RequestedProcessType)
+			assert.Equal(t, "ds-1", processed.DatasetID)
+			assert.Equal(t, tt.wantBatch, processed.Parameters["batch_size"])
+		})
+	}
+}
+
+func TestHighPerformanceProtoWorkflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		failWith error
+		wantErr  string
+	}{
+		{name: "converted"},
+		{name: "processing fails", failWith: temporal.NewNonRetryableApplicationError("disk full", "StorageFull", nil), wantErr: "disk full"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			var processed ProcessLargeDatasetInput
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+					processed = input
+					return ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, tt.failWith
+				})
+
+			data, err := structpb.NewStruct(map[string]interface{}{"source": "s3://bucket/key"})
+			require.NoError(t, err)
+			env.ExecuteWorkflow(HighPerformanceProtoWorkflow, &contractsv1.HighPerformanceInputProto{TaskType: "etl", Concurrency: 1, Data: data})
+			require.True(t, env.IsWorkflowCompleted())
+
+			assert.Equal(t, "high_perf_etl", processed.DatasetID)
+			assert.Equal(t, map[string]interface{}{"source": "s3://bucket/key"}, processed.Parameters["data"])
+			if tt.wantErr != "" {
+				require.Error(t, env.GetWorkflowError())
+				assert.Contains(t, env.GetWorkflowError().Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, env.GetWorkflowError())
+		})
+	}
+}
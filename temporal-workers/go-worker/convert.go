@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+
+	"temporal-go-worker/internal/parquet"
+)
+
+// Dataset formats ConvertFormat reads and writes
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// Application error types returned by ConvertFormat. Neither goes away on
+// retry.
+const (
+	ErrTypeUnsupportedConversion = "UnsupportedConversion"
+	ErrTypeMalformedDataset      = "MalformedDataset"
+)
+
+const (
+	// convertHeartbeatRows is how often conversion progress is reported
+	convertHeartbeatRows = 1000
+	// convertMaxLineBytes bounds one JSON-lines record
+	convertMaxLineBytes = 16 << 20
+)
+
+// supportedConversions lists the from -> to pairs ConvertFormat handles.
+// Parquet is write-only: internal/parquet has no reader.
+var supportedConversions = map[string][]string{
+	FormatCSV:   {FormatJSONL, FormatParquet},
+	FormatJSONL: {FormatCSV, FormatParquet},
+}
+
+// ConvertColumn names a column and its type: string, int64, double or
+// boolean
+type ConvertColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ConvertFormatInput represents input for converting a stored dataset.
+// Columns, when set, picks and types the output columns; otherwise they are
+// the CSV header, as strings, or the fields of the first JSON record, typed
+// from their values. Key defaults to converted/<dataset_id>.<to>.
+type ConvertFormatInput struct {
+	DatasetID string          `json:"dataset_id"`
+	SourceKey string          `json:"source_key"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Columns   []ConvertColumn `json:"columns,omitempty"`
+	Key       string          `json:"key,omitempty"`
+}
+
+// ConvertFormatResult represents the converted dataset
+type ConvertFormatResult struct {
+	Object ObjectRef `json:"object"`
+	Rows   int64     `json:"rows"`
+}
+
+// ConvertFormat converts a stored dataset between CSV, JSON lines and
+// Parquet. Rows are streamed from the source to the destination, so memory
+// use is bounded by one Parquet row group rather than the dataset size.
+// Unsupported pairs, bad columns and rows that can't be parsed or don't fit
+// their column fail without retries.
+func (a *Activities) ConvertFormat(ctx context.Context, input ConvertFormatInput) (ConvertFormatResult, error) {
+	from, to := strings.ToLower(input.From), strings.ToLower(input.To)
+	if !conversionSupported(from, to) {
+		return ConvertFormatResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unsupported conversion %q -> %q (supported: csv -> jsonl, csv -> parquet, jsonl -> csv, jsonl -> parquet)", input.From, input.To),
+			ErrTypeUnsupportedConversion, nil)
+	}
+	columns, err := parquetColumns(input.Columns)
+	if err != nil {
+		return ConvertFormatResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeUnsupportedConversion, err)
+	}
+	key := input.Key
+	if key == "" {
+		key = fmt.Sprintf("converted/%s.%s", input.DatasetID, to)
+	}
+	activityLog.Infof("🔁 Converting %s from %s to %s: %s", input.SourceKey, from, to, key)
+
+	src, err := objectStore.Get(ctx, input.SourceKey)
+	if err != nil {
+		return ConvertFormatResult{}, err
+	}
+	defer src.Close()
+
+	pr, pw := io.Pipe()
+	type outcome struct {
+		rows int64
+		err  error
+	}
+	converted := make(chan outcome, 1)
+	go func() {
+		rows, err := convertDataset(ctx, src, pw, from, to, columns)
+		converted <- outcome{rows, err}
+		pw.CloseWithError(err)
+	}()
+
+	ref, err := objectStore.Put(ctx, key, pr)
+	pr.CloseWithError(err)
+	done := <-converted
+	if done.err != nil {
+		return ConvertFormatResult{}, done.err
+	}
+	if err != nil {
+		return ConvertFormatResult{}, err
+	}
+
+	activityLog.Infof("✅ Conversion completed: %d rows, %d bytes", done.rows, ref.Size)
+	return ConvertFormatResult{Object: ref, Rows: done.rows}, nil
+}
+
+func conversionSupported(from, to string) bool {
+	for _, target := range supportedConversions[from] {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// parquetColumns maps configured columns to Parquet columns; nil means
+// the columns come from the source
+func parquetColumns(columns []ConvertColumn) ([]parquet.Column, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	out := make([]parquet.Column, len(columns))
+	for i, col := range columns {
+		if col.Name == "" {
+			return nil, fmt.Errorf("column %d has no name", i)
+		}
+		out[i].Name = col.Name
+		switch strings.ToLower(col.Type) {
+		case "", "string":
+			out[i].Type = parquet.String
+		case "int64":
+			out[i].Type = parquet.Int64
+		case "double":
+			out[i].Type = parquet.Double
+		case "boolean":
+			out[i].Type = parquet.Boolean
+		default:
+			return nil, fmt.Errorf("column %q: unknown type %q (want string, int64, double or boolean)", col.Name, col.Type)
+		}
+	}
+	return out, nil
+}
+
+// convertDataset streams rows from r in one format to w in another,
+// heartbeating the rows converted so far
+func convertDataset(ctx context.Context, r io.Reader, w io.Writer, from, to string, columns []parquet.Column) (int64, error) {
+	var reader datasetReader
+	var err error
+	switch from {
+	case FormatCSV:
+		reader, err = newCSVDatasetReader(r, columns)
+	case FormatJSONL:
+		reader, err = newJSONLDatasetReader(r, columns)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var writer datasetWriter
+	switch to {
+	case FormatCSV:
+		writer, err = newCSVDatasetWriter(w, reader.Columns())
+	case FormatJSONL:
+		writer = newJSONLDatasetWriter(w, reader.Columns())
+	case FormatParquet:
+		writer, err = parquet.NewWriter(w, reader.Columns(), 0)
+	}
+	if err != nil {
+		return 0, malformedDataset(err)
+	}
+
+	var rows int64
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		if err := writer.Write(row); err != nil {
+			return rows, err
+		}
+		rows++
+		if rows%convertHeartbeatRows == 0 {
+			activity.RecordHeartbeat(ctx, rows)
+			if ctx.Err() != nil {
+				return rows, ctx.Err()
+			}
+		}
+	}
+	return rows, writer.Close()
+}
+
+func malformedDataset(err error) error {
+	return temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeMalformedDataset, err)
+}
+
+// datasetReader yields a dataset's rows, lined up with its columns and
+// holding values of the column types. Read returns io.EOF at the end.
+type datasetReader interface {
+	Columns() []parquet.Column
+	Read() ([]interface{}, error)
+}
+
+// datasetWriter writes rows in one format; *parquet.Writer is one
+type datasetWriter interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// csvDatasetReader reads CSV with a header row
+type csvDatasetReader struct {
+	r       *csv.Reader
+	columns []parquet.Column
+	// index maps each column to its field in the CSV records
+	index []int
+}
+
+func newCSVDatasetReader(r io.Reader, columns []parquet.Column) (*csvDatasetReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, malformedDataset(errors.New("CSV has no header row"))
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	fields := make(map[string]int, len(header))
+	for i, name := range header {
+		fields[name] = i
+	}
+	if columns == nil {
+		for _, name := range header {
+			columns = append(columns, parquet.Column{Name: name, Type: parquet.String})
+		}
+	}
+	index := make([]int, len(columns))
+	for i, col := range columns {
+		field, ok := fields[col.Name]
+		if !ok {
+			return nil, malformedDataset(fmt.Errorf("CSV header has no column %q", col.Name))
+		}
+		index[i] = field
+	}
+	return &csvDatasetReader{r: cr, columns: columns, index: index}, nil
+}
+
+func (c *csvDatasetReader) Columns() []parquet.Column { return c.columns }
+
+func (c *csvDatasetReader) Read() ([]interface{}, error) {
+	record, err := c.r.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	line, _ := c.r.FieldPos(0)
+	row := make([]interface{}, len(c.columns))
+	for i, col := range c.columns {
+		if row[i], err = columnValue(col, record[c.index[i]]); err != nil {
+			return nil, malformedDataset(fmt.Errorf("line %d: %w", line, err))
+		}
+	}
+	return row, nil
+}
+
+// csvError marks CSV syntax errors as malformed; read errors stay
+// retryable
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return malformedDataset(err)
+	}
+	return err
+}
+
+// jsonlDatasetReader reads one JSON object per line; blank lines are
+// skipped
+type jsonlDatasetReader struct {
+	scanner *bufio.Scanner
+	columns []parquet.Column
+	line    int
+	// first is the record read ahead to find the columns
+	first map[string]interface{}
+}
+
+func newJSONLDatasetReader(r io.Reader, columns []parquet.Column) (*jsonlDatasetReader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), convertMaxLineBytes)
+	j := &jsonlDatasetReader{scanner: scanner, columns: columns}
+	if columns != nil {
+		return j, nil
+	}
+	first, err := j.next()
+	if err == io.EOF {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	j.first = first
+	names := make([]string, 0, len(first))
+	for name := range first {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		j.columns = append(j.columns, parquet.Column{Name: name, Type: inferColumnType(first[name])})
+	}
+	return j, nil
+}
+
+func (j *jsonlDatasetReader) Columns() []parquet.Column { return j.columns }
+
+func (j *jsonlDatasetReader) Read() ([]interface{}, error) {
+	record := j.first
+	j.first = nil
+	if record == nil {
+		var err error
+		if record, err = j.next(); err != nil {
+			return nil, err
+		}
+	}
+	row := make([]interface{}, len(j.columns))
+	for i, col := range j.columns {
+		var err error
+		if row[i], err = columnValue(col, record[col.Name]); err != nil {
+			return nil, malformedDataset(fmt.Errorf("line %d: %w", j.line, err))
+		}
+	}
+	return row, nil
+}
+
+func (j *jsonlDatasetReader) next() (map[string]interface{}, error) {
+	for j.scanner.Scan() {
+		j.line++
+		line := bytes.TrimSpace(j.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil || record == nil {
+			return nil, malformedDataset(fmt.Errorf("line %d is not a JSON object", j.line))
+		}
+		return record, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// inferColumnType types a column from a JSON value: numbers without a
+// fraction or exponent are int64, other numbers double
+func inferColumnType(v interface{}) parquet.Type {
+	switch v := v.(type) {
+	case bool:
+		return parquet.Boolean
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return parquet.Int64
+		}
+		return parquet.Double
+	}
+	return parquet.String
+}
+
+// columnValue converts a CSV field or decoded JSON value to the column's
+// type. Missing and null values, and empty CSV fields of non-string
+// columns, are nil. Nested JSON becomes its JSON text in string columns.
+func columnValue(col parquet.Column, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if s, ok := v.(string); ok && s == "" && col.Type != parquet.String {
+		return nil, nil
+	}
+	switch col.Type {
+	case parquet.String:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		default:
+			text, err := json.Marshal(v)
+			return string(text), err
+		}
+	case parquet.Int64:
+		switch v := v.(type) {
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, nil
+			}
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				return n, nil
+			}
+		}
+	case parquet.Double:
+		switch v := v.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, nil
+			}
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				return f, nil
+			}
+		}
+	case parquet.Boolean:
+		switch v := v.(type) {
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		case bool:
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("column %q: %v doesn't fit its type", col.Name, v)
+}
+
+// csvDatasetWriter writes a header row, then one record per row; nulls
+// are empty fields
+type csvDatasetWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVDatasetWriter(w io.Writer, columns []parquet.Column) (*csvDatasetWriter, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("dataset has no columns")
+	}
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvDatasetWriter{w: cw, record: make([]string, len(columns))}, nil
+}
+
+func (c *csvDatasetWriter) Write(row []interface{}) error {
+	for i, v := range row {
+		switch v := v.(type) {
+		case nil:
+			c.record[i] = ""
+		case string:
+			c.record[i] = v
+		case int64:
+			c.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			c.record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			c.record[i] = strconv.FormatBool(v)
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvDatasetWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlDatasetWriter writes one JSON object per row, with fields in column
+// order and nulls included
+type jsonlDatasetWriter struct {
+	w     *bufio.Writer
+	names [][]byte
+}
+
+func newJSONLDatasetWriter(w io.Writer, columns []parquet.Column) *jsonlDatasetWriter {
+	names := make([][]byte, len(columns))
+	for i, col := range columns {
+		names[i], _ = json.Marshal(col.Name)
+	}
+	return &jsonlDatasetWriter{w: bufio.NewWriter(w), names: names}
+}
+
+func (j *jsonlDatasetWriter) Write(row []interface{}) error {
+	j.w.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return malformedDataset(err)
+		}
+		j.w.Write(j.names[i])
+		j.w.WriteByte(':')
+		j.w.Write(value)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlDatasetWriter) Close() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"temporal-go-worker/internal/parquet"
+)
+
+// convert stores source under raw/dataset-1 and converts it
+func convert(t *testing.T, source, from, to string, columns []ConvertColumn) (ConvertFormatResult, error) {
+	prev := objectStore
+	t.Cleanup(func() { objectStore = prev })
+	objectStore = newFileObjectStore(t.TempDir())
+	_, err := objectStore.Put(context.Background(), "raw/dataset-1", strings.NewReader(source))
+	require.NoError(t, err)
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities)
+	value, err := env.ExecuteActivity(activities.ConvertFormat, ConvertFormatInput{
+		DatasetID: "dataset-1", SourceKey: "raw/dataset-1", From: from, To: to, Columns: columns,
+	})
+	if err != nil {
+		return ConvertFormatResult{}, err
+	}
+	var result ConvertFormatResult
+	require.NoError(t, value.Get(&result))
+	return result, nil
+}
+
+func requireApplicationError(t *testing.T, err error, errType string) {
+	t.Helper()
+	var appErr *temporal.ApplicationError
+	require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+	assert.Equal(t, errType, appErr.Type())
+	assert.True(t, appErr.NonRetryable())
+}
+
+func TestConvertCSVToJSONL(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		columns     []ConvertColumn
+		want        string
+		wantRows    int64
+		wantErrType string
+		wantErr     string
+	}{
+		{
+			name:     "quoted fields",
+			source:   "id,name,note\n1,\"Smith, J\",\"said \"\"hi\"\"\"\n2,Jones,\"two\nlines\"\n",
+			want:     `{"id":"1","name":"Smith, J","note":"said \"hi\""}` + "\n" + `{"id":"2","name":"Jones","note":"two\nlines"}` + "\n",
+			wantRows: 2,
+		},
+		{
+			name:     "typed columns",
+			source:   "id,score,valid,extra\n1,0.5,true,x\n2,,false,y\n",
+			columns:  []ConvertColumn{{Name: "valid", Type: "boolean"}, {Name: "id", Type: "int64"}, {Name: "score", Type: "double"}},
+			want:     `{"valid":true,"id":1,"score":0.5}` + "\n" + `{"valid":false,"id":2,"score":null}` + "\n",
+			wantRows: 2,
+		},
+		{name: "header only", source: "id,name\n", want: ""},
+		{name: "empty", source: "", wantErrType: ErrTypeMalformedDataset, wantErr: "CSV has no header row"},
+		{name: "unterminated quote", source: "id,name\n1,\"Smith\n", wantErrType: ErrTypeMalformedDataset, wantErr: "extraneous or missing"},
+		{name: "bare quote", source: "id,name\n1,Sm\"ith\n", wantErrType: ErrTypeMalformedDataset, wantErr: "bare \""},
+		{
+			name:        "value doesn't fit",
+			source:      "id\n1\nabc\n",
+			columns:     []ConvertColumn{{Name: "id", Type: "int64"}},
+			wantErrType: ErrTypeMalformedDataset,
+			wantErr:     `line 3: column "id": abc doesn't fit its type`,
+		},
+		{
+			name:        "missing column",
+			source:      "id\n1\n",
+			columns:     []ConvertColumn{{Name: "name"}},
+			wantErrType: ErrTypeMalformedDataset,
+			wantErr:     `CSV header has no column "name"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := convert(t, tt.source, FormatCSV, FormatJSONL, tt.columns)
+			if tt.wantErrType != "" {
+				requireApplicationError(t, err, tt.wantErrType)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "converted/dataset-1.jsonl", result.Object.Key)
+			assert.Equal(t, tt.wantRows, result.Rows)
+
+			r, err := objectStore.Get(context.Background(), result.Object.Key)
+			require.NoError(t, err)
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestConvertJSONLToParquet(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		columns     []ConvertColumn
+		wantColumns []parquet.Column
+		wantRows    [][]interface{}
+		wantErrType string
+		wantErr     string
+	}{
+		{
+			name:   "inferred columns",
+			source: `{"id": 1, "name": "Smith, \"J\"", "score": 0.5, "valid": true, "tags": ["a"]}` + "\n\n" + `{"id": 2, "name": null, "score": 3}` + "\n",
+			wantColumns: []parquet.Column{
+				{Name: "id", Type: parquet.Int64}, {Name: "name", Type: parquet.String}, {Name: "score", Type: parquet.Double},
+				{Name: "tags", Type: parquet.String}, {Name: "valid", Type: parquet.Boolean},
+			},
+			wantRows: [][]interface{}{
+				{int64(1), `Smith, "J"`, 0.5, `["a"]`, true},
+				{int64(2), nil, 3.0, nil, nil},
+			},
+		},
+		{
+			name:        "configured columns",
+			source:      `{"id": "7", "score": "1.5"}` + "\n",
+			columns:     []ConvertColumn{{Name: "score", Type: "double"}, {Name: "id"}},
+			wantColumns: []parquet.Column{{Name: "score", Type: parquet.Double}, {Name: "id", Type: parquet.String}},
+			wantRows:    [][]interface{}{{1.5, "7"}},
+		},
+		{
+			name:        "empty with columns",
+			source:      "",
+			columns:     []ConvertColumn{{Name: "id", Type: "int64"}},
+			wantColumns: []parquet.Column{{Name: "id", Type: parquet.Int64}},
+		},
+		{name: "empty", source: "\n", wantErrType: ErrTypeMalformedDataset, wantErr: "schema must have at least one column"},
+		{name: "not an object", source: `{"id": 1}` + "\n" + `[1]` + "\n", wantErrType: ErrTypeMalformedDataset, wantErr: "line 2 is not a JSON object"},
+		{name: "not JSON", source: `{"id": 1`, wantErrType: ErrTypeMalformedDataset, wantErr: "line 1 is not a JSON object"},
+		{
+			name:        "value doesn't fit",
+			source:      `{"id": 1}` + "\n" + `{"id": 1.5}` + "\n",
+			wantErrType: ErrTypeMalformedDataset,
+			wantErr:     `line 2: column "id": 1.5 doesn't fit its type`,
+		},
+		{name: "unknown column type", columns: []ConvertColumn{{Name: "id", Type: "uuid"}}, wantErrType: ErrTypeUnsupportedConversion, wantErr: `unknown type "uuid"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := convert(t, tt.source, FormatJSONL, FormatParquet, tt.columns)
+			if tt.wantErrType != "" {
+				requireApplicationError(t, err, tt.wantErrType)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "converted/dataset-1.parquet", result.Object.Key)
+			assert.Equal(t, int64(len(tt.wantRows)), result.Rows)
+
+			file := readParquetObject(t, result.Object.Key)
+			assert.Equal(t, tt.wantColumns, file.Columns)
+			assert.Equal(t, int64(len(tt.wantRows)), file.NumRows)
+			assert.Equal(t, tt.wantRows, file.Rows)
+		})
+	}
+}
+
+func TestConvertUnsupported(t *testing.T) {
+	for _, pair := range [][2]string{{FormatParquet, FormatCSV}, {FormatCSV, FormatCSV}, {"xml", FormatJSONL}} {
+		t.Run(pair[0]+" to "+pair[1], func(t *testing.T) {
+			_, err := convert(t, "id\n1\n", pair[0], pair[1], nil)
+			requireApplicationError(t, err, ErrTypeUnsupportedConversion)
+		})
+	}
+}
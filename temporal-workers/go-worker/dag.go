@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeInvalidDAG is returned, without retries, for graphs with unknown
+// or duplicate datasets or a cycle
+const ErrTypeInvalidDAG = "InvalidDAG"
+
+// DAG node statuses. A node is skipped when a dataset it depends on didn't
+// complete.
+const (
+	DAGNodeCompleted = "completed"
+	DAGNodeFailed    = "failed"
+	DAGNodeSkipped   = "skipped"
+)
+
+// DAGNode is one dataset of the graph: the input of its
+// ComplexProcessingWorkflow and the datasets that must complete first
+type DAGNode struct {
+	ComplexProcessingInput
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// UnmarshalJSON decodes the input and depends_on; without it the input's
+// own UnmarshalJSON, promoted from the embedded field, would drop
+// depends_on
+func (n *DAGNode) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &n.ComplexProcessingInput); err != nil {
+		return err
+	}
+	var deps struct {
+		DependsOn []string `json:"depends_on"`
+	}
+	if err := json.Unmarshal(data, &deps); err != nil {
+		return err
+	}
+	n.DependsOn = deps.DependsOn
+	return nil
+}
+
+// DAGWorkflowInput represents input for the DAG workflow. Parallelism caps
+// how many children run at once; zero runs every ready node.
+type DAGWorkflowInput struct {
+	Nodes       []DAGNode `json:"nodes"`
+	Parallelism int       `json:"parallelism,omitempty"`
+}
+
+// DAGNodeStatus reports how one node went
+type DAGNodeStatus struct {
+	DatasetID  string `json:"dataset_id"`
+	WorkflowID string `json:"workflow_id,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DAGWorkflowResult represents the result of a DAG run. Order lists the
+// completed datasets in completion order.
+type DAGWorkflowResult struct {
+	Status    string          `json:"status"`
+	Completed int             `json:"completed"`
+	Failed    int             `json:"failed"`
+	Skipped   int             `json:"skipped"`
+	Order     []string        `json:"order"`
+	Nodes     []DAGNodeStatus `json:"nodes"`
+}
+
+// DAGWorkflow runs a ComplexProcessingWorkflow child per dataset, starting
+// each only once every dataset it depends on has completed. Independent
+// branches run in parallel up to the parallelism limit. When a node fails,
+// the nodes downstream of it are skipped and the other branches carry on.
+func DAGWorkflow(ctx workflow.Context, input DAGWorkflowInput) (DAGWorkflowResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🕸️ Starting DAG workflow", "nodes", len(input.Nodes), "parallelism", input.Parallelism)
+	logWorkflowInput(ctx, input)
+
+	if err := validateDAG(input.Nodes); err != nil {
+		return DAGWorkflowResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidDAG, err)
+	}
+
+	result := DAGWorkflowResult{Nodes: make([]DAGNodeStatus, len(input.Nodes))}
+	index := make(map[string]int, len(input.Nodes))
+	for i, node := range input.Nodes {
+		index[node.DatasetID] = i
+		result.Nodes[i].DatasetID = node.DatasetID
+	}
+
+	parentID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	selector := workflow.NewSelector(ctx)
+	history := watchHistoryLength(ctx)
+	running, decided := 0, 0
+
+	// ready reports whether node i can start, marking it skipped when a
+	// dependency didn't complete
+	ready := func(i int) bool {
+		for _, dep := range input.Nodes[i].DependsOn {
+			switch result.Nodes[index[dep]].Status {
+			case DAGNodeCompleted:
+			case DAGNodeFailed, DAGNodeSkipped:
+				result.Nodes[i].Status = DAGNodeSkipped
+				result.Nodes[i].Error = fmt.Sprintf("dependency %s %s", dep, result.Nodes[index[dep]].Status)
+				logger.Warn("⏭️ Skipping DAG node", "dataset_id", input.Nodes[i].DatasetID, "reason", result.Nodes[i].Error)
+				decided++
+				return false
+			default:
+				return false
+			}
+		}
+		return true
+	}
+
+	for decided < len(input.Nodes) {
+		// Passes repeat so skips propagate down the graph before waiting
+		for changed := true; changed; {
+			changed = false
+			for i := range input.Nodes {
+				status := &result.Nodes[i]
+				if status.Status != "" || (input.Parallelism > 0 && running >= input.Parallelism) {
+					continue
+				}
+				if !ready(i) {
+					changed = changed || status.Status == DAGNodeSkipped
+					continue
+				}
+
+				i := i
+				status.Status = "running"
+				status.WorkflowID = fmt.Sprintf("%s-dag-%s", parentID, input.Nodes[i].DatasetID)
+				childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{WorkflowID: status.WorkflowID})
+				logger.Info("▶️ Starting DAG node", "dataset_id", input.Nodes[i].DatasetID, "workflow_id", status.WorkflowID)
+				running++
+				selector.AddFuture(workflow.ExecuteChildWorkflow(childCtx, ComplexProcessingWorkflow, input.Nodes[i].ComplexProcessingInput), func(f workflow.Future) {
+					running--
+					decided++
+					if err := f.Get(ctx, nil); err != nil {
+						result.Nodes[i].Status = DAGNodeFailed
+						result.Nodes[i].Error = err.Error()
+						logger.Error("❌ DAG node failed", "dataset_id", input.Nodes[i].DatasetID, "error", err)
+						return
+					}
+					result.Nodes[i].Status = DAGNodeCompleted
+					result.Order = append(result.Order, input.Nodes[i].DatasetID)
+				})
+			}
+		}
+		if running > 0 {
+			selector.Select(ctx)
+			history.check()
+		}
+	}
+
+	for _, node := range result.Nodes {
+		switch node.Status {
+		case DAGNodeCompleted:
+			result.Completed++
+		case DAGNodeFailed:
+			result.Failed++
+		case DAGNodeSkipped:
+			result.Skipped++
+		}
+	}
+	result.Status = "completed"
+	if result.Failed > 0 || result.Skipped > 0 {
+		result.Status = "partial"
+	}
+	logger.Info("✅ DAG workflow completed", "completed", result.Completed, "failed", result.Failed, "skipped", result.Skipped)
+	return result, nil
+}
+
+// validateDAG rejects duplicate or missing dataset IDs, dependencies on
+// datasets outside the graph, and cycles
+func validateDAG(nodes []DAGNode) error {
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		if node.DatasetID == "" {
+			return fmt.Errorf("node %d has no dataset_id", i)
+		}
+		if _, dup := index[node.DatasetID]; dup {
+			return fmt.Errorf("dataset %s appears more than once", node.DatasetID)
+		}
+		index[node.DatasetID] = i
+	}
+	for _, node := range nodes {
+		for _, dep := range node.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("dataset %s depends on unknown dataset %s", node.DatasetID, dep)
+			}
+		}
+	}
+
+	// Depth-first search; reaching a node still on the path closes a cycle
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make([]int, len(nodes))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		state[i] = onPath
+		path = append(path, nodes[i].DatasetID)
+		for _, dep := range nodes[i].DependsOn {
+			j := index[dep]
+			switch state[j] {
+			case onPath:
+				start := 0
+				for path[start] != dep {
+					start++
+				}
+				return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path[start:], " -> "), dep)
+			case unvisited:
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = done
+		return nil
+	}
+	for i := range nodes {
+		if state[i] == unvisited {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func dagNode(datasetID string, dependsOn ...string) DAGNode {
+	return DAGNode{ComplexProcessingInput: ComplexProcessingInput{DatasetID: datasetID}, DependsOn: dependsOn}
+}
+
+func TestValidateDAG(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []DAGNode
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "diamond", nodes: []DAGNode{dagNode("d", "b", "c"), dagNode("b", "a"), dagNode("c", "a"), dagNode("a")}},
+		{name: "no dataset", nodes: []DAGNode{dagNode("a"), dagNode("")}, wantErr: "node 1 has no dataset_id"},
+		{name: "duplicate", nodes: []DAGNode{dagNode("a"), dagNode("a")}, wantErr: "dataset a appears more than once"},
+		{name: "unknown dependency", nodes: []DAGNode{dagNode("a", "z")}, wantErr: "dataset a depends on unknown dataset z"},
+		{name: "self cycle", nodes: []DAGNode{dagNode("a", "a")}, wantErr: "dependency cycle: a -> a"},
+		{
+			name:    "cycle",
+			nodes:   []DAGNode{dagNode("root"), dagNode("a", "root", "c"), dagNode("b", "a"), dagNode("c", "b")},
+			wantErr: "dependency cycle: a -> c -> b -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDAG(tt.nodes)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDAGWorkflow(t *testing.T) {
+	diamond := []DAGNode{dagNode("d", "b", "c"), dagNode("b", "a"), dagNode("c", "a"), dagNode("a")}
+	tests := []struct {
+		name        string
+		nodes       []DAGNode
+		parallelism int
+		// fail names the datasets whose child fails
+		fail []string
+		// wantStarts is when each dataset's child started; each runs a minute
+		wantStarts map[string]time.Duration
+		wantResult DAGWorkflowResult
+	}{
+		{
+			name:       "diamond",
+			nodes:      diamond,
+			wantStarts: map[string]time.Duration{"a": 0, "b": time.Minute, "c": time.Minute, "d": 2 * time.Minute},
+			wantResult: DAGWorkflowResult{
+				Status: "completed", Completed: 4, Order: []string{"a", "b", "c", "d"},
+				Nodes: []DAGNodeStatus{
+					{DatasetID: "d", WorkflowID: "parent-dag-d", Status: DAGNodeCompleted},
+					{DatasetID: "b", WorkflowID: "parent-dag-b", Status: DAGNodeCompleted},
+					{DatasetID: "c", WorkflowID: "parent-dag-c", Status: DAGNodeCompleted},
+					{DatasetID: "a", WorkflowID: "parent-dag-a", Status: DAGNodeCompleted},
+				},
+			},
+		},
+		{
+			name:        "one at a time",
+			nodes:       diamond,
+			parallelism: 1,
+			wantStarts:  map[string]time.Duration{"a": 0, "b": time.Minute, "c": 2 * time.Minute, "d": 3 * time.Minute},
+			wantResult: DAGWorkflowResult{
+				Status: "completed", Completed: 4, Order: []string{"a", "b", "c", "d"},
+				Nodes: []DAGNodeStatus{
+					{DatasetID: "d", WorkflowID: "parent-dag-d", Status: DAGNodeCompleted},
+					{DatasetID: "b", WorkflowID: "parent-dag-b", Status: DAGNodeCompleted},
+					{DatasetID: "c", WorkflowID: "parent-dag-c", Status: DAGNodeCompleted},
+					{DatasetID: "a", WorkflowID: "parent-dag-a", Status: DAGNodeCompleted},
+				},
+			},
+		},
+		{
+			name:       "dependency fails",
+			nodes:      append([]DAGNode{dagNode("e", "d")}, diamond...),
+			fail:       []string{"b"},
+			wantStarts: map[string]time.Duration{"a": 0, "b": time.Minute, "c": time.Minute},
+			wantResult: DAGWorkflowResult{
+				Status: "partial", Completed: 2, Failed: 1, Skipped: 2, Order: []string{"a", "c"},
+				Nodes: []DAGNodeStatus{
+					{DatasetID: "e", Status: DAGNodeSkipped, Error: "dependency d skipped"},
+					{DatasetID: "d", Status: DAGNodeSkipped, Error: "dependency b failed"},
+					{DatasetID: "b", WorkflowID: "parent-dag-b", Status: DAGNodeFailed},
+					{DatasetID: "c", WorkflowID: "parent-dag-c", Status: DAGNodeCompleted},
+					{DatasetID: "a", WorkflowID: "parent-dag-a", Status: DAGNodeCompleted},
+				},
+			},
+		},
+		{
+			name:       "root fails",
+			nodes:      diamond,
+			fail:       []string{"a"},
+			wantStarts: map[string]time.Duration{"a": 0},
+			wantResult: DAGWorkflowResult{
+				Status: "partial", Failed: 1, Skipped: 3,
+				Nodes: []DAGNodeStatus{
+					{DatasetID: "d", Status: DAGNodeSkipped, Error: "dependency b skipped"},
+					{DatasetID: "b", Status: DAGNodeSkipped, Error: "dependency a failed"},
+					{DatasetID: "c", Status: DAGNodeSkipped, Error: "dependency a failed"},
+					{DatasetID: "a", WorkflowID: "parent-dag-a", Status: DAGNodeFailed},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "parent"})
+			env.RegisterWorkflow(ComplexProcessingWorkflow)
+			start := env.Now()
+
+			starts := map[string]time.Duration{}
+			env.OnWorkflow(ComplexProcessingWorkflow, mock.Anything, mock.Anything).Return(
+				func(ctx workflow.Context, input ComplexProcessingInput) (ComplexProcessingResult, error) {
+					starts[input.DatasetID] = workflow.Now(ctx).Sub(start)
+					if err := workflow.Sleep(ctx, time.Minute); err != nil {
+						return ComplexProcessingResult{}, err
+					}
+					for _, fail := range tt.fail {
+						if input.DatasetID == fail {
+							return ComplexProcessingResult{}, errors.New("processing failed")
+						}
+					}
+					return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "completed"}, nil
+				})
+
+			env.ExecuteWorkflow(DAGWorkflow, DAGWorkflowInput{Nodes: tt.nodes, Parallelism: tt.parallelism})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result DAGWorkflowResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			assert.Equal(t, tt.wantStarts, starts)
+			// Failed children report the child error, which names its run
+			for i, node := range result.Nodes {
+				if node.Status == DAGNodeFailed {
+					assert.Contains(t, node.Error, "processing failed")
+					result.Nodes[i].Error = ""
+				}
+			}
+			// Siblings finishing together may complete in either order
+			if len(result.Order) == 4 && result.Order[1] == "c" {
+				result.Order[1], result.Order[2] = result.Order[2], result.Order[1]
+			}
+			assert.Equal(t, tt.wantResult, result)
+		})
+	}
+}
+
+func TestDAGWorkflowInvalid(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ComplexProcessingWorkflow)
+	started := 0
+	env.OnWorkflow(ComplexProcessingWorkflow, mock.Anything, mock.Anything).Return(
+		func(ctx workflow.Context, input ComplexProcessingInput) (ComplexProcessingResult, error) {
+			started++
+			return ComplexProcessingResult{}, nil
+		})
+
+	env.ExecuteWorkflow(DAGWorkflow, DAGWorkflowInput{Nodes: []DAGNode{dagNode("a", "b"), dagNode("b", "a"), dagNode("c")}})
+	require.True(t, env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	var appErr *temporal.ApplicationError
+	require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+	assert.Equal(t, ErrTypeInvalidDAG, appErr.Type())
+	assert.True(t, appErr.NonRetryable())
+	assert.Contains(t, appErr.Error(), "dependency cycle: a -> b -> a")
+	assert.Zero(t, started)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeInvalidQualityConfig marks a quality field with an unknown format
+// or a pattern that doesn't compile
+const ErrTypeInvalidQualityConfig = "InvalidQualityConfig"
+
+// Formats a QualityField can be checked against
+const (
+	QualityFormatEmail   = "email"
+	QualityFormatNumber  = "number"
+	QualityFormatInteger = "integer"
+	QualityFormatDate    = "date" // RFC 3339 or YYYY-MM-DD
+	QualityFormatUUID    = "uuid"
+)
+
+// Data quality dimensions
+const (
+	QualityCompleteness = "completeness"
+	QualityValidity     = "validity"
+	QualityUniqueness   = "uniqueness"
+)
+
+const (
+	// qualityHeartbeatBytes is how often scanning progress is reported
+	qualityHeartbeatBytes = 1 << 20
+	// qualityMaxLineBytes is the longest record line that can be scanned
+	qualityMaxLineBytes = 16 << 20
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// QualityField configures the checks for one record field. Completeness is
+// always measured; validity only with Format or Pattern, uniqueness only
+// with Unique.
+type QualityField struct {
+	Name    string `json:"name"`
+	Format  string `json:"format,omitempty"`
+	Pattern string `json:"pattern,omitempty"` // regular expression string values must match
+	Unique  bool   `json:"unique,omitempty"`
+}
+
+// ComputeDataQualityInput represents input for scoring a dataset stored as
+// JSON lines
+type ComputeDataQualityInput struct {
+	DatasetID string         `json:"dataset_id"`
+	SourceKey string         `json:"source_key"`
+	Fields    []QualityField `json:"fields"`
+	Key       string         `json:"key,omitempty"`
+}
+
+// FieldQuality reports the measured dimensions for one field, as 0-1 rates
+type FieldQuality struct {
+	Name         string   `json:"name"`
+	NonNull      int64    `json:"non_null"`
+	Completeness float64  `json:"completeness"`
+	Validity     *float64 `json:"validity,omitempty"`
+	Uniqueness   *float64 `json:"uniqueness,omitempty"`
+}
+
+// DataQualityReport represents a dataset's quality score. Score is the mean
+// of the measured dimensions, each the mean over the fields measuring it.
+type DataQualityReport struct {
+	DatasetID  string             `json:"dataset_id"`
+	Records    int64              `json:"records"`
+	Malformed  int64              `json:"malformed"`
+	Score      float64            `json:"score"`
+	Dimensions map[string]float64 `json:"dimensions"`
+	Fields     []FieldQuality     `json:"fields"`
+	Object     ObjectRef          `json:"object"`
+}
+
+// fieldScan accumulates counts for one field while the dataset streams by
+type fieldScan struct {
+	config  QualityField
+	check   func(interface{}) bool
+	nonNull int64
+	valid   int64
+	seen    map[string]struct{}
+}
+
+// ComputeDataQuality streams a JSON-lines dataset and measures completeness,
+// validity and uniqueness of the configured fields. The report is stored
+// under quality/ and returned. Lines that aren't JSON objects count as
+// records with every field missing.
+func (a *Activities) ComputeDataQuality(ctx context.Context, input ComputeDataQualityInput) (DataQualityReport, error) {
+	key := input.Key
+	if key == "" {
+		key = fmt.Sprintf("quality/%s.json", input.DatasetID)
+	}
+	activityLog.Infof("🧮 Computing data quality of %s (%d fields)", input.SourceKey, len(input.Fields))
+
+	scans := make([]*fieldScan, len(input.Fields))
+	for i, field := range input.Fields {
+		check, err := qualityCheck(field)
+		if err != nil {
+			return DataQualityReport{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidQualityConfig, err)
+		}
+		scans[i] = &fieldScan{config: field, check: check}
+		if field.Unique {
+			scans[i].seen = make(map[string]struct{})
+		}
+	}
+
+	src, err := objectStore.Get(ctx, input.SourceKey)
+	if err != nil {
+		return DataQualityReport{}, err
+	}
+	defer src.Close()
+
+	report := DataQualityReport{DatasetID: input.DatasetID}
+	scanner := bufio.NewScanner(&heartbeatReader{ctx: ctx, r: src, every: qualityHeartbeatBytes})
+	scanner.Buffer(make([]byte, 64*1024), qualityMaxLineBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		report.Records++
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(line, &record); err != nil {
+			report.Malformed++
+			continue
+		}
+		for _, scan := range scans {
+			scan.observe(record[scan.config.Name])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return DataQualityReport{}, fmt.Errorf("scanning %s: %w", input.SourceKey, err)
+	}
+
+	report.Fields, report.Dimensions, report.Score = scoreQuality(report.Records, scans)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return DataQualityReport{}, err
+	}
+	ref, err := objectStore.Put(ctx, key, bytes.NewReader(data))
+	if err != nil {
+		return DataQualityReport{}, fmt.Errorf("storing quality report: %w", err)
+	}
+	report.Object = ref
+
+	activityLog.Infof("✅ Data quality of %s: %.3f over %d records", input.DatasetID, report.Score, report.Records)
+	return report, nil
+}
+
+// observe counts one record's value. Missing, null and empty-string values
+// are null; validity is judged on non-null values only.
+func (s *fieldScan) observe(raw json.RawMessage) {
+	if len(raw) == 0 || string(raw) == "null" || string(raw) == `""` {
+		return
+	}
+	s.nonNull++
+	if s.check != nil {
+		var value interface{}
+		if json.Unmarshal(raw, &value) == nil && s.check(value) {
+			s.valid++
+		}
+	}
+	if s.seen != nil {
+		s.seen[string(raw)] = struct{}{}
+	}
+}
+
+// scoreQuality turns the field counts into rates, averages each dimension
+// over the fields measuring it, and averages the dimensions into the score
+func scoreQuality(records int64, scans []*fieldScan) ([]FieldQuality, map[string]float64, float64) {
+	fields := make([]FieldQuality, 0, len(scans))
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	add := func(dimension string, rate float64) {
+		sums[dimension] += rate
+		counts[dimension]++
+	}
+
+	for _, scan := range scans {
+		field := FieldQuality{Name: scan.config.Name, NonNull: scan.nonNull}
+		field.Completeness = qualityRate(scan.nonNull, records)
+		add(QualityCompleteness, field.Completeness)
+		if scan.check != nil {
+			validity := qualityRate(scan.valid, scan.nonNull)
+			field.Validity = &validity
+			add(QualityValidity, validity)
+		}
+		if scan.seen != nil {
+			uniqueness := qualityRate(int64(len(scan.seen)), scan.nonNull)
+			field.Uniqueness = &uniqueness
+			add(QualityUniqueness, uniqueness)
+		}
+		fields = append(fields, field)
+	}
+
+	dimensions := make(map[string]float64, len(sums))
+	var score float64
+	for _, dimension := range []string{QualityCompleteness, QualityValidity, QualityUniqueness} {
+		if counts[dimension] == 0 {
+			continue
+		}
+		dimensions[dimension] = sums[dimension] / float64(counts[dimension])
+		score += dimensions[dimension]
+	}
+	if len(dimensions) > 0 {
+		score /= float64(len(dimensions))
+	}
+	return fields, dimensions, score
+}
+
+// qualityRate returns n/total, or 0 when there is nothing to measure
+func qualityRate(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// qualityCheck returns the validity check for a field, or nil if it has
+// none
+func qualityCheck(field QualityField) (func(interface{}) bool, error) {
+	var checks []func(interface{}) bool
+	switch field.Format {
+	case "":
+	case QualityFormatEmail:
+		checks = append(checks, stringCheck(func(s string) bool {
+			addr, err := mail.ParseAddress(s)
+			return err == nil && addr.Address == s
+		}))
+	case QualityFormatNumber:
+		checks = append(checks, func(v interface{}) bool {
+			switch v := v.(type) {
+			case float64:
+				return true
+			case string:
+				_, err := strconv.ParseFloat(v, 64)
+				return err == nil
+			}
+			return false
+		})
+	case QualityFormatInteger:
+		checks = append(checks, func(v interface{}) bool {
+			switch v := v.(type) {
+			case float64:
+				return v == float64(int64(v))
+			case string:
+				_, err := strconv.ParseInt(v, 10, 64)
+				return err == nil
+			}
+			return false
+		})
+	case QualityFormatDate:
+		checks = append(checks, stringCheck(func(s string) bool {
+			if _, err := time.Parse(time.RFC3339, s); err == nil {
+				return true
+			}
+			_, err := time.Parse("2006-01-02", s)
+			return err == nil
+		}))
+	case QualityFormatUUID:
+		checks = append(checks, stringCheck(uuidPattern.MatchString))
+	default:
+		return nil, fmt.Errorf("field %q: unknown format %q", field.Name, field.Format)
+	}
+	if field.Pattern != "" {
+		pattern, err := regexp.Compile(field.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: invalid pattern: %w", field.Name, err)
+		}
+		checks = append(checks, stringCheck(pattern.MatchString))
+	}
+	if len(checks) == 0 {
+		return nil, nil
+	}
+	return func(v interface{}) bool {
+		for _, check := range checks {
+			if !check(v) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// stringCheck applies check to string values; anything else is invalid
+func stringCheck(check func(string) bool) func(interface{}) bool {
+	return func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && check(s)
+	}
+}
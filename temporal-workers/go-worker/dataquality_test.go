@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestComputeDataQuality(t *testing.T) {
+	dataset := strings.Join([]string{
+		`{"id":"1","email":"a@example.com","age":30}`,
+		`{"id":"2","email":"not an email","age":"31"}`,
+		`{"id":"2","email":null,"age":30.5}`,
+		`not json`,
+		``,
+	}, "\n")
+	tests := []struct {
+		name        string
+		fields      []QualityField
+		wantFields  []FieldQuality
+		wantScore   float64
+		wantErrType string
+		wantErr     string
+		missing     bool
+	}{
+		{
+			name:   "measured",
+			fields: []QualityField{{Name: "id", Unique: true}, {Name: "email", Format: QualityFormatEmail}, {Name: "age", Format: QualityFormatInteger}},
+			wantFields: []FieldQuality{
+				{Name: "id", NonNull: 3, Completeness: 0.75, Uniqueness: float64Ptr(2.0 / 3)},
+				{Name: "email", NonNull: 2, Completeness: 0.5, Validity: float64Ptr(0.5)},
+				{Name: "age", NonNull: 3, Completeness: 0.75, Validity: float64Ptr(2.0 / 3)},
+			},
+			// completeness 2/3, validity 7/12, uniqueness 2/3
+			wantScore: (2.0/3 + 7.0/12 + 2.0/3) / 3,
+		},
+		{name: "unknown format", fields: []QualityField{{Name: "id", Format: "ssn"}}, wantErrType: ErrTypeInvalidQualityConfig},
+		{name: "invalid pattern", fields: []QualityField{{Name: "id", Pattern: "("}}, wantErrType: ErrTypeInvalidQualityConfig},
+		{name: "missing dataset", fields: []QualityField{{Name: "id"}}, missing: true, wantErr: "missing.jsonl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			_, err := store.Put(context.Background(), "datasets/ds-1.jsonl", strings.NewReader(dataset))
+			require.NoError(t, err)
+			sourceKey := "datasets/ds-1.jsonl"
+			if tt.missing {
+				sourceKey = "datasets/missing.jsonl"
+			}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.ComputeDataQuality, ComputeDataQualityInput{DatasetID: "ds-1", SourceKey: sourceKey, Fields: tt.fields})
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var report DataQualityReport
+			require.NoError(t, value.Get(&report))
+			assert.Equal(t, int64(4), report.Records)
+			assert.Equal(t, int64(1), report.Malformed)
+			require.Len(t, report.Fields, len(tt.wantFields))
+			for i, want := range tt.wantFields {
+				got := report.Fields[i]
+				assert.Equal(t, want.Name, got.Name)
+				assert.Equal(t, want.NonNull, got.NonNull, want.Name)
+				assert.InDelta(t, want.Completeness, got.Completeness, 1e-9, want.Name)
+				assertRate(t, want.Validity, got.Validity, want.Name+" validity")
+				assertRate(t, want.Uniqueness, got.Uniqueness, want.Name+" uniqueness")
+			}
+			assert.InDelta(t, tt.wantScore, report.Score, 1e-9)
+			assert.Equal(t, "quality/ds-1.json", report.Object.Key)
+			assert.Equal(t, 1, store.writes["quality/ds-1.json"])
+		})
+	}
+}
+
+func float64Ptr(f float64) *float64 { return &f }
+
+func assertRate(t *testing.T, want, got *float64, msg string) {
+	t.Helper()
+	if want == nil {
+		assert.Nil(t, got, msg)
+		return
+	}
+	require.NotNil(t, got, msg)
+	assert.InDelta(t, *want, *got, 1e-9, msg)
+}
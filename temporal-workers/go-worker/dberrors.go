@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// Application error types returned by DatabaseOperation. Workflows list
+// ErrTypeConstraintViolation in NonRetryableErrorTypes: retrying a statement
+// that violates a constraint can never succeed.
+const (
+	ErrTypeDatabaseDeadlock    = "DatabaseDeadlock"
+	ErrTypeConstraintViolation = "DatabaseConstraintViolation"
+	ErrTypeDatabase            = "DatabaseError"
+)
+
+// deadlockRetryDelay asks the server to retry deadlocked statements sooner
+// than the retry policy's backoff would, since the competing transaction has
+// usually already been rolled back
+const deadlockRetryDelay = 100 * time.Millisecond
+
+// sqlStateError is implemented by the pgx and lib/pq error types
+type sqlStateError interface {
+	SQLState() string
+}
+
+// classifySQLError wraps a driver error in an ApplicationError whose type
+// tells the workflow's retry policy how to treat it
+func classifySQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch sqlErrorKind(err) {
+	case ErrTypeDatabaseDeadlock:
+		return temporal.NewApplicationErrorWithOptions(err.Error(), ErrTypeDatabaseDeadlock, temporal.ApplicationErrorOptions{
+			Cause:          err,
+			NextRetryDelay: deadlockRetryDelay,
+		})
+	case ErrTypeConstraintViolation:
+		return temporal.NewApplicationErrorWithOptions(err.Error(), ErrTypeConstraintViolation, temporal.ApplicationErrorOptions{
+			Cause:        err,
+			NonRetryable: true,
+		})
+	default:
+		return temporal.NewApplicationErrorWithCause(err.Error(), ErrTypeDatabase, err)
+	}
+}
+
+func sqlErrorKind(err error) string {
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		state := stateErr.SQLState()
+		switch {
+		case state == "40P01" || state == "40001":
+			// deadlock_detected, serialization_failure
+			return ErrTypeDatabaseDeadlock
+		case strings.HasPrefix(state, "23"):
+			// integrity_constraint_violation class
+			return ErrTypeConstraintViolation
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "deadlock"):
+		return ErrTypeDatabaseDeadlock
+	case strings.Contains(msg, "constraint"), strings.Contains(msg, "duplicate key"):
+		return ErrTypeConstraintViolation
+	default:
+		return ErrTypeDatabase
+	}
+}
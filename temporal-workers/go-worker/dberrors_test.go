@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// stateError stands in for the pgx and lib/pq error types
+type stateError struct {
+	state, msg string
+}
+
+func (e stateError) Error() string    { return e.msg }
+func (e stateError) SQLState() string { return e.state }
+
+func TestClassifySQLError(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		wantType         string
+		wantNonRetryable bool
+		wantRetryDelay   time.Duration
+	}{
+		{name: "deadlock state", err: stateError{"40P01", "ERROR: tuple locked"}, wantType: ErrTypeDatabaseDeadlock, wantRetryDelay: deadlockRetryDelay},
+		{name: "serialization failure", err: stateError{"40001", "could not serialize access"}, wantType: ErrTypeDatabaseDeadlock, wantRetryDelay: deadlockRetryDelay},
+		{name: "unique violation", err: stateError{"23505", "ERROR: already there"}, wantType: ErrTypeConstraintViolation, wantNonRetryable: true},
+		{name: "wrapped state", err: fmt.Errorf("exec: %w", stateError{"23503", "foreign key"}), wantType: ErrTypeConstraintViolation, wantNonRetryable: true},
+		{name: "deadlock message", err: errors.New("Deadlock found when trying to get lock"), wantType: ErrTypeDatabaseDeadlock, wantRetryDelay: deadlockRetryDelay},
+		{name: "duplicate key message", err: errors.New("duplicate key value violates unique constraint"), wantType: ErrTypeConstraintViolation, wantNonRetryable: true},
+		{name: "other state", err: stateError{"08006", "connection failure"}, wantType: ErrTypeDatabase},
+		{name: "other error", err: errors.New("connection refused"), wantType: ErrTypeDatabase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifySQLError(tt.err)
+			var appErr *temporal.ApplicationError
+			require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+			assert.Equal(t, tt.wantType, appErr.Type())
+			assert.Equal(t, tt.wantNonRetryable, appErr.NonRetryable())
+			assert.Equal(t, tt.wantRetryDelay, appErr.NextRetryDelay())
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	assert.NoError(t, classifySQLError(nil))
+}
+
+// TestSystemOperationRetriesByErrorType checks SystemOperationWorkflow
+// retries deadlocks and gives up on constraint violations at once
+func TestSystemOperationRetriesByErrorType(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int
+		wantErrType  string
+	}{
+		{name: "deadlock retried", err: stateError{"40P01", "deadlock detected"}, wantAttempts: 2},
+		{name: "constraint violation", err: stateError{"23505", "duplicate key"}, wantAttempts: 1, wantErrType: ErrTypeConstraintViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			attempts := 0
+			env.OnActivity(activities.DatabaseOperation, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input DatabaseOperationInput) (DatabaseOperationResult, error) {
+					attempts++
+					if attempts == 1 {
+						return DatabaseOperationResult{}, classifySQLError(tt.err)
+					}
+					return DatabaseOperationResult{}, nil
+				})
+
+			env.ExecuteWorkflow(SystemOperationWorkflow, SystemOperationInput{Operation: "select", Target: "users"})
+			require.True(t, env.IsWorkflowCompleted())
+			assert.Equal(t, tt.wantAttempts, attempts)
+
+			if tt.wantErrType != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(env.GetWorkflowError(), &appErr), "want an application error, got %v", env.GetWorkflowError())
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				return
+			}
+			assert.NoError(t, env.GetWorkflowError())
+		})
+	}
+}
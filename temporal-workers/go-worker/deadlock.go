@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
+)
+
+// deadlockErrorCode identifies the SDK's "potential deadlock detected" error
+const deadlockErrorCode = "TMPRL1101"
+
+// deadlockReportingLogger wraps the SDK logger to spot the deadlock detector
+// firing. The SDK only reports it as a generic "Workflow panic" error log, so
+// we re-emit it as a dedicated log line and a counter tagged with the
+// workflow type, making offending workflows easy to find.
+type deadlockReportingLogger struct {
+	log.Logger
+	metrics client.MetricsHandler
+	keyvals []interface{}
+}
+
+// newWorkerLogger returns the SDK logger used by the client and worker
+func newWorkerLogger(metrics client.MetricsHandler) log.Logger {
+	return newDeadlockReportingLogger(log.NewStructuredLogger(slog.Default()), metrics)
+}
+
+func newDeadlockReportingLogger(logger log.Logger, metrics client.MetricsHandler) *deadlockReportingLogger {
+	return &deadlockReportingLogger{Logger: logger, metrics: metrics}
+}
+
+// Error logs the message and reports it if it is a deadlock
+func (l *deadlockReportingLogger) Error(msg string, keyvals ...interface{}) {
+	l.Logger.Error(msg, keyvals...)
+
+	all := append(append([]interface{}{}, l.keyvals...), keyvals...)
+	if !isDeadlockReport(all) {
+		return
+	}
+	workflowType := keyvalString(all, "WorkflowType")
+	l.metrics.WithTags(map[string]string{"workflow_type": workflowType}).
+		Counter("go_worker_workflow_deadlocks").Inc(1)
+	l.Logger.Error("🔒 Workflow deadlock detected",
+		"WorkflowType", workflowType,
+		"WorkflowID", keyvalString(all, "WorkflowID"),
+		"RunID", keyvalString(all, "RunID"))
+}
+
+// With keeps deadlock reporting on loggers derived by the SDK
+func (l *deadlockReportingLogger) With(keyvals ...interface{}) log.Logger {
+	return &deadlockReportingLogger{
+		Logger:  log.With(l.Logger, keyvals...),
+		metrics: l.metrics,
+		keyvals: append(append([]interface{}{}, l.keyvals...), keyvals...),
+	}
+}
+
+// WithCallerSkip keeps deadlock reporting when the SDK adjusts caller depth
+func (l *deadlockReportingLogger) WithCallerSkip(depth int) log.Logger {
+	return &deadlockReportingLogger{
+		Logger:  log.Skip(l.Logger, depth),
+		metrics: l.metrics,
+		keyvals: l.keyvals,
+	}
+}
+
+func isDeadlockReport(keyvals []interface{}) bool {
+	for i := 1; i < len(keyvals); i += 2 {
+		if key, _ := keyvals[i-1].(string); key != "Error" {
+			continue
+		}
+		if strings.Contains(fmt.Sprint(keyvals[i]), deadlockErrorCode) {
+			return true
+		}
+	}
+	return false
+}
+
+func keyvalString(keyvals []interface{}, key string) string {
+	for i := 1; i < len(keyvals); i += 2 {
+		if k, _ := keyvals[i-1].(string); k == key {
+			return fmt.Sprint(keyvals[i])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/client"
+)
+
+// recordingLogger keeps the messages of the lines logged at error level
+// and the key-values of those logged at info level
+type recordingLogger struct {
+	errors []string
+	infos  map[string][]interface{}
+	warns  map[string][]interface{}
+}
+
+func (l *recordingLogger) Debug(string, ...interface{}) {}
+func (l *recordingLogger) Warn(msg string, keyvals ...interface{}) {
+	if l.warns == nil {
+		l.warns = make(map[string][]interface{})
+	}
+	l.warns[msg] = keyvals
+}
+func (l *recordingLogger) Info(msg string, keyvals ...interface{}) {
+	if l.infos == nil {
+		l.infos = make(map[string][]interface{})
+	}
+	l.infos[msg] = keyvals
+}
+func (l *recordingLogger) Error(msg string, keyvals ...interface{}) {
+	l.errors = append(l.errors, msg)
+}
+
+// counterHandler records counter increments by name and tags
+type counterHandler struct {
+	client.MetricsHandler
+	tags   map[string]string
+	counts map[string]int64
+}
+
+func newCounterHandler() *counterHandler {
+	return &counterHandler{MetricsHandler: client.MetricsNopHandler, counts: make(map[string]int64)}
+}
+
+func (h *counterHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := make(map[string]string, len(h.tags)+len(tags))
+	for k, v := range h.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return &counterHandler{MetricsHandler: h.MetricsHandler, tags: merged, counts: h.counts}
+}
+
+func (h *counterHandler) Counter(name string) client.MetricsCounter {
+	return counterFunc(func(n int64) { h.counts[name+tagString(h.tags)] += n })
+}
+
+type counterFunc func(int64)
+
+func (f counterFunc) Inc(n int64) { f(n) }
+
+// tagString formats tags as {name=value,...} sorted by name
+func tagString(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func TestDeadlockReportingLogger(t *testing.T) {
+	deadlock := errors.New("[TMPRL1101]Potential deadlock detected: workflow goroutine \"root\" didn't yield for over a second")
+	tests := []struct {
+		name string
+		// with are the key-values the SDK scopes the logger with
+		with       []interface{}
+		msg        string
+		keyvals    []interface{}
+		wantErrors []string
+		wantCounts map[string]int64
+	}{
+		{
+			name:       "deadlock",
+			with:       []interface{}{"WorkflowType", "ComplexProcessingWorkflow", "WorkflowID", "wf-1", "RunID", "run-1"},
+			msg:        "Workflow panic",
+			keyvals:    []interface{}{"Error", deadlock},
+			wantErrors: []string{"Workflow panic", "🔒 Workflow deadlock detected"},
+			wantCounts: map[string]int64{"go_worker_workflow_deadlocks{workflow_type=ComplexProcessingWorkflow}": 1},
+		},
+		{
+			name:       "other panic",
+			with:       []interface{}{"WorkflowType", "ComplexProcessingWorkflow"},
+			msg:        "Workflow panic",
+			keyvals:    []interface{}{"Error", errors.New("index out of range")},
+			wantErrors: []string{"Workflow panic"},
+			wantCounts: map[string]int64{},
+		},
+		{
+			name:       "code outside the error",
+			msg:        "TMPRL1101 mentioned in passing",
+			keyvals:    []interface{}{"Detail", deadlock},
+			wantErrors: []string{"TMPRL1101 mentioned in passing"},
+			wantCounts: map[string]int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &recordingLogger{}
+			metrics := newCounterHandler()
+			logger := newDeadlockReportingLogger(inner, metrics).With(tt.with...).(*deadlockReportingLogger).WithCallerSkip(1)
+
+			logger.Error(tt.msg, tt.keyvals...)
+			assert.Equal(t, tt.wantErrors, inner.errors)
+			assert.Equal(t, tt.wantCounts, metrics.counts)
+		})
+	}
+}
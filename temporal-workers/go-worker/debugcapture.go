@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// defaultRedactedKeys are always redacted from debug captures
+var defaultRedactedKeys = []string{"password", "secret", "token", "api_key", "apikey", "authorization", "credentials", "private_key"}
+
+const redactedValue = "[REDACTED]"
+
+// DebugCapture is one activity execution as written to the debug sink
+type DebugCapture struct {
+	WorkflowID   string          `json:"workflow_id"`
+	RunID        string          `json:"run_id"`
+	ActivityType string          `json:"activity_type"`
+	ActivityID   string          `json:"activity_id"`
+	Attempt      int32           `json:"attempt"`
+	StartedAt    time.Time       `json:"started_at"`
+	DurationMs   int64           `json:"duration_ms"`
+	Input        json.RawMessage `json:"input"`
+	Output       json.RawMessage `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// DebugSink receives debug captures
+type DebugSink interface {
+	WriteCapture(ctx context.Context, capture DebugCapture) error
+}
+
+// objectStoreDebugSink writes each capture as a JSON object under debug/
+type objectStoreDebugSink struct{}
+
+func (objectStoreDebugSink) WriteCapture(ctx context.Context, capture DebugCapture) error {
+	body, err := marshalUnescaped(capture)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("debug/%s/%s-%s-%d.json", url.PathEscape(capture.WorkflowID),
+		capture.ActivityType, url.PathEscape(capture.ActivityID), capture.Attempt)
+	_, err = objectStore.Put(ctx, key, bytes.NewReader(body))
+	return err
+}
+
+// debugCaptureInterceptor captures full activity inputs and outputs. It is
+// only installed when DEBUG_CAPTURE=true, so it costs nothing when off.
+type debugCaptureInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	sink   DebugSink
+	redact map[string]bool
+}
+
+func newDebugCaptureInterceptor(sink DebugSink, redactKeys []string) *debugCaptureInterceptor {
+	redact := make(map[string]bool, len(defaultRedactedKeys)+len(redactKeys))
+	for _, k := range append(append([]string{}, defaultRedactedKeys...), redactKeys...) {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			redact[k] = true
+		}
+	}
+	return &debugCaptureInterceptor{sink: sink, redact: redact}
+}
+
+func (i *debugCaptureInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	a := &debugCaptureActivityInterceptor{root: i}
+	a.Next = next
+	return a
+}
+
+type debugCaptureActivityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	root *debugCaptureInterceptor
+}
+
+func (a *debugCaptureActivityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	startedAt := time.Now()
+	result, err := a.Next.ExecuteActivity(ctx, in)
+
+	info := activity.GetInfo(ctx)
+	capture := DebugCapture{
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		ActivityType: info.ActivityType.Name,
+		ActivityID:   info.ActivityID,
+		Attempt:      info.Attempt,
+		StartedAt:    startedAt,
+		DurationMs:   elapsedSince(startedAt).Milliseconds(),
+		Input:        a.root.redactedJSON(in.Args),
+	}
+	if err != nil {
+		capture.Error = err.Error()
+	} else {
+		capture.Output = a.root.redactedJSON(result)
+	}
+	// Capture failures never fail the activity itself
+	if writeErr := a.root.sink.WriteCapture(context.WithoutCancel(ctx), capture); writeErr != nil {
+		activityLog.Errorf("❌ Unable to write debug capture for %s: %v", capture.ActivityType, writeErr)
+	}
+	return result, err
+}
+
+// redactedJSON encodes v with the values of sensitive keys replaced, at any
+// depth. Keys match case-insensitively.
+func (i *debugCaptureInterceptor) redactedJSON(v interface{}) json.RawMessage {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		raw, _ = json.Marshal(fmt.Sprintf("<unencodable: %v>", err))
+		return raw
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return raw
+	}
+	redacted, err := marshalUnescaped(i.redactValue(generic))
+	if err != nil {
+		return raw
+	}
+	return redacted
+}
+
+func (i *debugCaptureInterceptor) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if i.redact[strings.ToLower(k)] {
+				t[k] = redactedValue
+			} else {
+				t[k] = i.redactValue(child)
+			}
+		}
+	case []interface{}:
+		for n, child := range t {
+			t[n] = i.redactValue(child)
+		}
+	}
+	return v
+}
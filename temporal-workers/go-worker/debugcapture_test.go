@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// recordingDebugSink keeps captures and fails every write with err, if set
+type recordingDebugSink struct {
+	captures []DebugCapture
+	err      error
+}
+
+func (s *recordingDebugSink) WriteCapture(ctx context.Context, capture DebugCapture) error {
+	s.captures = append(s.captures, capture)
+	return s.err
+}
+
+func TestDebugCaptureInterceptor(t *testing.T) {
+	tests := []struct {
+		name        string
+		activityErr error
+		sinkErr     error
+		wantOutput  string
+		wantError   string
+	}{
+		{name: "output captured", wantOutput: `{"Items":3,"Nested":{"Token":"[REDACTED]"}}`},
+		{name: "error captured", activityErr: errors.New("source unavailable"), wantError: "source unavailable"},
+		{name: "sink failure ignored", sinkErr: errors.New("store unavailable"), wantOutput: `{"Items":3,"Nested":{"Token":"[REDACTED]"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type nested struct{ Token string }
+			type output struct {
+				Items  int
+				Nested nested
+			}
+			sink := &recordingDebugSink{err: tt.sinkErr}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{newDebugCaptureInterceptor(sink, []string{" Tenant_Key "})}})
+			env.RegisterActivityWithOptions(func(ctx context.Context, input map[string]interface{}) (output, error) {
+				return output{Items: 3, Nested: nested{Token: "abc"}}, tt.activityErr
+			}, activity.RegisterOptions{Name: "captured"})
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) error {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+					StartToCloseTimeout: time.Minute,
+					RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+				})
+				return workflow.ExecuteActivity(ctx, "captured", map[string]interface{}{
+					"dataset_id": "ds-1",
+					"Password":   "hunter2",
+					"tenant_key": "acme",
+					"sources":    []interface{}{map[string]interface{}{"api_key": "k"}},
+				}).Get(ctx, nil)
+			}, workflow.RegisterOptions{Name: "captureWorkflow"})
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "wf-1"})
+
+			env.ExecuteWorkflow("captureWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			if tt.activityErr != nil {
+				require.Error(t, env.GetWorkflowError())
+			} else {
+				require.NoError(t, env.GetWorkflowError())
+			}
+
+			require.Len(t, sink.captures, 1)
+			capture := sink.captures[0]
+			assert.Equal(t, "wf-1", capture.WorkflowID)
+			assert.Equal(t, "captured", capture.ActivityType)
+			assert.Equal(t, int32(1), capture.Attempt)
+			assert.JSONEq(t, `[{"dataset_id":"ds-1","Password":"[REDACTED]","tenant_key":"[REDACTED]","sources":[{"api_key":"[REDACTED]"}]}]`, string(capture.Input))
+			if tt.wantOutput != "" {
+				assert.JSONEq(t, tt.wantOutput, string(capture.Output))
+			} else {
+				assert.Empty(t, capture.Output)
+			}
+			assert.Contains(t, capture.Error, tt.wantError)
+		})
+	}
+}
+
+func TestObjectStoreDebugSink(t *testing.T) {
+	capture := DebugCapture{WorkflowID: "wf/1", ActivityType: "ProcessLargeDataset", ActivityID: "5", Attempt: 2, Input: json.RawMessage(`[]`)}
+	tests := []struct {
+		name    string
+		failPut int
+		wantErr string
+	}{
+		{name: "written"},
+		{name: "store unavailable", failPut: 1, wantErr: "store unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			store.failPut = tt.failPut
+
+			err := objectStoreDebugSink{}.WriteCapture(context.Background(), capture)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, store.writes["debug/wf%2F1/ProcessLargeDataset-5-2.json"])
+		})
+	}
+}
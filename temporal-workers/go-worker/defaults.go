@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// defaultParameters are org-wide parameter defaults from DEFAULT_PARAMETERS,
+// applied under every run's own parameters
+var defaultParameters Parameters
+
+// parseDefaultParameters reads DEFAULT_PARAMETERS, a JSON object
+func parseDefaultParameters(value string) (Parameters, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var params Parameters
+	if err := json.Unmarshal([]byte(value), &params); err != nil {
+		return nil, fmt.Errorf("DEFAULT_PARAMETERS must be a JSON object: %w", err)
+	}
+	return params, nil
+}
+
+// withDefaultParameters overlays the caller's parameters on the worker's
+// defaults: top-level keys the caller set, even to null, win. The defaults
+// are recorded in history when the run starts, so replays and workers
+// configured differently see the ones the run started with. Versioned so
+// runs started before defaults existed replay unchanged.
+func withDefaultParameters(ctx workflow.Context, params Parameters) (Parameters, error) {
+	if workflow.GetVersion(ctx, "default-parameters", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return params, nil
+	}
+	var defaults Parameters
+	err := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return defaultParameters
+	}).Get(&defaults)
+	if err != nil {
+		return params, err
+	}
+	if len(defaults) == 0 {
+		return params, nil
+	}
+	return mergeParameters(defaults, params), nil
+}
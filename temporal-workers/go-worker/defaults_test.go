@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestParseDefaultParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Parameters
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "object", value: `{"batch_size": 500, "region": "eu"}`, want: Parameters{"batch_size": json.Number("500"), "region": "eu"}},
+		{name: "array", value: `["batch_size"]`, wantErr: "DEFAULT_PARAMETERS must be a JSON object"},
+		{name: "malformed", value: `{"batch_size":`, wantErr: "DEFAULT_PARAMETERS must be a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := parseDefaultParameters(tt.value)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, params)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+		})
+	}
+}
+
+func TestWithDefaultParameters(t *testing.T) {
+	defaults := Parameters{"batch_size": 500, "region": "eu", "dry_run": true}
+	tests := []struct {
+		name     string
+		defaults Parameters
+		params   Parameters
+		want     Parameters
+	}{
+		{name: "defaults fill missing keys", defaults: defaults, params: Parameters{"priority": "high"}, want: Parameters{"batch_size": json.Number("500"), "region": "eu", "dry_run": true, "priority": "high"}},
+		{name: "caller wins", defaults: defaults, params: Parameters{"region": "us", "dry_run": nil}, want: Parameters{"batch_size": json.Number("500"), "region": "us", "dry_run": nil}},
+		{name: "no caller parameters", defaults: defaults, want: Parameters{"batch_size": json.Number("500"), "region": "eu", "dry_run": true}},
+		{name: "no defaults", params: Parameters{"region": "us"}, want: Parameters{"region": "us"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := defaultParameters
+			t.Cleanup(func() { defaultParameters = prev })
+			defaultParameters = tt.defaults
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context, params Parameters) (Parameters, error) {
+				return withDefaultParameters(ctx, params)
+			}, workflow.RegisterOptions{Name: "defaultsWorkflow"})
+
+			env.ExecuteWorkflow("defaultsWorkflow", tt.params)
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var merged Parameters
+			require.NoError(t, env.GetWorkflowResult(&merged))
+			assert.Equal(t, tt.want, merged)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeRequiredSinkFailed is returned when a required sink couldn't take
+// the result. Its details carry the per-sink statuses.
+const ErrTypeRequiredSinkFailed = "RequiredSinkFailed"
+
+// Sink types understood by Deliver
+const (
+	SinkTypeDatabase = "database"
+	SinkTypeCache    = "cache"
+	SinkTypeKafka    = "kafka"
+)
+
+// SinkConfig names a destination for results. Required sinks must succeed
+// for delivery to succeed; optional ones may fail softly.
+type SinkConfig struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Target   string `json:"target"` // table, key prefix or topic
+	Required bool   `json:"required"`
+}
+
+// SinkStatus reports how delivery to one sink went
+type SinkStatus struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Status   string `json:"status"` // delivered or failed
+	Error    string `json:"error,omitempty"`
+}
+
+// DeliverInput represents input for delivering a result to sinks
+type DeliverInput struct {
+	DatasetID string                 `json:"dataset_id"`
+	Result    map[string]interface{} `json:"result"`
+	Sinks     []SinkConfig           `json:"sinks"`
+}
+
+// DeliverResult represents per-sink delivery outcomes
+type DeliverResult struct {
+	Sinks []SinkStatus `json:"sinks"`
+}
+
+// ResultSink delivers an encoded result to one kind of destination
+type ResultSink interface {
+	Deliver(ctx context.Context, target, key string, payload []byte) error
+}
+
+// MessagePublisher publishes messages to a topic (Kafka or similar)
+type MessagePublisher interface {
+	Publish(ctx context.Context, topic, key string, value []byte) error
+}
+
+// messagePublisher is used by kafka sinks. It defaults to a simulated
+// publisher; assign a Kafka producer to publish for real.
+var messagePublisher MessagePublisher = simulatedPublisher{}
+
+// resultSinks maps sink types to implementations
+func (a *Activities) resultSinks() map[string]ResultSink {
+	return map[string]ResultSink{
+		SinkTypeDatabase: databaseSink{db: a.DB},
+		SinkTypeCache:    cacheSink{},
+		SinkTypeKafka:    kafkaSink{},
+	}
+}
+
+// Deliver sends a result to every configured sink and reports each
+// outcome. Sinks that already succeeded are recorded in heartbeat details,
+// so a retry after a required-sink failure doesn't deliver to them twice.
+func (a *Activities) Deliver(ctx context.Context, input DeliverInput) (DeliverResult, error) {
+	activityLog.Infof("📬 Delivering result of %s to %d sinks", input.DatasetID, len(input.Sinks))
+
+	payload, err := json.Marshal(input.Result)
+	if err != nil {
+		return DeliverResult{}, temporal.NewNonRetryableApplicationError("unable to encode result", "InvalidInput", err)
+	}
+
+	delivered := map[string]bool{}
+	if activity.HasHeartbeatDetails(ctx) {
+		_ = activity.GetHeartbeatDetails(ctx, &delivered)
+	}
+
+	sinks := a.resultSinks()
+	var result DeliverResult
+	var failedRequired []string
+	for _, sink := range input.Sinks {
+		status := SinkStatus{Name: sink.Name, Type: sink.Type, Required: sink.Required, Status: "delivered"}
+		if !delivered[sink.Name] {
+			if err := deliverToSink(ctx, sinks, sink, input.DatasetID, payload); err != nil {
+				status.Status = "failed"
+				status.Error = err.Error()
+				if sink.Required {
+					failedRequired = append(failedRequired, sink.Name)
+					activityLog.Errorf("❌ Required sink %s failed: %v", sink.Name, err)
+				} else {
+					activityLog.Errorf("⚠️ Optional sink %s failed: %v", sink.Name, err)
+				}
+			} else {
+				delivered[sink.Name] = true
+				activity.RecordHeartbeat(ctx, delivered)
+			}
+		}
+		result.Sinks = append(result.Sinks, status)
+	}
+
+	if len(failedRequired) > 0 {
+		return result, temporal.NewApplicationError(
+			fmt.Sprintf("required sinks failed: %s", strings.Join(failedRequired, ", ")),
+			ErrTypeRequiredSinkFailed, result)
+	}
+
+	activityLog.Infof("✅ Result delivered to %d sinks", len(delivered))
+	return result, nil
+}
+
+func deliverToSink(ctx context.Context, sinks map[string]ResultSink, sink SinkConfig, key string, payload []byte) error {
+	impl, ok := sinks[sink.Type]
+	if !ok {
+		return fmt.Errorf("unknown sink type %q", sink.Type)
+	}
+	return impl.Deliver(ctx, sink.Target, key, payload)
+}
+
+// databaseSink upserts the result as a row of the target table
+type databaseSink struct {
+	db sqlExecer
+}
+
+func (s databaseSink) Deliver(ctx context.Context, table, key string, payload []byte) error {
+	quoted, err := sqlIdentifier(table)
+	if err != nil {
+		return err
+	}
+	db := newTracedExecer(s.db, "deliver", table, activity.GetMetricsHandler(ctx))
+	query := "INSERT INTO " + quoted + " (id, data) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data"
+	_, err = db.ExecContext(ctx, query, key, payload)
+	return classifySQLError(err)
+}
+
+// cacheSink stores the result under the target key prefix
+type cacheSink struct{}
+
+func (cacheSink) Deliver(ctx context.Context, prefix, key string, payload []byte) error {
+	time.Sleep(time.Duration(20+rand.Intn(80)) * time.Millisecond)
+	activityLog.Infof("🗄️ Cached %d bytes at %s%s", len(payload), prefix, key)
+	return nil
+}
+
+// kafkaSink publishes the result to the target topic
+type kafkaSink struct{}
+
+func (kafkaSink) Deliver(ctx context.Context, topic, key string, payload []byte) error {
+	return messagePublisher.Publish(ctx, topic, key, payload)
+}
+
+// simulatedPublisher stands in for a Kafka producer in local runs
+type simulatedPublisher struct{}
+
+func (simulatedPublisher) Publish(ctx context.Context, topic, key string, value []byte) error {
+	time.Sleep(time.Duration(20+rand.Intn(80)) * time.Millisecond)
+	activityLog.Infof("📨 Published %d bytes to %s (key: %s)", len(value), topic, key)
+	return nil
+}
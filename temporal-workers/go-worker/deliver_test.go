@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// recordingExecer records statements and fails them all with err, if set
+type recordingExecer struct {
+	queries []string
+	err     error
+}
+
+func (e *recordingExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return driverResult(1), nil
+}
+
+type driverResult int64
+
+func (r driverResult) LastInsertId() (int64, error) { return 0, nil }
+func (r driverResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+// recordingPublisher records published topics and fails the ones in fail
+type recordingPublisher struct {
+	topics []string
+	fail   map[string]error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic, key string, value []byte) error {
+	if err := p.fail[topic]; err != nil {
+		return err
+	}
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestDeliver(t *testing.T) {
+	sinks := []SinkConfig{
+		{Name: "warehouse", Type: SinkTypeDatabase, Target: "analytics.results", Required: true},
+		{Name: "events", Type: SinkTypeKafka, Target: "results", Required: true},
+		{Name: "audit", Type: SinkTypeKafka, Target: "audit"},
+		{Name: "legacy", Type: "ftp", Target: "/results"},
+	}
+	tests := []struct {
+		name string
+		// delivered is heartbeated by an earlier attempt
+		delivered   map[string]bool
+		dbErr       error
+		publishErr  map[string]error
+		wantStatus  []string
+		wantTopics  []string
+		wantQueries int
+		wantErr     bool
+	}{
+		{
+			name:        "optional sinks fail softly",
+			publishErr:  map[string]error{"audit": errors.New("broker down")},
+			wantStatus:  []string{"delivered", "delivered", "failed", "failed"},
+			wantTopics:  []string{"results"},
+			wantQueries: 1,
+		},
+		{
+			name:        "required sink fails",
+			publishErr:  map[string]error{"results": errors.New("broker down")},
+			wantStatus:  []string{"delivered", "failed", "delivered", "failed"},
+			wantTopics:  []string{"audit"},
+			wantQueries: 1,
+			wantErr:     true,
+		},
+		{
+			name:       "retry skips delivered sinks",
+			delivered:  map[string]bool{"warehouse": true, "audit": true},
+			wantStatus: []string{"delivered", "delivered", "delivered", "failed"},
+			wantTopics: []string{"results"},
+		},
+		{
+			name:        "database constraint violation",
+			dbErr:       stateError{"23505", "duplicate key"},
+			wantStatus:  []string{"failed", "delivered", "delivered", "failed"},
+			wantTopics:  []string{"results", "audit"},
+			wantQueries: 1,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{fail: tt.publishErr}
+			prev := messagePublisher
+			t.Cleanup(func() { messagePublisher = prev })
+			messagePublisher = publisher
+			db := &recordingExecer{err: tt.dbErr}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(&Activities{DB: db})
+			if tt.delivered != nil {
+				env.SetHeartbeatDetails(tt.delivered)
+			}
+
+			value, err := env.ExecuteActivity(activities.Deliver, DeliverInput{DatasetID: "ds-1", Result: map[string]interface{}{"processed_items": 10}, Sinks: sinks})
+			var result DeliverResult
+			if tt.wantErr {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+				assert.Equal(t, ErrTypeRequiredSinkFailed, appErr.Type())
+				assert.False(t, appErr.NonRetryable())
+				require.NoError(t, appErr.Details(&result))
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, value.Get(&result))
+			}
+
+			var statuses []string
+			for _, status := range result.Sinks {
+				statuses = append(statuses, status.Status)
+				assert.Equal(t, status.Status == "failed", status.Error != "", status.Name)
+			}
+			assert.Equal(t, tt.wantStatus, statuses)
+			assert.Equal(t, tt.wantTopics, publisher.topics)
+			assert.Len(t, db.queries, tt.wantQueries)
+			assert.Contains(t, result.Sinks[3].Error, `unknown sink type "ftp"`)
+		})
+	}
+}
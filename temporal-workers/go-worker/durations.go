@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// minElapsed is the smallest duration reported for work that ran. It keeps
+// derived rates such as items per second finite and positive.
+const minElapsed = time.Microsecond
+
+// elapsedSince returns the time since start. Starts taken from time.Now
+// carry a monotonic clock reading, so wall-clock steps (NTP corrections, VM
+// resume) don't affect the result; starts without one, e.g. decoded from
+// JSON, can still go backwards and are clamped.
+func elapsedSince(start time.Time) time.Duration {
+	return clampElapsed(time.Since(start))
+}
+
+// clampElapsed raises a measured duration to at least minElapsed
+func clampElapsed(d time.Duration) time.Duration {
+	if d < minElapsed {
+		return minElapsed
+	}
+	return d
+}
+
+// nonNegative clamps spans between workflow timestamps, which come from
+// server event times and can step backwards across server clock changes
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Duration is a time.Duration serialized as a Go duration string such as
+// "30s" or "1m30s"
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON parses a duration string. A bare JSON number is taken as
+// nanoseconds, as a time.Duration field encodes.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return json.Unmarshal(data, &d.Duration)
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	d.Duration = parsed
+	return nil
+}
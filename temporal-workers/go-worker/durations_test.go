@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElapsedSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   time.Time
+		atLeast time.Duration
+		atMost  time.Duration
+	}{
+		{name: "second ago", start: time.Now().Add(-time.Second), atLeast: time.Second, atMost: time.Minute},
+		{name: "just now", start: time.Now(), atLeast: minElapsed, atMost: time.Minute},
+		// Round(0) drops the monotonic reading, as decoding a time does
+		{name: "wall clock stepped back", start: time.Now().Add(time.Hour).Round(0), atLeast: minElapsed, atMost: minElapsed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := elapsedSince(tt.start)
+			assert.GreaterOrEqual(t, got, tt.atLeast)
+			assert.LessOrEqual(t, got, tt.atMost)
+		})
+	}
+}
+
+func TestClampedDurations(t *testing.T) {
+	tests := []struct {
+		in              time.Duration
+		wantElapsed     time.Duration
+		wantNonNegative time.Duration
+	}{
+		{in: 5 * time.Second, wantElapsed: 5 * time.Second, wantNonNegative: 5 * time.Second},
+		{in: 0, wantElapsed: minElapsed, wantNonNegative: 0},
+		{in: -3 * time.Second, wantElapsed: minElapsed, wantNonNegative: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			assert.Equal(t, tt.wantElapsed, clampElapsed(tt.in))
+			assert.Equal(t, tt.wantNonNegative, nonNegative(tt.in))
+		})
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: `"30s"`, want: 30 * time.Second},
+		{in: `"1m30s"`, want: 90 * time.Second},
+		{in: `"0s"`},
+		// Inputs recorded while the field was a time.Duration
+		{in: `30000000000`, want: 30 * time.Second},
+		{in: `"soon"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tt.in), &d)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, d.Duration)
+
+			encoded, err := json.Marshal(d)
+			require.NoError(t, err)
+			assert.Equal(t, `"`+tt.want.String()+`"`, string(encoded))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Error types returned by NormalizeEncoding without retries: the data won't
+// decode any better on another attempt
+const (
+	ErrTypeUnsupportedEncoding = "UnsupportedEncoding"
+	ErrTypeInvalidEncoding     = "InvalidEncoding"
+)
+
+// Encodings recognized by NormalizeEncoding
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "latin-1"
+)
+
+const (
+	// encodingSniffBytes is how much of the input is inspected for detection
+	encodingSniffBytes = 4096
+	// encodingHeartbeatBytes is how often transcoding progress is reported
+	encodingHeartbeatBytes = 1 << 20
+)
+
+// NormalizeEncodingInput represents input for normalizing a dataset's
+// encoding. Encoding skips detection when set.
+type NormalizeEncodingInput struct {
+	DatasetID string `json:"dataset_id"`
+	SourceKey string `json:"source_key"`
+	Encoding  string `json:"encoding,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// NormalizeEncodingResult represents the normalized UTF-8 copy
+type NormalizeEncodingResult struct {
+	Object           ObjectRef `json:"object"`
+	DetectedEncoding string    `json:"detected_encoding"`
+	BytesRead        int64     `json:"bytes_read"`
+}
+
+// NormalizeEncoding detects the encoding of a stored dataset, transcodes it
+// to UTF-8 and stores the normalized copy. Data is streamed from the source
+// to the destination, so memory use doesn't grow with the dataset size.
+func (a *Activities) NormalizeEncoding(ctx context.Context, input NormalizeEncodingInput) (NormalizeEncodingResult, error) {
+	key := input.Key
+	if key == "" {
+		key = fmt.Sprintf("normalized/%s.utf8", input.DatasetID)
+	}
+	activityLog.Infof("🔤 Normalizing encoding of %s", input.SourceKey)
+
+	src, err := objectStore.Get(ctx, input.SourceKey)
+	if err != nil {
+		return NormalizeEncodingResult{}, err
+	}
+	defer src.Close()
+
+	progress := &heartbeatReader{ctx: ctx, r: src, every: encodingHeartbeatBytes}
+	buffered := bufio.NewReaderSize(progress, encodingSniffBytes)
+
+	name := strings.ToLower(input.Encoding)
+	if name == "" {
+		sample, err := buffered.Peek(encodingSniffBytes)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return NormalizeEncodingResult{}, err
+		}
+		name = detectEncoding(sample)
+	}
+	decoder, err := decoderFor(name)
+	if err != nil {
+		return NormalizeEncodingResult{}, err
+	}
+
+	ref, err := objectStore.Put(ctx, key, transform.NewReader(buffered, decoder))
+	if errors.Is(err, encoding.ErrInvalidUTF8) {
+		return NormalizeEncodingResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("%s is not valid %s", input.SourceKey, name), ErrTypeInvalidEncoding, err)
+	}
+	if err != nil {
+		return NormalizeEncodingResult{}, fmt.Errorf("transcoding %s from %s: %w", input.SourceKey, name, err)
+	}
+
+	activityLog.Infof("✅ Encoding normalized: %s -> utf-8, %d bytes read", name, progress.n)
+	return NormalizeEncodingResult{Object: ref, DetectedEncoding: name, BytesRead: progress.n}, nil
+}
+
+// detectEncoding guesses the encoding from the start of the data: a byte
+// order mark wins, then NUL-byte patterns typical of UTF-16 ASCII text, then
+// UTF-8 validity. Anything else is treated as Latin-1, which accepts every
+// byte sequence.
+func detectEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	var evenNUL, oddNUL int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNUL++
+		} else {
+			oddNUL++
+		}
+	}
+	if half := len(sample) / 2; half > 0 {
+		if oddNUL*10 > half*3 && evenNUL*10 < half {
+			return EncodingUTF16LE
+		}
+		if evenNUL*10 > half*3 && oddNUL*10 < half {
+			return EncodingUTF16BE
+		}
+	}
+
+	// The sample may end in the middle of a multi-byte rune
+	trimmed := sample
+	for i := 0; i < utf8.UTFMax-1 && len(trimmed) > 0 && !utf8.Valid(trimmed); i++ {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	if utf8.Valid(trimmed) {
+		return EncodingUTF8
+	}
+	return EncodingLatin1
+}
+
+// decoderFor returns a transformer from the named encoding to UTF-8. Byte
+// order marks are stripped; UTF-8 input is validated rather than repaired.
+func decoderFor(name string) (transform.Transformer, error) {
+	switch name {
+	case EncodingUTF8, "utf8":
+		return transform.Chain(encoding.UTF8Validator, unicode.UTF8BOM.NewDecoder()), nil
+	case EncodingUTF16LE, "utf-16":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder(), nil
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder(), nil
+	case EncodingLatin1, "latin1", "iso-8859-1":
+		return charmap.ISO8859_1.NewDecoder(), nil
+	default:
+		return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("unsupported encoding %q", name), ErrTypeUnsupportedEncoding, nil)
+	}
+}
+
+// heartbeatReader counts bytes read and heartbeats the total periodically
+type heartbeatReader struct {
+	ctx   context.Context
+	r     io.Reader
+	every int64
+	n     int64
+	last  int64
+}
+
+func (h *heartbeatReader) Read(p []byte) (int, error) {
+	if err := h.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := h.r.Read(p)
+	h.n += int64(n)
+	if h.n-h.last >= h.every {
+		activity.RecordHeartbeat(h.ctx, h.n)
+		h.last = h.n
+	}
+	return n, err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestNormalizeEncoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       []byte
+		encoding     string
+		want         string
+		wantDetected string
+		wantErrType  string
+	}{
+		{
+			name:         "utf-16le with byte order mark",
+			source:       []byte{0xFF, 0xFE, 'i', 0, 'd', 0, ',', 0, 0xE9, 0, '\n', 0, 0x3D, 0xD8, 0x00, 0xDE},
+			want:         "id,é\n😀",
+			wantDetected: EncodingUTF16LE,
+		},
+		{
+			name:         "utf-16be without byte order mark",
+			source:       []byte{0, 'i', 0, 'd', 0, ',', 0, 0xE9},
+			want:         "id,é",
+			wantDetected: EncodingUTF16BE,
+		},
+		{name: "latin-1", source: []byte{'c', 'a', 'f', 0xE9}, encoding: "latin1", want: "café", wantDetected: "latin1"},
+		{name: "utf-8", source: []byte("id,é"), want: "id,é", wantDetected: EncodingUTF8},
+		{name: "invalid utf-8", source: []byte{'c', 'a', 'f', 0xE9}, encoding: EncodingUTF8, wantErrType: ErrTypeInvalidEncoding},
+		{name: "unsupported encoding", source: []byte("id"), encoding: "ebcdic", wantErrType: ErrTypeUnsupportedEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := objectStore
+			t.Cleanup(func() { objectStore = prev })
+			objectStore = newFileObjectStore(t.TempDir())
+			ctx := context.Background()
+			_, err := objectStore.Put(ctx, "raw/dataset-1", strings.NewReader(string(tt.source)))
+			require.NoError(t, err)
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			value, err := env.ExecuteActivity(activities.NormalizeEncoding, NormalizeEncodingInput{DatasetID: "dataset-1", SourceKey: "raw/dataset-1", Encoding: tt.encoding})
+			if tt.wantErrType != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "got %v", err)
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+				return
+			}
+			require.NoError(t, err)
+			var result NormalizeEncodingResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantDetected, result.DetectedEncoding)
+			assert.Equal(t, int64(len(tt.source)), result.BytesRead)
+
+			normalized, err := objectStore.Get(ctx, result.Object.Key)
+			require.NoError(t, err)
+			defer normalized.Close()
+			got, err := io.ReadAll(normalized)
+			require.NoError(t, err)
+			assert.Equal(t, []byte(tt.want), got)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeInvalidEnumValue is returned, without retries, for an enum field
+// outside its values. JSON payloads are rejected while decoding; this
+// covers inputs built in code, such as from proto contracts.
+const ErrTypeInvalidEnumValue = "InvalidEnumValue"
+
+// ProcessType selects a processing path. The empty value means the caller
+// left the choice to routing.
+type ProcessType string
+
+const (
+	ProcessTypeParallel   ProcessType = "parallel"
+	ProcessTypeStandard   ProcessType = "standard"
+	ProcessTypeSequential ProcessType = "sequential"
+	ProcessTypeBatch      ProcessType = "batch"
+)
+
+var processTypes = []ProcessType{ProcessTypeParallel, ProcessTypeStandard, ProcessTypeSequential, ProcessTypeBatch}
+
+func (p ProcessType) MarshalJSON() ([]byte, error) {
+	return marshalEnum("process_type", p, processTypes)
+}
+func (p *ProcessType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum("process_type", data, p, processTypes)
+}
+
+// Priority is a run's scheduling priority; empty means normal
+type Priority string
+
+const (
+	PriorityLow      Priority = "low"
+	PriorityNormal   Priority = "normal"
+	PriorityMedium   Priority = "medium"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
+var priorities = []Priority{PriorityLow, PriorityNormal, PriorityMedium, PriorityHigh, PriorityCritical}
+
+func (p Priority) MarshalJSON() ([]byte, error) { return marshalEnum("priority", p, priorities) }
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum("priority", data, p, priorities)
+}
+
+// ProcessingStatus is the status of a ComplexProcessingWorkflow run
+type ProcessingStatus string
+
+const (
+	StatusProcessing ProcessingStatus = "processing"
+	StatusCompleted  ProcessingStatus = "completed"
+	StatusFailed     ProcessingStatus = "failed"
+)
+
+var processingStatuses = []ProcessingStatus{StatusProcessing, StatusCompleted, StatusFailed}
+
+func (s ProcessingStatus) MarshalJSON() ([]byte, error) {
+	return marshalEnum("status", s, processingStatuses)
+}
+func (s *ProcessingStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum("status", data, s, processingStatuses)
+}
+
+// validateComplexProcessingEnums checks the enum fields of an input
+func validateComplexProcessingEnums(input ComplexProcessingInput) error {
+	if !validEnum(input.ProcessType, processTypes) {
+		err := errInvalidEnum("process_type", input.ProcessType, processTypes)
+		return temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidEnumValue, nil)
+	}
+	if !validEnum(input.Priority, priorities) {
+		err := errInvalidEnum("priority", input.Priority, priorities)
+		return temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeInvalidEnumValue, nil)
+	}
+	return nil
+}
+
+// validEnum reports whether v is empty or one of allowed
+func validEnum[T ~string](v T, allowed []T) bool {
+	if v == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// errInvalidEnum names the field and the values it accepts
+func errInvalidEnum[T ~string](field string, v T, allowed []T) error {
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = string(a)
+	}
+	return fmt.Errorf("invalid %s %q: want one of %s", field, string(v), strings.Join(names, ", "))
+}
+
+func marshalEnum[T ~string](field string, v T, allowed []T) ([]byte, error) {
+	if !validEnum(v, allowed) {
+		return nil, errInvalidEnum(field, v, allowed)
+	}
+	return json.Marshal(string(v))
+}
+
+func unmarshalEnum[T ~string](field string, data []byte, v *T, allowed []T) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid %s: %w", field, err)
+	}
+	if !validEnum(T(s), allowed) {
+		return errInvalidEnum(field, T(s), allowed)
+	}
+	*v = T(s)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enumFields holds one field of each enum type
+type enumFields struct {
+	ProcessType ProcessType      `json:"process_type"`
+	Priority    Priority         `json:"priority"`
+	Status      ProcessingStatus `json:"status"`
+}
+
+func TestEnumUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    enumFields
+		wantErr string
+	}{
+		{
+			name:    "known values",
+			payload: `{"process_type": "batch", "priority": "high", "status": "failed"}`,
+			want:    enumFields{ProcessType: ProcessTypeBatch, Priority: PriorityHigh, Status: StatusFailed},
+		},
+		{name: "empty values", payload: `{"process_type": "", "priority": "", "status": ""}`},
+		{
+			name:    "unknown process type",
+			payload: `{"process_type": "paralel"}`,
+			wantErr: `invalid process_type "paralel": want one of parallel, standard, sequential, batch`,
+		},
+		{name: "unknown priority", payload: `{"priority": "urgent"}`, wantErr: `invalid priority "urgent": want one of low, normal, medium, high, critical`},
+		{name: "unknown status", payload: `{"status": "complete"}`, wantErr: `invalid status "complete": want one of processing, completed, failed`},
+		{name: "not a string", payload: `{"priority": 3}`, wantErr: "invalid priority: json: cannot unmarshal number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got enumFields
+			err := json.Unmarshal([]byte(tt.payload), &got)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnumMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(enumFields{ProcessType: ProcessTypeParallel, Status: StatusCompleted})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"process_type": "parallel", "priority": "", "status": "completed"}`, string(encoded))
+
+	_, err = json.Marshal(enumFields{Status: "complete"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid status "complete": want one of processing, completed, failed`)
+}
+
+// TestValidateComplexProcessingEnums checks inputs built in code, which
+// skip JSON decoding
+func TestValidateComplexProcessingEnums(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   ComplexProcessingInput
+		wantErr string
+	}{
+		{name: "valid", input: ComplexProcessingInput{ProcessType: ProcessTypeSequential, Priority: PriorityLow}},
+		{name: "unset", input: ComplexProcessingInput{}},
+		{name: "process type", input: ComplexProcessingInput{ProcessType: "turbo"}, wantErr: `invalid process_type "turbo"`},
+		{name: "priority", input: ComplexProcessingInput{Priority: "urgent"}, wantErr: `invalid priority "urgent"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateComplexProcessingEnums(tt.input)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			requireApplicationError(t, err, ErrTypeInvalidEnumValue)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
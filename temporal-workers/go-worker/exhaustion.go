@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// exhaustionPolicy configures runActivity. MaxAttempts, when positive,
+// overrides the retry policy's MaximumAttempts; OnExhausted, when set, is
+// the activity (function or registered name) run once retries run out.
+type exhaustionPolicy struct {
+	MaxAttempts int32
+	OnExhausted interface{}
+}
+
+// exhaustionPolicyFromParameters reads max_activity_attempts and
+// on_exhausted (an activity name)
+func exhaustionPolicyFromParameters(p Parameters) exhaustionPolicy {
+	var policy exhaustionPolicy
+	if attempts, ok := p.Int64("max_activity_attempts"); ok && attempts > 0 {
+		policy.MaxAttempts = int32(attempts)
+	}
+	if name, ok := p.String("on_exhausted"); ok && name != "" {
+		policy.OnExhausted = name
+	}
+	return policy
+}
+
+// ExhaustedActivityInput describes an activity whose retries ran out
+type ExhaustedActivityInput struct {
+	WorkflowID   string    `json:"workflow_id"`
+	RunID        string    `json:"run_id"`
+	ActivityType string    `json:"activity_type"`
+	ActivityID   string    `json:"activity_id"`
+	RetryState   string    `json:"retry_state"`
+	Error        string    `json:"error"`
+	FailedAt     time.Time `json:"failed_at"`
+}
+
+// runActivity executes an activity and waits for its result. If it fails
+// because its attempts or retry time ran out, policy.OnExhausted runs once
+// with the details before the original error is returned. Failures that
+// were never going to be retried don't trigger it.
+func runActivity(ctx workflow.Context, policy exhaustionPolicy, result interface{}, activity interface{}, args ...interface{}) error {
+	ctx = withExhaustionPolicy(ctx, policy)
+	err := workflow.ExecuteActivity(ctx, activity, args...).Get(ctx, result)
+	return handleExhaustion(ctx, policy, err)
+}
+
+// withExhaustionPolicy caps the attempts of activities started with the
+// returned context at policy.MaxAttempts, if set
+func withExhaustionPolicy(ctx workflow.Context, policy exhaustionPolicy) workflow.Context {
+	if policy.MaxAttempts <= 0 {
+		return ctx
+	}
+	options := workflow.GetActivityOptions(ctx)
+	retryPolicy := &temporal.RetryPolicy{}
+	if options.RetryPolicy != nil {
+		copied := *options.RetryPolicy
+		retryPolicy = &copied
+	}
+	retryPolicy.MaximumAttempts = policy.MaxAttempts
+	options.RetryPolicy = retryPolicy
+	return workflow.WithActivityOptions(ctx, options)
+}
+
+// handleExhaustion runs policy.OnExhausted if err, from an activity started
+// with ctx, means its retries ran out, and returns err unchanged. It is
+// runActivity's second half, for activities whose futures are gathered.
+func handleExhaustion(ctx workflow.Context, policy exhaustionPolicy, err error) error {
+	var activityErr *temporal.ActivityError
+	if err == nil || policy.OnExhausted == nil || !errors.As(err, &activityErr) ||
+		!retriesExhausted(activityErr, workflow.GetActivityOptions(ctx).RetryPolicy) {
+		return err
+	}
+
+	logger := workflow.GetLogger(ctx)
+	info := workflow.GetInfo(ctx)
+	logger.Warn("🪦 Activity retries exhausted, running exhaustion handler",
+		"activity_type", activityErr.ActivityType().GetName(), "retry_state", activityErr.RetryState().String())
+	// The handler must run even if the workflow is being cancelled
+	handlerCtx, _ := workflow.NewDisconnectedContext(ctx)
+	handlerErr := workflow.ExecuteActivity(handlerCtx, policy.OnExhausted, ExhaustedActivityInput{
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		ActivityType: activityErr.ActivityType().GetName(),
+		ActivityID:   activityErr.ActivityID(),
+		RetryState:   activityErr.RetryState().String(),
+		Error:        err.Error(),
+		FailedAt:     workflow.Now(ctx).UTC(),
+	}).Get(handlerCtx, nil)
+	if handlerErr != nil {
+		logger.Error("❌ Exhaustion handler failed", "error", handlerErr)
+	}
+	return err
+}
+
+// retriesExhausted reports whether an activity failed because it ran out of
+// attempts or retry time, rather than with a non-retryable error
+func retriesExhausted(err *temporal.ActivityError, retryPolicy *temporal.RetryPolicy) bool {
+	switch err.RetryState() {
+	case enumspb.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED, enumspb.RETRY_STATE_TIMEOUT:
+		return true
+	case enumspb.RETRY_STATE_UNSPECIFIED:
+		// Not reported (e.g. by the test server). A retryable failure only
+		// reaches the workflow once retries are used up.
+		if temporal.IsCanceledError(err) {
+			return false
+		}
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) {
+			return true
+		}
+		if appErr.NonRetryable() {
+			return false
+		}
+		if retryPolicy != nil {
+			for _, t := range retryPolicy.NonRetryableErrorTypes {
+				if t == appErr.Type() {
+					return false
+				}
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// RecordExhaustedActivity is a ready-made exhaustion handler: it writes the
+// failure to the exhausted/ prefix of the object store for follow-up
+func (a *Activities) RecordExhaustedActivity(ctx context.Context, input ExhaustedActivityInput) (ObjectRef, error) {
+	activityLog.Errorf("🪦 Activity %s of workflow %s exhausted its retries: %s", input.ActivityType, input.WorkflowID, input.Error)
+
+	body, err := marshalUnescaped(input)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	key := fmt.Sprintf("exhausted/%s/%s-%s.json", url.PathEscape(input.WorkflowID), input.ActivityType, url.PathEscape(input.ActivityID))
+	return objectStore.Put(ctx, key, bytes.NewReader(body))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestExhaustionPolicyFromParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Parameters
+		want   exhaustionPolicy
+	}{
+		{name: "unset", params: Parameters{}},
+		{
+			name:   "set",
+			params: Parameters{"max_activity_attempts": json.Number("3"), "on_exhausted": "RecordExhaustedActivity"},
+			want:   exhaustionPolicy{MaxAttempts: 3, OnExhausted: "RecordExhaustedActivity"},
+		},
+		{name: "attempts not positive", params: Parameters{"max_activity_attempts": 0, "on_exhausted": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exhaustionPolicyFromParameters(tt.params))
+		})
+	}
+}
+
+func TestComplexProcessingExhaustion(t *testing.T) {
+	tests := []struct {
+		name string
+		// processErr fails every ProcessLargeDataset attempt, if set
+		processErr   error
+		wantAttempts int
+		wantHandled  bool
+	}{
+		{name: "succeeds", wantAttempts: 1},
+		{name: "retries exhausted", processErr: errors.New("disk full"), wantAttempts: 2, wantHandled: true},
+		{
+			name:         "non-retryable failure",
+			processErr:   temporal.NewNonRetryableApplicationError("bad dataset", "BadDataset", nil),
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "wf-1"})
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(
+				SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			var mu sync.Mutex
+			attempts := 0
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+					mu.Lock()
+					attempts++
+					mu.Unlock()
+					if tt.processErr != nil {
+						return ProcessLargeDatasetResult{}, tt.processErr
+					}
+					return ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil
+				})
+			var handled []ExhaustedActivityInput
+			env.OnActivity(activities.RecordExhaustedActivity, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input ExhaustedActivityInput) (ObjectRef, error) {
+					handled = append(handled, input)
+					return ObjectRef{}, nil
+				})
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:    CurrentComplexProcessingInputVersion,
+				DatasetID:  "ds-1",
+				Parameters: Parameters{"max_activity_attempts": 2, "on_exhausted": "RecordExhaustedActivity"},
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.processErr == nil {
+				require.NoError(t, env.GetWorkflowError())
+				assert.Empty(t, handled)
+				return
+			}
+			err := env.GetWorkflowError()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.processErr.Error())
+			if !tt.wantHandled {
+				assert.Empty(t, handled)
+				return
+			}
+			require.Len(t, handled, 1)
+			assert.Equal(t, "ProcessLargeDataset", handled[0].ActivityType)
+			assert.Equal(t, "wf-1", handled[0].WorkflowID)
+			assert.Contains(t, handled[0].Error, "disk full")
+		})
+	}
+}
+
+func TestRecordExhaustedActivity(t *testing.T) {
+	input := ExhaustedActivityInput{WorkflowID: "wf/1", RunID: "run-1", ActivityType: "ProcessLargeDataset", ActivityID: "5", Error: "disk full"}
+	tests := []struct {
+		name    string
+		failPut int
+		wantErr string
+	}{
+		{name: "recorded"},
+		{name: "store unavailable", failPut: 1, wantErr: "store unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			store.failPut = tt.failPut
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.RecordExhaustedActivity, input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Empty(t, store.writes)
+				return
+			}
+			require.NoError(t, err)
+			var ref ObjectRef
+			require.NoError(t, value.Get(&ref))
+			assert.Equal(t, "exhausted/wf%2F1/ProcessLargeDataset-5.json", ref.Key)
+
+			r, err := objectStore.Get(context.Background(), ref.Key)
+			require.NoError(t, err)
+			defer r.Close()
+			body, err := io.ReadAll(r)
+			require.NoError(t, err)
+			var recorded ExhaustedActivityInput
+			require.NoError(t, json.Unmarshal(body, &recorded))
+			assert.Equal(t, input, recorded)
+		})
+	}
+}
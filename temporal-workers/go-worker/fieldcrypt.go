@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeFieldEncryptionUnavailable is returned when fields must be
+// encrypted but no key is configured
+const ErrTypeFieldEncryptionUnavailable = "FieldEncryptionUnavailable"
+
+// encryptedFieldPrefix marks a value encrypted by RedactAndEncrypt
+const encryptedFieldPrefix = "enc:v1:"
+
+// fieldEncryptionKey is the AES key for field-level encryption. It comes
+// from FIELD_ENCRYPTION_KEY (base64, 16, 24 or 32 bytes).
+var fieldEncryptionKey []byte
+
+// RedactAndEncryptInput represents input for encrypting fields. Paths are
+// dot-separated keys into Data, e.g. "results.customer.email".
+type RedactAndEncryptInput struct {
+	Data  map[string]interface{} `json:"data"`
+	Paths []string               `json:"paths"`
+}
+
+// RedactAndEncryptResult carries Data with the configured fields encrypted
+type RedactAndEncryptResult struct {
+	Data      map[string]interface{} `json:"data"`
+	Encrypted []string               `json:"encrypted"`
+}
+
+// RedactAndEncrypt replaces the value at each path with its AES-GCM
+// ciphertext, so PII is protected wherever the result is stored even
+// without a payload codec. Paths that don't exist are skipped. It runs as
+// an activity because the key must stay out of workflow code and
+// encryption uses random nonces.
+func (a *Activities) RedactAndEncrypt(ctx context.Context, input RedactAndEncryptInput) (RedactAndEncryptResult, error) {
+	activityLog.Infof("🔐 Encrypting %d result fields", len(input.Paths))
+
+	if len(fieldEncryptionKey) == 0 {
+		return RedactAndEncryptResult{}, temporal.NewNonRetryableApplicationError(
+			"no FIELD_ENCRYPTION_KEY configured", ErrTypeFieldEncryptionUnavailable, nil)
+	}
+	aead, err := newFieldAEAD(fieldEncryptionKey)
+	if err != nil {
+		return RedactAndEncryptResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeFieldEncryptionUnavailable, err)
+	}
+
+	result := RedactAndEncryptResult{Data: input.Data}
+	for _, path := range input.Paths {
+		parent, leaf, ok := lookupFieldParent(input.Data, path)
+		if !ok {
+			continue
+		}
+		if s, isString := parent[leaf].(string); isString && strings.HasPrefix(s, encryptedFieldPrefix) {
+			// Already encrypted by an earlier attempt
+			result.Encrypted = append(result.Encrypted, path)
+			continue
+		}
+		encrypted, err := encryptField(aead, path, parent[leaf])
+		if err != nil {
+			return RedactAndEncryptResult{}, fmt.Errorf("encrypting %s: %w", path, err)
+		}
+		parent[leaf] = encrypted
+		result.Encrypted = append(result.Encrypted, path)
+	}
+
+	activityLog.Infof("✅ Encrypted %d fields", len(result.Encrypted))
+	return result, nil
+}
+
+// DecryptFields reverses RedactAndEncrypt for the given paths in place
+func DecryptFields(key []byte, data map[string]interface{}, paths []string) error {
+	aead, err := newFieldAEAD(key)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		parent, leaf, ok := lookupFieldParent(data, path)
+		if !ok {
+			continue
+		}
+		s, isString := parent[leaf].(string)
+		if !isString || !strings.HasPrefix(s, encryptedFieldPrefix) {
+			continue
+		}
+		value, err := decryptField(aead, path, s)
+		if err != nil {
+			return fmt.Errorf("decrypting %s: %w", path, err)
+		}
+		parent[leaf] = value
+	}
+	return nil
+}
+
+func newFieldAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptField encrypts the JSON encoding of value. The path is bound as
+// additional data, so ciphertext can't be moved to another field.
+func encryptField(aead cipher.AEAD, path string, value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(path))
+	return encryptedFieldPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptField(aead cipher.AEAD, path, encoded string) (interface{}, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, encryptedFieldPrefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(path))
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(plaintext, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// lookupFieldParent walks a dot-separated path and returns the map holding
+// its last key
+func lookupFieldParent(data map[string]interface{}, path string) (map[string]interface{}, string, bool) {
+	keys := strings.Split(path, ".")
+	current := data
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := current[k].(map[string]interface{})
+		if !ok {
+			return nil, "", false
+		}
+		current = next
+	}
+	leaf := keys[len(keys)-1]
+	if _, ok := current[leaf]; !ok {
+		return nil, "", false
+	}
+	return current, leaf, true
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fieldCryptData is a result with PII at several depths and of several types
+func fieldCryptData() map[string]interface{} {
+	return map[string]interface{}{
+		"dataset_id": "dataset-1",
+		"results": map[string]interface{}{
+			"customer": map[string]interface{}{
+				"email": "ada@example.com",
+				"phone": "+44 20 7946 0000",
+			},
+			"score": 0.97,
+		},
+		"ssn":       "078-05-1120",
+		"addresses": []interface{}{"1 Main St", "2 High St"},
+		"count":     float64(3),
+	}
+}
+
+func TestRedactAndEncrypt(t *testing.T) {
+	key := newTestKey(t)
+	tests := []struct {
+		name  string
+		paths []string
+		// wantEncrypted are the paths that must come back as ciphertext;
+		// every other field must be unchanged
+		wantEncrypted []string
+		noKey         bool
+		wantErrType   string
+	}{
+		{name: "top-level string", paths: []string{"ssn"}, wantEncrypted: []string{"ssn"}},
+		{
+			name:          "nested fields",
+			paths:         []string{"results.customer.email", "results.customer.phone"},
+			wantEncrypted: []string{"results.customer.email", "results.customer.phone"},
+		},
+		{name: "number", paths: []string{"count"}, wantEncrypted: []string{"count"}},
+		{name: "list", paths: []string{"addresses"}, wantEncrypted: []string{"addresses"}},
+		{name: "object", paths: []string{"results.customer"}, wantEncrypted: []string{"results.customer"}},
+		{name: "missing paths are skipped", paths: []string{"results.customer.name", "ssn.area"}},
+		{name: "no key", paths: []string{"ssn"}, noKey: true, wantErrType: ErrTypeFieldEncryptionUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := fieldEncryptionKey
+			t.Cleanup(func() { fieldEncryptionKey = prev })
+			fieldEncryptionKey = key
+			if tt.noKey {
+				fieldEncryptionKey = nil
+			}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			value, err := env.ExecuteActivity(activities.RedactAndEncrypt, RedactAndEncryptInput{Data: fieldCryptData(), Paths: tt.paths})
+			if tt.wantErrType != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "got %v", err)
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+				return
+			}
+			require.NoError(t, err)
+			var result RedactAndEncryptResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantEncrypted, result.Encrypted)
+
+			// Configured fields are ciphertext; with the plaintext put
+			// back, the rest is exactly the input
+			want := fieldCryptData()
+			encoded, err := json.Marshal(result.Data)
+			require.NoError(t, err)
+			withPlaintext := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(encoded, &withPlaintext))
+			for _, path := range tt.wantEncrypted {
+				parent, leaf, ok := lookupFieldParent(withPlaintext, path)
+				require.True(t, ok, path)
+				ciphertext, isString := parent[leaf].(string)
+				require.True(t, isString, path)
+				assert.True(t, strings.HasPrefix(ciphertext, encryptedFieldPrefix), path)
+				parent[leaf] = mustLookupField(t, want, path)
+			}
+			assert.Equal(t, want, withPlaintext)
+
+			// Decrypting round-trips
+			require.NoError(t, DecryptFields(key, result.Data, result.Encrypted))
+			assert.Equal(t, want, result.Data)
+		})
+	}
+}
+
+func TestDecryptFields(t *testing.T) {
+	key := newTestKey(t)
+	aead, err := newFieldAEAD(key)
+	require.NoError(t, err)
+	email, err := encryptField(aead, "email", "ada@example.com")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     []byte
+		data    map[string]interface{}
+		paths   []string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "ciphertext",
+			key:   key,
+			data:  map[string]interface{}{"email": email},
+			paths: []string{"email"},
+			want:  map[string]interface{}{"email": "ada@example.com"},
+		},
+		{
+			name:  "plaintext is left alone",
+			key:   key,
+			data:  map[string]interface{}{"email": "ada@example.com"},
+			paths: []string{"email"},
+			want:  map[string]interface{}{"email": "ada@example.com"},
+		},
+		{name: "another key", key: newTestKey(t), data: map[string]interface{}{"email": email}, paths: []string{"email"}, wantErr: true},
+		{
+			name:    "ciphertext moved to another field",
+			key:     key,
+			data:    map[string]interface{}{"contact": email},
+			paths:   []string{"contact"},
+			wantErr: true,
+		},
+		{name: "tampered", key: key, data: map[string]interface{}{"email": email[:len(email)-4] + "AAA="}, paths: []string{"email"}, wantErr: true},
+		{name: "truncated", key: key, data: map[string]interface{}{"email": encryptedFieldPrefix + "AAAA"}, paths: []string{"email"}, wantErr: true},
+		{name: "invalid key", key: []byte("short"), data: map[string]interface{}{"email": email}, paths: []string{"email"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecryptFields(tt.key, tt.data, tt.paths)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.data)
+		})
+	}
+}
+
+func mustLookupField(t *testing.T, data map[string]interface{}, path string) interface{} {
+	parent, leaf, ok := lookupFieldParent(data, path)
+	require.True(t, ok, path)
+	return parent[leaf]
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// HealthCheckWorkflow runs a periodic system health check. The worker
+// schedules it itself when HEALTHCHECK_CRON is set.
+func HealthCheckWorkflow(ctx workflow.Context, input SystemHealthCheckInput) (SystemHealthCheckResult, error) {
+	if input.CheckType == "" {
+		input.CheckType = "scheduled"
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🩺 Starting health check workflow", "check_type", input.CheckType)
+
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Minute}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    10 * time.Second,
+		MaximumAttempts:    3,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return SystemHealthCheckResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	var result SystemHealthCheckResult
+	if err := workflow.ExecuteActivity(ctx, activities.SystemHealthCheck, input).Get(ctx, &result); err != nil {
+		logger.Error("❌ Health check failed", "error", err)
+		return result, err
+	}
+
+	logger.Info("✅ Health check workflow completed", "status", result.Status, "health_score", result.HealthScore)
+	return result, nil
+}
+
+// ensureHealthCheckSchedule creates a Temporal schedule running
+// HealthCheckWorkflow on the cron spec, unless a schedule with the ID
+// already exists. Existing schedules are left alone, so restarts and many
+// replicas starting at once are safe; change a schedule's spec through
+// Temporal rather than by redeploying.
+func ensureHealthCheckSchedule(ctx context.Context, schedules client.ScheduleClient, scheduleID, spec, taskQueue string) (bool, error) {
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return false, fmt.Errorf("invalid HEALTHCHECK_CRON %q: %w", spec, err)
+	}
+
+	_, err := schedules.Create(ctx, client.ScheduleOptions{
+		ID: scheduleID,
+		Spec: client.ScheduleSpec{
+			CronExpressions: []string{spec},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        scheduleID,
+			Workflow:  HealthCheckWorkflow,
+			Args:      []interface{}{SystemHealthCheckInput{CheckType: "scheduled"}},
+			TaskQueue: taskQueue,
+		},
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("creating schedule %s: %w", scheduleID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// scheduleClient records the schedules created and fails them with err
+type scheduleClient struct {
+	client.ScheduleClient
+	created []client.ScheduleOptions
+	err     error
+}
+
+func (c *scheduleClient) Create(ctx context.Context, options client.ScheduleOptions) (client.ScheduleHandle, error) {
+	c.created = append(c.created, options)
+	return nil, c.err
+}
+
+func TestEnsureHealthCheckSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		createErr   error
+		wantCreated bool
+		wantCalls   int
+		wantErr     string
+	}{
+		{name: "created", spec: "*/5 * * * *", wantCreated: true, wantCalls: 1},
+		{name: "already exists", spec: "*/5 * * * *", createErr: temporal.ErrScheduleAlreadyRunning, wantCalls: 1},
+		{name: "invalid spec", spec: "every five minutes", wantErr: `invalid HEALTHCHECK_CRON "every five minutes"`},
+		{name: "create fails", spec: "@hourly", createErr: errors.New("permission denied"), wantCalls: 1, wantErr: "creating schedule hc-1: permission denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedules := &scheduleClient{err: tt.createErr}
+
+			created, err := ensureHealthCheckSchedule(context.Background(), schedules, "hc-1", tt.spec, "queue-1")
+			require.Len(t, schedules.created, tt.wantCalls)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.False(t, created)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCreated, created)
+
+			options := schedules.created[0]
+			assert.Equal(t, "hc-1", options.ID)
+			assert.Equal(t, []string{tt.spec}, options.Spec.CronExpressions)
+			action, ok := options.Action.(*client.ScheduleWorkflowAction)
+			require.True(t, ok)
+			assert.Equal(t, "queue-1", action.TaskQueue)
+			assert.Equal(t, []interface{}{SystemHealthCheckInput{CheckType: "scheduled"}}, action.Args)
+		})
+	}
+}
+
+func TestHealthCheckWorkflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   SystemHealthCheckInput
+		err     error
+		wantErr bool
+	}{
+		{name: "healthy", input: SystemHealthCheckInput{CheckType: "deep"}},
+		{name: "defaults to scheduled"},
+		{name: "check fails", err: errors.New("database unreachable"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			wantCheckType := tt.input.CheckType
+			if wantCheckType == "" {
+				wantCheckType = "scheduled"
+			}
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, SystemHealthCheckInput{CheckType: wantCheckType}).
+				Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, tt.err)
+
+			env.ExecuteWorkflow(HealthCheckWorkflow, tt.input)
+			require.True(t, env.IsWorkflowCompleted())
+			env.AssertExpectations(t)
+			if tt.wantErr {
+				require.Error(t, env.GetWorkflowError())
+				assert.Contains(t, env.GetWorkflowError().Error(), "database unreachable")
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result SystemHealthCheckResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, "healthy", result.Status)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeInvalidActivityOptions marks activity options that can't work,
+// such as a heartbeat timeout shorter than the heartbeat interval
+const ErrTypeInvalidActivityOptions = "InvalidActivityOptions"
+
+// heartbeatTimeoutMultiple is how many heartbeat intervals may pass before
+// the server times an activity out. Anything under 2 turns one slow
+// heartbeat into a failed attempt.
+const (
+	heartbeatTimeoutMultiple    = 3
+	minHeartbeatTimeoutMultiple = 2
+)
+
+// Heartbeat intervals the long-running activities are written to keep
+const (
+	normalizeEncodingHeartbeatInterval = 20 * time.Second
+	purgeHeartbeatInterval             = 20 * time.Second
+	rollupHeartbeatInterval            = 20 * time.Second
+	incrementalHeartbeatInterval       = 20 * time.Second
+	// Short so that activity_timeout values down to 15s still leave room
+	// for the heartbeat timeout
+	processDatasetHeartbeatInterval = 5 * time.Second
+	// IndexResults heartbeats once per bulk request, which may take up to
+	// the indexer's one-minute HTTP timeout
+	indexResultsHeartbeatInterval = time.Minute
+)
+
+// withHeartbeatInterval sets HeartbeatTimeout to a safe multiple of the
+// interval the activity heartbeats at
+func withHeartbeatInterval(options workflow.ActivityOptions, interval time.Duration) workflow.ActivityOptions {
+	options.HeartbeatTimeout = heartbeatTimeoutMultiple * interval
+	return options
+}
+
+// validateHeartbeatOptions checks that HeartbeatTimeout leaves room for at
+// least two heartbeats and fits in StartToCloseTimeout (ScheduleToClose
+// when that is the only bound)
+func validateHeartbeatOptions(options workflow.ActivityOptions, interval time.Duration) error {
+	timeout := options.StartToCloseTimeout
+	if timeout == 0 {
+		timeout = options.ScheduleToCloseTimeout
+	}
+	switch {
+	case interval <= 0:
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("heartbeat interval must be positive, got %s", interval),
+			ErrTypeInvalidActivityOptions, nil)
+	case options.HeartbeatTimeout < minHeartbeatTimeoutMultiple*interval:
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("heartbeat timeout %s is less than %dx the %s heartbeat interval",
+				options.HeartbeatTimeout, minHeartbeatTimeoutMultiple, interval),
+			ErrTypeInvalidActivityOptions, nil)
+	case timeout > 0 && options.HeartbeatTimeout >= timeout:
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("heartbeat timeout %s is not shorter than the %s activity timeout",
+				options.HeartbeatTimeout, timeout),
+			ErrTypeInvalidActivityOptions, nil)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestValidateHeartbeatOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  workflow.ActivityOptions
+		interval time.Duration
+		wantErr  string
+	}{
+		{name: "derived timeout", options: withHeartbeatInterval(workflow.ActivityOptions{StartToCloseTimeout: time.Hour}, 20*time.Second), interval: 20 * time.Second},
+		{name: "no start-to-close timeout", options: workflow.ActivityOptions{HeartbeatTimeout: time.Minute}, interval: 30 * time.Second},
+		{name: "zero interval", options: workflow.ActivityOptions{HeartbeatTimeout: time.Minute}, wantErr: "heartbeat interval must be positive"},
+		{name: "timeout too tight", options: workflow.ActivityOptions{HeartbeatTimeout: time.Minute}, interval: 45 * time.Second, wantErr: "is less than 2x the 45s heartbeat interval"},
+		{
+			name:     "timeout outlasts the attempt",
+			options:  withHeartbeatInterval(workflow.ActivityOptions{StartToCloseTimeout: time.Minute}, 20*time.Second),
+			interval: 20 * time.Second,
+			wantErr:  "heartbeat timeout 1m0s is not shorter than the 1m0s activity timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHeartbeatOptions(tt.options, tt.interval)
+			if tt.wantErr != "" {
+				requireApplicationError(t, err, ErrTypeInvalidActivityOptions)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestRollupWorkflowHeartbeatTimeout checks the activity runs with the
+// timeout derived from its heartbeat interval
+func TestRollupWorkflowHeartbeatTimeout(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities)
+	var heartbeatTimeout time.Duration
+	env.OnActivity(activities.ComputeRollup, mock.Anything, mock.Anything).Return(func(ctx context.Context, input RollupInput) (RollupRecord, error) {
+		heartbeatTimeout = activity.GetInfo(ctx).HeartbeatTimeout
+		return RollupRecord{Date: input.Date}, nil
+	})
+
+	env.ExecuteWorkflow(RollupWorkflow, RollupInput{Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow"})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	assert.Equal(t, heartbeatTimeoutMultiple*rollupHeartbeatInterval, heartbeatTimeout)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"go.temporal.io/sdk/workflow"
+)
+
+// historyLengthWarning is the history length, in events, past which long
+// workflows log a warning, from HISTORY_LENGTH_WARNING; zero disables it.
+// Temporal fails a workflow at 51,200 events, so this should leave room to
+// continue as new.
+var historyLengthWarning = 10000
+
+// historyLengthGauge reports each long workflow's current history length,
+// tagged with workflow_type by the SDK
+const historyLengthGauge = "go_worker_workflow_history_length"
+
+// historyWatch reports a workflow's history length as it grows. Metrics
+// and logs aren't recorded while replaying, so it is safe to call anywhere
+// in workflow code.
+type historyWatch struct {
+	ctx    workflow.Context
+	warned bool
+}
+
+func watchHistoryLength(ctx workflow.Context) *historyWatch {
+	return &historyWatch{ctx: ctx}
+}
+
+// check records the current history length and warns, once per run, when
+// it passes historyLengthWarning. Call it where a workflow loops.
+func (h *historyWatch) check() {
+	length := workflow.GetInfo(h.ctx).GetCurrentHistoryLength()
+	workflow.GetMetricsHandler(h.ctx).Gauge(historyLengthGauge).Update(float64(length))
+	if h.warned || historyLengthWarning <= 0 || length < historyLengthWarning {
+		return
+	}
+	h.warned = true
+	workflow.GetLogger(h.ctx).Warn("⚠️ Workflow history is getting long; continue as new before it reaches the limit",
+		"history_length", length, "threshold", historyLengthWarning)
+}
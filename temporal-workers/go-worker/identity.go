@@ -0,0 +1,26 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// lookupHostname is os.Hostname, swapped out in tests to make it fail
+var lookupHostname = os.Hostname
+
+// workerIdentity returns the identity the worker reports to the server:
+// the SDK's usual "<pid>@<hostname>" plus a random nonce. PID and hostname
+// alone can repeat, e.g. while a StatefulSet pod is replaced by one with
+// the same name, so the nonce keeps two processes apart even when
+// everything else about them is identical.
+func workerIdentity() string {
+	hostname, err := lookupHostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	var nonce [4]byte
+	_, _ = rand.Read(nonce[:])
+	return fmt.Sprintf("%d@%s@%s", os.Getpid(), hostname, hex.EncodeToString(nonce[:]))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerIdentity(t *testing.T) {
+	tests := []struct {
+		name         string
+		hostname     string
+		hostnameErr  error
+		wantHostname string
+	}{
+		{name: "hostname", hostname: "worker-0", wantHostname: "worker-0"},
+		{name: "hostname unavailable", hostnameErr: errors.New("uname failed"), wantHostname: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := lookupHostname
+			t.Cleanup(func() { lookupHostname = prev })
+			lookupHostname = func() (string, error) { return tt.hostname, tt.hostnameErr }
+
+			pattern := regexp.MustCompile("^" + strconv.Itoa(os.Getpid()) + "@" + regexp.QuoteMeta(tt.wantHostname) + "@[0-9a-f]{8}$")
+			first, second := workerIdentity(), workerIdentity()
+			assert.Regexp(t, pattern, first)
+			assert.Regexp(t, pattern, second)
+			assert.NotEqual(t, first, second, "each process gets its own nonce")
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeWatermarkConflict is returned when the stored watermark moved
+// while a pass was running, i.e. another run advanced it first
+const ErrTypeWatermarkConflict = "WatermarkConflict"
+
+const defaultWatermarkKey = "datasets"
+
+// Watermark marks the last dataset an incremental pass covered. Datasets
+// are ordered by CreatedAt, then ID, so datasets created in the same
+// instant are neither skipped nor processed twice. The zero Watermark
+// comes before every dataset.
+type Watermark struct {
+	Timestamp time.Time `json:"timestamp"`
+	ID        string    `json:"id"`
+}
+
+// before reports whether the record comes after the watermark
+func (w Watermark) before(r DatasetRecord) bool {
+	if !r.CreatedAt.Equal(w.Timestamp) {
+		return r.CreatedAt.After(w.Timestamp)
+	}
+	return r.ID > w.ID
+}
+
+func (w Watermark) equal(o Watermark) bool {
+	return w.Timestamp.Equal(o.Timestamp) && w.ID == o.ID
+}
+
+// WatermarkStore persists watermarks by key. CompareAndSwap must be atomic:
+// it stores next only if the current watermark still equals prev.
+type WatermarkStore interface {
+	Load(ctx context.Context, key string) (Watermark, error)
+	CompareAndSwap(ctx context.Context, key string, prev, next Watermark) (bool, error)
+}
+
+// watermarkStore is the store used by the incremental activities
+var watermarkStore WatermarkStore = newMemoryWatermarkStore()
+
+// memoryWatermarkStore is an in-process WatermarkStore for local runs
+type memoryWatermarkStore struct {
+	mu         sync.Mutex
+	watermarks map[string]Watermark
+}
+
+func newMemoryWatermarkStore() *memoryWatermarkStore {
+	return &memoryWatermarkStore{watermarks: make(map[string]Watermark)}
+}
+
+func (s *memoryWatermarkStore) Load(ctx context.Context, key string) (Watermark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.watermarks[key], nil
+}
+
+func (s *memoryWatermarkStore) CompareAndSwap(ctx context.Context, key string, prev, next Watermark) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.watermarks[key].equal(prev) {
+		return false, nil
+	}
+	s.watermarks[key] = next
+	return true, nil
+}
+
+// ReadWatermark returns the stored watermark for a key
+func (a *Activities) ReadWatermark(ctx context.Context, key string) (Watermark, error) {
+	return watermarkStore.Load(ctx, key)
+}
+
+// ProcessIncrementalInput represents input for one incremental pass
+type ProcessIncrementalInput struct {
+	After       Watermark `json:"after"`
+	FailureRate float64   `json:"failure_rate"`
+	PageSize    int       `json:"page_size"`
+}
+
+// ProcessIncrementalResult represents the outcome of an incremental pass.
+// Last is the watermark to advance to; it equals the input's After when
+// nothing new was found.
+type ProcessIncrementalResult struct {
+	Scanned   int       `json:"scanned"`
+	Processed int       `json:"processed"`
+	Last      Watermark `json:"last"`
+}
+
+// incrementalProgress is heartbeated so a retried pass resumes at the page
+// it reached
+type incrementalProgress struct {
+	PageToken string                   `json:"page_token"`
+	Result    ProcessIncrementalResult `json:"result"`
+}
+
+// ProcessIncrementalRecords processes the datasets after the watermark,
+// page by page, keeping only counts and the latest watermark seen. It
+// doesn't touch the stored watermark; a failure part way through leaves it
+// where it was, so the next pass starts over from there.
+func (a *Activities) ProcessIncrementalRecords(ctx context.Context, input ProcessIncrementalInput) (ProcessIncrementalResult, error) {
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+
+	progress := incrementalProgress{Result: ProcessIncrementalResult{Last: input.After}}
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &progress); err != nil {
+			activityLog.Errorf("⚠️ Ignoring unreadable incremental progress: %v", err)
+			progress = incrementalProgress{Result: ProcessIncrementalResult{Last: input.After}}
+		}
+	}
+	activityLog.Infof("🔄 Processing datasets after %s/%s", input.After.Timestamp.Format(time.RFC3339Nano), input.After.ID)
+
+	for {
+		records, next, err := datasetStore.ListDatasets(ctx, progress.PageToken, pageSize)
+		if err != nil {
+			return progress.Result, err
+		}
+
+		for _, record := range records {
+			progress.Result.Scanned++
+			if !input.After.before(record) {
+				continue
+			}
+			if err := processRecord(input.FailureRate); err != nil {
+				return progress.Result, fmt.Errorf("processing dataset %s: %w", record.ID, err)
+			}
+			progress.Result.Processed++
+			if progress.Result.Last.before(record) {
+				progress.Result.Last = Watermark{Timestamp: record.CreatedAt, ID: record.ID}
+			}
+		}
+
+		progress.PageToken = next
+		activity.RecordHeartbeat(ctx, progress)
+		if ctx.Err() != nil {
+			return progress.Result, ctx.Err()
+		}
+		if next == "" {
+			break
+		}
+	}
+
+	activityLog.Infof("✅ Incremental pass completed: %d scanned, %d processed", progress.Result.Scanned, progress.Result.Processed)
+	return progress.Result, nil
+}
+
+// AdvanceWatermarkInput represents input for advancing a watermark
+type AdvanceWatermarkInput struct {
+	Key  string    `json:"key"`
+	From Watermark `json:"from"`
+	To   Watermark `json:"to"`
+}
+
+// AdvanceWatermark moves a watermark from From to To in one
+// compare-and-swap. A retry after a lost response finds To already stored
+// and succeeds; any other stored value means another run got there first.
+func (a *Activities) AdvanceWatermark(ctx context.Context, input AdvanceWatermarkInput) error {
+	swapped, err := watermarkStore.CompareAndSwap(ctx, input.Key, input.From, input.To)
+	if err != nil || swapped {
+		return err
+	}
+	current, err := watermarkStore.Load(ctx, input.Key)
+	if err != nil {
+		return err
+	}
+	if current.equal(input.To) {
+		return nil
+	}
+	return temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("watermark %s moved to %s/%s during the pass", input.Key, current.Timestamp.Format(time.RFC3339Nano), current.ID),
+		ErrTypeWatermarkConflict, nil)
+}
+
+// IncrementalInput represents input for the incremental workflow. With an
+// Interval the workflow runs a pass every Interval, continuing as new
+// between passes. Run is carried across restarts and should be left empty
+// by callers.
+type IncrementalInput struct {
+	Key         string        `json:"key"`
+	Interval    time.Duration `json:"interval"`
+	FailureRate float64       `json:"failure_rate"`
+	PageSize    int           `json:"page_size"`
+	Run         int           `json:"run,omitempty"`
+}
+
+// IncrementalResult represents the result of one incremental pass
+type IncrementalResult struct {
+	Key       string    `json:"key"`
+	Status    string    `json:"status"` // advanced, unchanged or rolled_back
+	Previous  Watermark `json:"previous"`
+	Watermark Watermark `json:"watermark"`
+	Processed int       `json:"processed"`
+	Run       int       `json:"run"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// IncrementalProcessingWorkflow processes the datasets created since the
+// stored watermark and then advances it. The watermark is only written
+// after the whole pass succeeded, so a failed pass rolls back to the
+// stored watermark and the next pass picks up the same datasets again.
+func IncrementalProcessingWorkflow(ctx workflow.Context, input IncrementalInput) (IncrementalResult, error) {
+	if input.Key == "" {
+		input.Key = defaultWatermarkKey
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🔄 Starting incremental processing workflow", "key", input.Key, "run", input.Run+1)
+	logWorkflowInput(ctx, input)
+
+	activityOptions, err := newHeartbeatActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Hour}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}, incrementalHeartbeatInterval)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return IncrementalResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	result, err := incrementalPass(ctx, input)
+	if err != nil {
+		logger.Error("❌ Incremental pass failed; watermark unchanged", "key", input.Key, "error", err)
+		if input.Interval <= 0 {
+			return result, err
+		}
+	}
+	if input.Interval <= 0 {
+		logger.Info("✅ Incremental processing workflow completed", "status", result.Status, "processed", result.Processed)
+		return result, nil
+	}
+
+	if err := workflow.Sleep(ctx, input.Interval); err != nil {
+		return result, err
+	}
+	input.Run++
+	return result, workflow.NewContinueAsNewError(ctx, IncrementalProcessingWorkflow, input)
+}
+
+// incrementalPass reads the watermark, processes what follows it and
+// advances it
+func incrementalPass(ctx workflow.Context, input IncrementalInput) (IncrementalResult, error) {
+	result := IncrementalResult{Key: input.Key, Run: input.Run + 1}
+
+	if err := workflow.ExecuteActivity(ctx, activities.ReadWatermark, input.Key).Get(ctx, &result.Previous); err != nil {
+		result.Status = "rolled_back"
+		result.Message = "Reading watermark failed: " + err.Error()
+		return result, err
+	}
+	result.Watermark = result.Previous
+
+	var processed ProcessIncrementalResult
+	err := workflow.ExecuteActivity(ctx, activities.ProcessIncrementalRecords, ProcessIncrementalInput{
+		After:       result.Previous,
+		FailureRate: input.FailureRate,
+		PageSize:    input.PageSize,
+	}).Get(ctx, &processed)
+	if err != nil {
+		result.Status = "rolled_back"
+		result.Message = "Processing failed: " + err.Error()
+		return result, err
+	}
+	result.Processed = processed.Processed
+	if processed.Processed == 0 {
+		result.Status = "unchanged"
+		return result, nil
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.AdvanceWatermark, AdvanceWatermarkInput{
+		Key:  input.Key,
+		From: result.Previous,
+		To:   processed.Last,
+	}).Get(ctx, nil)
+	if err != nil {
+		result.Status = "rolled_back"
+		result.Message = "Advancing watermark failed: " + err.Error()
+		return result, err
+	}
+
+	result.Watermark = processed.Last
+	result.Status = "advanced"
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// racingWatermarkStore moves the watermark to moved just before every
+// compare-and-swap, as if another run advanced it first
+type racingWatermarkStore struct {
+	*memoryWatermarkStore
+	moved Watermark
+}
+
+func (s racingWatermarkStore) CompareAndSwap(ctx context.Context, key string, prev, next Watermark) (bool, error) {
+	s.mu.Lock()
+	s.watermarks[key] = s.moved
+	s.mu.Unlock()
+	return s.memoryWatermarkStore.CompareAndSwap(ctx, key, prev, next)
+}
+
+func TestIncrementalProcessingWorkflow(t *testing.T) {
+	t1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	records := []DatasetRecord{{ID: "ds-a", CreatedAt: t1}, {ID: "ds-b", CreatedAt: t1}, {ID: "ds-c", CreatedAt: t2}}
+	latest := Watermark{Timestamp: t2, ID: "ds-c"}
+	moved := Watermark{Timestamp: t2.Add(time.Hour), ID: "ds-z"}
+
+	tests := []struct {
+		name          string
+		stored        Watermark
+		failureRate   float64
+		racing        bool
+		wantStatus    string
+		wantProcessed int
+		wantStored    Watermark
+		wantErr       string
+	}{
+		{name: "first pass", wantStatus: "advanced", wantProcessed: 3, wantStored: latest},
+		{name: "same instant as the watermark", stored: Watermark{Timestamp: t1, ID: "ds-a"}, wantStatus: "advanced", wantProcessed: 2, wantStored: latest},
+		{name: "nothing new", stored: latest, wantStatus: "unchanged", wantStored: latest},
+		{name: "processing fails", failureRate: 1, wantStored: Watermark{}, wantErr: "processing dataset ds-a"},
+		{name: "watermark moved", racing: true, wantStored: moved, wantErr: "watermark datasets moved to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevDatasets, prevWatermarks := datasetStore, watermarkStore
+			t.Cleanup(func() { datasetStore, watermarkStore = prevDatasets, prevWatermarks })
+			datasetStore = newMemoryDatasetStore(records...)
+			watermarks := newMemoryWatermarkStore()
+			watermarks.watermarks[defaultWatermarkKey] = tt.stored
+			watermarkStore = watermarks
+			if tt.racing {
+				watermarkStore = racingWatermarkStore{memoryWatermarkStore: watermarks, moved: moved}
+			}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+
+			env.ExecuteWorkflow(IncrementalProcessingWorkflow, IncrementalInput{FailureRate: tt.failureRate, PageSize: 2})
+			require.True(t, env.IsWorkflowCompleted())
+			stored, err := watermarks.Load(context.Background(), defaultWatermarkKey)
+			require.NoError(t, err)
+			assert.True(t, tt.wantStored.equal(stored), "stored watermark %+v", stored)
+			if tt.wantErr != "" {
+				require.Error(t, env.GetWorkflowError())
+				assert.Contains(t, env.GetWorkflowError().Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result IncrementalResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantProcessed, result.Processed)
+			assert.True(t, tt.stored.equal(result.Previous))
+			assert.True(t, tt.wantStored.equal(result.Watermark))
+		})
+	}
+}
+
+func TestAdvanceWatermark(t *testing.T) {
+	from := Watermark{Timestamp: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), ID: "ds-a"}
+	to := Watermark{Timestamp: from.Timestamp.Add(time.Hour), ID: "ds-b"}
+	tests := []struct {
+		name        string
+		stored      Watermark
+		wantErrType string
+	}{
+		{name: "advanced", stored: from},
+		{name: "retried after advancing", stored: to},
+		{name: "advanced by another run", stored: Watermark{Timestamp: to.Timestamp, ID: "ds-c"}, wantErrType: ErrTypeWatermarkConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := watermarkStore
+			t.Cleanup(func() { watermarkStore = prev })
+			watermarks := newMemoryWatermarkStore()
+			watermarks.watermarks["orders"] = tt.stored
+			watermarkStore = watermarks
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			_, err := env.ExecuteActivity(activities.AdvanceWatermark, AdvanceWatermarkInput{Key: "orders", From: from, To: to})
+			if tt.wantErrType != "" {
+				requireApplicationError(t, err, tt.wantErrType)
+				assert.Equal(t, tt.stored, watermarks.watermarks["orders"])
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, to, watermarks.watermarks["orders"])
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// inputLogMaxBytes is the byte budget for workflow inputs logged at start.
+// It comes from INPUT_LOG_MAX_BYTES.
+var inputLogMaxBytes = 2048
+
+// logWorkflowInput logs the workflow input as JSON through the workflow
+// logger, eliding large fields so big Parameters maps don't flood logs
+func logWorkflowInput(ctx workflow.Context, input interface{}) {
+	workflow.GetLogger(ctx).Info("📥 Workflow input", "input", truncatedJSON(input, inputLogMaxBytes))
+}
+
+// truncatedJSON encodes v as JSON of at most roughly maxBytes. The largest
+// leaf values are replaced, one at a time, with a placeholder giving their
+// size until the whole fits, so small fields stay intact next to huge ones.
+// Objects and arrays are never elided themselves, only what they hold. If
+// the result is still over budget it is cut off.
+func truncatedJSON(v interface{}, maxBytes int) string {
+	raw, err := marshalUnescaped(v)
+	if err != nil {
+		return fmt.Sprintf("<unencodable input: %v>", err)
+	}
+	if maxBytes <= 0 || len(raw) <= maxBytes {
+		return string(raw)
+	}
+
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return cutJSON(raw, maxBytes)
+	}
+
+	var leaves []jsonLeaf
+	collectLeaves(generic, "", nil, &leaves)
+	sort.SliceStable(leaves, func(i, j int) bool {
+		if leaves[i].size != leaves[j].size {
+			return leaves[i].size > leaves[j].size
+		}
+		return leaves[i].path < leaves[j].path
+	})
+	size := len(raw)
+	for _, leaf := range leaves {
+		if size <= maxBytes {
+			break
+		}
+		placeholder := fmt.Sprintf("<elided %d bytes>", leaf.size)
+		if saved := leaf.size - len(placeholder) - 2; saved > 0 {
+			leaf.set(placeholder)
+			size -= saved
+		}
+	}
+
+	elided, err := marshalUnescaped(generic)
+	if err != nil {
+		return cutJSON(raw, maxBytes)
+	}
+	if len(elided) > maxBytes {
+		return cutJSON(elided, maxBytes)
+	}
+	return string(elided)
+}
+
+// jsonLeaf is a scalar in decoded JSON, with its encoded size and a way to
+// replace it in its parent
+type jsonLeaf struct {
+	path string
+	size int
+	set  func(interface{})
+}
+
+// collectLeaves walks decoded JSON, recording every scalar held by an
+// object or array. A scalar at the root has no parent, so it isn't recorded.
+func collectLeaves(v interface{}, path string, set func(interface{}), leaves *[]jsonLeaf) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			k := k
+			collectLeaves(child, path+"."+k, func(x interface{}) { t[k] = x }, leaves)
+		}
+	case []interface{}:
+		for i, child := range t {
+			i := i
+			collectLeaves(child, fmt.Sprintf("%s[%d]", path, i), func(x interface{}) { t[i] = x }, leaves)
+		}
+	default:
+		if set == nil {
+			return
+		}
+		encoded, err := marshalUnescaped(v)
+		if err != nil {
+			return
+		}
+		*leaves = append(*leaves, jsonLeaf{path: path, size: len(encoded), set: set})
+	}
+}
+
+// cutJSON truncates raw to maxBytes on a UTF-8 boundary
+func cutJSON(raw []byte, maxBytes int) string {
+	cut := raw[:maxBytes]
+	for len(cut) > 0 && !utf8.Valid(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return fmt.Sprintf("%s…<truncated %d bytes>", cut, len(raw)-len(cut))
+}
+
+// marshalUnescaped encodes without HTML escaping so placeholders stay readable
+func marshalUnescaped(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncatedJSON(t *testing.T) {
+	manyLarge := map[string]interface{}{"small": "keep-me"}
+	for i := 0; i < 10; i++ {
+		manyLarge[fmt.Sprintf("field%d", i)] = strings.Repeat("x", 300)
+	}
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		maxBytes int
+		// kept are fields that must survive, by top-level key
+		kept   map[string]interface{}
+		elided []string
+	}{
+		{
+			name:     "under budget",
+			input:    map[string]interface{}{"a": "b", "n": 1},
+			maxBytes: 2048,
+			kept:     map[string]interface{}{"a": "b", "n": json.Number("1")},
+		},
+		{
+			name:     "many large fields keep the small one",
+			input:    manyLarge,
+			maxBytes: 2048,
+			kept:     map[string]interface{}{"small": "keep-me"},
+		},
+		{
+			name: "largest field goes first",
+			input: map[string]interface{}{
+				"huge":   strings.Repeat("h", 5000),
+				"medium": strings.Repeat("m", 400),
+				"id":     "dataset-1",
+			},
+			maxBytes: 2048,
+			kept:     map[string]interface{}{"medium": strings.Repeat("m", 400), "id": "dataset-1"},
+			elided:   []string{"huge"},
+		},
+		{
+			name: "nested containers stay",
+			input: ComplexProcessingInput{
+				DatasetID:  "dataset-1",
+				Parameters: Parameters{"blob": strings.Repeat("b", 4000), "mode": "fast"},
+			},
+			maxBytes: 1024,
+			kept: map[string]interface{}{
+				"dataset_id": "dataset-1",
+				"parameters": map[string]interface{}{"blob": "<elided 4002 bytes>", "mode": "fast"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := truncatedJSON(tt.input, tt.maxBytes)
+			assert.LessOrEqual(t, len(out), tt.maxBytes)
+
+			var decoded map[string]interface{}
+			dec := json.NewDecoder(strings.NewReader(out))
+			dec.UseNumber()
+			require.NoError(t, dec.Decode(&decoded), out)
+			for k, want := range tt.kept {
+				assert.Equal(t, want, decoded[k], k)
+			}
+			for _, k := range tt.elided {
+				assert.True(t, strings.HasPrefix(decoded[k].(string), "<elided "), k)
+			}
+		})
+	}
+}
+
+func TestTruncatedJSONCutsWhatCantBeElided(t *testing.T) {
+	out := truncatedJSON(strings.Repeat("é", 100), 51)
+	assert.True(t, strings.HasSuffix(out, "…<truncated 151 bytes>"), out)
+
+	items := make([]int, 1000)
+	out = truncatedJSON(items, 100)
+	assert.True(t, strings.HasPrefix(out, "[0,0,0"), out)
+	assert.Contains(t, out, "<truncated ")
+}
@@ -0,0 +1,254 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Encode writes v, as decoded by encoding/json, in the Avro binary format.
+// Record fields missing from v take their schema default.
+func Encode(s *Schema, v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, s, v, s.Name); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ConfluentFrame prefixes an encoded value with the Confluent wire format
+// header: a zero magic byte and the big-endian schema ID
+func ConfluentFrame(schemaID int, body []byte) []byte {
+	framed := make([]byte, 5, 5+len(body))
+	binary.BigEndian.PutUint32(framed[1:], uint32(schemaID))
+	return append(framed, body...)
+}
+
+func encode(buf *bytes.Buffer, s *Schema, v interface{}, path string) error {
+	switch s.Type {
+	case "null":
+		if v != nil {
+			return fmt.Errorf("avro: %s: want null, got %T", path, v)
+		}
+	case "boolean":
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("avro: %s: want boolean, got %T", path, v)
+		}
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case "int", "long":
+		n, err := integer(v, s.Type == "int")
+		if err != nil {
+			return fmt.Errorf("avro: %s: %w", path, err)
+		}
+		writeLong(buf, n)
+	case "float", "double":
+		f, ok := number(v)
+		if !ok {
+			return fmt.Errorf("avro: %s: want %s, got %T", path, s.Type, v)
+		}
+		if s.Type == "float" {
+			_ = binary.Write(buf, binary.LittleEndian, math.Float32bits(float32(f)))
+		} else {
+			_ = binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("avro: %s: want string, got %T", path, v)
+		}
+		writeLong(buf, int64(len(str)))
+		buf.WriteString(str)
+	case "bytes", "fixed":
+		b, err := byteValue(v)
+		if err != nil {
+			return fmt.Errorf("avro: %s: %w", path, err)
+		}
+		if s.Type == "fixed" {
+			if len(b) != s.Size {
+				return fmt.Errorf("avro: %s: want %d bytes, got %d", path, s.Size, len(b))
+			}
+		} else {
+			writeLong(buf, int64(len(b)))
+		}
+		buf.Write(b)
+	case "enum":
+		symbol, _ := v.(string)
+		for i, candidate := range s.Symbols {
+			if candidate == symbol {
+				writeLong(buf, int64(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("avro: %s: %v is not a symbol of %s", path, v, s.Name)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("avro: %s: want array, got %T", path, v)
+		}
+		if len(items) > 0 {
+			writeLong(buf, int64(len(items)))
+			for i, item := range items {
+				if err := encode(buf, s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		writeLong(buf, 0)
+	case "map":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("avro: %s: want map, got %T", path, v)
+		}
+		if len(m) > 0 {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			writeLong(buf, int64(len(keys)))
+			for _, k := range keys {
+				writeLong(buf, int64(len(k)))
+				buf.WriteString(k)
+				if err := encode(buf, s.Values, m[k], path+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+		writeLong(buf, 0)
+	case "union":
+		for i, branch := range s.Branches {
+			if !matches(branch, v) {
+				continue
+			}
+			writeLong(buf, int64(i))
+			return encode(buf, branch, v, path)
+		}
+		return fmt.Errorf("avro: %s: %T matches no branch of the union", path, v)
+	case "record":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("avro: %s: want record %s, got %T", path, s.Name, v)
+		}
+		for _, f := range s.Fields {
+			value, present := m[f.Name]
+			if !present {
+				if !f.HasDefault {
+					return fmt.Errorf("avro: %s.%s is missing and has no default", path, f.Name)
+				}
+				value = f.Default
+			}
+			if err := encode(buf, f.Type, value, path+"."+f.Name); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("avro: %s: unsupported type %q", path, s.Type)
+	}
+	return nil
+}
+
+// matches picks union branches by the value's JSON shape
+func matches(s *Schema, v interface{}) bool {
+	switch s.Type {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "int", "long":
+		_, err := integer(v, s.Type == "int")
+		return err == nil
+	case "float", "double":
+		_, ok := number(v)
+		return ok
+	case "string", "bytes", "fixed":
+		_, ok := v.(string)
+		if !ok && s.Type != "string" {
+			_, ok = v.([]byte)
+		}
+		return ok
+	case "enum":
+		symbol, ok := v.(string)
+		if ok {
+			for _, candidate := range s.Symbols {
+				if candidate == symbol {
+					return true
+				}
+			}
+		}
+		return false
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "map", "record":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+func integer(v interface{}, is32 bool) (int64, error) {
+	var n int64
+	switch v := v.(type) {
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<63 {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		n = int64(v)
+	case json.Number:
+		var err error
+		if n, err = v.Int64(); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("want integer, got %T", v)
+	}
+	if is32 && (n < math.MinInt32 || n > math.MaxInt32) {
+		return 0, fmt.Errorf("%d overflows int", n)
+	}
+	return n, nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// byteValue accepts []byte or a string, taken as its raw bytes
+func byteValue(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("want bytes, got %T", v)
+}
+
+// writeLong writes a zig-zag varint
+func writeLong(buf *bytes.Buffer, n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutVarint(tmp[:], n)])
+}
@@ -0,0 +1,97 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "record", "name": "Order", "namespace": "shop",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "count", "type": "long"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "DONE"]}},
+		{"name": "note", "type": ["null", "string"], "default": null}
+	]
+}`
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    []byte
+		wantErr string
+	}{
+		{
+			name:  "defaults filled",
+			value: map[string]interface{}{"name": "ab", "count": float64(3), "tags": []interface{}{"x"}, "status": "DONE"},
+			want:  []byte{4, 'a', 'b', 6, 2, 2, 'x', 0, 2, 0},
+		},
+		{
+			name:  "union branch picked",
+			value: map[string]interface{}{"name": "", "count": -1, "tags": []interface{}{}, "status": "NEW", "note": "hi"},
+			want:  []byte{0, 1, 0, 0, 2, 4, 'h', 'i'},
+		},
+		{name: "missing field", value: map[string]interface{}{"name": "ab"}, wantErr: "avro: shop.Order.count is missing and has no default"},
+		{name: "not an integer", value: map[string]interface{}{"name": "ab", "count": 1.5}, wantErr: "avro: shop.Order.count: 1.5 is not an integer"},
+		{
+			name:    "unknown symbol",
+			value:   map[string]interface{}{"name": "ab", "count": 1, "tags": []interface{}{}, "status": "LOST"},
+			wantErr: "avro: shop.Order.status: LOST is not a symbol of shop.Status",
+		},
+		{
+			name:    "no union branch",
+			value:   map[string]interface{}{"name": "ab", "count": 1, "tags": []interface{}{}, "status": "NEW", "note": true},
+			wantErr: "avro: shop.Order.note: bool matches no branch of the union",
+		},
+		{name: "not a record", value: []interface{}{}, wantErr: "avro: shop.Order: want record shop.Order, got []interface {}"},
+	}
+	schema, err := Parse([]byte(orderSchema))
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(schema, tt.value)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "named reference", schema: `{"type": "record", "name": "Node", "fields": [{"name": "next", "type": ["null", "Node"]}]}`},
+		{name: "logical type", schema: `{"type": "long", "logicalType": "timestamp-millis"}`},
+		{name: "invalid JSON", schema: `{"type":`, wantErr: "avro: invalid schema JSON"},
+		{name: "unknown type", schema: `{"type": "array", "items": "Missing"}`, wantErr: `avro: unknown type "Missing"`},
+		{name: "record without a name", schema: `{"type": "record", "fields": []}`, wantErr: "avro: record without a name"},
+		{name: "nested union", schema: `["null", ["int"]]`, wantErr: "avro: unions can't contain unions"},
+		{name: "fixed without size", schema: `{"type": "fixed", "name": "Hash"}`, wantErr: "avro: fixed Hash needs a size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConfluentFrame(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 1, 2, 'x'}, ConfluentFrame(258, []byte{'x'}))
+}
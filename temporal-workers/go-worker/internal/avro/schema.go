@@ -0,0 +1,160 @@
+// Package avro encodes JSON-shaped values in the Avro binary format without
+// external dependencies. It supports primitives, records, enums, arrays,
+// maps, unions, fixed and named type references. Logical types are encoded
+// as their underlying type.
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema is a parsed Avro schema
+type Schema struct {
+	Type     string
+	Name     string // full name of records, enums and fixed
+	Fields   []Field
+	Symbols  []string
+	Items    *Schema
+	Values   *Schema
+	Branches []*Schema
+	Size     int
+}
+
+// Field is one field of a record
+type Field struct {
+	Name       string
+	Type       *Schema
+	Default    interface{}
+	HasDefault bool
+}
+
+var primitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// Parse parses a schema document
+func Parse(document []byte) (*Schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal(document, &raw); err != nil {
+		return nil, fmt.Errorf("avro: invalid schema JSON: %w", err)
+	}
+	p := &parser{named: map[string]*Schema{}}
+	return p.parse(raw, "")
+}
+
+type parser struct {
+	named map[string]*Schema
+}
+
+func (p *parser) parse(raw interface{}, namespace string) (*Schema, error) {
+	switch v := raw.(type) {
+	case string:
+		if primitives[v] {
+			return &Schema{Type: v}, nil
+		}
+		if s, ok := p.named[fullName(v, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[v]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("avro: unknown type %q", v)
+	case []interface{}:
+		union := &Schema{Type: "union"}
+		for _, branch := range v {
+			s, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if s.Type == "union" {
+				return nil, fmt.Errorf("avro: unions can't contain unions")
+			}
+			union.Branches = append(union.Branches, s)
+		}
+		return union, nil
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	default:
+		return nil, fmt.Errorf("avro: invalid schema %v", raw)
+	}
+}
+
+func (p *parser) parseComplex(v map[string]interface{}, namespace string) (*Schema, error) {
+	typ, _ := v["type"].(string)
+	switch typ {
+	case "record", "error", "enum", "fixed":
+	case "array":
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: typ, Items: items}, nil
+	case "map":
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: typ, Values: values}, nil
+	default:
+		// {"type": "string", "logicalType": ...} and the like
+		return p.parse(v["type"], namespace)
+	}
+
+	name, _ := v["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("avro: %s without a name", typ)
+	}
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+	s := &Schema{Type: typ, Name: fullName(name, namespace)}
+	if i := strings.LastIndex(s.Name, "."); i >= 0 {
+		namespace = s.Name[:i]
+	}
+	p.named[s.Name] = s
+
+	switch typ {
+	case "enum":
+		symbols, _ := v["symbols"].([]interface{})
+		for _, symbol := range symbols {
+			name, ok := symbol.(string)
+			if !ok {
+				return nil, fmt.Errorf("avro: enum %s has a non-string symbol", s.Name)
+			}
+			s.Symbols = append(s.Symbols, name)
+		}
+	case "fixed":
+		size, ok := v["size"].(float64)
+		if !ok || size < 0 {
+			return nil, fmt.Errorf("avro: fixed %s needs a size", s.Name)
+		}
+		s.Size = int(size)
+	default:
+		s.Type = "record"
+		fields, _ := v["fields"].([]interface{})
+		for _, raw := range fields {
+			f, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("avro: record %s has an invalid field", s.Name)
+			}
+			name, _ := f["name"].(string)
+			fieldType, err := p.parse(f["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("avro: field %s.%s: %w", s.Name, name, err)
+			}
+			def, hasDefault := f["default"]
+			s.Fields = append(s.Fields, Field{Name: name, Type: fieldType, Default: def, HasDefault: hasDefault})
+		}
+	}
+	return s, nil
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
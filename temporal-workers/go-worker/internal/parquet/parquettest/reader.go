@@ -0,0 +1,218 @@
+// Package parquettest reads back the Parquet files internal/parquet
+// writes, so tests can check what was exported. It reads flat files of
+// optional columns with PLAIN values and no compression, and nothing else.
+package parquettest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"temporal-go-worker/internal/parquet"
+)
+
+const magic = "PAR1"
+
+// File is a decoded Parquet file
+type File struct {
+	Columns []parquet.Column
+	NumRows int64
+	// RowGroups holds the row count of each row group
+	RowGroups []int64
+	// Rows holds each row's values: nil, int64, float64, bool or string
+	Rows [][]interface{}
+}
+
+// Read decodes a whole Parquet file
+func Read(data []byte) (File, error) {
+	if len(data) < 2*len(magic)+4 || string(data[:len(magic)]) != magic || string(data[len(data)-len(magic):]) != magic {
+		return File{}, errors.New("parquettest: not a Parquet file")
+	}
+	footerEnd := len(data) - len(magic) - 4
+	footerLength := int(binary.LittleEndian.Uint32(data[footerEnd:]))
+	if footerLength > footerEnd-len(magic) {
+		return File{}, errors.New("parquettest: footer length out of range")
+	}
+	meta, err := decodeStruct(data[footerEnd-footerLength : footerEnd])
+	if err != nil {
+		return File{}, fmt.Errorf("parquettest: footer: %w", err)
+	}
+
+	var file File
+	schema, _ := meta[2].([]interface{})
+	if len(schema) == 0 {
+		return File{}, errors.New("parquettest: no schema")
+	}
+	for _, element := range schema[1:] {
+		col, err := column(element.(thriftStruct))
+		if err != nil {
+			return File{}, err
+		}
+		file.Columns = append(file.Columns, col)
+	}
+	file.NumRows, _ = meta[3].(int64)
+
+	groups, _ := meta[4].([]interface{})
+	for _, g := range groups {
+		group := g.(thriftStruct)
+		rows, _ := group[3].(int64)
+		file.RowGroups = append(file.RowGroups, rows)
+		chunks, _ := group[1].([]interface{})
+		if len(chunks) != len(file.Columns) {
+			return File{}, fmt.Errorf("parquettest: row group has %d column chunks, schema has %d columns", len(chunks), len(file.Columns))
+		}
+		groupRows := make([][]interface{}, rows)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(file.Columns))
+		}
+		for c, chunk := range chunks {
+			chunkMeta, _ := chunk.(thriftStruct)[3].(thriftStruct)
+			offset, _ := chunkMeta[9].(int64)
+			values, err := readPage(data, offset, file.Columns[c])
+			if err != nil {
+				return File{}, fmt.Errorf("parquettest: column %q: %w", file.Columns[c].Name, err)
+			}
+			if int64(len(values)) != rows {
+				return File{}, fmt.Errorf("parquettest: column %q has %d values in a row group of %d rows", file.Columns[c].Name, len(values), rows)
+			}
+			for r, v := range values {
+				groupRows[r][c] = v
+			}
+		}
+		file.Rows = append(file.Rows, groupRows...)
+	}
+	return file, nil
+}
+
+// column maps a schema element to the column the writer was given
+func column(element thriftStruct) (parquet.Column, error) {
+	name, _ := element[4].(string)
+	physical, _ := element[1].(int64)
+	if repetition, _ := element[3].(int64); repetition != 1 {
+		return parquet.Column{}, fmt.Errorf("parquettest: column %q isn't optional", name)
+	}
+	col := parquet.Column{Name: name, Type: parquet.Type(physical)}
+	if col.Type == parquet.String {
+		if converted, ok := element[6].(int64); !ok || converted != 0 {
+			return parquet.Column{}, fmt.Errorf("parquettest: column %q is binary, not UTF8", name)
+		}
+	}
+	return col, nil
+}
+
+// readPage decodes the single data page of a column chunk
+func readPage(data []byte, offset int64, col parquet.Column) ([]interface{}, error) {
+	if offset <= 0 || offset >= int64(len(data)) {
+		return nil, fmt.Errorf("page offset %d out of range", offset)
+	}
+	d := &decoder{data: data[offset:]}
+	header, err := d.structValue()
+	if err != nil {
+		return nil, err
+	}
+	size, _ := header[3].(int64)
+	dataHeader, _ := header[5].(thriftStruct)
+	count, _ := dataHeader[1].(int64)
+	if int64(len(d.data)) < size {
+		return nil, errors.New("page runs past the end of the file")
+	}
+	body := d.data[:size]
+
+	if len(body) < 4 {
+		return nil, errors.New("page has no definition levels")
+	}
+	levelsLength := int(binary.LittleEndian.Uint32(body))
+	if levelsLength > len(body)-4 {
+		return nil, errors.New("definition levels run past the page")
+	}
+	present, err := definitionLevels(body[4:4+levelsLength], int(count))
+	if err != nil {
+		return nil, err
+	}
+	return plainValues(bytes.NewReader(body[4+levelsLength:]), col.Type, present)
+}
+
+// definitionLevels decodes count bit-width-1 levels in the RLE/bit-packed
+// hybrid encoding
+func definitionLevels(data []byte, count int) ([]bool, error) {
+	r := bytes.NewReader(data)
+	present := make([]bool, 0, count)
+	for len(present) < count {
+		header, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("definition levels: %w", err)
+		}
+		if header&1 == 0 {
+			level, err := r.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("definition levels: %w", err)
+			}
+			for i := uint64(0); i < header>>1; i++ {
+				present = append(present, level == 1)
+			}
+			continue
+		}
+		for group := uint64(0); group < header>>1; group++ {
+			bits, err := r.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("definition levels: %w", err)
+			}
+			for bit := 0; bit < 8; bit++ {
+				present = append(present, bits&(1<<bit) != 0)
+			}
+		}
+	}
+	return present[:count], nil
+}
+
+// plainValues decodes the PLAIN values of the present entries
+func plainValues(r *bytes.Reader, typ parquet.Type, present []bool) ([]interface{}, error) {
+	values := make([]interface{}, len(present))
+	var bits byte
+	bit := 8
+	for i, ok := range present {
+		if !ok {
+			continue
+		}
+		var err error
+		switch typ {
+		case parquet.Boolean:
+			if bit == 8 {
+				if bits, err = r.ReadByte(); err != nil {
+					break
+				}
+				bit = 0
+			}
+			values[i] = bits&(1<<bit) != 0
+			bit++
+		case parquet.Int64:
+			var n int64
+			err = binary.Read(r, binary.LittleEndian, &n)
+			values[i] = n
+		case parquet.Double:
+			var n uint64
+			err = binary.Read(r, binary.LittleEndian, &n)
+			values[i] = math.Float64frombits(n)
+		case parquet.String:
+			var length uint32
+			if err = binary.Read(r, binary.LittleEndian, &length); err != nil {
+				break
+			}
+			s := make([]byte, length)
+			_, err = io.ReadFull(r, s)
+			values[i] = string(s)
+		default:
+			return nil, fmt.Errorf("unsupported type %d", typ)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %w", i, err)
+		}
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes left after the values", r.Len())
+	}
+	return values, nil
+}
@@ -0,0 +1,123 @@
+package parquettest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// thriftStruct is a decoded Thrift struct by field ID. Integers decode to
+// int64, binaries to string, lists to []interface{}.
+type thriftStruct map[int16]interface{}
+
+// decoder reads the Thrift compact protocol
+type decoder struct {
+	data []byte
+}
+
+func decodeStruct(data []byte) (thriftStruct, error) {
+	return (&decoder{data: data}).structValue()
+}
+
+func (d *decoder) byte() (byte, error) {
+	if len(d.data) == 0 {
+		return 0, errors.New("unexpected end of data")
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, errors.New("bad varint")
+	}
+	d.data = d.data[n:]
+	return v, nil
+}
+
+func (d *decoder) varint() (int64, error) {
+	v, err := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *decoder) structValue() (thriftStruct, error) {
+	fields := thriftStruct{}
+	var last int16
+	for {
+		header, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			n, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(n)
+		}
+		last = id
+		typ := header & 0x0F
+		switch typ {
+		case 1, 2:
+			// Booleans are carried in the field type
+			fields[id] = typ == 1
+		default:
+			if fields[id], err = d.value(typ); err != nil {
+				return nil, fmt.Errorf("field %d: %w", id, err)
+			}
+		}
+	}
+}
+
+func (d *decoder) value(typ byte) (interface{}, error) {
+	switch typ {
+	case 1, 2:
+		b, err := d.byte()
+		return b == 1, err
+	case 3:
+		b, err := d.byte()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		return d.varint()
+	case 8:
+		n, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.data)) {
+			return nil, errors.New("binary runs past the end of data")
+		}
+		s := string(d.data[:n])
+		d.data = d.data[n:]
+		return s, nil
+	case 9:
+		header, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = d.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		list := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			v, err := d.value(header & 0x0F)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case 12:
+		return d.structValue()
+	}
+	return nil, fmt.Errorf("unsupported Thrift type %d", typ)
+}
@@ -0,0 +1,87 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol type codes used by the Parquet metadata structs
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the small subset of the Thrift compact protocol that
+// Parquet page headers and file metadata need
+type thriftWriter struct {
+	buf    bytes.Buffer
+	lastID []int16
+}
+
+func (t *thriftWriter) structBegin() {
+	t.lastID = append(t.lastID, 0)
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.lastID = t.lastID[:len(t.lastID)-1]
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &t.lastID[len(t.lastID)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func (t *thriftWriter) i32Field(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64Field(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) stringField(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.str(s)
+}
+
+func (t *thriftWriter) str(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBegin()
+}
+
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xF0 | elemType)
+	t.uvarint(uint64(size))
+}
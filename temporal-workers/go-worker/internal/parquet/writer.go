@@ -0,0 +1,365 @@
+// Package parquet writes flat Parquet files without external dependencies.
+// It supports optional INT64, DOUBLE, BOOLEAN and UTF8 string columns,
+// PLAIN encoding and no compression, which every Parquet reader accepts.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type is a column's physical type
+type Type int32
+
+// Supported column types
+const (
+	Boolean Type = 0
+	Int64   Type = 2
+	Double  Type = 5
+	String  Type = 6 // BYTE_ARRAY annotated as UTF8
+)
+
+const (
+	magic                 = "PAR1"
+	encodingPlain         = 0
+	encodingRLE           = 3
+	repetitionOptional    = 1
+	convertedTypeUTF8     = 0
+	pageTypeData          = 0
+	codecUncompressed     = 0
+	defaultRowGroupLength = 10000
+)
+
+// Column describes one column of the schema. All columns are optional, so
+// nil values are written as nulls.
+type Column struct {
+	Name string
+	Type Type
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	rows    int64
+	size    int64
+}
+
+// Writer writes rows to a Parquet file. Rows are buffered and flushed as a
+// row group every rowGroupLength rows, which bounds memory use.
+type Writer struct {
+	out            *countingWriter
+	schema         []Column
+	rowGroupLength int
+	buffered       [][]interface{}
+	bufferedRows   int
+	rowGroups      []rowGroup
+	numRows        int64
+	closed         bool
+}
+
+// NewWriter starts a Parquet file on w. rowGroupLength <= 0 uses a default.
+func NewWriter(w io.Writer, schema []Column, rowGroupLength int) (*Writer, error) {
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("parquet: schema must have at least one column")
+	}
+	if rowGroupLength <= 0 {
+		rowGroupLength = defaultRowGroupLength
+	}
+	out := &countingWriter{w: w}
+	if _, err := io.WriteString(out, magic); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		out:            out,
+		schema:         schema,
+		rowGroupLength: rowGroupLength,
+		buffered:       make([][]interface{}, len(schema)),
+	}, nil
+}
+
+// Write appends a row. Values must line up with the schema; each is nil or
+// an int/int64, float64, bool or string matching the column type.
+func (w *Writer) Write(row []interface{}) error {
+	if w.closed {
+		return fmt.Errorf("parquet: write after close")
+	}
+	if len(row) != len(w.schema) {
+		return fmt.Errorf("parquet: row has %d values, schema has %d columns", len(row), len(w.schema))
+	}
+	// Check the whole row first, so a bad row leaves the columns aligned
+	values := make([]interface{}, len(row))
+	for i, col := range w.schema {
+		v, err := normalize(col, row[i])
+		if err != nil {
+			return err
+		}
+		values[i] = v
+	}
+	for i, v := range values {
+		w.buffered[i] = append(w.buffered[i], v)
+	}
+	w.bufferedRows++
+	if w.bufferedRows >= w.rowGroupLength {
+		return w.flush()
+	}
+	return nil
+}
+
+// NumRows returns the number of rows written so far
+func (w *Writer) NumRows() int64 {
+	return w.numRows + int64(w.bufferedRows)
+}
+
+// Close flushes buffered rows and writes the file footer. It does not close
+// the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	footer := w.footer()
+	if _, err := w.out.Write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if _, err := w.out.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, magic)
+	return err
+}
+
+func (w *Writer) flush() error {
+	if w.bufferedRows == 0 {
+		return nil
+	}
+	group := rowGroup{rows: int64(w.bufferedRows)}
+	for i, col := range w.schema {
+		offset := w.out.n
+		page := encodePage(col, w.buffered[i])
+		if _, err := w.out.Write(page); err != nil {
+			return err
+		}
+		chunk := columnChunk{offset: offset, size: w.out.n - offset, values: int64(len(w.buffered[i]))}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+		w.buffered[i] = w.buffered[i][:0]
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += group.rows
+	w.bufferedRows = 0
+	return nil
+}
+
+// encodePage returns a page header followed by a v1 data page holding
+// RLE definition levels and PLAIN values for the non-null entries
+func encodePage(col Column, values []interface{}) []byte {
+	var levels, data bytes.Buffer
+	writeDefinitionLevels(&levels, values)
+	if col.Type == Boolean {
+		writePlainBooleans(&data, values)
+	} else {
+		for _, v := range values {
+			if v != nil {
+				writePlain(&data, col.Type, v)
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	var levelLength [4]byte
+	binary.LittleEndian.PutUint32(levelLength[:], uint32(levels.Len()))
+	body.Write(levelLength[:])
+	body.Write(levels.Bytes())
+	body.Write(data.Bytes())
+
+	t := &thriftWriter{}
+	t.structBegin()
+	t.i32Field(1, pageTypeData)
+	t.i32Field(2, int32(body.Len()))
+	t.i32Field(3, int32(body.Len()))
+	t.structField(5)
+	t.i32Field(1, int32(len(values)))
+	t.i32Field(2, encodingPlain)
+	t.i32Field(3, encodingRLE)
+	t.i32Field(4, encodingRLE)
+	t.structEnd()
+	t.structEnd()
+
+	return append(t.buf.Bytes(), body.Bytes()...)
+}
+
+// writeDefinitionLevels writes the RLE/bit-packed hybrid encoding of the
+// definition levels (1 = present, 0 = null) as RLE runs of bit width 1
+func writeDefinitionLevels(buf *bytes.Buffer, values []interface{}) {
+	var tmp [binary.MaxVarintLen64]byte
+	for i := 0; i < len(values); {
+		present := values[i] != nil
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == present {
+			run++
+		}
+		n := binary.PutUvarint(tmp[:], uint64(run)<<1)
+		buf.Write(tmp[:n])
+		if present {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i += run
+	}
+}
+
+func writePlain(buf *bytes.Buffer, typ Type, v interface{}) {
+	var tmp [8]byte
+	switch typ {
+	case Int64:
+		binary.LittleEndian.PutUint64(tmp[:], uint64(v.(int64)))
+		buf.Write(tmp[:])
+	case Double:
+		binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v.(float64)))
+		buf.Write(tmp[:])
+	case String:
+		s := v.(string)
+		binary.LittleEndian.PutUint32(tmp[:4], uint32(len(s)))
+		buf.Write(tmp[:4])
+		buf.WriteString(s)
+	}
+}
+
+func writePlainBooleans(buf *bytes.Buffer, values []interface{}) {
+	var current byte
+	bit := 0
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if v.(bool) {
+			current |= 1 << bit
+		}
+		bit++
+		if bit == 8 {
+			buf.WriteByte(current)
+			current, bit = 0, 0
+		}
+	}
+	if bit > 0 {
+		buf.WriteByte(current)
+	}
+}
+
+func (w *Writer) footer() []byte {
+	t := &thriftWriter{}
+	t.structBegin()
+	t.i32Field(1, 1)
+
+	t.listField(2, thriftStruct, len(w.schema)+1)
+	t.structBegin()
+	t.stringField(4, "schema")
+	t.i32Field(5, int32(len(w.schema)))
+	t.structEnd()
+	for _, col := range w.schema {
+		t.structBegin()
+		t.i32Field(1, int32(col.Type))
+		t.i32Field(3, repetitionOptional)
+		t.stringField(4, col.Name)
+		if col.Type == String {
+			t.i32Field(6, convertedTypeUTF8)
+		}
+		t.structEnd()
+	}
+
+	t.i64Field(3, w.numRows)
+
+	t.listField(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.structBegin()
+		t.listField(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			t.structBegin()
+			t.i64Field(2, chunk.offset)
+			t.structField(3)
+			t.i32Field(1, int32(w.schema[i].Type))
+			t.listField(2, thriftI32, 2)
+			t.varint(encodingPlain)
+			t.varint(encodingRLE)
+			t.listField(3, thriftBinary, 1)
+			t.str(w.schema[i].Name)
+			t.i32Field(4, codecUncompressed)
+			t.i64Field(5, chunk.values)
+			t.i64Field(6, chunk.size)
+			t.i64Field(7, chunk.size)
+			t.i64Field(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64Field(2, group.size)
+		t.i64Field(3, group.rows)
+		t.structEnd()
+	}
+
+	t.stringField(6, "temporal-go-worker")
+	t.structEnd()
+	return t.buf.Bytes()
+}
+
+func normalize(col Column, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch col.Type {
+	case Int64:
+		switch n := v.(type) {
+		case int64:
+			return n, nil
+		case int:
+			return int64(n), nil
+		case int32:
+			return int64(n), nil
+		}
+	case Double:
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case float32:
+			return float64(n), nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		}
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case String:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("parquet: column %q: unsupported value %T", col.Name, v)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
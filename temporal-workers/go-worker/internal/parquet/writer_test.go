@@ -0,0 +1,124 @@
+package parquet_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"temporal-go-worker/internal/parquet"
+	"temporal-go-worker/internal/parquet/parquettest"
+)
+
+var schema = []parquet.Column{
+	{Name: "id", Type: parquet.String},
+	{Name: "count", Type: parquet.Int64},
+	{Name: "score", Type: parquet.Double},
+	{Name: "valid", Type: parquet.Boolean},
+}
+
+func TestWriterReadBack(t *testing.T) {
+	tests := []struct {
+		name           string
+		rowGroupLength int
+		rows           [][]interface{}
+		want           [][]interface{}
+		wantRowGroups  []int64
+	}{
+		{name: "empty"},
+		{
+			name: "one row group",
+			rows: [][]interface{}{
+				{"a", 1, 0.5, true},
+				{"b", int64(2), float32(1.5), false},
+			},
+			want: [][]interface{}{
+				{"a", int64(1), 0.5, true},
+				{"b", int64(2), 1.5, false},
+			},
+			wantRowGroups: []int64{2},
+		},
+		{
+			name: "nulls",
+			rows: [][]interface{}{
+				{"a", nil, nil, true},
+				{nil, int64(2), 2.5, nil},
+				{"", nil, 3, false},
+			},
+			want: [][]interface{}{
+				{"a", nil, nil, true},
+				{nil, int64(2), 2.5, nil},
+				{"", nil, 3.0, false},
+			},
+			wantRowGroups: []int64{3},
+		},
+		{
+			name:           "several row groups",
+			rowGroupLength: 2,
+			rows: [][]interface{}{
+				{"a", 1, 1.0, true}, {"b", 2, 2.0, false}, {"c", 3, 3.0, true},
+				{"d", 4, 4.0, true}, {"e", 5, 5.0, false},
+			},
+			want: [][]interface{}{
+				{"a", int64(1), 1.0, true}, {"b", int64(2), 2.0, false}, {"c", int64(3), 3.0, true},
+				{"d", int64(4), 4.0, true}, {"e", int64(5), 5.0, false},
+			},
+			wantRowGroups: []int64{2, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := parquet.NewWriter(&buf, schema, tt.rowGroupLength)
+			require.NoError(t, err)
+			for _, row := range tt.rows {
+				require.NoError(t, w.Write(row))
+			}
+			require.NoError(t, w.Close())
+			assert.Equal(t, int64(len(tt.rows)), w.NumRows())
+
+			file, err := parquettest.Read(buf.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, schema, file.Columns)
+			assert.Equal(t, int64(len(tt.rows)), file.NumRows)
+			assert.Equal(t, tt.wantRowGroups, file.RowGroups)
+			assert.Equal(t, tt.want, file.Rows)
+		})
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	_, err := parquet.NewWriter(&bytes.Buffer{}, nil, 0)
+	assert.EqualError(t, err, "parquet: schema must have at least one column")
+
+	tests := []struct {
+		name    string
+		row     []interface{}
+		wantErr string
+	}{
+		{name: "short row", row: []interface{}{"a"}, wantErr: "parquet: row has 1 values, schema has 4 columns"},
+		{name: "wrong type", row: []interface{}{"a", "1", 1.0, true}, wantErr: `parquet: column "count": unsupported value string`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := parquet.NewWriter(&buf, schema, 0)
+			require.NoError(t, err)
+			assert.EqualError(t, w.Write(tt.row), tt.wantErr)
+			assert.Zero(t, w.NumRows())
+
+			// The rejected row leaves nothing behind
+			require.NoError(t, w.Write([]interface{}{"b", 2, 2.0, false}))
+			require.NoError(t, w.Close())
+			file, err := parquettest.Read(buf.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, [][]interface{}{{"b", int64(2), 2.0, false}}, file.Rows)
+		})
+	}
+
+	w, err := parquet.NewWriter(&bytes.Buffer{}, schema, 0)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.EqualError(t, w.Write([]interface{}{"a", 1, 1.0, true}), "parquet: write after close")
+}
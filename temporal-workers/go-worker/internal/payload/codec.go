@@ -0,0 +1,131 @@
+// Package payload builds the data converter shared by the worker and the
+// command-line tools, so every binary reads and writes payloads, encrypted
+// or not, the same way.
+package payload
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
+)
+
+// EncryptedEncoding marks payloads sealed by EncryptionCodec
+const EncryptedEncoding = "binary/encrypted"
+
+// EncryptionCodec seals whole payloads with AES-GCM, so the Temporal server
+// and its persistence only ever see ciphertext. Payloads without the
+// encrypted encoding pass through Decode untouched, so histories written
+// before encryption was turned on still decode.
+type EncryptionCodec struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptionCodec returns a codec sealing payloads with an AES key of 16,
+// 24 or 32 bytes
+func NewEncryptionCodec(key []byte) (*EncryptionCodec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptionCodec{aead: aead}, nil
+}
+
+// ParseAESKey decodes and checks a base64 AES key
+func ParseAESKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+}
+
+// Encode encrypts each payload, metadata included
+func (c *EncryptionCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	out := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		plaintext, err := proto.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		out[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{converter.MetadataEncoding: []byte(EncryptedEncoding)},
+			Data:     c.aead.Seal(nonce, nonce, plaintext, nil),
+		}
+	}
+	return out, nil
+}
+
+// Decode decrypts payloads sealed by Encode
+func (c *EncryptionCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	out := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		if string(p.GetMetadata()[converter.MetadataEncoding]) != EncryptedEncoding {
+			out[i] = p
+			continue
+		}
+		sealed := p.GetData()
+		if len(sealed) < c.aead.NonceSize() {
+			return nil, fmt.Errorf("encrypted payload too short")
+		}
+		nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+		plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting payload: %w", err)
+		}
+		decoded := &commonpb.Payload{}
+		if err := proto.Unmarshal(plaintext, decoded); err != nil {
+			return nil, err
+		}
+		out[i] = decoded
+	}
+	return out, nil
+}
+
+// DataConverterFromEnv builds the data converter from DATA_CONVERTER and,
+// when PAYLOAD_ENCRYPTION_KEY is set, wraps it with the encryption codec.
+// The worker, the replayer, the gateway and bulk-start all use it, so they
+// read each other's payloads and encrypted histories replay with exactly
+// the production converter. Conversion and codec overhead are reported to
+// metrics.
+func DataConverterFromEnv(metrics client.MetricsHandler) (converter.DataConverter, error) {
+	dc, err := NewDataConverter(os.Getenv("DATA_CONVERTER"))
+	if err != nil {
+		return nil, err
+	}
+	var metered converter.DataConverter = newMeteredDataConverter(dc, metrics)
+	encoded := os.Getenv("PAYLOAD_ENCRYPTION_KEY")
+	if encoded == "" {
+		return metered, nil
+	}
+	key, err := ParseAESKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYLOAD_ENCRYPTION_KEY: %w", err)
+	}
+	codec, err := NewEncryptionCodec(key)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewCodecDataConverter(metered, newMeteredCodec(codec, metrics)), nil
+}
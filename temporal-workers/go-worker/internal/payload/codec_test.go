@@ -0,0 +1,87 @@
+package payload
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+func TestEncryptionCodec(t *testing.T) {
+	codec, err := NewEncryptionCodec(make([]byte, 32))
+	require.NoError(t, err)
+	plain, err := converter.GetDefaultDataConverter().ToPayload(map[string]string{"dataset_id": "dataset-1"})
+	require.NoError(t, err)
+
+	sealed, err := codec.Encode([]*commonpb.Payload{plain})
+	require.NoError(t, err)
+	require.Len(t, sealed, 1)
+	assert.Equal(t, EncryptedEncoding, string(sealed[0].GetMetadata()[converter.MetadataEncoding]))
+	assert.NotContains(t, string(sealed[0].GetData()), "dataset-1")
+
+	tampered := &commonpb.Payload{Metadata: sealed[0].GetMetadata(), Data: append([]byte(nil), sealed[0].GetData()...)}
+	tampered.Data[len(tampered.Data)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		in      *commonpb.Payload
+		want    *commonpb.Payload
+		wantErr bool
+	}{
+		{name: "sealed", in: sealed[0], want: plain},
+		{name: "written before encryption", in: plain, want: plain},
+		{name: "tampered", in: tampered, wantErr: true},
+		{name: "truncated", in: &commonpb.Payload{Metadata: sealed[0].GetMetadata(), Data: []byte("short")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := codec.Decode([]*commonpb.Payload{tt.in})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.GetMetadata(), decoded[0].GetMetadata())
+			assert.Equal(t, tt.want.GetData(), decoded[0].GetData())
+		})
+	}
+}
+
+func TestDataConverterFromEnv(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 16))
+	tests := []struct {
+		name         string
+		mode         string
+		key          string
+		wantEncoding string
+		wantErr      bool
+	}{
+		{name: "default", wantEncoding: converter.MetadataEncodingJSON},
+		{name: "precise", mode: ModePrecise, wantEncoding: converter.MetadataEncodingJSON},
+		{name: "encrypted", key: key, wantEncoding: EncryptedEncoding},
+		{name: "unknown mode", mode: "xml", wantErr: true},
+		{name: "short key", key: base64.StdEncoding.EncodeToString(make([]byte, 8)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DATA_CONVERTER", tt.mode)
+			t.Setenv("PAYLOAD_ENCRYPTION_KEY", tt.key)
+			dc, err := DataConverterFromEnv(client.MetricsNopHandler)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			p, err := dc.ToPayload(map[string]int{"n": 1})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEncoding, string(p.GetMetadata()[converter.MetadataEncoding]))
+			var got map[string]int
+			require.NoError(t, dc.FromPayload(p, &got))
+			assert.Equal(t, map[string]int{"n": 1}, got)
+		})
+	}
+}
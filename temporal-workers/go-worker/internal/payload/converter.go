@@ -0,0 +1,79 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// Data converter modes selectable via DATA_CONVERTER
+const (
+	ModeDefault = "default"
+	ModePrecise = "precise"
+)
+
+// NewDataConverter builds the data converter for the given mode. The precise
+// mode keeps the standard "json/plain" encoding so payloads stay readable by
+// the Python and TypeScript workers, but decodes numbers without float64
+// rounding.
+func NewDataConverter(mode string) (converter.DataConverter, error) {
+	switch mode {
+	case "", ModeDefault:
+		return converter.GetDefaultDataConverter(), nil
+	case ModePrecise:
+		return converter.NewCompositeDataConverter(
+			converter.NewNilPayloadConverter(),
+			converter.NewByteSlicePayloadConverter(),
+			converter.NewProtoJSONPayloadConverter(),
+			converter.NewProtoPayloadConverter(),
+			newPreciseJSONPayloadConverter(),
+		), nil
+	default:
+		return nil, fmt.Errorf("unsupported DATA_CONVERTER %q (expected %q or %q)", mode, ModeDefault, ModePrecise)
+	}
+}
+
+// preciseJSONPayloadConverter is a JSON payload converter that decodes
+// numbers held in interface{} values as json.Number instead of float64
+type preciseJSONPayloadConverter struct{}
+
+func newPreciseJSONPayloadConverter() *preciseJSONPayloadConverter {
+	return &preciseJSONPayloadConverter{}
+}
+
+// ToPayload converts a single value to a payload
+func (c *preciseJSONPayloadConverter) ToPayload(value interface{}) (*commonpb.Payload, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, fmt.Errorf("%w: %v", converter.ErrUnableToEncode, err)
+	}
+	return &commonpb.Payload{
+		Metadata: map[string][]byte{converter.MetadataEncoding: []byte(c.Encoding())},
+		Data:     bytes.TrimRight(buf.Bytes(), "\n"),
+	}, nil
+}
+
+// FromPayload converts a single payload to a value
+func (c *preciseJSONPayloadConverter) FromPayload(payload *commonpb.Payload, valuePtr interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(payload.GetData()))
+	dec.UseNumber()
+	if err := dec.Decode(valuePtr); err != nil {
+		return fmt.Errorf("%w: %v", converter.ErrUnableToDecode, err)
+	}
+	return nil
+}
+
+// ToString converts a payload into a human-readable string
+func (c *preciseJSONPayloadConverter) ToString(payload *commonpb.Payload) string {
+	return string(payload.GetData())
+}
+
+// Encoding returns the standard JSON encoding name
+func (c *preciseJSONPayloadConverter) Encoding() string {
+	return converter.MetadataEncodingJSON
+}
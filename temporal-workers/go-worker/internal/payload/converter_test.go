@@ -0,0 +1,54 @@
+package payload
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDataConverter(t *testing.T) {
+	type record struct {
+		At     time.Time              `json:"at"`
+		Values map[string]interface{} `json:"values"`
+	}
+	at := time.Date(2026, 3, 1, 12, 30, 45, 123456789, time.FixedZone("CET", 3600))
+	in := record{At: at, Values: map[string]interface{}{
+		"amount": json.Number("1234567.890123456789"),
+		"id":     json.Number("9007199254740993"),
+	}}
+
+	tests := []struct {
+		mode       string
+		wantAmount interface{}
+		wantID     interface{}
+		wantErr    bool
+	}{
+		// float64 rounds both numbers
+		{mode: ModeDefault, wantAmount: 1234567.890123456789, wantID: float64(9007199254740992)},
+		{mode: ModePrecise, wantAmount: json.Number("1234567.890123456789"), wantID: json.Number("9007199254740993")},
+		{mode: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			dc, err := NewDataConverter(tt.mode)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			p, err := dc.ToPayload(in)
+			require.NoError(t, err)
+			var out record
+			require.NoError(t, dc.FromPayload(p, &out))
+
+			// Times keep their nanoseconds and instant in either mode
+			assert.True(t, at.Equal(out.At), "got %s", out.At)
+			assert.Equal(t, at.Nanosecond(), out.At.Nanosecond())
+			assert.Equal(t, tt.wantAmount, out.Values["amount"])
+			assert.Equal(t, tt.wantID, out.Values["id"])
+		})
+	}
+}
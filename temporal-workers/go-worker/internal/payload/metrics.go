@@ -0,0 +1,152 @@
+package payload
+
+import (
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// Payload conversion stages, reported as the stage tag
+const (
+	payloadStageConverter = "converter"
+	payloadStageCodec     = "codec"
+)
+
+// payloadMetrics records the time and size of payload encode and decode
+// operations as go_worker_payload_{encode,decode}_latency and
+// go_worker_payload_{encode,decode}_bytes, tagged by stage and payload
+// encoding. Sizes are of the payload data on the encoded side.
+type payloadMetrics struct {
+	handler client.MetricsHandler
+	stage   string
+}
+
+func (m payloadMetrics) record(operation string, p *commonpb.Payload, elapsed time.Duration) {
+	encoding := string(p.GetMetadata()[converter.MetadataEncoding])
+	if encoding == "" {
+		encoding = "unknown"
+	}
+	h := m.handler.WithTags(map[string]string{"stage": m.stage, "encoding": encoding})
+	h.Timer("go_worker_payload_" + operation + "_latency").Record(elapsed)
+	h.Counter("go_worker_payload_" + operation + "_bytes").Inc(int64(len(p.GetData())))
+}
+
+// meteredDataConverter times the conversion of values to and from payloads,
+// one payload at a time so each is tagged with its own encoding
+type meteredDataConverter struct {
+	next    converter.DataConverter
+	metrics payloadMetrics
+}
+
+func newMeteredDataConverter(next converter.DataConverter, metrics client.MetricsHandler) *meteredDataConverter {
+	return &meteredDataConverter{next: next, metrics: payloadMetrics{handler: metrics, stage: payloadStageConverter}}
+}
+
+// ToPayload converts a single value to a payload
+func (c *meteredDataConverter) ToPayload(value interface{}) (*commonpb.Payload, error) {
+	start := time.Now()
+	p, err := c.next.ToPayload(value)
+	if err != nil {
+		return nil, err
+	}
+	c.metrics.record("encode", p, time.Since(start))
+	return p, nil
+}
+
+// FromPayload converts a single payload to a value
+func (c *meteredDataConverter) FromPayload(payload *commonpb.Payload, valuePtr interface{}) error {
+	start := time.Now()
+	if err := c.next.FromPayload(payload, valuePtr); err != nil {
+		return err
+	}
+	c.metrics.record("decode", payload, time.Since(start))
+	return nil
+}
+
+// ToPayloads converts values to payloads
+func (c *meteredDataConverter) ToPayloads(values ...interface{}) (*commonpb.Payloads, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := &commonpb.Payloads{}
+	for i, value := range values {
+		p, err := c.ToPayload(value)
+		if err != nil {
+			return nil, fmt.Errorf("values[%d]: %w", i, err)
+		}
+		result.Payloads = append(result.Payloads, p)
+	}
+	return result, nil
+}
+
+// FromPayloads converts payloads into valuePtrs, stopping at the shorter
+func (c *meteredDataConverter) FromPayloads(payloads *commonpb.Payloads, valuePtrs ...interface{}) error {
+	for i, p := range payloads.GetPayloads() {
+		if i >= len(valuePtrs) {
+			break
+		}
+		if err := c.FromPayload(p, valuePtrs[i]); err != nil {
+			return fmt.Errorf("payload item %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ToString converts a payload into a human-readable string
+func (c *meteredDataConverter) ToString(payload *commonpb.Payload) string {
+	return c.next.ToString(payload)
+}
+
+// ToStrings converts payloads into human-readable strings
+func (c *meteredDataConverter) ToStrings(payloads *commonpb.Payloads) []string {
+	return c.next.ToStrings(payloads)
+}
+
+// meteredCodec times a payload codec, such as encryption, separately from
+// conversion. Encode is tagged with the encoding it produces and Decode with
+// the encoding it receives, so payloads passed through untouched stand
+// apart from the ones the codec did work on.
+type meteredCodec struct {
+	next    converter.PayloadCodec
+	metrics payloadMetrics
+}
+
+func newMeteredCodec(next converter.PayloadCodec, metrics client.MetricsHandler) *meteredCodec {
+	return &meteredCodec{next: next, metrics: payloadMetrics{handler: metrics, stage: payloadStageCodec}}
+}
+
+// Encode encodes each payload with the wrapped codec
+func (c *meteredCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	out := make([]*commonpb.Payload, 0, len(payloads))
+	for _, p := range payloads {
+		start := time.Now()
+		encoded, err := c.next.Encode([]*commonpb.Payload{p})
+		if err != nil {
+			return nil, err
+		}
+		elapsed := time.Since(start)
+		for _, e := range encoded {
+			c.metrics.record("encode", e, elapsed)
+		}
+		out = append(out, encoded...)
+	}
+	return out, nil
+}
+
+// Decode decodes each payload with the wrapped codec
+func (c *meteredCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	out := make([]*commonpb.Payload, 0, len(payloads))
+	for _, p := range payloads {
+		start := time.Now()
+		decoded, err := c.next.Decode([]*commonpb.Payload{p})
+		if err != nil {
+			return nil, err
+		}
+		c.metrics.record("decode", p, time.Since(start))
+		out = append(out, decoded...)
+	}
+	return out, nil
+}
@@ -0,0 +1,165 @@
+package payload
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// recordingHandler keeps counter totals and timer observation counts by
+// "name{tag=value,...}", tags sorted
+type recordingHandler struct {
+	client.MetricsHandler
+	tags     map[string]string
+	mu       *sync.Mutex
+	counters map[string]int64
+	timers   map[string]int
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{MetricsHandler: client.MetricsNopHandler, mu: &sync.Mutex{}, counters: map[string]int64{}, timers: map[string]int{}}
+}
+
+func (h *recordingHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := make(map[string]string, len(h.tags)+len(tags))
+	for k, v := range h.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	tagged := *h
+	tagged.tags = merged
+	return &tagged
+}
+
+func (h *recordingHandler) key(name string) string {
+	pairs := make([]string, 0, len(h.tags))
+	for k, v := range h.tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (h *recordingHandler) Counter(name string) client.MetricsCounter {
+	return counterFunc(func(n int64) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.counters[h.key(name)] += n
+	})
+}
+
+func (h *recordingHandler) Timer(name string) client.MetricsTimer {
+	return timerFunc(func(time.Duration) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.timers[h.key(name)]++
+	})
+}
+
+type counterFunc func(int64)
+
+func (f counterFunc) Inc(n int64) { f(n) }
+
+type timerFunc func(time.Duration)
+
+func (f timerFunc) Record(d time.Duration) { f(d) }
+
+func TestMeteredDataConverter(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      interface{}
+		decodeInto interface{}
+		wantTimers map[string]int
+		wantBytes  map[string]int64
+		wantEncErr bool
+		wantDecErr bool
+	}{
+		{
+			name:       "json",
+			value:      map[string]int{"items": 10},
+			decodeInto: &map[string]int{},
+			wantTimers: map[string]int{
+				"go_worker_payload_encode_latency{encoding=json/plain,stage=converter}": 1,
+				"go_worker_payload_decode_latency{encoding=json/plain,stage=converter}": 1,
+			},
+			wantBytes: map[string]int64{
+				"go_worker_payload_encode_bytes{encoding=json/plain,stage=converter}": int64(len(`{"items":10}`)),
+				"go_worker_payload_decode_bytes{encoding=json/plain,stage=converter}": int64(len(`{"items":10}`)),
+			},
+		},
+		{
+			name:       "decode fails",
+			value:      "not a number",
+			decodeInto: new(int),
+			wantTimers: map[string]int{"go_worker_payload_encode_latency{encoding=json/plain,stage=converter}": 1},
+			wantBytes:  map[string]int64{"go_worker_payload_encode_bytes{encoding=json/plain,stage=converter}": int64(len(`"not a number"`))},
+			wantDecErr: true,
+		},
+		{name: "encode fails", value: make(chan int), wantTimers: map[string]int{}, wantBytes: map[string]int64{}, wantEncErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := newRecordingHandler()
+			dc := newMeteredDataConverter(converter.GetDefaultDataConverter(), metrics)
+
+			payloads, err := dc.ToPayloads(tt.value)
+			if tt.wantEncErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				err = dc.FromPayloads(payloads, tt.decodeInto)
+				if tt.wantDecErr {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+			assert.Equal(t, tt.wantTimers, metrics.timers)
+			assert.Equal(t, tt.wantBytes, metrics.counters)
+		})
+	}
+}
+
+func TestMeteredCodec(t *testing.T) {
+	codec, err := NewEncryptionCodec(make([]byte, 32))
+	require.NoError(t, err)
+	plain, err := converter.GetDefaultDataConverter().ToPayload(map[string]string{"dataset_id": "ds-1"})
+	require.NoError(t, err)
+
+	metrics := newRecordingHandler()
+	metered := newMeteredCodec(codec, metrics)
+	sealed, err := metered.Encode([]*commonpb.Payload{plain})
+	require.NoError(t, err)
+	require.Len(t, sealed, 1)
+	decoded, err := metered.Decode([]*commonpb.Payload{sealed[0], plain})
+	require.NoError(t, err)
+	assert.Equal(t, plain.GetData(), decoded[0].GetData())
+
+	sealedBytes := int64(len(sealed[0].GetData()))
+	assert.Equal(t, map[string]int{
+		"go_worker_payload_encode_latency{encoding=binary/encrypted,stage=codec}": 1,
+		"go_worker_payload_decode_latency{encoding=binary/encrypted,stage=codec}": 1,
+		// Payloads the codec passes through are tagged with their own encoding
+		"go_worker_payload_decode_latency{encoding=json/plain,stage=codec}": 1,
+	}, metrics.timers)
+	assert.Equal(t, sealedBytes, metrics.counters["go_worker_payload_encode_bytes{encoding=binary/encrypted,stage=codec}"])
+	assert.Equal(t, sealedBytes, metrics.counters["go_worker_payload_decode_bytes{encoding=binary/encrypted,stage=codec}"])
+
+	// A payload that fails to decode isn't measured
+	metrics = newRecordingHandler()
+	metered = newMeteredCodec(codec, metrics)
+	_, err = metered.Decode([]*commonpb.Payload{{Metadata: sealed[0].GetMetadata(), Data: []byte("short")}})
+	require.Error(t, err)
+	assert.Empty(t, metrics.timers)
+	assert.Empty(t, metrics.counters)
+}
@@ -0,0 +1,297 @@
+// Package predicate evaluates small boolean expressions over named numeric
+// values, such as "throughput < 50000 && cpu_utilization >= 0.8".
+//
+// The grammar supports number literals, identifiers, the comparisons
+// < <= > >= == !=, the logical operators && || !, and parentheses.
+package predicate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a parsed predicate
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a predicate. The result must evaluate to a boolean.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if root.kind() != kindBool {
+		return nil, fmt.Errorf("predicate %q: expression is not a condition", src)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the predicate source
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the predicate. Every identifier must be present in vars.
+func (e *Expr) Eval(vars map[string]float64) (bool, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("predicate %q: %w", e.src, err)
+	}
+	return v.b, nil
+}
+
+type valueKind int
+
+const (
+	kindNumber valueKind = iota
+	kindBool
+)
+
+type value struct {
+	n float64
+	b bool
+}
+
+type node interface {
+	kind() valueKind
+	eval(vars map[string]float64) (value, error)
+}
+
+type numberNode float64
+
+func (n numberNode) kind() valueKind { return kindNumber }
+func (n numberNode) eval(map[string]float64) (value, error) {
+	return value{n: float64(n)}, nil
+}
+
+type identNode string
+
+func (n identNode) kind() valueKind { return kindNumber }
+func (n identNode) eval(vars map[string]float64) (value, error) {
+	v, ok := vars[string(n)]
+	if !ok {
+		return value{}, fmt.Errorf("unknown value %q", string(n))
+	}
+	return value{n: v}, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) kind() valueKind { return kindBool }
+func (n compareNode) eval(vars map[string]float64) (value, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return value{}, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return value{}, err
+	}
+	var b bool
+	switch n.op {
+	case "<":
+		b = l.n < r.n
+	case "<=":
+		b = l.n <= r.n
+	case ">":
+		b = l.n > r.n
+	case ">=":
+		b = l.n >= r.n
+	case "==":
+		b = l.n == r.n
+	case "!=":
+		b = l.n != r.n
+	}
+	return value{b: b}, nil
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicNode) kind() valueKind { return kindBool }
+func (n logicNode) eval(vars map[string]float64) (value, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return value{}, err
+	}
+	if n.op == "&&" && !l.b || n.op == "||" && l.b {
+		return l, nil
+	}
+	return n.right.eval(vars)
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) kind() valueKind { return kindBool }
+func (n notNode) eval(vars map[string]float64) (value, error) {
+	v, err := n.operand.eval(vars)
+	return value{b: !v.b}, err
+}
+
+type token struct {
+	text string
+	pos  int
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("predicate %q: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) lex() error {
+	for i := 0; i < len(p.src); {
+		c := rune(p.src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("()", c):
+			p.tokens = append(p.tokens, token{string(c), i})
+			i++
+		case strings.ContainsRune("<>=!&|", c):
+			op := string(c)
+			if i+1 < len(p.src) {
+				if two := p.src[i : i+2]; two == "<=" || two == ">=" || two == "==" || two == "!=" || two == "&&" || two == "||" {
+					op = two
+				}
+			}
+			if op == "=" || op == "&" || op == "|" {
+				return p.errorf("unexpected %q at %d", op, i)
+			}
+			p.tokens = append(p.tokens, token{op, i})
+			i += len(op)
+		case c == '.' || c == '-' || unicode.IsDigit(c) || unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(p.src) && (p.src[i] == '.' || p.src[i] == '_' || p.src[i] == '-' && i == start ||
+				unicode.IsDigit(rune(p.src[i])) || unicode.IsLetter(rune(p.src[i]))) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{p.src[start:i], start})
+		default:
+			return p.errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	if len(p.tokens) == 0 {
+		return p.errorf("empty expression")
+	}
+	return nil
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogic("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogic("&&", p.parseUnary)
+}
+
+func (p *parser) parseLogic(op string, next func() (node, error)) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == op {
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindBool || right.kind() != kindBool {
+			return nil, p.errorf("operands of %s must be conditions", op)
+		}
+		left = logicNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek() == "!" {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != kindBool {
+			return nil, p.errorf("operand of ! must be a condition")
+		}
+		return notNode{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindNumber || right.kind() != kindNumber {
+			return nil, p.errorf("operands of %s must be numbers", op)
+		}
+		return compareNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case tok.text == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, p.errorf("missing ) for ( at %d", tok.pos)
+		}
+		p.pos++
+		return inner, nil
+	case tok.text[0] == '.' || tok.text[0] == '-' || unicode.IsDigit(rune(tok.text[0])):
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q at %d", tok.text, tok.pos)
+		}
+		return numberNode(n), nil
+	case unicode.IsLetter(rune(tok.text[0])) || tok.text[0] == '_':
+		return identNode(tok.text), nil
+	default:
+		return nil, p.errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+}
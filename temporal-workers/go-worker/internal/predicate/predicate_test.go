@@ -0,0 +1,56 @@
+package predicate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEval(t *testing.T) {
+	vars := map[string]float64{"throughput": 40000, "cpu_utilization": 0.85, "memory_usage": 0.5}
+	tests := []struct {
+		src          string
+		want         bool
+		wantParseErr string
+		wantEvalErr  string
+	}{
+		{src: "throughput < 50000", want: true},
+		{src: "throughput >= 50000", want: false},
+		{src: "cpu_utilization > 0.8 && memory_usage <= 0.5", want: true},
+		{src: "cpu_utilization > 0.9 || memory_usage != 0.5", want: false},
+		{src: "!(throughput == 40000)", want: false},
+		{src: "(throughput < 1 || cpu_utilization > 0.8) && !(memory_usage > 0.9)", want: true},
+		// Short-circuiting skips values the right side would need
+		{src: "throughput > 1 || missing > 0", want: true},
+		{src: "throughput < 1 && missing > 0", want: false},
+		{src: "throughput <", wantParseErr: `predicate "throughput <"`},
+		{src: "throughput", wantParseErr: "expression is not a condition"},
+		{src: "throughput < 1 1", wantParseErr: `unexpected "1"`},
+		{src: "(throughput < 1", wantParseErr: `predicate "(throughput < 1"`},
+		{src: "throughput $ 1", wantParseErr: `predicate "throughput $ 1"`},
+		{src: "missing > 0", wantEvalErr: `predicate "missing > 0": unknown value "missing"`},
+		{src: "!(missing > 0)", wantEvalErr: `unknown value "missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Parse(tt.src)
+			if tt.wantParseErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantParseErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.src, expr.String())
+
+			got, err := expr.Eval(vars)
+			if tt.wantEvalErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantEvalErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package temporalconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+)
+
+// RateLimitBackoff retries calls the server rejected with ResourceExhausted,
+// waiting an exponentially growing, jittered delay between attempts so a
+// burst of callers spreads out instead of retrying in lockstep. Other errors
+// are returned at once.
+type RateLimitBackoff struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxAttempts     int
+}
+
+// DefaultRateLimitBackoff suits batch callers that can afford to wait
+var DefaultRateLimitBackoff = RateLimitBackoff{
+	InitialInterval: 200 * time.Millisecond,
+	MaxInterval:     10 * time.Second,
+	MaxAttempts:     10,
+}
+
+// IsResourceExhausted reports whether the server rejected a call for rate
+// or capacity limits
+func IsResourceExhausted(err error) bool {
+	var exhausted *serviceerror.ResourceExhausted
+	return errors.As(err, &exhausted)
+}
+
+// Do runs call until it succeeds, fails with anything but
+// ResourceExhausted, runs out of attempts or ctx is done
+func (b RateLimitBackoff) Do(ctx context.Context, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || !IsResourceExhausted(err) {
+			return err
+		}
+		if attempt >= b.MaxAttempts {
+			return fmt.Errorf("still rate limited after %d attempts: %w", attempt, err)
+		}
+		timer := time.NewTimer(b.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns a random wait between half and all of the capped
+// exponential interval for the given attempt
+func (b RateLimitBackoff) delay(attempt int) time.Duration {
+	d := b.InitialInterval
+	for i := 1; i < attempt && d < b.MaxInterval; i++ {
+		d *= 2
+	}
+	if d > b.MaxInterval {
+		d = b.MaxInterval
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,212 @@
+// Package temporalconn holds the Temporal connection settings shared by the
+// worker and the command-line tools, so they all dial the cluster the same
+// way.
+package temporalconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
+)
+
+// Defaults used when TEMPORAL_ADDRESS or TEMPORAL_NAMESPACE are unset
+const (
+	DefaultAddress   = "temporal.temporal-cluster.local:7233"
+	DefaultNamespace = "default"
+)
+
+// ClientOptionsFromEnv returns client options from the same TEMPORAL_* and
+// GRPC_MAX_* variables the worker reads. Callers add their own data
+// converter, logger or metrics.
+func ClientOptionsFromEnv() (client.Options, error) {
+	maxRecv, err := envInt("GRPC_MAX_RECV_MSG_SIZE")
+	if err != nil {
+		return client.Options{}, err
+	}
+	maxSend, err := envInt("GRPC_MAX_SEND_MSG_SIZE")
+	if err != nil {
+		return client.Options{}, err
+	}
+	security, err := SecurityFromEnv()
+	if err != nil {
+		return client.Options{}, err
+	}
+	return client.Options{
+		HostPort:    envOr("TEMPORAL_ADDRESS", DefaultAddress),
+		Namespace:   envOr("TEMPORAL_NAMESPACE", DefaultNamespace),
+		Credentials: security.Credentials,
+		ConnectionOptions: client.ConnectionOptions{
+			TLS:         security.TLS,
+			DialOptions: GRPCDialOptions(maxRecv, maxSend),
+		},
+	}, nil
+}
+
+// Auth modes, as reported by Security.Mode
+const (
+	AuthNone   = "none"
+	AuthMTLS   = "mtls"
+	AuthAPIKey = "api-key"
+)
+
+// Security is how a client secures and authenticates its connection
+type Security struct {
+	TLS         *tls.Config
+	Credentials client.Credentials
+	Mode        string
+}
+
+// SecurityFromEnv returns the connection security from the TEMPORAL_TLS_*
+// variables (see TLSConfigFromEnv) and TEMPORAL_API_KEY. An API key, used by
+// Temporal Cloud in place of client certificates, turns on TLS by itself and
+// can't be combined with TEMPORAL_TLS_CERT/KEY; TEMPORAL_TLS_SERVER_NAME and
+// TEMPORAL_TLS_CA still apply to it.
+func SecurityFromEnv() (Security, error) {
+	config, err := TLSConfigFromEnv()
+	if err != nil {
+		return Security{}, err
+	}
+	apiKey := os.Getenv("TEMPORAL_API_KEY")
+	switch {
+	case apiKey != "" && config != nil && len(config.Certificates) > 0:
+		return Security{}, fmt.Errorf("TEMPORAL_API_KEY and TEMPORAL_TLS_CERT/TEMPORAL_TLS_KEY are mutually exclusive: configure one way to authenticate")
+	case apiKey != "":
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		return Security{TLS: config, Credentials: client.NewAPIKeyStaticCredentials(apiKey), Mode: AuthAPIKey}, nil
+	case config != nil && len(config.Certificates) > 0:
+		return Security{TLS: config, Mode: AuthMTLS}, nil
+	default:
+		return Security{TLS: config, Mode: AuthNone}, nil
+	}
+}
+
+// TLSConfig returns the client TLS configuration, or nil to dial without
+// TLS. serverName overrides the name certificates are verified against, for
+// frontends behind a proxy whose certificate doesn't match the dial host;
+// setting it implies TLS.
+func TLSConfig(serverName string) *tls.Config {
+	if serverName == "" {
+		return nil
+	}
+	return &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+// TLSConfigFromEnv returns the TLS configuration from TEMPORAL_TLS_SERVER_NAME
+// and, for clusters that authenticate clients by certificate (Temporal
+// Cloud), the TEMPORAL_TLS_CERT and TEMPORAL_TLS_KEY files plus an optional
+// TEMPORAL_TLS_CA bundle to verify the server with. The certificate
+// variables go together: setting only some of them is an error naming the
+// missing one. With none of them set the result is TLSConfig's.
+// TEMPORAL_TLS_CA on its own is allowed with TEMPORAL_API_KEY, which
+// authenticates without a client certificate.
+func TLSConfigFromEnv() (*tls.Config, error) {
+	config := TLSConfig(os.Getenv("TEMPORAL_TLS_SERVER_NAME"))
+	certFile, keyFile, caFile := os.Getenv("TEMPORAL_TLS_CERT"), os.Getenv("TEMPORAL_TLS_KEY"), os.Getenv("TEMPORAL_TLS_CA")
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return config, nil
+	}
+	if certFile == "" && keyFile == "" && os.Getenv("TEMPORAL_API_KEY") != "" {
+		return withRootCAs(config, caFile)
+	}
+	if certFile == "" {
+		return nil, fmt.Errorf("TEMPORAL_TLS_CERT is required when TEMPORAL_TLS_KEY or TEMPORAL_TLS_CA is set")
+	}
+	if keyFile == "" {
+		return nil, fmt.Errorf("TEMPORAL_TLS_KEY is required when TEMPORAL_TLS_CERT is set")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TEMPORAL_TLS_CERT and TEMPORAL_TLS_KEY: %w", err)
+	}
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return withRootCAs(config, caFile)
+}
+
+// withRootCAs verifies the server against the CA bundle in caFile, if any,
+// instead of the system roots
+func withRootCAs(config *tls.Config, caFile string) (*tls.Config, error) {
+	if caFile == "" {
+		return config, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading TEMPORAL_TLS_CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("TEMPORAL_TLS_CA %s contains no PEM certificates", caFile)
+	}
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	config.RootCAs = pool
+	return config, nil
+}
+
+// GRPCDialOptions returns dial options raising the per-call gRPC message
+// limits. A zero size keeps the SDK default. These only lift the client's own
+// limits: the Temporal server still rejects payloads above its blob size
+// limits (limit.blobSize.error in dynamic config, 2MB by default), so large
+// results must also fit the server's configuration.
+func GRPCDialOptions(maxRecvMsgSize, maxSendMsgSize int) []grpc.DialOption {
+	var callOptions []grpc.CallOption
+	if maxRecvMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallRecvMsgSize(maxRecvMsgSize))
+	}
+	if maxSendMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallSendMsgSize(maxSendMsgSize))
+	}
+	if len(callOptions) == 0 {
+		return nil
+	}
+	return []grpc.DialOption{grpc.WithDefaultCallOptions(callOptions...)}
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func envInt(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+// TaskQueue returns the task queue name for the ENVIRONMENT the process runs
+// in, so environments sharing a cluster don't poll each other's work
+func TaskQueue(name string) string {
+	return PrefixTaskQueue(os.Getenv("ENVIRONMENT"), name)
+}
+
+// PrefixTaskQueue returns "<environment>-<name>" (e.g. prod-go-workers), or
+// name unchanged when environment is empty or name already carries the
+// prefix
+func PrefixTaskQueue(environment, name string) string {
+	if environment == "" || strings.HasPrefix(name, environment+"-") {
+		return name
+	}
+	return environment + "-" + name
+}
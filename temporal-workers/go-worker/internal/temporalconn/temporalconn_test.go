@@ -0,0 +1,205 @@
+package temporalconn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestGRPCDialOptions dials an in-memory health server with the options and
+// checks the limits apply to calls made through the connection
+func TestGRPCDialOptions(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(strings.Repeat("s", 512), healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	tests := []struct {
+		name             string
+		maxRecv, maxSend int
+		wantOptions      int
+		wantCode         codes.Code
+	}{
+		{name: "sdk defaults", wantOptions: 0, wantCode: codes.OK},
+		{name: "raised limits", maxRecv: 64 << 20, maxSend: 64 << 20, wantOptions: 1, wantCode: codes.OK},
+		{name: "send limit below the request", maxSend: 16, wantOptions: 1, wantCode: codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := GRPCDialOptions(tt.maxRecv, tt.maxSend)
+			assert.Len(t, options, tt.wantOptions)
+
+			options = append(options,
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			conn, err := grpc.NewClient("passthrough:///bufnet", options...)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: strings.Repeat("s", 512)})
+			assert.Equal(t, tt.wantCode, status.Code(err), "%v", err)
+		})
+	}
+}
+
+func TestClientOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantHost    string
+		wantDialOps int
+		wantErr     string
+	}{
+		{name: "defaults", wantHost: DefaultAddress},
+		{
+			name:        "message sizes",
+			env:         map[string]string{"TEMPORAL_ADDRESS": "localhost:7233", "GRPC_MAX_RECV_MSG_SIZE": "8388608", "GRPC_MAX_SEND_MSG_SIZE": "8388608"},
+			wantHost:    "localhost:7233",
+			wantDialOps: 1,
+		},
+		{name: "invalid receive size", env: map[string]string{"GRPC_MAX_RECV_MSG_SIZE": "8MB"}, wantErr: `invalid GRPC_MAX_RECV_MSG_SIZE "8MB"`},
+		{name: "invalid send size", env: map[string]string{"GRPC_MAX_SEND_MSG_SIZE": "lots"}, wantErr: `invalid GRPC_MAX_SEND_MSG_SIZE "lots"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"TEMPORAL_ADDRESS", "TEMPORAL_NAMESPACE", "GRPC_MAX_RECV_MSG_SIZE", "GRPC_MAX_SEND_MSG_SIZE",
+				"TEMPORAL_TLS_SERVER_NAME", "TEMPORAL_TLS_CERT", "TEMPORAL_TLS_KEY", "TEMPORAL_TLS_CA", "TEMPORAL_API_KEY"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			options, err := ClientOptionsFromEnv()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHost, options.HostPort)
+			assert.Equal(t, DefaultNamespace, options.Namespace)
+			assert.Len(t, options.ConnectionOptions.DialOptions, tt.wantDialOps)
+		})
+	}
+}
+
+// writeKeyPair writes a self-signed certificate and its key to dir
+func writeKeyPair(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "worker"}, NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestSecurityFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeKeyPair(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantMode       string
+		wantTLS        bool
+		wantServerName string
+		wantCerts      int
+		wantRootCAs    bool
+		wantErr        string
+	}{
+		{name: "plaintext", wantMode: AuthNone},
+		{name: "server name", env: map[string]string{"TEMPORAL_TLS_SERVER_NAME": "frontend.internal"}, wantMode: AuthNone, wantTLS: true, wantServerName: "frontend.internal"},
+		{
+			name:     "mtls",
+			env:      map[string]string{"TEMPORAL_TLS_CERT": certFile, "TEMPORAL_TLS_KEY": keyFile, "TEMPORAL_TLS_CA": certFile, "TEMPORAL_TLS_SERVER_NAME": "ns.tmprl.cloud"},
+			wantMode: AuthMTLS, wantTLS: true, wantServerName: "ns.tmprl.cloud", wantCerts: 1, wantRootCAs: true,
+		},
+		{name: "api key", env: map[string]string{"TEMPORAL_API_KEY": "secret"}, wantMode: AuthAPIKey, wantTLS: true},
+		{name: "api key with a CA", env: map[string]string{"TEMPORAL_API_KEY": "secret", "TEMPORAL_TLS_CA": certFile}, wantMode: AuthAPIKey, wantTLS: true, wantRootCAs: true},
+		{name: "cert without key", env: map[string]string{"TEMPORAL_TLS_CERT": certFile}, wantErr: "TEMPORAL_TLS_KEY is required"},
+		{name: "key without cert", env: map[string]string{"TEMPORAL_TLS_KEY": keyFile}, wantErr: "TEMPORAL_TLS_CERT is required"},
+		{name: "missing cert file", env: map[string]string{"TEMPORAL_TLS_CERT": filepath.Join(dir, "missing.crt"), "TEMPORAL_TLS_KEY": keyFile}, wantErr: "loading TEMPORAL_TLS_CERT and TEMPORAL_TLS_KEY"},
+		{name: "CA without certificates", env: map[string]string{"TEMPORAL_API_KEY": "secret", "TEMPORAL_TLS_CA": notPEM}, wantErr: "contains no PEM certificates"},
+		{name: "api key and cert", env: map[string]string{"TEMPORAL_API_KEY": "secret", "TEMPORAL_TLS_CERT": certFile, "TEMPORAL_TLS_KEY": keyFile}, wantErr: "mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"TEMPORAL_TLS_SERVER_NAME", "TEMPORAL_TLS_CERT", "TEMPORAL_TLS_KEY", "TEMPORAL_TLS_CA", "TEMPORAL_API_KEY"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			security, err := SecurityFromEnv()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMode, security.Mode)
+			assert.Equal(t, tt.wantMode == AuthAPIKey, security.Credentials != nil)
+			if !tt.wantTLS {
+				assert.Nil(t, security.TLS)
+				return
+			}
+			require.NotNil(t, security.TLS)
+			assert.Equal(t, uint16(tls.VersionTLS12), security.TLS.MinVersion)
+			assert.Equal(t, tt.wantServerName, security.TLS.ServerName)
+			assert.Len(t, security.TLS.Certificates, tt.wantCerts)
+			assert.Equal(t, tt.wantRootCAs, security.TLS.RootCAs != nil)
+		})
+	}
+}
+
+func TestTaskQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		queue       string
+		want        string
+	}{
+		{name: "no environment", queue: "go-workers", want: "go-workers"},
+		{name: "prefixed", environment: "prod", queue: "go-workers", want: "prod-go-workers"},
+		{name: "already prefixed", environment: "prod", queue: "prod-go-workers", want: "prod-go-workers"},
+		{name: "other environment's queue", environment: "prod", queue: "staging-go-workers", want: "prod-staging-go-workers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.environment)
+			assert.Equal(t, tt.want, TaskQueue(tt.queue))
+			assert.Equal(t, tt.want, PrefixTaskQueue(tt.environment, tt.queue))
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Lock manager signal and query names. Grants are signalled to the
+// requester on a per-resource channel so one workflow can hold several locks.
+const (
+	AcquireLockSignalName   = "acquireLock"
+	ReleaseLockSignalName   = "releaseLock"
+	LockGrantedSignalPrefix = "lockGranted:"
+	LockStateQueryName      = "lockState"
+)
+
+const (
+	lockManagerWorkflowIDPrefix = "lock-manager:"
+	defaultLockLease            = 5 * time.Minute
+	defaultLockWait             = 10 * time.Minute
+	lockManagerIdleTimeout      = 10 * time.Minute
+	// lockManagerEventsPerRun bounds history before continuing as new
+	lockManagerEventsPerRun = 500
+	// lockLeaseMargin covers signalling the grant and the release on top
+	// of the guarded operation's budget
+	lockLeaseMargin = time.Minute
+)
+
+// temporalClient is used by activities that talk to Temporal directly. It
+// is set in main once the client is dialed.
+var temporalClient client.Client
+
+// LockRequest asks the lock manager for a resource's lock. Signals are
+// delivered at least once; a request repeating a recent SignalID is ignored.
+type LockRequest struct {
+	WorkflowID   string        `json:"workflow_id"`
+	RunID        string        `json:"run_id"`
+	LeaseTimeout time.Duration `json:"lease_timeout"`
+	SignalID     string        `json:"signal_id,omitempty"`
+}
+
+// LockRelease gives a lock back, or withdraws a request that hasn't been
+// granted yet when LeaseID is empty
+type LockRelease struct {
+	WorkflowID string `json:"workflow_id"`
+	LeaseID    string `json:"lease_id"`
+	SignalID   string `json:"signal_id,omitempty"`
+}
+
+// LockGrant is signalled to the requester when it holds the lock
+type LockGrant struct {
+	LeaseID   string    `json:"lease_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LockHolder is the current holder of a lock
+type LockHolder struct {
+	Request   LockRequest `json:"request"`
+	LeaseID   string      `json:"lease_id"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// LockManagerInput is the lock manager's state. It is carried across
+// continue-as-new; callers start the workflow with only Resource set.
+type LockManagerInput struct {
+	Resource string        `json:"resource"`
+	Holder   *LockHolder   `json:"holder,omitempty"`
+	Queue    []LockRequest `json:"queue,omitempty"`
+	Sequence int           `json:"sequence"`
+	Seen     seenSignals   `json:"seen_signals"`
+}
+
+// lockManagerWorkflowID is the ID of the lock manager for resource; there is
+// exactly one per resource
+func lockManagerWorkflowID(resource string) string {
+	return lockManagerWorkflowIDPrefix + resource
+}
+
+// LockManagerWorkflow grants a named lock to one workflow at a time, in
+// request order. Every grant carries a lease: if the holder doesn't release
+// it in time (say its worker crashed), the lease expires and the next
+// requester gets the lock. The workflow exits when it has been idle for a
+// while; the next request starts it again.
+func LockManagerWorkflow(ctx workflow.Context, input LockManagerInput) error {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🔐 Starting lock manager", "resource", input.Resource, "queued", len(input.Queue))
+
+	state := input
+	if err := workflow.SetQueryHandler(ctx, LockStateQueryName, func() (LockManagerInput, error) {
+		return state, nil
+	}); err != nil {
+		return err
+	}
+
+	acquireCh := workflow.GetSignalChannel(ctx, AcquireLockSignalName)
+	releaseCh := workflow.GetSignalChannel(ctx, ReleaseLockSignalName)
+
+	onAcquire := func(req LockRequest) {
+		if !state.Seen.firstDelivery(req.SignalID) {
+			logger.Info("🔁 Ignoring duplicate lock request", "resource", state.Resource, "signal_id", req.SignalID)
+			return
+		}
+		for _, queued := range state.Queue {
+			if queued.WorkflowID == req.WorkflowID {
+				return
+			}
+		}
+		if state.Holder != nil && state.Holder.Request.WorkflowID == req.WorkflowID {
+			return
+		}
+		state.Queue = append(state.Queue, req)
+	}
+	onRelease := func(rel LockRelease) {
+		if !state.Seen.firstDelivery(rel.SignalID) {
+			logger.Info("🔁 Ignoring duplicate lock release", "resource", state.Resource, "signal_id", rel.SignalID)
+			return
+		}
+		if state.Holder != nil && state.Holder.LeaseID == rel.LeaseID && rel.LeaseID != "" {
+			logger.Info("🔓 Lock released", "resource", state.Resource, "holder", rel.WorkflowID)
+			state.Holder = nil
+			return
+		}
+		for i, queued := range state.Queue {
+			if queued.WorkflowID == rel.WorkflowID && rel.LeaseID == "" {
+				state.Queue = append(state.Queue[:i], state.Queue[i+1:]...)
+				return
+			}
+		}
+	}
+
+	history := watchHistoryLength(ctx)
+	for events := 0; ; events++ {
+		history.check()
+		if state.Holder == nil {
+			grantNextLock(ctx, &state)
+		}
+
+		if events >= lockManagerEventsPerRun {
+			drainLockSignals(ctx, acquireCh, releaseCh, onAcquire, onRelease)
+			return workflow.NewContinueAsNewError(ctx, LockManagerWorkflow, state)
+		}
+
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(acquireCh, func(c workflow.ReceiveChannel, more bool) {
+			var req LockRequest
+			c.Receive(ctx, &req)
+			onAcquire(req)
+		})
+		selector.AddReceive(releaseCh, func(c workflow.ReceiveChannel, more bool) {
+			var rel LockRelease
+			c.Receive(ctx, &rel)
+			onRelease(rel)
+		})
+
+		idle := false
+		if state.Holder != nil {
+			expiresIn := state.Holder.ExpiresAt.Sub(workflow.Now(ctx))
+			if expiresIn < 0 {
+				expiresIn = 0
+			}
+			selector.AddFuture(workflow.NewTimer(timerCtx, expiresIn), func(f workflow.Future) {
+				if f.Get(ctx, nil) == nil && state.Holder != nil {
+					logger.Warn("⌛ Lock lease expired", "resource", state.Resource, "holder", state.Holder.Request.WorkflowID)
+					state.Holder = nil
+				}
+			})
+		} else {
+			selector.AddFuture(workflow.NewTimer(timerCtx, lockManagerIdleTimeout), func(f workflow.Future) {
+				idle = f.Get(ctx, nil) == nil
+			})
+		}
+		selector.Select(ctx)
+		cancelTimer()
+
+		if idle && len(state.Queue) == 0 {
+			// Requests may have arrived while the timer fired
+			drainLockSignals(ctx, acquireCh, releaseCh, onAcquire, onRelease)
+			if state.Holder == nil && len(state.Queue) == 0 {
+				logger.Info("💤 Lock manager idle, exiting", "resource", state.Resource)
+				return nil
+			}
+		}
+	}
+}
+
+// grantNextLock hands the lock to the first queued requester that is still
+// running. Requesters that have gone away are skipped.
+func grantNextLock(ctx workflow.Context, state *LockManagerInput) {
+	for len(state.Queue) > 0 {
+		req := state.Queue[0]
+		state.Queue = state.Queue[1:]
+
+		lease := req.LeaseTimeout
+		if lease <= 0 {
+			lease = defaultLockLease
+		}
+		state.Sequence++
+		grant := LockGrant{
+			LeaseID:   fmt.Sprintf("%s#%d", state.Resource, state.Sequence),
+			ExpiresAt: workflow.Now(ctx).Add(lease),
+		}
+		err := workflow.SignalExternalWorkflow(ctx, req.WorkflowID, req.RunID, LockGrantedSignalPrefix+state.Resource, grant).Get(ctx, nil)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("⚠️ Skipping lock requester", "resource", state.Resource, "requester", req.WorkflowID, "error", err)
+			continue
+		}
+		workflow.GetLogger(ctx).Info("🔒 Lock granted", "resource", state.Resource, "holder", req.WorkflowID, "lease", lease)
+		state.Holder = &LockHolder{Request: req, LeaseID: grant.LeaseID, ExpiresAt: grant.ExpiresAt}
+		return
+	}
+}
+
+func drainLockSignals(ctx workflow.Context, acquireCh, releaseCh workflow.ReceiveChannel, onAcquire func(LockRequest), onRelease func(LockRelease)) {
+	for {
+		var req LockRequest
+		var rel LockRelease
+		switch {
+		case acquireCh.ReceiveAsync(&req):
+			onAcquire(req)
+		case releaseCh.ReceiveAsync(&rel):
+			onRelease(rel)
+		default:
+			return
+		}
+	}
+}
+
+// RequestLockInput represents input for RequestLock
+type RequestLockInput struct {
+	Resource string      `json:"resource"`
+	Request  LockRequest `json:"request"`
+}
+
+// RequestLock queues a lock request, starting the resource's lock manager
+// if it isn't running. Workflows can't signal-with-start themselves, so this
+// runs as a local activity.
+func (a *Activities) RequestLock(ctx context.Context, input RequestLockInput) error {
+	if temporalClient == nil {
+		return temporal.NewNonRetryableApplicationError("no Temporal client configured", "LockUnavailable", nil)
+	}
+	_, err := temporalClient.SignalWithStartWorkflow(ctx, lockManagerWorkflowID(input.Resource), AcquireLockSignalName, input.Request,
+		client.StartWorkflowOptions{TaskQueue: activity.GetInfo(ctx).TaskQueue},
+		LockManagerWorkflow, LockManagerInput{Resource: input.Resource})
+	return err
+}
+
+// resourceLock is a lock held by the calling workflow
+type resourceLock struct {
+	resource string
+	grant    LockGrant
+}
+
+// errLockWaitTimeout is returned when a lock isn't granted in time
+var errLockWaitTimeout = errors.New("timed out waiting for lock")
+
+// acquireResourceLock blocks until the calling workflow holds the lock on
+// resource, or wait elapses. The lock must be released with release.
+func acquireResourceLock(ctx workflow.Context, resource string, lease, wait time.Duration) (*resourceLock, error) {
+	logger := workflow.GetLogger(ctx)
+	info := workflow.GetInfo(ctx)
+
+	lactx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+	})
+	err := workflow.ExecuteLocalActivity(lactx, activities.RequestLock, RequestLockInput{
+		Resource: resource,
+		Request: LockRequest{
+			WorkflowID:   info.WorkflowExecution.ID,
+			RunID:        info.WorkflowExecution.RunID,
+			LeaseTimeout: lease,
+			SignalID:     newSignalID(ctx, "acquire:"+resource),
+		},
+	}).Get(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("requesting lock on %s: %w", resource, err)
+	}
+	logger.Info("⏳ Waiting for lock", "resource", resource)
+
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+
+	var grant LockGrant
+	granted := false
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(workflow.GetSignalChannel(ctx, LockGrantedSignalPrefix+resource), func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &grant)
+		granted = true
+	})
+	selector.AddFuture(workflow.NewTimer(timerCtx, wait), func(f workflow.Future) {})
+	selector.Select(ctx)
+
+	if !granted {
+		// Withdraw the request so the lock isn't granted to us later
+		_ = workflow.SignalExternalWorkflow(ctx, lockManagerWorkflowID(resource), "", ReleaseLockSignalName,
+			LockRelease{WorkflowID: info.WorkflowExecution.ID, SignalID: newSignalID(ctx, "withdraw:"+resource)}).Get(ctx, nil)
+		return nil, fmt.Errorf("%w on %s after %s", errLockWaitTimeout, resource, wait)
+	}
+	logger.Info("🔒 Lock acquired", "resource", resource, "lease_id", grant.LeaseID)
+	return &resourceLock{resource: resource, grant: grant}, nil
+}
+
+// release gives the lock back. It runs in a disconnected context so the
+// lock is returned even when the workflow is being cancelled.
+func (l *resourceLock) release(ctx workflow.Context) {
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	err := workflow.SignalExternalWorkflow(ctx, lockManagerWorkflowID(l.resource), "", ReleaseLockSignalName, LockRelease{
+		WorkflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+		LeaseID:    l.grant.LeaseID,
+		SignalID:   newSignalID(ctx, "release:"+l.resource),
+	}).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("⚠️ Unable to release lock; it will expire with its lease", "resource", l.resource, "error", err)
+	}
+}
+
+// lockLeaseFor returns the lease of a lock guarding an activity run with
+// options: its whole retry budget, so the lease can't expire while an
+// attempt is still running. A longer requested lease wins.
+func lockLeaseFor(options workflow.ActivityOptions, requested time.Duration) time.Duration {
+	lease := activityBudget(options) + lockLeaseMargin
+	if requested > lease {
+		return requested
+	}
+	return lease
+}
+
+// mutatingOperation reports whether a system operation changes its target.
+// Unknown operations are assumed to.
+func mutatingOperation(operation string) bool {
+	return strings.ToLower(operation) != "select"
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestLockLeaseFor(t *testing.T) {
+	// SystemOperationWorkflow's default: five 60s attempts with 1s, 2s, 4s
+	// and 8s of backoff between them
+	systemOperation := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 2, MaximumInterval: 10 * time.Second, MaximumAttempts: 5},
+	}
+	tests := []struct {
+		name      string
+		options   workflow.ActivityOptions
+		requested time.Duration
+		want      time.Duration
+	}{
+		{name: "retry budget", options: systemOperation, want: 5*time.Minute + 15*time.Second + lockLeaseMargin},
+		{name: "shorter request", options: systemOperation, requested: time.Minute, want: 5*time.Minute + 15*time.Second + lockLeaseMargin},
+		{name: "longer request", options: systemOperation, requested: time.Hour, want: time.Hour},
+		{
+			name: "backoff capped",
+			options: workflow.ActivityOptions{
+				StartToCloseTimeout: time.Second,
+				RetryPolicy:         &temporal.RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 10, MaximumInterval: 5 * time.Second, MaximumAttempts: 4},
+			},
+			want: 4*time.Second + (1+5+5)*time.Second + lockLeaseMargin,
+		},
+		{
+			name:    "bounded total",
+			options: workflow.ActivityOptions{ScheduleToCloseTimeout: 20 * time.Minute, StartToCloseTimeout: time.Minute},
+			want:    20*time.Minute + lockLeaseMargin,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lockLeaseFor(tt.options, tt.requested))
+		})
+	}
+}
+
+func TestLockManagerWorkflow(t *testing.T) {
+	const lease = 20 * time.Minute
+	tests := []struct {
+		name string
+		// releaseAt is when the first holder releases; zero never does
+		releaseAt time.Duration
+		// wantSecondAt is when the second requester gets the lock
+		wantSecondAt time.Duration
+	}{
+		{name: "waits until release", releaseAt: 10 * time.Minute, wantSecondAt: 10 * time.Minute},
+		{name: "waits until the lease expires", wantSecondAt: time.Minute + lease},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			start := env.Now()
+
+			type grantAt struct {
+				WorkflowID string
+				At         time.Duration
+				Grant      LockGrant
+			}
+			var grants []grantAt
+			env.OnSignalExternalWorkflow(mock.Anything, mock.Anything, mock.Anything, LockGrantedSignalPrefix+"orders", mock.Anything).Return(
+				func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+					grants = append(grants, grantAt{WorkflowID: workflowID, At: env.Now().Sub(start), Grant: arg.(LockGrant)})
+					return nil
+				})
+
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(AcquireLockSignalName, LockRequest{WorkflowID: "wf-a", LeaseTimeout: lease, SignalID: "a-1"})
+			}, time.Minute)
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(AcquireLockSignalName, LockRequest{WorkflowID: "wf-b", LeaseTimeout: lease, SignalID: "b-1"})
+			}, 2*time.Minute)
+			if tt.releaseAt > 0 {
+				env.RegisterDelayedCallback(func() {
+					require.Len(t, grants, 1)
+					env.SignalWorkflow(ReleaseLockSignalName, LockRelease{WorkflowID: "wf-a", LeaseID: grants[0].Grant.LeaseID, SignalID: "a-2"})
+				}, tt.releaseAt)
+			}
+
+			env.ExecuteWorkflow(LockManagerWorkflow, LockManagerInput{Resource: "orders"})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			require.Len(t, grants, 2)
+			assert.Equal(t, "wf-a", grants[0].WorkflowID)
+			assert.Equal(t, time.Minute, grants[0].At)
+			assert.Equal(t, "wf-b", grants[1].WorkflowID)
+			assert.Equal(t, tt.wantSecondAt, grants[1].At)
+			assert.NotEqual(t, grants[0].Grant.LeaseID, grants[1].Grant.LeaseID)
+		})
+	}
+}
+
+// TestDrainLockSignals checks that every buffered lock signal is handled
+// before the manager continues as new or exits idle, in arrival order per
+// channel, and that draining an empty buffer returns at once
+func TestDrainLockSignals(t *testing.T) {
+	tests := []struct {
+		name     string
+		acquires []string
+		releases []string
+	}{
+		{name: "buffered signals", acquires: []string{"wf-a", "wf-b", "wf-c"}, releases: []string{"wf-x", "wf-y"}},
+		{name: "nothing buffered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) ([]string, error) {
+				// Signals sent while the workflow sleeps stay buffered
+				if err := workflow.Sleep(ctx, time.Minute); err != nil {
+					return nil, err
+				}
+				var handled []string
+				drainLockSignals(ctx,
+					workflow.GetSignalChannel(ctx, AcquireLockSignalName),
+					workflow.GetSignalChannel(ctx, ReleaseLockSignalName),
+					func(req LockRequest) { handled = append(handled, "acquire:"+req.WorkflowID) },
+					func(rel LockRelease) { handled = append(handled, "release:"+rel.WorkflowID) })
+				return handled, nil
+			}, workflow.RegisterOptions{Name: "drainWorkflow"})
+			env.RegisterDelayedCallback(func() {
+				for _, id := range tt.acquires {
+					env.SignalWorkflow(AcquireLockSignalName, LockRequest{WorkflowID: id})
+				}
+				for _, id := range tt.releases {
+					env.SignalWorkflow(ReleaseLockSignalName, LockRelease{WorkflowID: id})
+				}
+			}, time.Second)
+
+			env.ExecuteWorkflow("drainWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var handled []string
+			require.NoError(t, env.GetWorkflowResult(&handled))
+
+			var acquired, released []string
+			for _, h := range handled {
+				if id, ok := strings.CutPrefix(h, "acquire:"); ok {
+					acquired = append(acquired, id)
+				} else if id, ok := strings.CutPrefix(h, "release:"); ok {
+					released = append(released, id)
+				}
+			}
+			assert.Len(t, handled, len(tt.acquires)+len(tt.releases))
+			assert.Equal(t, tt.acquires, acquired)
+			assert.Equal(t, tt.releases, released)
+		})
+	}
+}
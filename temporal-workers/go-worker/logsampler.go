@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// sampledLogger emits one in every N info lines per format string so
+// high-frequency activity logs don't flood output under load. Errors are
+// always logged.
+type sampledLogger struct {
+	every    atomic.Uint64
+	counters sync.Map // format string -> *atomic.Uint64
+}
+
+// activityLog is used for per-call activity log lines. Its rate comes from
+// ACTIVITY_LOG_SAMPLE_RATE.
+var activityLog = newSampledLogger(1)
+
+func newSampledLogger(every int) *sampledLogger {
+	l := &sampledLogger{}
+	l.SetEvery(every)
+	return l
+}
+
+// SetEvery sets the sampling rate; values below 1 log every line
+func (l *sampledLogger) SetEvery(every int) {
+	if every < 1 {
+		every = 1
+	}
+	l.every.Store(uint64(every))
+}
+
+// Infof logs the line if it is selected by sampling
+func (l *sampledLogger) Infof(format string, args ...interface{}) {
+	every := l.every.Load()
+	if every > 1 {
+		counter, _ := l.counters.LoadOrStore(format, new(atomic.Uint64))
+		if counter.(*atomic.Uint64).Add(1)%every != 1 {
+			return
+		}
+	}
+	log.Printf(format, args...)
+}
+
+// Errorf always logs the line
+func (l *sampledLogger) Errorf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
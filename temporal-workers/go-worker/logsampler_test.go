@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureLog sends the standard logger to a buffer for the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prevOutput, prevFlags := log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		log.SetOutput(prevOutput)
+		log.SetFlags(prevFlags)
+	})
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	return &buf
+}
+
+func TestSampledLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		every int
+		// calls are the info lines logged, by format
+		calls []string
+		// errors are logged with Errorf after the info lines
+		errors int
+		want   []string
+	}{
+		{name: "every line", every: 1, calls: []string{"a", "a", "a"}, want: []string{"a 1", "a 2", "a 3"}},
+		{name: "one in three", every: 3, calls: []string{"a", "a", "a", "a", "a", "a", "a"}, want: []string{"a 1", "a 4", "a 7"}},
+		{name: "per format", every: 2, calls: []string{"a", "b", "a", "b", "a"}, want: []string{"a 1", "b 2", "a 5"}},
+		{name: "errors unsampled", every: 100, calls: []string{"a", "a"}, errors: 2, want: []string{"a 1", "error 1", "error 2"}},
+		{name: "zero rate logs every line", every: 0, calls: []string{"a", "a"}, want: []string{"a 1", "a 2"}},
+		{name: "negative rate logs every line", every: -5, calls: []string{"a", "a"}, want: []string{"a 1", "a 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+			l := newSampledLogger(tt.every)
+			for i, format := range tt.calls {
+				l.Infof(format+" %d", i+1)
+			}
+			for i := 0; i < tt.errors; i++ {
+				l.Errorf("error %d", i+1)
+			}
+			assert.Equal(t, tt.want, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+		})
+	}
+}
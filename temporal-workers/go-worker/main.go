@@ -2,60 +2,302 @@ package main
 
 import (
 	"context"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
+
+	"temporal-go-worker/internal/payload"
+	"temporal-go-worker/internal/temporalconn"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatalf("❌ Replay failed: %v", err)
+		}
+		return
+	}
+
 	// Get configuration from environment
 	temporalAddress := getEnv("TEMPORAL_ADDRESS", "temporal.temporal-cluster.local:7233")
 	namespace := getEnv("TEMPORAL_NAMESPACE", "default")
-	taskQueue := getEnv("TASK_QUEUE", "go-workers")
-	buildID := getEnv("BUILD_ID", "go-v1.0.0")
+	tlsServerName := os.Getenv("TEMPORAL_TLS_SERVER_NAME")
+	taskQueue := temporalconn.TaskQueue(getEnv("TASK_QUEUE", "go-workers"))
+	buildID := getEnv("BUILD_ID", defaultBuildID())
+	compatibleBuildID := os.Getenv("COMPATIBLE_BUILD_ID")
+	identity := workerIdentity()
+	healthPort := getEnv("HEALTH_PORT", "8080")
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	dataConverterMode := getEnv("DATA_CONVERTER", payload.ModeDefault)
+	grpcMaxRecvMsgSize := getEnvInt("GRPC_MAX_RECV_MSG_SIZE", 0)
+	grpcMaxSendMsgSize := getEnvInt("GRPC_MAX_SEND_MSG_SIZE", 0)
+	activityLogSampleRate := getEnvInt("ACTIVITY_LOG_SAMPLE_RATE", 1)
+	deadlockDetectionTimeout := getEnvDuration("DEADLOCK_DETECTION_TIMEOUT", 0)
+	workerStopTimeout := getEnvDuration("WORKER_STOP_TIMEOUT", 30*time.Second)
+	objectStoreDir := os.Getenv("OBJECT_STORE_DIR")
+	objectStoreURL := os.Getenv("OBJECT_STORE_URL")
+	healthCheckCron := os.Getenv("HEALTHCHECK_CRON")
+	// METRICS_SINK is the variable's former name
+	metricsBackend := getEnv("METRICS_BACKEND", getEnv("METRICS_SINK", MetricsBackendNone))
+	processorName := getEnv("DATASET_PROCESSOR", DatasetProcessorSimulated)
+	statsdAddr := getEnv("STATSD_ADDR", "127.0.0.1:8125")
+	metricsPort := getEnv("METRICS_PORT", "9090")
+	schemaRegistryURL = os.Getenv("SCHEMA_REGISTRY_URL")
+	webhookSecret = os.Getenv("WEBHOOK_SECRET")
+	quarantineThreshold = getEnvInt("QUARANTINE_THRESHOLD", quarantineThreshold)
+	historyLengthWarning = getEnvInt("HISTORY_LENGTH_WARNING", historyLengthWarning)
+	inputLogMaxBytes = getEnvInt("INPUT_LOG_MAX_BYTES", inputLogMaxBytes)
+	optimizeCacheTTL = getEnvDuration("OPTIMIZE_CACHE_TTL", optimizeCacheTTL)
+	debugCapture := os.Getenv("DEBUG_CAPTURE") == "true"
+	enablePprof := os.Getenv("ENABLE_PPROF") == "true"
+	registrationCheck := getEnv("REGISTRATION_CHECK", RegistrationCheckStrict)
+	activityConcurrency, err := parseActivityConcurrency(os.Getenv("ACTIVITY_CONCURRENCY"))
+	if err != nil {
+		log.Fatalf("❌ Invalid activity concurrency configuration: %v", err)
+	}
+	chaos, chaosEnabled, err := chaosConfigFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Invalid chaos configuration: %v", err)
+	}
+	security, err := temporalconn.SecurityFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Invalid Temporal auth configuration: %v", err)
+	}
 
 	log.Printf("🚀 Starting Go Temporal Worker...")
 	log.Printf("   - Task Queue: %s", taskQueue)
 	log.Printf("   - Build ID: %s", buildID)
+	if compatibleBuildID != "" {
+		log.Printf("   - Compatible With: %s", compatibleBuildID)
+	}
+	log.Printf("   - Identity: %s", identity)
 	log.Printf("   - Temporal Address: %s", temporalAddress)
 	log.Printf("   - Namespace: %s", namespace)
+	if tlsServerName != "" {
+		log.Printf("   - TLS Server Name: %s", tlsServerName)
+	}
+	switch security.Mode {
+	case temporalconn.AuthAPIKey:
+		log.Printf("   - Auth: API key (TEMPORAL_API_KEY, over TLS)")
+	case temporalconn.AuthMTLS:
+		log.Printf("   - Auth: mTLS (client certificate %s)", os.Getenv("TEMPORAL_TLS_CERT"))
+	default:
+		log.Printf("   - Auth: None")
+	}
 	log.Printf("   - Versioning: Enabled")
+	log.Printf("   - Health Port: %s", healthPort)
+	log.Printf("   - Data Converter: %s", dataConverterMode)
+	if os.Getenv("PAYLOAD_ENCRYPTION_KEY") != "" {
+		log.Printf("   - Payload Encryption: Enabled")
+	}
+	log.Printf("   - Activity Log Sampling: 1 in %d", activityLogSampleRate)
+	log.Printf("   - Metrics Backend: %s", metricsBackend)
+	log.Printf("   - Dataset Processor: %s", processorName)
+	if debugCapture {
+		log.Printf("   - Debug Capture: Enabled")
+	}
+	if enablePprof {
+		log.Printf("   - Profiling: /debug/pprof/ on the admin server")
+	}
+	if chaosEnabled {
+		log.Printf("   - Chaos: failure rate %.2f, latency rate %.2f (%s-%s), seed %d",
+			chaos.FailureRate, chaos.LatencyRate, chaos.MinLatency, chaos.MaxLatency, chaos.Seed)
+	}
+
+	if deadlockDetectionTimeout > 0 {
+		log.Printf("   - Deadlock Detection Timeout: %s", deadlockDetectionTimeout)
+	}
+
+	switch registrationCheck {
+	case RegistrationCheckOff:
+	case RegistrationCheckStrict, RegistrationCheckWarn:
+		// Only names are checked, so the nil activities value is enough to
+		// register the methods against
+		if err := validateRegistrations(registerWorkflowsAndActivities(activities), workflowDependencies); err != nil {
+			if registrationCheck == RegistrationCheckStrict {
+				log.Fatalf("❌ Registration check failed: %v", err)
+			}
+			log.Printf("⚠️ Registration check failed: %v", err)
+		}
+	default:
+		log.Fatalf("❌ Invalid REGISTRATION_CHECK %q: want %s, %s or %s", registrationCheck,
+			RegistrationCheckStrict, RegistrationCheckWarn, RegistrationCheckOff)
+	}
+
+	if defaultParameters, err = parseDefaultParameters(os.Getenv("DEFAULT_PARAMETERS")); err != nil {
+		log.Fatalf("❌ Invalid default parameters: %v", err)
+	}
+	if regionTaskQueues, err = parseRegionTaskQueues(os.Getenv("REGION_TASK_QUEUES"), temporalconn.TaskQueue); err != nil {
+		log.Fatalf("❌ Invalid region task queues: %v", err)
+	}
+	tenantQuotas, err := parseTenantQuotas(os.Getenv("TENANT_QUOTAS"))
+	if err != nil {
+		log.Fatalf("❌ Invalid tenant quotas: %v", err)
+	}
+	acts := newActivities()
+	acts.Quotas = newMemoryQuotaStore(tenantQuotas)
+	if acts.Processor, err = newDatasetProcessor(processorName); err != nil {
+		log.Fatalf("❌ Invalid dataset processor: %v", err)
+	}
+
+	if encoded := os.Getenv("FIELD_ENCRYPTION_KEY"); encoded != "" {
+		key, err := payload.ParseAESKey(encoded)
+		if err != nil {
+			log.Fatalf("❌ Invalid field encryption configuration: %v", err)
+		}
+		fieldEncryptionKey = key
+	}
+
+	if url := os.Getenv("OPENSEARCH_URL"); url != "" {
+		acts.Search = newOpenSearchIndexer(url, os.Getenv("OPENSEARCH_USERNAME"), os.Getenv("OPENSEARCH_PASSWORD"))
+	}
+
+	activityLog.SetEvery(activityLogSampleRate)
+	switch {
+	case objectStoreURL != "":
+		store, err := newS3ObjectStore(objectStoreURL, os.Getenv("AWS_REGION"), os.Getenv("S3_ENDPOINT"), s3Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		})
+		if err != nil {
+			log.Fatalf("❌ Invalid object store configuration: %v", err)
+		}
+		objectStore = store
+		log.Printf("   - Object Store: %s", objectStoreURL)
+	case objectStoreDir != "":
+		objectStore = newFileObjectStore(objectStoreDir)
+		log.Printf("   - Object Store: %s", objectStoreDir)
+	default:
+		log.Printf("⚠️ Object store is a local temp directory; artifacts one worker writes can't be read by another. Set OBJECT_STORE_URL or a shared OBJECT_STORE_DIR when running more than one worker")
+	}
+	metricsHandler, err := newMetricsHandler(metricsBackend, statsdAddr)
+	if err != nil {
+		log.Fatalf("❌ Invalid metrics configuration: %v", err)
+	}
+	if closer, ok := metricsHandler.(io.Closer); ok {
+		onShutdown("metrics", 0, func(context.Context) error { return closer.Close() })
+	}
+	if scrape, ok := metricsHandler.(http.Handler); ok {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", scrape)
+		metricsServer := &http.Server{Addr: ":" + metricsPort, Handler: mux}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("❌ Metrics server failed: %v", err)
+			}
+		}()
+		onShutdown("metrics-server", 5*time.Second, metricsServer.Shutdown)
+		log.Printf("📈 Serving Prometheus metrics on :%s/metrics", metricsPort)
+	}
+
+	dataConverter, err := payload.DataConverterFromEnv(metricsHandler)
+	if err != nil {
+		log.Fatalf("❌ Invalid data converter configuration: %v", err)
+	}
 
 	// Create Temporal client
 	c, err := client.Dial(client.Options{
-		HostPort:  temporalAddress,
-		Namespace: namespace,
+		HostPort:       temporalAddress,
+		Namespace:      namespace,
+		Identity:       identity,
+		Credentials:    security.Credentials,
+		DataConverter:  dataConverter,
+		MetricsHandler: metricsHandler,
+		Logger:         newWorkerLogger(metricsHandler),
+		ConnectionOptions: client.ConnectionOptions{
+			TLS:         security.TLS,
+			DialOptions: temporalconn.GRPCDialOptions(grpcMaxRecvMsgSize, grpcMaxSendMsgSize),
+		},
 	})
 	if err != nil {
 		log.Fatalf("❌ Unable to create Temporal client: %v", err)
 	}
-	defer c.Close()
+	onShutdown("temporal-client", 0, func(context.Context) error {
+		c.Close()
+		return nil
+	})
+	temporalClient = c
+	largeResults := grpcMaxRecvMsgSize > grpcDefaultMaxMsgSize || grpcMaxSendMsgSize > grpcDefaultMaxMsgSize
+	retention := logRetention(c.WorkflowService(), namespace, objectStoreDir != "" || objectStoreURL != "", largeResults)
 
 	// Create worker
-	w := worker.New(c, taskQueue, worker.Options{
-		BuildID:                             buildID,
-		UseBuildIDForVersioning:             true,
-		MaxConcurrentActivityExecutions:     10,
-		MaxConcurrentWorkflowTaskExecutions: 10,
-	})
+	workerOptions := worker.Options{
+		BuildID:                                buildID,
+		UseBuildIDForVersioning:                true,
+		MaxConcurrentActivityExecutionSize:     10,
+		MaxConcurrentWorkflowTaskExecutionSize: 10,
+		DeadlockDetectionTimeout:               deadlockDetectionTimeout,
+		WorkerStopTimeout:                      workerStopTimeout,
+		Interceptors:                           []interceptor.WorkerInterceptor{&taskQueueInterceptor{}},
+	}
+	if len(activityConcurrency) > 0 {
+		log.Printf("   - Activity Concurrency: %v", activityConcurrency)
+		workerOptions.Interceptors = append(workerOptions.Interceptors, newActivityConcurrencyInterceptor(activityConcurrency))
+	}
+	if debugCapture {
+		redactKeys := strings.Split(os.Getenv("DEBUG_CAPTURE_REDACT_KEYS"), ",")
+		workerOptions.Interceptors = append(workerOptions.Interceptors,
+			newDebugCaptureInterceptor(objectStoreDebugSink{}, redactKeys))
+	}
+	if chaosEnabled {
+		workerOptions.Interceptors = append(workerOptions.Interceptors, newChaosInterceptor(chaos))
+	}
+	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
+		transport, err := newHTTPSentryTransport(dsn, buildID)
+		if err != nil {
+			log.Fatalf("❌ Invalid Sentry configuration: %v", err)
+		}
+		reporter := newSentryReporter(transport, getEnv("SENTRY_ENVIRONMENT", os.Getenv("ENVIRONMENT")), buildID)
+		workerOptions.Interceptors = append(workerOptions.Interceptors, newSentryInterceptor(reporter))
+		onShutdown("sentry", 5*time.Second, reporter.Flush)
+		log.Printf("   - Sentry: Enabled")
+	}
+	if healthCheckCron != "" {
+		scheduleID := getEnv("HEALTHCHECK_SCHEDULE_ID", "go-worker-healthcheck-"+taskQueue)
+		created, err := ensureHealthCheckSchedule(context.Background(), c.ScheduleClient(), scheduleID, healthCheckCron, taskQueue)
+		switch {
+		case err != nil:
+			log.Fatalf("❌ Unable to schedule health checks: %v", err)
+		case created:
+			log.Printf("🗓️ Created health check schedule %s (%s)", scheduleID, healthCheckCron)
+		default:
+			log.Printf("🗓️ Health check schedule %s already exists", scheduleID)
+		}
+	}
 
-	// Register workflows and activities
-	w.RegisterWorkflow(ComplexProcessingWorkflow)
-	w.RegisterWorkflow(SystemOperationWorkflow)
-	w.RegisterWorkflow(HighPerformanceWorkflow)
+	if compatibleBuildID != "" {
+		joined, err := joinCompatibleBuild(context.Background(), c, taskQueue, buildID, compatibleBuildID)
+		switch {
+		case err != nil:
+			log.Fatalf("❌ Unable to register build %s as compatible with %s: %v", buildID, compatibleBuildID, err)
+		case joined:
+			log.Printf("🔁 Build %s now takes over runs from %s", buildID, compatibleBuildID)
+		default:
+			log.Printf("🔁 Build %s is already registered", buildID)
+		}
+	}
 
-	w.RegisterActivity(ProcessLargeDataset)
-	w.RegisterActivity(OptimizePerformance)
-	w.RegisterActivity(SystemHealthCheck)
-	w.RegisterActivity(DatabaseOperation)
-	w.RegisterActivity(CacheOperation)
-	w.RegisterActivity(AuditLog)
+	workers := newWorkerManager(c, taskQueue, workerOptions, registerWorkflowsAndActivities(acts))
 
-	log.Printf("✅ Go Worker registered workflows and activities")
+	// Start admin/health server
+	admin := newAdminServer(":"+healthPort, adminToken, workers, enablePprof, retention)
+	go func() {
+		if err := admin.ListenAndServe(); err != nil {
+			log.Fatalf("❌ Admin server failed: %v", err)
+		}
+	}()
+	onShutdown("admin-server", 5*time.Second, admin.Shutdown)
 
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -68,22 +310,80 @@ func main() {
 	go func() {
 		<-sigChan
 		log.Printf("\n🛑 Received shutdown signal, stopping Go Worker...")
+		admin.MarkStopping()
 		cancel()
 	}()
 
 	// Start worker
 	log.Printf("🔄 Worker starting...")
-	err = w.Run(worker.InterruptCh())
-	if err != nil {
+	if err := workers.Start(); err != nil {
 		log.Fatalf("❌ Unable to start worker: %v", err)
 	}
+	admin.MarkStarted()
+
+	<-ctx.Done()
+	workers.Stop()
+	workerShutdown.runAll()
 
 	log.Printf("👋 Go Worker stopped")
 }
 
+// registerWorkflowsAndActivities returns a func registering everything this
+// worker serves, with a as the activities. It runs again each time the
+// worker is re-created after a resume.
+func registerWorkflowsAndActivities(a *Activities) func(worker.Registry) {
+	return func(r worker.Registry) {
+		r.RegisterWorkflow(ComplexProcessingWorkflow)
+		r.RegisterWorkflow(SystemOperationWorkflow)
+		r.RegisterWorkflow(HighPerformanceWorkflow)
+		r.RegisterWorkflow(ComplexProcessingProtoWorkflow)
+		r.RegisterWorkflow(SystemOperationProtoWorkflow)
+		r.RegisterWorkflow(HighPerformanceProtoWorkflow)
+		r.RegisterWorkflow(RetentionWorkflow)
+		r.RegisterWorkflow(PipelineWorkflow)
+		r.RegisterWorkflow(LockManagerWorkflow)
+		r.RegisterWorkflow(RollupWorkflow)
+		r.RegisterWorkflow(HealthCheckWorkflow)
+		r.RegisterWorkflow(BatchProcessingWorkflow)
+		r.RegisterWorkflow(DAGWorkflow)
+		r.RegisterWorkflow(CanaryWorkflow)
+		r.RegisterWorkflow(PromotionWorkflow)
+		r.RegisterWorkflow(IncrementalProcessingWorkflow)
+		r.RegisterWorkflow(MultiRegionWorkflow)
+
+		r.RegisterActivity(a)
+
+		log.Printf("✅ Go Worker registered workflows and activities")
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("❌ Invalid %s %q: must be a duration such as 2s", key, value)
+	}
+	return parsed
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("❌ Invalid %s %q: must be an integer", key, value)
+	}
+	return parsed
+}
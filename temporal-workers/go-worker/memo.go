@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ResultCache stores activity results for memoization
+type ResultCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// resultCache backs memoized activities. It defaults to a cache local to
+// the worker process.
+var resultCache ResultCache = newMemoryCache()
+
+// optimizeCacheTTL is how long OptimizePerformance results are reused, from
+// OPTIMIZE_CACHE_TTL. Zero turns memoization off.
+var optimizeCacheTTL = 15 * time.Minute
+
+// memoize returns the cached result of compute for input, computing and
+// caching it on a miss. Only pure computations qualify: the key is a hash
+// of name and input, nothing else. The cache is best effort, so its errors
+// fall back to computing.
+func memoize[In, Out any](ctx context.Context, name string, ttl time.Duration, input In, compute func() (Out, error)) (Out, bool, error) {
+	if ttl <= 0 {
+		out, err := compute()
+		return out, false, err
+	}
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		out, err := compute()
+		return out, false, err
+	}
+	sum := sha256.Sum256(encoded)
+	key := name + ":" + hex.EncodeToString(sum[:])
+
+	if cached, ok, err := resultCache.Get(ctx, key); err != nil {
+		activityLog.Errorf("⚠️ Result cache lookup failed for %s: %v", name, err)
+	} else if ok {
+		var out Out
+		if err := json.Unmarshal(cached, &out); err == nil {
+			return out, true, nil
+		}
+	}
+
+	out, err := compute()
+	if err != nil {
+		return out, false, err
+	}
+	if value, err := json.Marshal(out); err == nil {
+		if err := resultCache.Set(ctx, key, value, ttl); err != nil {
+			activityLog.Errorf("⚠️ Unable to cache result of %s: %v", name, err)
+		}
+	}
+	return out, false, nil
+}
+
+// memoryCache is an in-process ResultCache. Expired entries are dropped
+// when read, or swept once the cache grows past memoryCacheSweepSize.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+const memoryCacheSweepSize = 10000
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= memoryCacheSweepSize {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = memoryCacheEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// failingCache wraps a memoryCache, failing reads with getErr and writes
+// with setErr
+type failingCache struct {
+	*memoryCache
+	getErr error
+	setErr error
+}
+
+func (c failingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if c.getErr != nil {
+		return nil, false, c.getErr
+	}
+	return c.memoryCache.Get(ctx, key)
+}
+
+func (c failingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c.setErr != nil {
+		return c.setErr
+	}
+	return c.memoryCache.Set(ctx, key, value, ttl)
+}
+
+func TestMemoize(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		wait       time.Duration
+		getErr     error
+		setErr     error
+		computeErr error
+		wantCached bool
+		wantCalls  int
+		wantErr    string
+	}{
+		{name: "second call hits", ttl: time.Minute, wantCached: true, wantCalls: 1},
+		{name: "memoization off", wantCalls: 2},
+		{name: "expired", ttl: time.Millisecond, wait: 5 * time.Millisecond, wantCalls: 2},
+		{name: "lookup fails", ttl: time.Minute, getErr: errors.New("cache unreachable"), wantCalls: 2},
+		{name: "store fails", ttl: time.Minute, setErr: errors.New("cache full"), wantCalls: 2},
+		{name: "errors not cached", ttl: time.Minute, computeErr: errors.New("boom"), wantCalls: 2, wantErr: "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := resultCache
+			t.Cleanup(func() { resultCache = prev })
+			resultCache = failingCache{memoryCache: newMemoryCache(), getErr: tt.getErr, setErr: tt.setErr}
+
+			calls := 0
+			compute := func() (int, error) {
+				calls++
+				return 40 + calls, tt.computeErr
+			}
+			first, cached, err := memoize(context.Background(), "answer", tt.ttl, map[string]int{"n": 1}, compute)
+			assert.False(t, cached)
+			time.Sleep(tt.wait)
+			second, cached, err2 := memoize(context.Background(), "answer", tt.ttl, map[string]int{"n": 1}, compute)
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantCached, cached)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.EqualError(t, err2, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, err2)
+			assert.Equal(t, 41, first)
+			if tt.wantCached {
+				assert.Equal(t, first, second)
+			} else {
+				assert.Equal(t, 42, second)
+			}
+		})
+	}
+}
+
+func TestOptimizePerformanceMemoized(t *testing.T) {
+	prev := resultCache
+	t.Cleanup(func() { resultCache = prev })
+	resultCache = newMemoryCache()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities)
+	optimize := func(throughput float64) OptimizePerformanceResult {
+		value, err := env.ExecuteActivity(activities.OptimizePerformance, OptimizePerformanceInput{DatasetID: "ds-1", Algorithm: "adaptive", Metrics: ProcessingMetrics{Throughput: throughput}})
+		require.NoError(t, err)
+		var result OptimizePerformanceResult
+		require.NoError(t, value.Get(&result))
+		return result
+	}
+
+	first := optimize(100)
+	assert.False(t, first.FromCache)
+	second := optimize(100)
+	assert.True(t, second.FromCache)
+	assert.Equal(t, first.PerformanceGain, second.PerformanceGain)
+	assert.Equal(t, first.NewMetrics, second.NewMetrics)
+	assert.False(t, optimize(200).FromCache, "different metrics share a cache entry")
+}
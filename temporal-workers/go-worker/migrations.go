@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeUnsupportedInputVersion is returned, non-retryable, for inputs
+// written by a newer client than this worker understands
+const ErrTypeUnsupportedInputVersion = "UnsupportedInputVersion"
+
+// CurrentComplexProcessingInputVersion is the ComplexProcessingInput shape
+// this worker reads natively. Inputs without a version are version 1.
+//
+//	v1: process_type and priority are free-form strings, as sent before
+//	    they became enums; anything goes, in any case
+//	v2: process_type and priority are enum values
+const CurrentComplexProcessingInputVersion = 2
+
+// complexProcessingInputMigrations upgrades an input from version i+1 to
+// version i+2. Migrations must be pure so replays see the same input.
+var complexProcessingInputMigrations = []func(ComplexProcessingInput) ComplexProcessingInput{
+	migrateComplexProcessingInputV1,
+}
+
+// migrateComplexProcessingInput upgrades older input shapes to the current
+// one, so starts queued by older clients keep working during a rollout
+func migrateComplexProcessingInput(input ComplexProcessingInput) (ComplexProcessingInput, error) {
+	version := input.Version
+	if version == 0 {
+		version = 1
+	}
+	if version < 0 || version > CurrentComplexProcessingInputVersion {
+		return input, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unsupported ComplexProcessingInput version %d (this worker supports 1 to %d)", input.Version, CurrentComplexProcessingInputVersion),
+			ErrTypeUnsupportedInputVersion, nil)
+	}
+	for v := version; v < CurrentComplexProcessingInputVersion; v++ {
+		input = complexProcessingInputMigrations[v-1](input)
+	}
+	input.Version = CurrentComplexProcessingInputVersion
+	return input, nil
+}
+
+// migrateComplexProcessingInputV1 maps the free-form process type and
+// priority onto the enums. Values that differ only in case or surrounding
+// space are kept; other process types are left to routing, as the old
+// worker processed them on its default path, and other priorities are
+// normal, since the old worker ignored priority.
+func migrateComplexProcessingInputV1(input ComplexProcessingInput) ComplexProcessingInput {
+	input.ProcessType = ProcessType(strings.ToLower(strings.TrimSpace(string(input.ProcessType))))
+	if !validEnum(input.ProcessType, processTypes) {
+		input.ProcessType = ""
+	}
+	input.Priority = Priority(strings.ToLower(strings.TrimSpace(string(input.Priority))))
+	if !validEnum(input.Priority, priorities) {
+		input.Priority = ""
+	}
+	return input
+}
+
+// UnmarshalJSON decodes any input version. Version 1 predates the enums,
+// so its process_type and priority are read as they are and left to
+// migrateComplexProcessingInputV1; later versions must use enum values.
+func (input *ComplexProcessingInput) UnmarshalJSON(data []byte) error {
+	type plain ComplexProcessingInput
+	var raw struct {
+		plain
+		// Shadow the enum fields so their decoders don't reject v1 values
+		ProcessType string `json:"process_type"`
+		Priority    string `json:"priority"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*input = ComplexProcessingInput(raw.plain)
+	input.ProcessType = ProcessType(raw.ProcessType)
+	input.Priority = Priority(raw.Priority)
+	if input.Version <= 1 {
+		return nil
+	}
+	if !validEnum(input.ProcessType, processTypes) {
+		return errInvalidEnum("process_type", input.ProcessType, processTypes)
+	}
+	if !validEnum(input.Priority, priorities) {
+		return errInvalidEnum("priority", input.Priority, priorities)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestMigrateComplexProcessingInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    ComplexProcessingInput
+		// wantDecodeErr fails decoding; wantErrType fails the migration
+		wantDecodeErr string
+		wantErrType   string
+	}{
+		{
+			name:    "v1 in another case",
+			payload: `{"dataset_id": "ds-1", "process_type": " PARALLEL", "parameters": {"batch_size": 500}, "priority": "High"}`,
+			want: ComplexProcessingInput{
+				Version: CurrentComplexProcessingInputVersion, DatasetID: "ds-1", ProcessType: ProcessTypeParallel,
+				Parameters: Parameters{"batch_size": json.Number("500")}, Priority: PriorityHigh,
+			},
+		},
+		{
+			name:    "v1 values the enums don't have",
+			payload: `{"dataset_id": "ds-1", "process_type": "turbo", "parameters": {}, "priority": "urgent"}`,
+			want:    ComplexProcessingInput{Version: CurrentComplexProcessingInputVersion, DatasetID: "ds-1", Parameters: Parameters{}},
+		},
+		{
+			name:    "explicit v1",
+			payload: `{"version": 1, "dataset_id": "ds-1", "process_type": "Batch", "priority": "LOW"}`,
+			want:    ComplexProcessingInput{Version: CurrentComplexProcessingInputVersion, DatasetID: "ds-1", ProcessType: ProcessTypeBatch, Priority: PriorityLow},
+		},
+		{
+			name:    "current",
+			payload: `{"version": 2, "dataset_id": "ds-1", "process_type": "standard", "priority": "critical", "health_threshold": 0.5}`,
+			want: ComplexProcessingInput{
+				Version: CurrentComplexProcessingInputVersion, DatasetID: "ds-1", ProcessType: ProcessTypeStandard,
+				Priority: PriorityCritical, HealthThreshold: 0.5,
+			},
+		},
+		{
+			name:          "current with a free-form process type",
+			payload:       `{"version": 2, "dataset_id": "ds-1", "process_type": "PARALLEL"}`,
+			wantDecodeErr: `invalid process_type "PARALLEL"`,
+		},
+		{
+			name:          "current with a free-form priority",
+			payload:       `{"version": 2, "dataset_id": "ds-1", "priority": "urgent"}`,
+			wantDecodeErr: `invalid priority "urgent"`,
+		},
+		{
+			name:        "newer than this worker",
+			payload:     `{"version": 3, "dataset_id": "ds-1"}`,
+			wantErrType: ErrTypeUnsupportedInputVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input ComplexProcessingInput
+			err := json.Unmarshal([]byte(tt.payload), &input)
+			if tt.wantDecodeErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantDecodeErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := migrateComplexProcessingInput(input)
+			if tt.wantErrType != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, validateComplexProcessingEnums(got))
+		})
+	}
+}
+
+// TestDAGNodeJSON checks types embedding the input still decode their own
+// fields alongside it
+func TestDAGNodeJSON(t *testing.T) {
+	var node DAGNode
+	require.NoError(t, json.Unmarshal([]byte(`{"dataset_id": "ds-2", "process_type": "Batch", "depends_on": ["ds-1"]}`), &node))
+	assert.Equal(t, DAGNode{ComplexProcessingInput: ComplexProcessingInput{DatasetID: "ds-2", ProcessType: "Batch"}, DependsOn: []string{"ds-1"}}, node)
+
+	encoded, err := json.Marshal(DAGNode{ComplexProcessingInput: ComplexProcessingInput{Version: 2, DatasetID: "ds-2"}, DependsOn: []string{"ds-1"}})
+	require.NoError(t, err)
+	var decoded DAGNode
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, []string{"ds-1"}, decoded.DependsOn)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"version": 2, "dataset_id": "ds-2", "process_type": "turbo"}`), &decoded))
+}
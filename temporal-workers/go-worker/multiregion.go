@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeUnknownRegion is returned, without retries, when a shard's region
+// has no task queue configured
+const ErrTypeUnknownRegion = "UnknownRegion"
+
+// regionTaskQueues maps regions to the task queues their region-local
+// workers poll, from REGION_TASK_QUEUES
+var regionTaskQueues map[string]string
+
+// parseRegionTaskQueues parses REGION_TASK_QUEUES, a comma-separated list
+// of region=task-queue pairs. Queues get the same ENVIRONMENT prefix as
+// TASK_QUEUE.
+func parseRegionTaskQueues(spec string, prefix func(string) string) (map[string]string, error) {
+	queues := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		region, queue, ok := strings.Cut(entry, "=")
+		region, queue = strings.TrimSpace(region), strings.TrimSpace(queue)
+		if !ok || region == "" || queue == "" {
+			return nil, fmt.Errorf("region task queue %q: want region=task-queue", entry)
+		}
+		queues[region] = prefix(queue)
+	}
+	return queues, nil
+}
+
+// RegionShard is the part of a dataset that lives in one region
+type RegionShard struct {
+	Region string                 `json:"region"`
+	Input  ComplexProcessingInput `json:"input"`
+}
+
+// MultiRegionInput represents input for the multi-region workflow
+type MultiRegionInput struct {
+	Shards []RegionShard `json:"shards"`
+}
+
+// RegionStatus reports how one region's child went
+type RegionStatus struct {
+	Region     string                   `json:"region"`
+	TaskQueue  string                   `json:"task_queue"`
+	WorkflowID string                   `json:"workflow_id"`
+	DatasetID  string                   `json:"dataset_id"`
+	Status     string                   `json:"status"` // completed or failed
+	Error      string                   `json:"error,omitempty"`
+	Result     *ComplexProcessingResult `json:"result,omitempty"`
+}
+
+// MultiRegionResult aggregates the regional runs
+type MultiRegionResult struct {
+	Status         string         `json:"status"` // completed or partial
+	Completed      int            `json:"completed"`
+	Failed         int            `json:"failed"`
+	ProcessedItems int            `json:"processed_items"`
+	Regions        []RegionStatus `json:"regions"`
+}
+
+// MultiRegionWorkflow processes each shard in its home region by running a
+// ComplexProcessingWorkflow child on the region's task queue, so only
+// region-local workers touch the data, and aggregates the results here.
+// Shards run in parallel; a failed region doesn't stop the others. The
+// region mapping is recorded when the run starts, so replays and workers
+// configured differently dispatch to the same queues.
+func MultiRegionWorkflow(ctx workflow.Context, input MultiRegionInput) (MultiRegionResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🌍 Starting multi-region workflow", "shards", len(input.Shards))
+	logWorkflowInput(ctx, input)
+
+	var queues map[string]string
+	err := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return regionTaskQueues
+	}).Get(&queues)
+	if err != nil {
+		return MultiRegionResult{}, err
+	}
+	var unknown []string
+	for _, shard := range input.Shards {
+		if queues[shard.Region] == "" {
+			unknown = append(unknown, shard.Region)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return MultiRegionResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("no task queue configured for regions %s (see REGION_TASK_QUEUES)", strings.Join(unknown, ", ")),
+			ErrTypeUnknownRegion, nil)
+	}
+
+	result := MultiRegionResult{Regions: make([]RegionStatus, len(input.Shards))}
+	parentID := workflow.GetInfo(ctx).WorkflowExecution.ID
+	futures := make([]workflow.ChildWorkflowFuture, len(input.Shards))
+	// Shards in the same region need their own child IDs; versioned so
+	// runs started with region-only IDs replay unchanged
+	indexedIDs := workflow.GetVersion(ctx, "region-shard-ids", workflow.DefaultVersion, 1) == 1
+	for i, shard := range input.Shards {
+		childID := fmt.Sprintf("%s-%s", parentID, shard.Region)
+		if indexedIDs {
+			childID = fmt.Sprintf("%s-%s-%d", parentID, shard.Region, i)
+		}
+		result.Regions[i] = RegionStatus{
+			Region:     shard.Region,
+			TaskQueue:  queues[shard.Region],
+			WorkflowID: childID,
+			DatasetID:  shard.Input.DatasetID,
+		}
+		childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			WorkflowID: childID,
+			TaskQueue:  queues[shard.Region],
+		})
+		logger.Info("🚚 Dispatching shard", "region", shard.Region, "task_queue", queues[shard.Region], "dataset_id", shard.Input.DatasetID)
+		futures[i] = workflow.ExecuteChildWorkflow(childCtx, ComplexProcessingWorkflow, shard.Input)
+	}
+
+	for i, future := range futures {
+		var childResult ComplexProcessingResult
+		if err := future.Get(ctx, &childResult); err != nil {
+			logger.Error("❌ Region failed", "region", result.Regions[i].Region, "error", err)
+			result.Regions[i].Status = "failed"
+			result.Regions[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+		result.Regions[i].Status = "completed"
+		result.Regions[i].Result = &childResult
+		result.Completed++
+		result.ProcessedItems += childResult.ProcessedItems
+	}
+
+	result.Status = "completed"
+	if result.Failed > 0 {
+		result.Status = "partial"
+	}
+	logger.Info("✅ Multi-region workflow completed", "completed", result.Completed, "failed", result.Failed)
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+// useRegionTaskQueues sets regionTaskQueues for the test
+func useRegionTaskQueues(t *testing.T, queues map[string]string) {
+	prev := regionTaskQueues
+	t.Cleanup(func() { regionTaskQueues = prev })
+	regionTaskQueues = queues
+}
+
+func TestParseRegionTaskQueues(t *testing.T) {
+	prefix := func(queue string) string { return "prod-" + queue }
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", spec: "", want: map[string]string{}},
+		{
+			name: "pairs",
+			spec: " eu-west-1 = go-workers-eu ,us-east-1=go-workers-us,",
+			want: map[string]string{"eu-west-1": "prod-go-workers-eu", "us-east-1": "prod-go-workers-us"},
+		},
+		{name: "missing queue", spec: "eu-west-1=", wantErr: true},
+		{name: "missing separator", spec: "eu-west-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRegionTaskQueues(tt.spec, prefix)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMultiRegionWorkflow(t *testing.T) {
+	useRegionTaskQueues(t, map[string]string{"eu-west-1": "go-workers-eu", "us-east-1": "go-workers-us"})
+	type child struct {
+		ID, TaskQueue, DatasetID string
+	}
+	// Both eu-west-1 shards get their own child
+	wantIDs := []string{"parent-eu-west-1-0", "parent-eu-west-1-1", "parent-us-east-1-2"}
+	tests := []struct {
+		name string
+		// fail names the dataset whose child fails
+		fail        string
+		wantStatus  string
+		wantItems   int
+		wantResults []string
+	}{
+		{
+			name:        "all regions complete",
+			wantStatus:  "completed",
+			wantItems:   30,
+			wantResults: []string{"completed", "completed", "completed"},
+		},
+		{
+			name:        "one region fails",
+			fail:        "ds-us",
+			wantStatus:  "partial",
+			wantItems:   20,
+			wantResults: []string{"completed", "completed", "failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "parent"})
+			env.RegisterWorkflow(ComplexProcessingWorkflow)
+
+			var children []child
+			env.OnWorkflow(ComplexProcessingWorkflow, mock.Anything, mock.Anything).Return(
+				func(ctx workflow.Context, input ComplexProcessingInput) (ComplexProcessingResult, error) {
+					info := workflow.GetInfo(ctx)
+					children = append(children, child{ID: info.WorkflowExecution.ID, TaskQueue: info.TaskQueueName, DatasetID: input.DatasetID})
+					if input.DatasetID == tt.fail {
+						return ComplexProcessingResult{}, errors.New("processing failed")
+					}
+					return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "completed", ProcessedItems: 10}, nil
+				})
+
+			env.ExecuteWorkflow(MultiRegionWorkflow, MultiRegionInput{Shards: []RegionShard{
+				{Region: "eu-west-1", Input: ComplexProcessingInput{DatasetID: "ds-eu-a"}},
+				{Region: "eu-west-1", Input: ComplexProcessingInput{DatasetID: "ds-eu-b"}},
+				{Region: "us-east-1", Input: ComplexProcessingInput{DatasetID: "ds-us"}},
+			}})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result MultiRegionResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			sort.Slice(children, func(i, j int) bool { return children[i].ID < children[j].ID })
+			assert.Equal(t, []child{
+				{ID: wantIDs[0], TaskQueue: "go-workers-eu", DatasetID: "ds-eu-a"},
+				{ID: wantIDs[1], TaskQueue: "go-workers-eu", DatasetID: "ds-eu-b"},
+				{ID: wantIDs[2], TaskQueue: "go-workers-us", DatasetID: "ds-us"},
+			}, children)
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantItems, result.ProcessedItems)
+			var statuses []string
+			for i, region := range result.Regions {
+				assert.Equal(t, wantIDs[i], region.WorkflowID)
+				statuses = append(statuses, region.Status)
+			}
+			assert.Equal(t, tt.wantResults, statuses)
+		})
+	}
+}
+
+func TestMultiRegionWorkflowUnknownRegion(t *testing.T) {
+	useRegionTaskQueues(t, map[string]string{"eu-west-1": "go-workers-eu"})
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(ComplexProcessingWorkflow)
+
+	env.ExecuteWorkflow(MultiRegionWorkflow, MultiRegionInput{Shards: []RegionShard{
+		{Region: "eu-west-1", Input: ComplexProcessingInput{DatasetID: "ds-eu"}},
+		{Region: "us-east-1", Input: ComplexProcessingInput{DatasetID: "ds-us"}},
+		{Region: "ap-south-1", Input: ComplexProcessingInput{DatasetID: "ds-ap"}},
+	}})
+	require.True(t, env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	var appErr *temporal.ApplicationError
+	require.True(t, errors.As(err, &appErr), "want an application error, got %v", err)
+	assert.Equal(t, ErrTypeUnknownRegion, appErr.Type())
+	assert.True(t, appErr.NonRetryable())
+	assert.Contains(t, appErr.Error(), "ap-south-1, us-east-1")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.temporal.io/api/workflowservice/v1"
+	"google.golang.org/grpc"
+)
+
+// grpcDefaultMaxMsgSize is gRPC's own per-call message limit, used when
+// GRPC_MAX_*_MSG_SIZE are unset
+const grpcDefaultMaxMsgSize = 4 << 20
+
+// namespaceDescriber is the part of the workflow service the retention
+// check needs
+type namespaceDescriber interface {
+	DescribeNamespace(ctx context.Context, in *workflowservice.DescribeNamespaceRequest, opts ...grpc.CallOption) (*workflowservice.DescribeNamespaceResponse, error)
+}
+
+// retentionReport is the namespace retention as reported on /healthz.
+// Workflow results live in history only until retention prunes it; past
+// that only results persisted to the object store can be read.
+type retentionReport struct {
+	Namespace         string `json:"namespace"`
+	Retention         string `json:"retention"`
+	ResultPersistence bool   `json:"result_persistence"`
+	Warning           string `json:"warning,omitempty"`
+}
+
+// describeRetention reads the namespace's workflow execution retention.
+// resultPersistence is whether results are persisted somewhere durable, and
+// largeResults whether this worker is configured for results beyond gRPC's
+// default size; together they decide whether to warn.
+func describeRetention(ctx context.Context, service namespaceDescriber, namespace string, resultPersistence, largeResults bool) (retentionReport, error) {
+	resp, err := service.DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{Namespace: namespace})
+	if err != nil {
+		return retentionReport{}, fmt.Errorf("describing namespace %s: %w", namespace, err)
+	}
+	retention := resp.GetConfig().GetWorkflowExecutionRetentionTtl().AsDuration()
+	report := retentionReport{
+		Namespace:         namespace,
+		Retention:         retention.String(),
+		ResultPersistence: resultPersistence,
+	}
+	if largeResults && !resultPersistence {
+		report.Warning = fmt.Sprintf("large results are only kept in workflow history, which namespace %s prunes after %s; set OBJECT_STORE_URL or OBJECT_STORE_DIR to a durable store and start runs with persist_result", namespace, retention)
+	}
+	return report, nil
+}
+
+// logRetention reports the namespace retention at startup. A failed
+// describe is logged and leaves /healthz without the report; it never
+// stops the worker.
+func logRetention(service namespaceDescriber, namespace string, resultPersistence, largeResults bool) *retentionReport {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	report, err := describeRetention(ctx, service, namespace, resultPersistence, largeResults)
+	if err != nil {
+		log.Printf("⚠️ Unable to read namespace retention: %v", err)
+		return nil
+	}
+	log.Printf("🗄️ Namespace %s retains workflow histories for %s", report.Namespace, report.Retention)
+	if report.Warning != "" {
+		log.Printf("⚠️ %s", report.Warning)
+	}
+	return &report
+}
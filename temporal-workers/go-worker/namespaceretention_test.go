@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	namespacepb "go.temporal.io/api/namespace/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// fakeDescriber reports retention for every namespace, or fails with err
+type fakeDescriber struct {
+	retention time.Duration
+	err       error
+}
+
+func (d fakeDescriber) DescribeNamespace(ctx context.Context, in *workflowservice.DescribeNamespaceRequest, opts ...grpc.CallOption) (*workflowservice.DescribeNamespaceResponse, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &workflowservice.DescribeNamespaceResponse{
+		Config: &namespacepb.NamespaceConfig{WorkflowExecutionRetentionTtl: durationpb.New(d.retention)},
+	}, nil
+}
+
+func TestLogRetention(t *testing.T) {
+	tests := []struct {
+		name              string
+		describer         fakeDescriber
+		resultPersistence bool
+		largeResults      bool
+		wantWarning       bool
+		wantLog           string
+	}{
+		{name: "persisted", describer: fakeDescriber{retention: 72 * time.Hour}, resultPersistence: true, largeResults: true, wantLog: "Namespace orders retains workflow histories for 72h0m0s"},
+		{name: "small results", describer: fakeDescriber{retention: 72 * time.Hour}, wantLog: "Namespace orders retains workflow histories for 72h0m0s"},
+		{name: "large results not persisted", describer: fakeDescriber{retention: 24 * time.Hour}, largeResults: true, wantWarning: true, wantLog: "prunes after 24h0m0s"},
+		{name: "describe fails", describer: fakeDescriber{err: errors.New("permission denied")}, wantLog: "Unable to read namespace retention: describing namespace orders: permission denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+
+			report := logRetention(tt.describer, "orders", tt.resultPersistence, tt.largeResults)
+			assert.Contains(t, logs.String(), tt.wantLog)
+			if tt.describer.err != nil {
+				assert.Nil(t, report)
+				return
+			}
+			require.NotNil(t, report)
+			assert.Equal(t, "orders", report.Namespace)
+			assert.Equal(t, tt.describer.retention.String(), report.Retention)
+			assert.Equal(t, tt.resultPersistence, report.ResultPersistence)
+			assert.Equal(t, tt.wantWarning, report.Warning != "", report.Warning)
+		})
+	}
+}
+
+func TestAdminHealthzRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention *retentionReport
+		want      string
+	}{
+		{
+			name:      "reported",
+			retention: &retentionReport{Namespace: "orders", Retention: "72h0m0s", ResultPersistence: true},
+			want:      `{"status": "ok", "paused": false, "retention": {"namespace": "orders", "retention": "72h0m0s", "result_persistence": true}}`,
+		},
+		{name: "describe failed", want: `{"status": "ok", "paused": false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newAdminServer(":0", "secret", newWorkerManager(nil, "go-workers", worker.Options{}, nil), false, tt.retention).server.Handler
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
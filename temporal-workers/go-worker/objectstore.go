@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ObjectRef points at a stored object
+type ObjectRef struct {
+	URI  string `json:"uri"`
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// ObjectStore stores activity artifacts (exports, normalized copies, ...).
+// fileObjectStore and s3ObjectStore implement it; other blob stores plug in
+// here.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (ObjectRef, error)
+	// Create is Put for a key that doesn't exist yet. If it does, even when
+	// another writer got there first, nothing is written and the error
+	// wraps fs.ErrExist.
+	Create(ctx context.Context, key string, r io.Reader) (ObjectRef, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (ObjectRef, error)
+}
+
+// objectStore is the store used by activities. It defaults to the local
+// filesystem, which only the worker that wrote an object can read;
+// OBJECT_STORE_DIR chooses the root directory (a shared volume lets workers
+// share it) and OBJECT_STORE_URL an S3 bucket instead.
+var objectStore ObjectStore = newFileObjectStore(filepath.Join(os.TempDir(), "go-worker-objects"))
+
+// fileObjectStore stores objects as files under a root directory
+type fileObjectStore struct {
+	root string
+}
+
+func newFileObjectStore(root string) *fileObjectStore {
+	return &fileObjectStore{root: root}
+}
+
+func (s *fileObjectStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, strings.TrimPrefix(clean, "/")), nil
+}
+
+// Put writes the object atomically so readers never see partial content
+func (s *fileObjectStore) Put(ctx context.Context, key string, r io.Reader) (ObjectRef, error) {
+	return s.write(key, r, os.Rename)
+}
+
+// Create writes the object like Put, but links it into place, which fails
+// if the file exists
+func (s *fileObjectStore) Create(ctx context.Context, key string, r io.Reader) (ObjectRef, error) {
+	return s.write(key, r, os.Link)
+}
+
+// write copies r to a temporary file and moves it into place with publish
+func (s *fileObjectStore) write(key string, r io.Reader, publish func(oldpath, newpath string) error) (ObjectRef, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return ObjectRef{}, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	if err := publish(tmp.Name(), path); err != nil {
+		return ObjectRef{}, err
+	}
+	return ObjectRef{URI: "file://" + path, Key: key, Size: size}, nil
+}
+
+// Get opens the object for reading
+func (s *fileObjectStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Stat returns a reference to an existing object
+func (s *fileObjectStore) Stat(ctx context.Context, key string) (ObjectRef, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	return ObjectRef{URI: "file://" + path, Key: key, Size: info.Size()}, nil
+}
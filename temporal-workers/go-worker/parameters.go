@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// Parameters is a free-form parameter map. Unlike a plain
+// map[string]interface{}, it decodes JSON numbers as json.Number so large
+// integer IDs don't silently become float64 and lose precision.
+type Parameters map[string]interface{}
+
+// mapOrEmpty returns m, or an empty map if m is nil, so callers that omit a
+// map field can't trigger nil-map writes further down
+func mapOrEmpty[M ~map[K]V, K comparable, V any](m M) M {
+	if m == nil {
+		return make(M)
+	}
+	return m
+}
+
+// UnmarshalJSON decodes the map keeping numbers exact
+func (p *Parameters) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	*p = m
+	return nil
+}
+
+// String returns the value for key if it is a string
+func (p Parameters) String(key string) (string, bool) {
+	s, ok := p[key].(string)
+	return s, ok
+}
+
+// Int64 returns the value for key if it is an integer, without going
+// through float64 for json.Number values
+func (p Parameters) Int64(key string) (int64, bool) {
+	switch v := p[key].(type) {
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return 0, false
+		}
+		return int64(v), true
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Float64 returns the value for key if it is numeric
+func (p Parameters) Float64(key string) (float64, bool) {
+	switch v := p[key].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Bool returns the value for key if it is a boolean
+func (p Parameters) Bool(key string) (bool, bool) {
+	b, ok := p[key].(bool)
+	return b, ok
+}
+
+// Strings returns the value for key if it is a list of strings
+func (p Parameters) Strings(key string) ([]string, bool) {
+	switch v := p[key].(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
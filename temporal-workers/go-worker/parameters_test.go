@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParametersUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		key     string
+		wantInt int64
+		// wantOK is whether Int64 returns the value
+		wantOK    bool
+		wantFloat float64
+		wantErr   string
+	}{
+		// 2^53+1 is the first integer a float64 can't hold
+		{name: "large id kept exact", payload: `{"id": 9007199254740993}`, key: "id", wantInt: 9007199254740993, wantOK: true, wantFloat: 9007199254740992},
+		{name: "negative", payload: `{"offset": -42}`, key: "offset", wantInt: -42, wantOK: true, wantFloat: -42},
+		{name: "fraction isn't an integer", payload: `{"ratio": 0.75}`, key: "ratio", wantFloat: 0.75},
+		{name: "not a number", payload: `{"id": "abc"}`, key: "id"},
+		{name: "invalid JSON", payload: `{"id": 1`, wantErr: "unexpected end of JSON input"},
+		{name: "not an object", payload: `[1, 2]`, wantErr: "cannot unmarshal array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Parameters
+			err := json.Unmarshal([]byte(tt.payload), &p)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got, ok := p.Int64(tt.key)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantInt, got)
+			f, _ := p.Float64(tt.key)
+			assert.Equal(t, tt.wantFloat, f)
+		})
+	}
+}
+
+// TestParametersRoundTrip checks json.Number values encode back to the
+// digits they were decoded from
+func TestParametersRoundTrip(t *testing.T) {
+	const payload = `{"id": 9007199254740993, "nested": {"big": 12345678901234567890}, "list": [1, 2.5]}`
+	var p Parameters
+	require.NoError(t, json.Unmarshal([]byte(payload), &p))
+	encoded, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, payload, string(encoded))
+	assert.Contains(t, string(encoded), "9007199254740993")
+}
+
+func TestMapOrEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Parameters
+		want Parameters
+	}{
+		{name: "nil", in: nil, want: Parameters{}},
+		{name: "empty", in: Parameters{}, want: Parameters{}},
+		{name: "kept", in: Parameters{"a": 1}, want: Parameters{"a": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapOrEmpty(tt.in)
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, got)
+			got["written"] = true
+		})
+	}
+}
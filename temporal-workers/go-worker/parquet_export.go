@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.temporal.io/sdk/activity"
+
+	"temporal-go-worker/internal/parquet"
+)
+
+// parquetRecordSchema is the schema of exported ProcessedRecord rows
+var parquetRecordSchema = []parquet.Column{
+	{Name: "dataset_id", Type: parquet.String},
+	{Name: "id", Type: parquet.String},
+	{Name: "status", Type: parquet.String},
+	{Name: "score", Type: parquet.Double},
+	{Name: "processed_at", Type: parquet.Int64},
+}
+
+const parquetHeartbeatEvery = 1000
+
+// ExportParquetInput represents input for exporting records to Parquet
+type ExportParquetInput struct {
+	DatasetID string            `json:"dataset_id"`
+	Records   []ProcessedRecord `json:"records"`
+	Key       string            `json:"key"`
+}
+
+// ExportParquetResult represents the exported Parquet object
+type ExportParquetResult struct {
+	Object ObjectRef `json:"object"`
+	Rows   int64     `json:"rows"`
+}
+
+// ExportParquet writes record-level processing output to a Parquet object
+func (a *Activities) ExportParquet(ctx context.Context, input ExportParquetInput) (ExportParquetResult, error) {
+	key := input.Key
+	if key == "" {
+		key = fmt.Sprintf("exports/%s.parquet", input.DatasetID)
+	}
+	activityLog.Infof("📦 Exporting %d records to Parquet: %s", len(input.Records), key)
+
+	pr, pw := io.Pipe()
+	rows := make(chan int64, 1)
+	go func() {
+		n, err := writeParquetRecords(ctx, pw, input)
+		rows <- n
+		pw.CloseWithError(err)
+	}()
+
+	ref, err := objectStore.Put(ctx, key, pr)
+	pr.CloseWithError(err)
+	written := <-rows
+	if err != nil {
+		return ExportParquetResult{}, err
+	}
+
+	activityLog.Infof("✅ Parquet export completed: %d rows, %d bytes", written, ref.Size)
+	return ExportParquetResult{Object: ref, Rows: written}, nil
+}
+
+func writeParquetRecords(ctx context.Context, w io.Writer, input ExportParquetInput) (int64, error) {
+	pw, err := parquet.NewWriter(w, parquetRecordSchema, 0)
+	if err != nil {
+		return 0, err
+	}
+	for i, r := range input.Records {
+		if err := pw.Write([]interface{}{input.DatasetID, r.ID, r.Status, r.Score, r.ProcessedAt}); err != nil {
+			return pw.NumRows(), err
+		}
+		if (i+1)%parquetHeartbeatEvery == 0 {
+			activity.RecordHeartbeat(ctx, i+1)
+			if ctx.Err() != nil {
+				return pw.NumRows(), ctx.Err()
+			}
+		}
+	}
+	return pw.NumRows(), pw.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"temporal-go-worker/internal/parquet/parquettest"
+)
+
+// readParquetObject reads back a Parquet object from objectStore
+func readParquetObject(t *testing.T, key string) parquettest.File {
+	r, err := objectStore.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	file, err := parquettest.Read(data)
+	require.NoError(t, err)
+	return file
+}
+
+func TestExportParquet(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []ProcessedRecord
+		key     string
+		wantKey string
+	}{
+		{name: "no records", wantKey: "exports/dataset-1.parquet"},
+		{
+			name: "records",
+			records: []ProcessedRecord{
+				{ID: "r-1", Status: "processed", Score: 0.9, ProcessedAt: 1760000000},
+				{ID: "r-2", Status: "quarantined", Score: 0.1, ProcessedAt: 1760000001},
+			},
+			key:     "exports/custom.parquet",
+			wantKey: "exports/custom.parquet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCountingStore(t)
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.ExportParquet, ExportParquetInput{DatasetID: "dataset-1", Records: tt.records, Key: tt.key})
+			require.NoError(t, err)
+			var result ExportParquetResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantKey, result.Object.Key)
+			assert.Equal(t, int64(len(tt.records)), result.Rows)
+
+			file := readParquetObject(t, tt.wantKey)
+			assert.Equal(t, parquetRecordSchema, file.Columns)
+			assert.Equal(t, int64(len(tt.records)), file.NumRows)
+			require.Len(t, file.Rows, len(tt.records))
+			for i, r := range tt.records {
+				assert.Equal(t, []interface{}{"dataset-1", r.ID, r.Status, r.Score, r.ProcessedAt}, file.Rows[i])
+			}
+		})
+	}
+}
+
+func TestExportParquetStoreUnavailable(t *testing.T) {
+	store := useCountingStore(t)
+	store.failPut = 1
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities)
+
+	_, err := env.ExecuteActivity(activities.ExportParquet, ExportParquetInput{
+		DatasetID: "dataset-1",
+		Records:   []ProcessedRecord{{ID: "r-1", Status: "processed"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "store unavailable")
+	assert.Empty(t, store.writes)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const defaultPipelineMaxRuns = 3
+
+// ErrTypePipelineStageFailed is returned when a stage still fails on the
+// last run. Its details are the stages that succeeded, so a retry of the
+// workflow under its RetryPolicy skips them too.
+const ErrTypePipelineStageFailed = "PipelineStageFailed"
+
+// PipelineStage is one stage of a pipeline, run as the named activity with
+// Input as its single argument
+type PipelineStage struct {
+	Name     string                 `json:"name"`
+	Activity string                 `json:"activity"`
+	Input    map[string]interface{} `json:"input"`
+}
+
+// PipelineInput represents input for the pipeline workflow. RetryDelay is a
+// duration string such as "30s". CompletedStages and Run are carried across
+// restarts and should be left empty by callers.
+type PipelineInput struct {
+	DatasetID       string          `json:"dataset_id"`
+	Stages          []PipelineStage `json:"stages"`
+	MaxRuns         int             `json:"max_runs"`
+	RetryDelay      Duration        `json:"retry_delay"`
+	CompletedStages map[string]bool `json:"completed_stages,omitempty"`
+	Run             int             `json:"run,omitempty"`
+}
+
+// PipelineResult represents the result of a pipeline run
+type PipelineResult struct {
+	DatasetID string   `json:"dataset_id"`
+	Status    string   `json:"status"`
+	Executed  []string `json:"executed"`
+	Skipped   []string `json:"skipped"`
+	Runs      int      `json:"runs"`
+}
+
+// PipelineWorkflow runs stages in order. When a stage fails after its
+// activity retries are exhausted, the workflow restarts itself via
+// continue-as-new carrying the set of stages that already succeeded, so the
+// rerun skips them and resumes at the failed stage. The completed set lives
+// in workflow input/state, so it is reproduced exactly on replay.
+//
+// After MaxRuns runs the workflow fails with ErrTypePipelineStageFailed,
+// carrying the completed set. A retry under the workflow's RetryPolicy
+// starts from the failed run's input and reads the set back from that
+// failure, so it resumes at the failed stage as well. Failures that aren't
+// a stage's, such as timeouts, carry no set and the retry reruns the stages
+// the failed run's input hadn't completed.
+func PipelineWorkflow(ctx workflow.Context, input PipelineInput) (PipelineResult, error) {
+	for i := range input.Stages {
+		input.Stages[i].Input = mapOrEmpty(input.Stages[i].Input)
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🧩 Starting pipeline workflow", "dataset_id", input.DatasetID, "stages", len(input.Stages), "run", input.Run+1)
+	logWorkflowInput(ctx, input)
+
+	maxRuns := input.MaxRuns
+	if maxRuns <= 0 {
+		maxRuns = defaultPipelineMaxRuns
+	}
+	input.CompletedStages = mapOrEmpty(input.CompletedStages)
+	for stage := range retriedStages(ctx) {
+		input.CompletedStages[stage] = true
+	}
+
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 10 * time.Minute}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    30 * time.Second,
+		MaximumAttempts:    3,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return PipelineResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	result := PipelineResult{
+		DatasetID: input.DatasetID,
+		Status:    "running",
+		Runs:      input.Run + 1,
+	}
+
+	history := watchHistoryLength(ctx)
+	for _, stage := range input.Stages {
+		history.check()
+		if input.CompletedStages[stage.Name] {
+			logger.Info("⏭️ Skipping already-succeeded stage", "stage", stage.Name)
+			result.Skipped = append(result.Skipped, stage.Name)
+			continue
+		}
+
+		logger.Info("▶️ Running pipeline stage", "stage", stage.Name, "activity", stage.Activity)
+		err := workflow.ExecuteActivity(ctx, stage.Activity, stage.Input).Get(ctx, nil)
+		if err != nil {
+			logger.Error("❌ Pipeline stage failed", "stage", stage.Name, "error", err)
+			if input.Run+1 >= maxRuns {
+				result.Status = "failed"
+				return result, temporal.NewApplicationErrorWithCause(
+					fmt.Sprintf("pipeline stage %q failed after %d runs", stage.Name, result.Runs),
+					ErrTypePipelineStageFailed, err, input.CompletedStages)
+			}
+
+			if input.RetryDelay.Duration > 0 {
+				if err := workflow.Sleep(ctx, input.RetryDelay.Duration); err != nil {
+					return result, err
+				}
+			}
+			input.Run++
+			return result, workflow.NewContinueAsNewError(ctx, PipelineWorkflow, input)
+		}
+
+		input.CompletedStages[stage.Name] = true
+		result.Executed = append(result.Executed, stage.Name)
+	}
+
+	result.Status = "completed"
+	logger.Info("✅ Pipeline workflow completed", "executed", result.Executed, "skipped", result.Skipped)
+	return result, nil
+}
+
+// retriedStages returns the stages a previous attempt completed, when this
+// run retries one that failed with ErrTypePipelineStageFailed
+func retriedStages(ctx workflow.Context) map[string]bool {
+	var appErr *temporal.ApplicationError
+	if !errors.As(workflow.GetLastError(ctx), &appErr) || appErr.Type() != ErrTypePipelineStageFailed || !appErr.HasDetails() {
+		return nil
+	}
+	var completed map[string]bool
+	if err := appErr.Details(&completed); err != nil {
+		workflow.GetLogger(ctx).Warn("⚠️ Unable to read the stages the previous attempt completed", "error", err)
+		return nil
+	}
+	return completed
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestPipelineWorkflow(t *testing.T) {
+	stages := []PipelineStage{
+		{Name: "extract", Activity: "pipelineExtract"},
+		{Name: "transform", Activity: "pipelineTransform"},
+		{Name: "load", Activity: "pipelineLoad"},
+	}
+
+	tests := []struct {
+		name string
+		// failing is the activity that fails every time
+		failing   string
+		maxRuns   int
+		completed map[string]bool
+		// lastError is the failure of the attempt this one retries
+		lastError error
+
+		wantExecuted    []string
+		wantSkipped     []string
+		wantContinued   *PipelineInput
+		wantErrType     string
+		wantErrComplete map[string]bool
+	}{
+		{name: "all stages", wantExecuted: []string{"extract", "transform", "load"}},
+		{
+			name:         "completed stage skipped on rerun",
+			completed:    map[string]bool{"extract": true},
+			wantExecuted: []string{"transform", "load"},
+			wantSkipped:  []string{"extract"},
+		},
+		{
+			name:    "failed stage continues as new",
+			failing: "pipelineTransform",
+			wantContinued: &PipelineInput{
+				DatasetID:       "dataset-1",
+				Stages:          stages,
+				RetryDelay:      Duration{30 * time.Second},
+				CompletedStages: map[string]bool{"extract": true},
+				Run:             1,
+			},
+			wantExecuted: []string{"extract"},
+		},
+		{
+			name:            "last run fails with the completed stages",
+			failing:         "pipelineTransform",
+			maxRuns:         1,
+			wantExecuted:    []string{"extract"},
+			wantErrType:     ErrTypePipelineStageFailed,
+			wantErrComplete: map[string]bool{"extract": true},
+		},
+		{
+			name:         "retry resumes after the completed stages",
+			lastError:    temporal.NewApplicationError("pipeline stage failed", ErrTypePipelineStageFailed, map[string]bool{"extract": true}),
+			wantExecuted: []string{"transform", "load"},
+			wantSkipped:  []string{"extract"},
+		},
+		{
+			name:         "retry after another failure",
+			lastError:    temporal.NewTimeoutError(0, nil),
+			wantExecuted: []string{"extract", "transform", "load"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			var mu sync.Mutex
+			var executed []string
+			for _, stage := range stages {
+				stage := stage
+				env.RegisterActivityWithOptions(func(ctx context.Context, input map[string]interface{}) error {
+					if stage.Activity == tt.failing {
+						return temporal.NewNonRetryableApplicationError("stage broke", "StageBroke", nil)
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					executed = append(executed, stage.Name)
+					return nil
+				}, activity.RegisterOptions{Name: stage.Activity})
+			}
+			if tt.lastError != nil {
+				env.SetLastError(tt.lastError)
+			}
+
+			env.ExecuteWorkflow(PipelineWorkflow, PipelineInput{
+				DatasetID:       "dataset-1",
+				Stages:          stages,
+				MaxRuns:         tt.maxRuns,
+				RetryDelay:      Duration{30 * time.Second},
+				CompletedStages: tt.completed,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			assert.Equal(t, tt.wantExecuted, executed)
+			err := env.GetWorkflowError()
+
+			switch {
+			case tt.wantContinued != nil:
+				var continued *workflow.ContinueAsNewError
+				require.True(t, errors.As(err, &continued), "got %v", err)
+				var next PipelineInput
+				require.NoError(t, converter.GetDefaultDataConverter().FromPayloads(continued.Input, &next))
+				for i := range next.Stages {
+					next.Stages[i].Input = nil
+				}
+				assert.Equal(t, *tt.wantContinued, next)
+			case tt.wantErrType != "":
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "got %v", err)
+				assert.Equal(t, tt.wantErrType, appErr.Type())
+				assert.False(t, appErr.NonRetryable())
+				var completed map[string]bool
+				require.NoError(t, appErr.Details(&completed))
+				assert.Equal(t, tt.wantErrComplete, completed)
+			default:
+				require.NoError(t, err)
+				var result PipelineResult
+				require.NoError(t, env.GetWorkflowResult(&result))
+				assert.Equal(t, "completed", result.Status)
+				assert.Equal(t, tt.wantExecuted, result.Executed)
+				assert.Equal(t, tt.wantSkipped, result.Skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Dataset processors selectable with DATASET_PROCESSOR
+const (
+	DatasetProcessorSimulated = "simulated"
+)
+
+// DatasetProcessor does the work of ProcessLargeDataset. It gets the input
+// with parameters defaulted and returns the result without a schema
+// version; the activity handles result versions and logging. Errors are
+// returned as they are, so processors decide what is retryable. Processors
+// heartbeat at least every processDatasetHeartbeatInterval and return once
+// ctx is done.
+type DatasetProcessor interface {
+	Process(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error)
+}
+
+// newDatasetProcessor returns the processor named by DATASET_PROCESSOR.
+// Real processors are added here.
+func newDatasetProcessor(name string) (DatasetProcessor, error) {
+	switch name {
+	case "", DatasetProcessorSimulated:
+		return SimulatedProcessor{}, nil
+	default:
+		return nil, fmt.Errorf("unknown dataset processor %q (want %q)", name, DatasetProcessorSimulated)
+	}
+}
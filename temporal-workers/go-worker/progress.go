@@ -0,0 +1,101 @@
+package main
+
+import (
+	"go.temporal.io/sdk/workflow"
+)
+
+// DashboardProgressSignalName is the signal carrying ProgressUpdate to a
+// dashboard workflow
+const DashboardProgressSignalName = "progress"
+
+// dashboardWorkflowParameter names the dashboard workflow to report to
+const dashboardWorkflowParameter = "dashboard_workflow_id"
+
+// ProgressUpdate reports how far a run has got
+type ProgressUpdate struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+	DatasetID  string `json:"dataset_id"`
+	Step       string `json:"step,omitempty"`
+	Percent    int    `json:"percent"`
+	Status     string `json:"status"`
+	// SignalID is set on the final update to "<workflow id>/<run id>/completed",
+	// the same for every completion of a run, so a dashboard can apply one
+	// completion per run (e.g. with seenSignals)
+	SignalID string `json:"signal_id,omitempty"`
+}
+
+// progressReporter signals a dashboard workflow after every step. Signals
+// aren't waited on, and a missing dashboard only logs a warning.
+type progressReporter struct {
+	ctx         workflow.Context
+	dashboardID string
+	datasetID   string
+	planned     int
+	finished    int
+	percent     int
+}
+
+// newProgressReporter returns nil when no dashboard is configured; a nil
+// reporter does nothing. planned is how many steps the run expects.
+func newProgressReporter(ctx workflow.Context, dashboardID, datasetID string, planned int) *progressReporter {
+	if dashboardID == "" {
+		return nil
+	}
+	if planned < 1 {
+		planned = 1
+	}
+	return &progressReporter{ctx: ctx, dashboardID: dashboardID, datasetID: datasetID, planned: planned}
+}
+
+// stepFinished reports progress after a step. Percent stays below 100
+// until the run finishes, since the plan is an estimate.
+func (p *progressReporter) stepFinished(step StepTiming) {
+	if p == nil {
+		return
+	}
+	p.finished++
+	percent := p.finished * 100 / p.planned
+	if percent > 99 {
+		percent = 99
+	}
+	if percent < p.percent {
+		percent = p.percent
+	}
+	p.send(step.Name, percent, "processing", "")
+}
+
+// finish reports 100% with the run's final status
+func (p *progressReporter) finish(status string) {
+	if p == nil {
+		return
+	}
+	info := workflow.GetInfo(p.ctx)
+	p.send("", 100, status, completionSignalID(info.WorkflowExecution.ID, info.WorkflowExecution.RunID))
+}
+
+// completionSignalID identifies a run's completion. Unlike newSignalID it
+// doesn't depend on history length, so a run that completes twice sends
+// the same ID both times.
+func completionSignalID(workflowID, runID string) string {
+	return workflowID + "/" + runID + "/completed"
+}
+
+func (p *progressReporter) send(step string, percent int, status, signalID string) {
+	p.percent = percent
+	info := workflow.GetInfo(p.ctx)
+	future := workflow.SignalExternalWorkflow(p.ctx, p.dashboardID, "", DashboardProgressSignalName, ProgressUpdate{
+		WorkflowID: info.WorkflowExecution.ID,
+		RunID:      info.WorkflowExecution.RunID,
+		DatasetID:  p.datasetID,
+		Step:       step,
+		Percent:    percent,
+		Status:     status,
+		SignalID:   signalID,
+	})
+	workflow.Go(p.ctx, func(ctx workflow.Context) {
+		if err := future.Get(ctx, nil); err != nil {
+			workflow.GetLogger(ctx).Warn("⚠️ Unable to signal dashboard", "dashboard_workflow_id", p.dashboardID, "error", err)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestComplexProcessingDashboardProgress(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters Parameters
+		signalErr  error
+		wantSteps  []string
+		wantWarn   bool
+	}{
+		{
+			name:       "progress signalled",
+			parameters: Parameters{dashboardWorkflowParameter: "dash-1"},
+			wantSteps:  []string{"fetch_metadata", "health_check", "process_dataset", "optimize_performance", "cache_results", "audit_log", ""},
+		},
+		{
+			name:       "dashboard missing",
+			parameters: Parameters{dashboardWorkflowParameter: "dash-1"},
+			signalErr:  errors.New("workflow not found"),
+			wantSteps:  []string{"fetch_metadata", "health_check", "process_dataset", "optimize_performance", "cache_results", "audit_log", ""},
+			wantWarn:   true,
+		},
+		{name: "no dashboard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			var suite testsuite.WorkflowTestSuite
+			suite.SetLogger(logger)
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "wf-1"})
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+			var mu sync.Mutex
+			var updates []ProgressUpdate
+			env.OnSignalExternalWorkflow(mock.Anything, "dash-1", "", DashboardProgressSignalName, mock.Anything).Return(
+				func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+					mu.Lock()
+					defer mu.Unlock()
+					updates = append(updates, arg.(ProgressUpdate))
+					return tt.signalErr
+				})
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:    CurrentComplexProcessingInputVersion,
+				DatasetID:  "ds-1",
+				Parameters: tt.parameters,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			mu.Lock()
+			defer mu.Unlock()
+			var steps []string
+			last := 0
+			for _, update := range updates {
+				steps = append(steps, update.Step)
+				assert.Equal(t, "wf-1", update.WorkflowID)
+				assert.Equal(t, "ds-1", update.DatasetID)
+				assert.GreaterOrEqual(t, update.Percent, last)
+				last = update.Percent
+			}
+			assert.Equal(t, tt.wantSteps, steps)
+			if len(updates) > 0 {
+				final := updates[len(updates)-1]
+				assert.Equal(t, 100, final.Percent)
+				assert.Equal(t, "completed", final.Status)
+				assert.Equal(t, completionSignalID("wf-1", final.RunID), final.SignalID)
+				assert.Less(t, updates[len(updates)-2].Percent, 100)
+			}
+			assert.Equal(t, tt.wantWarn, logger.warns["⚠️ Unable to signal dashboard"] != nil)
+		})
+	}
+}
+
+func TestPlannedComplexProcessingSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		input ComplexProcessingInput
+		want  int
+	}{
+		{name: "base", want: 6},
+		{name: "with sinks and export", input: ComplexProcessingInput{Sinks: []SinkConfig{{}}, Parameters: Parameters{"export_parquet": true}}, want: 8},
+		{name: "with source", input: ComplexProcessingInput{Parameters: Parameters{"source_key": "in.csv"}}, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, plannedComplexProcessingSteps(tt.input))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// complexProcessingResultFields are the JSON names of
+// ComplexProcessingResult's fields
+var complexProcessingResultFields = jsonFieldNames(reflect.TypeOf(ComplexProcessingResult{}))
+
+// projectResult limits the encoded result to the requested fields. Unknown
+// names are dropped with a warning rather than failing a finished run.
+func projectResult(ctx workflow.Context, result *ComplexProcessingResult, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	projection := make([]string, 0, len(fields))
+	var unknown []string
+	for _, f := range fields {
+		if complexProcessingResultFields[f] {
+			projection = append(projection, f)
+		} else {
+			unknown = append(unknown, f)
+		}
+	}
+	if len(unknown) > 0 {
+		workflow.GetLogger(ctx).Warn("⚠️ Ignoring unknown result fields", "fields", unknown)
+	}
+	result.projection = projection
+	if len(projection) == 0 {
+		// Nothing valid was asked for; an empty object would hide that
+		result.projection = nil
+	}
+}
+
+// MarshalJSON encodes only the projected fields when a projection is set
+func (r ComplexProcessingResult) MarshalJSON() ([]byte, error) {
+	type plain ComplexProcessingResult
+	raw, err := json.Marshal(plain(r))
+	if err != nil || len(r.projection) == 0 {
+		return raw, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	projected := make(map[string]json.RawMessage, len(r.projection))
+	for _, f := range r.projection {
+		if v, ok := all[f]; ok {
+			projected[f] = v
+		}
+	}
+	return json.Marshal(projected)
+}
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestComplexProcessingResultProjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		// wantFields nil means the full result
+		wantFields  []string
+		wantUnknown string
+	}{
+		{name: "no projection"},
+		{name: "projected", fields: []string{"status", "processed_items"}, wantFields: []string{"status", "processed_items"}},
+		{name: "unknown field dropped", fields: []string{"status", "throughput"}, wantFields: []string{"status"}, wantUnknown: "[throughput]"},
+		{name: "only unknown fields", fields: []string{"throughput"}, wantUnknown: "[throughput]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			var suite testsuite.WorkflowTestSuite
+			suite.SetLogger(logger)
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:   CurrentComplexProcessingInputVersion,
+				DatasetID: "ds-1",
+				Fields:    tt.fields,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var got map[string]json.RawMessage
+			require.NoError(t, env.GetWorkflowResult(&got))
+
+			var keys []string
+			for k := range got {
+				keys = append(keys, k)
+			}
+			if tt.wantFields != nil {
+				assert.ElementsMatch(t, tt.wantFields, keys)
+			} else {
+				for _, k := range keys {
+					assert.True(t, complexProcessingResultFields[k], k)
+				}
+				assert.Contains(t, keys, "steps")
+				assert.Contains(t, keys, "dataset_id")
+			}
+			if tt.wantUnknown != "" {
+				assert.Equal(t, tt.wantUnknown, keyvalString(logger.warns["⚠️ Ignoring unknown result fields"], "fields"))
+			} else {
+				assert.NotContains(t, logger.warns, "⚠️ Ignoring unknown result fields")
+			}
+		})
+	}
+}
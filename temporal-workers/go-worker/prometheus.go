@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/uber-go/tally/v4"
+	"github.com/uber-go/tally/v4/prometheus"
+	"go.temporal.io/sdk/client"
+)
+
+// prometheusReportInterval is how often tally reports to the Prometheus
+// registry
+const prometheusReportInterval = time.Second
+
+// prometheusHandler reports SDK and worker metrics through a tally scope
+// to a Prometheus registry, which is also the /metrics handler. Counters
+// and gauges keep their names; timers become histograms in seconds.
+type prometheusHandler struct {
+	client.MetricsHandler
+	http.Handler
+	io.Closer
+}
+
+func newPrometheusHandler() *prometheusHandler {
+	registry := prom.NewRegistry()
+	reporter := prometheus.NewReporter(prometheus.Options{
+		Registerer:       registry,
+		DefaultTimerType: prometheus.HistogramTimerType,
+		// The default panics, e.g. when a metric name is reused with
+		// different tags; losing one metric is better than the worker
+		OnRegisterError: func(err error) {
+			log.Printf("⚠️ Unable to register Prometheus metric: %v", err)
+		},
+	})
+	scope, closer := tally.NewRootScope(tally.ScopeOptions{
+		CachedReporter:  reporter,
+		Separator:       prometheus.DefaultSeparator,
+		SanitizeOptions: &prometheus.DefaultSanitizerOpts,
+		// tally's own cardinality gauges aren't worker metrics
+		OmitCardinalityMetrics: true,
+	}, prometheusReportInterval)
+	return &prometheusHandler{
+		MetricsHandler: tallyMetricsHandler{scope: scope},
+		Handler:        reporter.HTTPHandler(),
+		Closer:         closer,
+	}
+}
+
+// tallyMetricsHandler adapts a tally scope to the SDK's metrics handler, as
+// go.temporal.io/sdk/contrib/tally does
+type tallyMetricsHandler struct {
+	scope tally.Scope
+}
+
+// WithTags returns a handler that adds tags to every metric
+func (h tallyMetricsHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	return tallyMetricsHandler{scope: h.scope.Tagged(tags)}
+}
+
+// Counter returns a counter
+func (h tallyMetricsHandler) Counter(name string) client.MetricsCounter {
+	return h.scope.Counter(name)
+}
+
+// Gauge returns a gauge
+func (h tallyMetricsHandler) Gauge(name string) client.MetricsGauge {
+	return h.scope.Gauge(name)
+}
+
+// Timer returns a timer
+func (h tallyMetricsHandler) Timer(name string) client.MetricsTimer {
+	return h.scope.Timer(name)
+}
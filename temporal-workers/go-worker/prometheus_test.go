@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	namespacepb "go.temporal.io/api/namespace/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
+)
+
+func metricsTestActivity(ctx context.Context, name string) (string, error) {
+	return "hello " + name, nil
+}
+
+// fakeFrontend is just enough of the Temporal frontend for a worker to
+// start and poll. Polls come back empty.
+type fakeFrontend struct {
+	workflowservice.UnimplementedWorkflowServiceServer
+	// polls counts polls that returned empty
+	polls atomic.Int32
+}
+
+func (f *fakeFrontend) GetSystemInfo(ctx context.Context, req *workflowservice.GetSystemInfoRequest) (*workflowservice.GetSystemInfoResponse, error) {
+	return &workflowservice.GetSystemInfoResponse{Capabilities: &workflowservice.GetSystemInfoResponse_Capabilities{}}, nil
+}
+
+func (f *fakeFrontend) DescribeNamespace(ctx context.Context, req *workflowservice.DescribeNamespaceRequest) (*workflowservice.DescribeNamespaceResponse, error) {
+	return &workflowservice.DescribeNamespaceResponse{NamespaceInfo: &namespacepb.NamespaceInfo{Name: req.GetNamespace()}}, nil
+}
+
+func (f *fakeFrontend) PollWorkflowTaskQueue(ctx context.Context, req *workflowservice.PollWorkflowTaskQueueRequest) (*workflowservice.PollWorkflowTaskQueueResponse, error) {
+	f.emptyPoll(ctx)
+	return &workflowservice.PollWorkflowTaskQueueResponse{}, nil
+}
+
+func (f *fakeFrontend) PollActivityTaskQueue(ctx context.Context, req *workflowservice.PollActivityTaskQueueRequest) (*workflowservice.PollActivityTaskQueueResponse, error) {
+	f.emptyPoll(ctx)
+	return &workflowservice.PollActivityTaskQueueResponse{}, nil
+}
+
+func (f *fakeFrontend) emptyPoll(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(20 * time.Millisecond):
+		f.polls.Add(1)
+	}
+}
+
+// TestPrometheusMetricsEndpoint starts a worker with the Prometheus backend
+// against a fake frontend and checks /metrics serves the SDK's metrics
+func TestPrometheusMetricsEndpoint(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	frontend := &fakeFrontend{}
+	server := grpc.NewServer()
+	workflowservice.RegisterWorkflowServiceServer(server, frontend)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	handler, err := newMetricsHandler(MetricsBackendPrometheus, "")
+	require.NoError(t, err)
+	metrics := handler.(*prometheusHandler)
+
+	c, err := client.Dial(client.Options{HostPort: listener.Addr().String(), MetricsHandler: metrics})
+	require.NoError(t, err)
+	w := worker.New(c, "metrics-test", worker.Options{})
+	w.RegisterActivity(metricsTestActivity)
+	require.NoError(t, w.Start())
+	require.Eventually(t, func() bool { return frontend.polls.Load() >= 6 }, 10*time.Second, 10*time.Millisecond)
+	w.Stop()
+	c.Close()
+	// Closing the scope reports what hasn't been reported yet
+	require.NoError(t, metrics.Close())
+
+	scrape := httptest.NewServer(metrics)
+	defer scrape.Close()
+	resp, err := http.Get(scrape.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	families := scrapeFamilies(t, resp)
+
+	tests := []struct {
+		family string
+		kind   string
+	}{
+		{family: "temporal_worker_start", kind: "counter"},
+		{family: "temporal_poller_start", kind: "counter"},
+		{family: "temporal_request", kind: "counter"},
+		{family: "temporal_request_latency", kind: "histogram"},
+		{family: "temporal_long_request", kind: "counter"},
+		{family: "temporal_activity_poll_no_task", kind: "counter"},
+		{family: "temporal_worker_task_slots_available", kind: "gauge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.family, func(t *testing.T) {
+			assert.Equal(t, tt.kind, families[tt.family])
+		})
+	}
+}
+
+func TestNewMetricsHandler(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    interface{}
+		wantErr bool
+	}{
+		{backend: "", want: client.MetricsNopHandler},
+		{backend: MetricsBackendNone, want: client.MetricsNopHandler},
+		{backend: MetricsBackendPrometheus, want: &prometheusHandler{}},
+		{backend: "graphite", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			handler, err := newMetricsHandler(tt.backend, "")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, handler)
+			if closer, ok := handler.(*prometheusHandler); ok {
+				closer.Close()
+			}
+		})
+	}
+}
+
+// scrapeFamilies returns the type of every metric family in a scrape
+func scrapeFamilies(t *testing.T, resp *http.Response) map[string]string {
+	families := map[string]string{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 4 && fields[1] == "TYPE" {
+			families[fields[2]] = fields[3]
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return families
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	logs := captureLog(t)
+	metrics := newPrometheusHandler()
+	tagged := metrics.WithTags(map[string]string{"task_queue": "orders"})
+	tagged.Counter("records_processed").Inc(3)
+	tagged.Gauge("queue_depth").Update(7)
+	tagged.Timer("step_latency").Record(250 * time.Millisecond)
+	// The same name with other tag keys can't be registered; the metric is
+	// dropped and logged rather than panicking the worker
+	metrics.WithTags(map[string]string{"region": "eu"}).Counter("records_processed").Inc(1)
+	require.NoError(t, metrics.Close())
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `records_processed{task_queue="orders"} 3`)
+	assert.Contains(t, body, `queue_depth{task_queue="orders"} 7`)
+	assert.Contains(t, body, `step_latency_count{task_queue="orders"} 1`)
+	assert.Contains(t, body, `step_latency_sum{task_queue="orders"} 0.25`)
+	assert.NotContains(t, body, `region="eu"`)
+	assert.Contains(t, logs.String(), "Unable to register Prometheus metric")
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrTypeStagingValidationFailed is returned when staged data doesn't match
+// what was processed; the staging table has been dropped by then
+const ErrTypeStagingValidationFailed = "StagingValidationFailed"
+
+// PromotionInput represents input for the promotion workflow
+type PromotionInput struct {
+	DatasetID   string      `json:"dataset_id"`
+	Target      string      `json:"target"` // production table
+	ProcessType ProcessType `json:"process_type"`
+	Parameters  Parameters  `json:"parameters"`
+}
+
+// PromotionResult represents the outcome of a promotion
+type PromotionResult struct {
+	DatasetID  string     `json:"dataset_id"`
+	Target     string     `json:"target"`
+	Staging    string     `json:"staging"`
+	Status     string     `json:"status"` // promoted or rolled_back
+	Validation TableStats `json:"validation"`
+	Message    string     `json:"message"`
+}
+
+// TableStats summarizes a table's contents
+type TableStats struct {
+	Rows     int64  `json:"rows"`
+	Checksum string `json:"checksum"`
+}
+
+// StagingTableInput names a staging table and, for loads, its records
+type StagingTableInput struct {
+	Table   string            `json:"table"`
+	Records []ProcessedRecord `json:"records,omitempty"`
+}
+
+// ValidateStagingInput represents input for validating a staging table
+type ValidateStagingInput struct {
+	Table    string     `json:"table"`
+	Expected TableStats `json:"expected"`
+}
+
+// PromoteStagingInput represents input for swapping staging into place
+type PromoteStagingInput struct {
+	Target  string `json:"target"`
+	Staging string `json:"staging"`
+}
+
+// TableInspector reads row counts and checksums. Checksums must be
+// computed as recordsChecksum does: sha256 over each record's JSON, in ID
+// order.
+type TableInspector interface {
+	TableStats(ctx context.Context, table string) (TableStats, error)
+}
+
+// tableInspector is used by ValidateStaging. It defaults to an in-memory
+// stand-in fed by LoadStaging; assign a real implementation alongside
+// Activities.DB.
+var tableInspector TableInspector = simulatedTables
+
+// PromotionWorkflow processes a dataset into a staging table, checks that
+// the staged rows match what was processed, and only then swaps staging
+// into production. A load or validation failure drops staging and leaves
+// production untouched.
+func PromotionWorkflow(ctx workflow.Context, input PromotionInput) (PromotionResult, error) {
+	input.Parameters = mapOrEmpty(input.Parameters)
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🔀 Starting promotion workflow", "dataset_id", input.DatasetID, "target", input.Target)
+	logWorkflowInput(ctx, input)
+
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 10 * time.Minute}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    30 * time.Second,
+		MaximumAttempts:    3,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return PromotionResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	staging := input.Target + "_staging"
+	result := PromotionResult{DatasetID: input.DatasetID, Target: input.Target, Staging: staging}
+
+	var processed ProcessLargeDatasetResult
+	err = workflow.ExecuteActivity(ctx, activities.ProcessLargeDataset, ProcessLargeDatasetInput{
+		DatasetID:   input.DatasetID,
+		ProcessType: input.ProcessType,
+		Parameters:  mergeParameters(input.Parameters, Parameters{"emit_records": true}),
+	}).Get(ctx, &processed)
+	if err != nil {
+		logger.Error("❌ Failed to process dataset", "error", err)
+		result.Status = "failed"
+		result.Message = "Dataset processing failed: " + err.Error()
+		return result, err
+	}
+
+	expected, err := recordsStats(processed.Records)
+	if err != nil {
+		result.Status = "failed"
+		result.Message = err.Error()
+		return result, err
+	}
+
+	rollback := func(reason string, cause error) (PromotionResult, error) {
+		logger.Error("↩️ Rolling back promotion", "staging", staging, "reason", reason)
+		if err := workflow.ExecuteActivity(ctx, activities.DropStaging, StagingTableInput{Table: staging}).Get(ctx, nil); err != nil {
+			logger.Error("❌ Failed to drop staging table", "staging", staging, "error", err)
+		}
+		result.Status = "rolled_back"
+		result.Message = reason
+		return result, cause
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.LoadStaging, StagingTableInput{Table: staging, Records: processed.Records}).Get(ctx, nil)
+	if err != nil {
+		return rollback("Loading staging failed: "+err.Error(), err)
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.ValidateStaging, ValidateStagingInput{Table: staging, Expected: expected}).Get(ctx, &result.Validation)
+	if err != nil {
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.HasDetails() {
+			_ = appErr.Details(&result.Validation)
+		}
+		return rollback("Staging validation failed: "+err.Error(), err)
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.PromoteStaging, PromoteStagingInput{Target: input.Target, Staging: staging}).Get(ctx, nil)
+	if err != nil {
+		// The swap is a single transaction, so production is unchanged
+		return rollback("Promotion failed: "+err.Error(), err)
+	}
+
+	result.Status = "promoted"
+	result.Message = fmt.Sprintf("Promoted %d rows to %s", expected.Rows, input.Target)
+	logger.Info("✅ Promotion workflow completed", "target", input.Target, "rows", expected.Rows)
+	return result, nil
+}
+
+// recordsStats returns the row count and checksum records should have once
+// loaded
+func recordsStats(records []ProcessedRecord) (TableStats, error) {
+	checksum, err := recordsChecksum(records)
+	return TableStats{Rows: int64(len(records)), Checksum: checksum}, err
+}
+
+// recordsChecksum hashes each record's JSON in ID order
+func recordsChecksum(records []ProcessedRecord) (string, error) {
+	sorted := append([]ProcessedRecord(nil), records...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	h := sha256.New()
+	for _, r := range sorted {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return "", err
+		}
+		h.Write(line)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stagingInsertBatch is how many records go into one INSERT
+const stagingInsertBatch = 500
+
+// LoadStaging recreates the staging table and inserts the records
+func (a *Activities) LoadStaging(ctx context.Context, input StagingTableInput) error {
+	activityLog.Infof("📥 Loading %d records into %s", len(input.Records), input.Table)
+
+	table, err := sqlIdentifier(input.Table)
+	if err != nil {
+		return err
+	}
+	db := newTracedExecer(a.DB, "load_staging", input.Table, activity.GetMetricsHandler(ctx))
+	query := fmt.Sprintf("DROP TABLE IF EXISTS %[1]s; CREATE TABLE %[1]s (id TEXT PRIMARY KEY, data JSONB NOT NULL)", table)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return classifySQLError(err)
+	}
+	for start := 0; start < len(input.Records); start += stagingInsertBatch {
+		end := start + stagingInsertBatch
+		if end > len(input.Records) {
+			end = len(input.Records)
+		}
+		var values strings.Builder
+		args := make([]interface{}, 0, 2*(end-start))
+		for i, r := range input.Records[start:end] {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			fmt.Fprintf(&values, "($%d, $%d)", 2*i+1, 2*i+2)
+			args = append(args, r.ID, data)
+		}
+		insert := fmt.Sprintf("INSERT INTO %s (id, data) VALUES %s", table, values.String())
+		if _, err := db.ExecContext(ctx, insert, args...); err != nil {
+			return classifySQLError(err)
+		}
+		activity.RecordHeartbeat(ctx, end)
+	}
+	if simulated, ok := tableInspector.(*simulatedTableStore); ok {
+		simulated.load(input.Table, input.Records)
+	}
+
+	activityLog.Infof("✅ Loaded %d records into %s", len(input.Records), input.Table)
+	return nil
+}
+
+// ValidateStaging compares a staging table's row count and checksum with
+// what was processed. A mismatch won't fix itself, so it isn't retried.
+func (a *Activities) ValidateStaging(ctx context.Context, input ValidateStagingInput) (TableStats, error) {
+	activityLog.Infof("🔎 Validating staging table %s", input.Table)
+
+	actual, err := tableInspector.TableStats(ctx, input.Table)
+	if err != nil {
+		return TableStats{}, err
+	}
+	if actual.Rows != input.Expected.Rows || actual.Checksum != input.Expected.Checksum {
+		activityLog.Errorf("❌ Staging table %s has %d rows (checksum %s), expected %d (checksum %s)",
+			input.Table, actual.Rows, actual.Checksum, input.Expected.Rows, input.Expected.Checksum)
+		return actual, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("staging table %s has %d rows, expected %d, or its checksum differs", input.Table, actual.Rows, input.Expected.Rows),
+			ErrTypeStagingValidationFailed, nil, actual)
+	}
+
+	activityLog.Infof("✅ Staging table %s validated (%d rows)", input.Table, actual.Rows)
+	return actual, nil
+}
+
+// PromoteStaging swaps the staging table into place in one transaction, so
+// readers see either the old or the new table
+func (a *Activities) PromoteStaging(ctx context.Context, input PromoteStagingInput) error {
+	activityLog.Infof("🔀 Promoting %s to %s", input.Staging, input.Target)
+
+	target, err := sqlIdentifier(input.Target)
+	if err != nil {
+		return err
+	}
+	previous, err := sqlIdentifier(input.Target + "_previous")
+	if err != nil {
+		return err
+	}
+	staging, err := sqlIdentifier(input.Staging)
+	if err != nil {
+		return err
+	}
+	// RENAME TO takes a bare name, without the schema
+	targetName := target[strings.LastIndex(target, ".")+1:]
+	previousName := previous[strings.LastIndex(previous, ".")+1:]
+	db := newTracedExecer(a.DB, "promote_staging", input.Target, activity.GetMetricsHandler(ctx))
+	query := fmt.Sprintf(`BEGIN;
+DROP TABLE IF EXISTS %[1]s;
+ALTER TABLE IF EXISTS %[2]s RENAME TO %[3]s;
+ALTER TABLE %[4]s RENAME TO %[5]s;
+COMMIT;`, previous, target, previousName, staging, targetName)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return classifySQLError(err)
+	}
+
+	activityLog.Infof("✅ Promoted %s to %s", input.Staging, input.Target)
+	return nil
+}
+
+// DropStaging removes a staging table
+func (a *Activities) DropStaging(ctx context.Context, input StagingTableInput) error {
+	activityLog.Infof("🗑️ Dropping staging table %s", input.Table)
+
+	table, err := sqlIdentifier(input.Table)
+	if err != nil {
+		return err
+	}
+	db := newTracedExecer(a.DB, "drop_staging", input.Table, activity.GetMetricsHandler(ctx))
+	if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+		return classifySQLError(err)
+	}
+	if simulated, ok := tableInspector.(*simulatedTableStore); ok {
+		simulated.drop(input.Table)
+	}
+	return nil
+}
+
+// simulatedTables remembers what LoadStaging wrote, standing in for
+// reading the table back in local runs
+var simulatedTables = &simulatedTableStore{tables: map[string]TableStats{}}
+
+type simulatedTableStore struct {
+	mu     sync.Mutex
+	tables map[string]TableStats
+}
+
+func (s *simulatedTableStore) load(table string, records []ProcessedRecord) {
+	stats, _ := recordsStats(records)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[table] = stats
+}
+
+func (s *simulatedTableStore) drop(table string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tables, table)
+}
+
+func (s *simulatedTableStore) TableStats(ctx context.Context, table string) (TableStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tables[table], nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fixedInspector reports the same stats for every table
+type fixedInspector TableStats
+
+func (i fixedInspector) TableStats(ctx context.Context, table string) (TableStats, error) {
+	return TableStats(i), nil
+}
+
+func TestPromotionWorkflow(t *testing.T) {
+	records := []ProcessedRecord{{ID: "r2", Status: "ok", Score: 0.5}, {ID: "r1", Status: "ok", Score: 0.25}}
+	tests := []struct {
+		name      string
+		target    string
+		inspector TableInspector
+		dbErr     error
+		wantErr   string
+		// wantLast is the last statement run
+		wantLast string
+	}{
+		{name: "promoted", target: "analytics.results", wantLast: `ALTER TABLE "analytics"."results_staging" RENAME TO "results"`},
+		{
+			name:      "validation mismatch rolled back",
+			target:    "analytics.results",
+			inspector: fixedInspector{Rows: 1, Checksum: "stale"},
+			wantErr:   ErrTypeStagingValidationFailed,
+			wantLast:  `DROP TABLE IF EXISTS "analytics"."results_staging"`,
+		},
+		{
+			name:     "load failure rolled back",
+			target:   "analytics.results",
+			dbErr:    stateError{"23505", "duplicate key"},
+			wantErr:  ErrTypeConstraintViolation,
+			wantLast: `DROP TABLE IF EXISTS "analytics"."results_staging"`,
+		},
+		{name: "invalid target", target: "results; DROP TABLE users", wantErr: ErrTypeInvalidTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := tableInspector
+			t.Cleanup(func() { tableInspector = prev })
+			tableInspector = &simulatedTableStore{tables: map[string]TableStats{}}
+			if tt.inspector != nil {
+				tableInspector = tt.inspector
+			}
+			db := &recordingExecer{err: tt.dbErr}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&Activities{DB: db})
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 2, Records: records}, nil)
+
+			env.ExecuteWorkflow(PromotionWorkflow, PromotionInput{DatasetID: "ds-1", Target: tt.target, ProcessType: "batch"})
+			require.True(t, env.IsWorkflowCompleted())
+			if tt.wantErr != "" {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(env.GetWorkflowError(), &appErr), "want an application error, got %v", env.GetWorkflowError())
+				assert.Equal(t, tt.wantErr, appErr.Type())
+			} else {
+				require.NoError(t, env.GetWorkflowError())
+				var result PromotionResult
+				require.NoError(t, env.GetWorkflowResult(&result))
+				assert.Equal(t, "promoted", result.Status)
+				assert.Equal(t, "analytics.results_staging", result.Staging)
+				want, err := recordsStats(records)
+				require.NoError(t, err)
+				assert.Equal(t, want, result.Validation)
+			}
+			if tt.wantErr != "" {
+				for _, query := range db.queries {
+					assert.NotContains(t, query, "RENAME", "production must be left alone")
+				}
+			}
+			if tt.wantLast != "" {
+				require.NotEmpty(t, db.queries)
+				assert.Contains(t, db.queries[len(db.queries)-1], tt.wantLast)
+			} else {
+				assert.Empty(t, db.queries)
+			}
+		})
+	}
+}
+
+func TestRecordsChecksum(t *testing.T) {
+	a := []ProcessedRecord{{ID: "r1", Score: 0.5}, {ID: "r2", Score: 0.25}}
+	b := []ProcessedRecord{{ID: "r2", Score: 0.25}, {ID: "r1", Score: 0.5}}
+	changed := []ProcessedRecord{{ID: "r1", Score: 0.5}, {ID: "r2", Score: 0.75}}
+
+	sumA, err := recordsChecksum(a)
+	require.NoError(t, err)
+	sumB, err := recordsChecksum(b)
+	require.NoError(t, err)
+	sumChanged, err := recordsChecksum(changed)
+	require.NoError(t, err)
+	assert.Equal(t, sumA, sumB, "order must not matter")
+	assert.NotEqual(t, sumA, sumChanged)
+	assert.Regexp(t, "^[0-9a-f]{64}$", sumA)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeQuarantineThresholdExceeded is returned, non-retryable, when more
+// records fail than QUARANTINE_THRESHOLD allows. Reprocessing the same
+// dataset would fail the same records again.
+const ErrTypeQuarantineThresholdExceeded = "QuarantineThresholdExceeded"
+
+// recordAttempts is how many times a record is tried before it is
+// quarantined
+const recordAttempts = 3
+
+// quarantineThreshold caps quarantined records per activity run. It comes
+// from QUARANTINE_THRESHOLD.
+var quarantineThreshold = 100
+
+// QuarantinedRecord is a record set aside after repeatedly failing
+type QuarantinedRecord struct {
+	DatasetID     string `json:"dataset_id"`
+	RecordID      string `json:"record_id"`
+	Reason        string `json:"reason"`
+	Attempts      int    `json:"attempts"`
+	QuarantinedAt int64  `json:"quarantined_at"`
+}
+
+// recordQuarantine collects poison-pill records during one activity run
+type recordQuarantine struct {
+	datasetID string
+	threshold int
+	records   []QuarantinedRecord
+}
+
+func newRecordQuarantine(datasetID string, threshold int) *recordQuarantine {
+	return &recordQuarantine{datasetID: datasetID, threshold: threshold}
+}
+
+// process runs fn for a record, retrying it, and quarantines the record if
+// every attempt fails. It returns false for quarantined records, and an
+// error once the threshold is exceeded.
+func (q *recordQuarantine) process(recordID string, fn func() error) (bool, error) {
+	var err error
+	for attempt := 1; attempt <= recordAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return true, nil
+		}
+	}
+
+	q.records = append(q.records, QuarantinedRecord{
+		DatasetID:     q.datasetID,
+		RecordID:      recordID,
+		Reason:        err.Error(),
+		Attempts:      recordAttempts,
+		QuarantinedAt: time.Now().UnixMilli(),
+	})
+	if len(q.records) > q.threshold {
+		return false, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("%d records failed processing, more than the quarantine threshold of %d", len(q.records), q.threshold),
+			ErrTypeQuarantineThresholdExceeded, err)
+	}
+	return false, nil
+}
+
+// flush writes quarantined records as JSON lines under the quarantine/
+// prefix, one object per activity execution, and returns its reference
+func (q *recordQuarantine) flush(ctx context.Context) (*ObjectRef, error) {
+	if len(q.records) == 0 {
+		return nil, nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, r := range q.records {
+		if err := enc.Encode(r); err != nil {
+			return nil, err
+		}
+	}
+
+	key := fmt.Sprintf("quarantine/%s/%d.jsonl", q.datasetID, time.Now().UnixNano())
+	if activity.IsActivity(ctx) {
+		info := activity.GetInfo(ctx)
+		key = fmt.Sprintf("quarantine/%s/%s-%s-%d.jsonl", q.datasetID, info.WorkflowExecution.RunID, info.ActivityID, info.Attempt)
+	}
+	ref, err := objectStore.Put(ctx, key, &body)
+	if err != nil {
+		return nil, err
+	}
+	activityLog.Infof("🧪 Quarantined %d records to %s", len(q.records), ref.URI)
+	return &ref, nil
+}
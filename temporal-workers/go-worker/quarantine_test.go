@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordQuarantine(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		// failures is how many attempts of each record fail
+		failures         []int
+		wantOK           []bool
+		wantQuarantined  []string
+		wantThresholdErr bool
+	}{
+		{name: "all succeed", threshold: 1, failures: []int{0, 0}, wantOK: []bool{true, true}},
+		{name: "retried until it succeeds", threshold: 1, failures: []int{recordAttempts - 1}, wantOK: []bool{true}},
+		{name: "poison pill quarantined", threshold: 1, failures: []int{0, recordAttempts, 0}, wantOK: []bool{true, false, true}, wantQuarantined: []string{"r1"}},
+		{
+			name:             "threshold exceeded",
+			threshold:        1,
+			failures:         []int{recordAttempts, recordAttempts},
+			wantOK:           []bool{false, false},
+			wantQuarantined:  []string{"r0", "r1"},
+			wantThresholdErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newRecordQuarantine("ds-1", tt.threshold)
+			var err error
+			for i, failures := range tt.failures {
+				attempts := 0
+				var ok bool
+				ok, err = q.process(fmt.Sprintf("r%d", i), func() error {
+					attempts++
+					if attempts <= failures {
+						return fmt.Errorf("bad record, attempt %d", attempts)
+					}
+					return nil
+				})
+				assert.Equal(t, tt.wantOK[i], ok, "record %d", i)
+				if err != nil {
+					break
+				}
+			}
+			if tt.wantThresholdErr {
+				requireApplicationError(t, err, ErrTypeQuarantineThresholdExceeded)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var quarantined []string
+			for _, r := range q.records {
+				quarantined = append(quarantined, r.RecordID)
+				assert.Equal(t, recordAttempts, r.Attempts)
+				assert.Equal(t, fmt.Sprintf("bad record, attempt %d", recordAttempts), r.Reason)
+			}
+			assert.Equal(t, tt.wantQuarantined, quarantined)
+		})
+	}
+}
+
+func TestRecordQuarantineFlush(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []string
+		failPut int
+		wantErr string
+	}{
+		{name: "written as JSON lines", records: []string{"r1", "r2"}},
+		{name: "nothing quarantined"},
+		{name: "store unavailable", records: []string{"r1"}, failPut: 1, wantErr: "store unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			store.failPut = tt.failPut
+			q := newRecordQuarantine("ds-1", 10)
+			for _, id := range tt.records {
+				_, err := q.process(id, func() error { return errors.New("bad record") })
+				require.NoError(t, err)
+			}
+
+			ref, err := q.flush(context.Background())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Empty(t, store.writes)
+				return
+			}
+			require.NoError(t, err)
+			if len(tt.records) == 0 {
+				assert.Nil(t, ref)
+				assert.Empty(t, store.writes)
+				return
+			}
+			require.NotNil(t, ref)
+			assert.True(t, strings.HasPrefix(ref.Key, "quarantine/ds-1/"), ref.Key)
+
+			r, err := store.Get(context.Background(), ref.Key)
+			require.NoError(t, err)
+			defer r.Close()
+			var ids []string
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				var record QuarantinedRecord
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+				assert.Equal(t, "ds-1", record.DatasetID)
+				ids = append(ids, record.RecordID)
+			}
+			require.NoError(t, scanner.Err())
+			assert.Equal(t, tt.records, ids)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeQuotaExceeded is returned, without retries, when a dataset is
+// larger than its tenant's remaining quota
+const ErrTypeQuotaExceeded = "QuotaExceeded"
+
+// QuotaReservation is the outcome of reserving quota for one dataset
+type QuotaReservation struct {
+	Accepted  bool  `json:"accepted"`
+	Remaining int64 `json:"remaining"`
+	Unlimited bool  `json:"unlimited"` // the tenant has no quota configured
+}
+
+// QuotaStore tracks each tenant's remaining processing quota in bytes.
+// Reserve must be atomic: it takes amount from the tenant's quota only if
+// that much remains. Reserving again under the same ID returns the first
+// outcome without taking more, so retried activities are charged once.
+type QuotaStore interface {
+	Reserve(ctx context.Context, tenant, reservationID string, amount int64) (QuotaReservation, error)
+}
+
+// parseTenantQuotas parses TENANT_QUOTAS, a comma-separated list of
+// tenant=bytes pairs
+func parseTenantQuotas(spec string) (map[string]int64, error) {
+	quotas := make(map[string]int64)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tenant, value, ok := strings.Cut(entry, "=")
+		tenant = strings.TrimSpace(tenant)
+		limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if !ok || tenant == "" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("tenant quota %q: want tenant=bytes", entry)
+		}
+		quotas[tenant] = limit
+	}
+	return quotas, nil
+}
+
+// quotaReservationTTL is how long memoryQuotaStore remembers a
+// reservation. A retry of the same reservation after that is charged again.
+const quotaReservationTTL = 24 * time.Hour
+
+// memoryQuotaStore is an in-process QuotaStore for local runs. Tenants
+// without a configured quota are unlimited.
+//
+// Quotas and reservations live in one worker's memory: each worker
+// enforces the full quota on its own, and a retry that lands on another
+// worker is charged again. Running several workers needs a shared store.
+type memoryQuotaStore struct {
+	mu           sync.Mutex
+	now          func() time.Time
+	remaining    map[string]int64
+	reservations map[string]QuotaReservation
+	// expiries holds reservation keys in the order they were made, so
+	// expired ones are dropped from the front
+	expiries []quotaExpiry
+}
+
+type quotaExpiry struct {
+	key string
+	at  time.Time
+}
+
+func newMemoryQuotaStore(quotas map[string]int64) *memoryQuotaStore {
+	remaining := make(map[string]int64, len(quotas))
+	for tenant, limit := range quotas {
+		remaining[tenant] = limit
+	}
+	return &memoryQuotaStore{now: time.Now, remaining: remaining, reservations: make(map[string]QuotaReservation)}
+}
+
+func (s *memoryQuotaStore) Reserve(ctx context.Context, tenant, reservationID string, amount int64) (QuotaReservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for len(s.expiries) > 0 && !now.Before(s.expiries[0].at) {
+		delete(s.reservations, s.expiries[0].key)
+		s.expiries = s.expiries[1:]
+	}
+	key := tenant + "/" + reservationID
+	if reservation, ok := s.reservations[key]; ok {
+		return reservation, nil
+	}
+	remaining, limited := s.remaining[tenant]
+	reservation := QuotaReservation{Accepted: true, Remaining: remaining, Unlimited: !limited}
+	if limited {
+		if amount > remaining {
+			reservation.Accepted = false
+		} else {
+			reservation.Remaining = remaining - amount
+			s.remaining[tenant] = reservation.Remaining
+		}
+	}
+	s.reservations[key] = reservation
+	s.expiries = append(s.expiries, quotaExpiry{key: key, at: now.Add(quotaReservationTTL)})
+	return reservation, nil
+}
+
+// CheckQuotaInput represents input for a quota check. Size is in bytes;
+// when it is zero the size of the SourceKey object is used.
+type CheckQuotaInput struct {
+	Tenant    string `json:"tenant"`
+	DatasetID string `json:"dataset_id"`
+	Size      int64  `json:"size,omitempty"`
+	SourceKey string `json:"source_key,omitempty"`
+}
+
+// CheckQuotaResult represents an accepted quota check
+type CheckQuotaResult struct {
+	Tenant    string `json:"tenant"`
+	Size      int64  `json:"size"`
+	Remaining int64  `json:"remaining"`
+	Unlimited bool   `json:"unlimited"`
+}
+
+// CheckQuota charges a dataset against its tenant's quota, failing without
+// retries when the quota can't cover it. The charge is keyed by the calling
+// run, so a retried check doesn't charge the run twice.
+func (a *Activities) CheckQuota(ctx context.Context, input CheckQuotaInput) (CheckQuotaResult, error) {
+	if input.Tenant == "" {
+		return CheckQuotaResult{}, temporal.NewNonRetryableApplicationError("tenant is required", "InvalidInput", nil)
+	}
+	size := input.Size
+	if size == 0 && input.SourceKey != "" {
+		ref, err := objectStore.Stat(ctx, input.SourceKey)
+		if err != nil {
+			return CheckQuotaResult{}, fmt.Errorf("sizing %s: %w", input.SourceKey, err)
+		}
+		size = ref.Size
+	}
+	if size < 0 {
+		return CheckQuotaResult{}, temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid dataset size %d", size), "InvalidInput", nil)
+	}
+
+	info := activity.GetInfo(ctx)
+	reservationID := info.WorkflowExecution.ID + "/" + info.WorkflowExecution.RunID
+	reservation, err := a.Quotas.Reserve(ctx, input.Tenant, reservationID, size)
+	if err != nil {
+		return CheckQuotaResult{}, err
+	}
+	if !reservation.Accepted {
+		activityLog.Errorf("🚫 Quota exceeded for tenant %s: %s needs %d bytes, %d remain", input.Tenant, input.DatasetID, size, reservation.Remaining)
+		return CheckQuotaResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("tenant %s has %d bytes of quota left, dataset %s needs %d", input.Tenant, reservation.Remaining, input.DatasetID, size),
+			ErrTypeQuotaExceeded, nil, CheckQuotaResult{Tenant: input.Tenant, Size: size, Remaining: reservation.Remaining})
+	}
+
+	activityLog.Infof("✅ Quota reserved for tenant %s: %d bytes, %d remain", input.Tenant, size, reservation.Remaining)
+	return CheckQuotaResult{Tenant: input.Tenant, Size: size, Remaining: reservation.Remaining, Unlimited: reservation.Unlimited}, nil
+}
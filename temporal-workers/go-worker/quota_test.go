@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestCheckQuota(t *testing.T) {
+	tests := []struct {
+		name string
+		// sizes are checked in turn by the same run
+		sizes         []int64
+		tenant        string
+		wantRemaining int64
+		wantUnlimited bool
+		wantExceeded  bool
+	}{
+		{name: "accepted", tenant: "acme", sizes: []int64{400}, wantRemaining: 600},
+		{name: "whole quota", tenant: "acme", sizes: []int64{1000}, wantRemaining: 0},
+		{name: "over quota", tenant: "acme", sizes: []int64{1001}, wantRemaining: 1000, wantExceeded: true},
+		{name: "retried check charged once", tenant: "acme", sizes: []int64{400, 400}, wantRemaining: 600},
+		{name: "unlimited tenant", tenant: "globex", sizes: []int64{1 << 40}, wantUnlimited: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotas := newMemoryQuotaStore(map[string]int64{"acme": 1000})
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(&Activities{Quotas: quotas})
+
+			var err error
+			var result CheckQuotaResult
+			for _, size := range tt.sizes {
+				var value converter.EncodedValue
+				value, err = env.ExecuteActivity(activities.CheckQuota, CheckQuotaInput{Tenant: tt.tenant, DatasetID: "dataset-1", Size: size})
+				if err == nil {
+					require.NoError(t, value.Get(&result))
+				}
+			}
+
+			if tt.wantExceeded {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "got %v", err)
+				assert.Equal(t, ErrTypeQuotaExceeded, appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantRemaining, result.Remaining)
+				assert.Equal(t, tt.wantUnlimited, result.Unlimited)
+			}
+			// What's left of the quota, seen by another run
+			if !tt.wantUnlimited {
+				left, err := quotas.Reserve(context.Background(), tt.tenant, "another-run", 0)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantRemaining, left.Remaining)
+			}
+		})
+	}
+}
+
+func TestMemoryQuotaStoreForgetsOldReservations(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	quotas := newMemoryQuotaStore(map[string]int64{"acme": 1000})
+	quotas.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		after         time.Duration
+		id            string
+		wantRemaining int64
+		wantKept      int
+	}{
+		{name: "first charge", id: "run-1", wantRemaining: 900, wantKept: 1},
+		{name: "retry is free", after: time.Hour, id: "run-1", wantRemaining: 900, wantKept: 1},
+		{name: "another run", after: time.Hour, id: "run-2", wantRemaining: 800, wantKept: 2},
+		// run-1 was made 24 hours ago and is forgotten; run-2 isn't yet
+		{name: "retry after the TTL", after: quotaReservationTTL - 2*time.Hour, id: "run-1", wantRemaining: 700, wantKept: 2},
+		{name: "all forgotten", after: quotaReservationTTL, id: "run-3", wantRemaining: 600, wantKept: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.after)
+			reservation, err := quotas.Reserve(ctx, "acme", tt.id, 100)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRemaining, reservation.Remaining)
+			assert.Len(t, quotas.reservations, tt.wantKept)
+			assert.Len(t, quotas.expiries, tt.wantKept)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeCountMismatch is returned when the processed count differs from
+// the source's by more than the tolerance. Its details carry the
+// ReconcileCountsResult.
+const ErrTypeCountMismatch = "CountMismatch"
+
+// RecordCounter returns the authoritative record count of a source
+type RecordCounter interface {
+	RecordCount(ctx context.Context, source string) (int64, error)
+}
+
+// recordCounter, when set, is used by ReconcileCounts instead of counting
+// the rows of the source table in Activities.DB
+var recordCounter RecordCounter
+
+// ReconcileCountsInput represents input for reconciling processed counts.
+// Tolerance is the allowed relative difference (0.01 for 1%); zero requires
+// an exact match.
+type ReconcileCountsInput struct {
+	DatasetID string  `json:"dataset_id"`
+	Source    string  `json:"source"`
+	Processed int64   `json:"processed"`
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// ReconcileCountsResult represents the outcome of a reconciliation.
+// Discrepancy is processed minus expected, so negative means records were
+// lost.
+type ReconcileCountsResult struct {
+	Source      string  `json:"source"`
+	Expected    int64   `json:"expected"`
+	Processed   int64   `json:"processed"`
+	Discrepancy int64   `json:"discrepancy"`
+	Relative    float64 `json:"relative"`
+	Tolerance   float64 `json:"tolerance"`
+	Matched     bool    `json:"matched"`
+}
+
+// ReconcileCounts compares the processed count with the source's own count.
+// A difference beyond the tolerance won't go away on retry, so it fails
+// without retries.
+func (a *Activities) ReconcileCounts(ctx context.Context, input ReconcileCountsInput) (ReconcileCountsResult, error) {
+	activityLog.Infof("🧾 Reconciling %d processed records of %s against %s", input.Processed, input.DatasetID, input.Source)
+
+	counter := recordCounter
+	if counter == nil {
+		counter = sqlRecordCounter{db: a.DB}
+	}
+	expected, err := counter.RecordCount(ctx, input.Source)
+	if err != nil {
+		return ReconcileCountsResult{}, err
+	}
+	result := reconcileCounts(input, expected)
+	if !result.Matched {
+		activityLog.Errorf("❌ %s: processed %d records, source has %d (%+d, tolerance %.2f%%)",
+			input.DatasetID, result.Processed, result.Expected, result.Discrepancy, 100*result.Tolerance)
+		return result, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("processed %d records but %s has %d", result.Processed, input.Source, result.Expected),
+			ErrTypeCountMismatch, nil, result)
+	}
+
+	activityLog.Infof("✅ Counts reconciled: %d processed, %d expected", result.Processed, result.Expected)
+	return result, nil
+}
+
+// reconcileCounts compares processed with expected. Any difference from an
+// empty source is relative 1.
+func reconcileCounts(input ReconcileCountsInput, expected int64) ReconcileCountsResult {
+	result := ReconcileCountsResult{
+		Source:      input.Source,
+		Expected:    expected,
+		Processed:   input.Processed,
+		Discrepancy: input.Processed - expected,
+		Tolerance:   input.Tolerance,
+	}
+	switch {
+	case expected != 0:
+		result.Relative = math.Abs(float64(result.Discrepancy)) / float64(expected)
+	case result.Discrepancy != 0:
+		result.Relative = 1
+	}
+	result.Matched = result.Relative <= input.Tolerance
+	return result
+}
+
+// sqlRecordCounter counts the rows of a table
+type sqlRecordCounter struct {
+	db sqlExecer
+}
+
+func (c sqlRecordCounter) RecordCount(ctx context.Context, table string) (int64, error) {
+	quoted, err := sqlIdentifier(table)
+	if err != nil {
+		return 0, err
+	}
+	db := newTracedExecer(c.db, "reconcile_counts", table, activity.GetMetricsHandler(ctx))
+	rows, err := db.QueryRows(ctx, "SELECT COUNT(*) AS count FROM "+quoted)
+	if err != nil {
+		return 0, classifySQLError(err)
+	}
+	if len(rows) != 1 {
+		return 0, fmt.Errorf("count of %s returned %d rows", table, len(rows))
+	}
+	switch count := rows[0]["count"].(type) {
+	case int64:
+		return count, nil
+	case int:
+		return int64(count), nil
+	case float64:
+		return int64(count), nil
+	case string:
+		return strconv.ParseInt(count, 10, 64)
+	default:
+		return 0, fmt.Errorf("count of %s is %T, not a number", table, count)
+	}
+}
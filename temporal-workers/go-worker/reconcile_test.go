@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fixedCounter returns count for every source, or fails with err
+type fixedCounter struct {
+	count int64
+	err   error
+}
+
+func (c fixedCounter) RecordCount(ctx context.Context, source string) (int64, error) {
+	return c.count, c.err
+}
+
+func TestReconcileCounts(t *testing.T) {
+	tests := []struct {
+		name         string
+		processed    int64
+		tolerance    float64
+		counter      fixedCounter
+		wantRelative float64
+		wantMismatch bool
+		wantErr      string
+	}{
+		{name: "exact match", processed: 100, counter: fixedCounter{count: 100}},
+		{name: "within tolerance", processed: 99, tolerance: 0.01, counter: fixedCounter{count: 100}, wantRelative: 0.01},
+		{name: "records lost", processed: 90, tolerance: 0.01, counter: fixedCounter{count: 100}, wantRelative: 0.1, wantMismatch: true},
+		{name: "empty source", processed: 5, tolerance: 0.5, counter: fixedCounter{}, wantRelative: 1, wantMismatch: true},
+		{name: "count fails", processed: 100, counter: fixedCounter{err: errors.New("source unavailable")}, wantErr: "source unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := recordCounter
+			t.Cleanup(func() { recordCounter = prev })
+			recordCounter = tt.counter
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.ReconcileCounts, ReconcileCountsInput{DatasetID: "ds-1", Source: "raw.events", Processed: tt.processed, Tolerance: tt.tolerance})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			var result ReconcileCountsResult
+			if tt.wantMismatch {
+				requireApplicationError(t, err, ErrTypeCountMismatch)
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr))
+				require.NoError(t, appErr.Details(&result))
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, value.Get(&result))
+			}
+			assert.Equal(t, !tt.wantMismatch, result.Matched)
+			assert.Equal(t, tt.counter.count, result.Expected)
+			assert.Equal(t, tt.processed-tt.counter.count, result.Discrepancy)
+			assert.InDelta(t, tt.wantRelative, result.Relative, 1e-9)
+		})
+	}
+}
+
+func TestSQLRecordCounter(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		rows        []map[string]interface{}
+		dbErr       error
+		want        int64
+		wantQuery   string
+		wantErrType string
+		wantErr     string
+	}{
+		{name: "int64 count", source: "raw.events", rows: []map[string]interface{}{{"count": int64(42)}}, want: 42, wantQuery: `SELECT COUNT(*) AS count FROM "raw"."events"`},
+		{name: "string count", source: "events", rows: []map[string]interface{}{{"count": "42"}}, want: 42, wantQuery: `SELECT COUNT(*) AS count FROM "events"`},
+		{name: "invalid table", source: "events; DROP TABLE events", wantErrType: ErrTypeInvalidTarget},
+		{name: "no rows", source: "events", wantErr: "count of events returned 0 rows"},
+		{name: "not a number", source: "events", rows: []map[string]interface{}{{"count": []byte("42")}}, wantErr: "count of events is []uint8, not a number"},
+		{name: "query fails", source: "events", dbErr: errors.New("connection refused"), wantErr: "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := recordCounter
+			t.Cleanup(func() { recordCounter = prev })
+			recordCounter = nil
+			db := &rowsDB{recordingExecer: recordingExecer{err: tt.dbErr}, rows: tt.rows}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(&Activities{DB: db})
+
+			value, err := env.ExecuteActivity(activities.ReconcileCounts, ReconcileCountsInput{DatasetID: "ds-1", Source: tt.source, Processed: tt.want})
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				assert.Empty(t, db.queries)
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var result ReconcileCountsResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.want, result.Expected)
+			assert.True(t, result.Matched)
+			assert.Equal(t, []string{tt.wantQuery}, db.queries)
+		})
+	}
+}
+
+func TestComplexProcessingReconcile(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int64
+		wantErr  string
+	}{
+		{name: "reconciled", expected: 10},
+		{name: "mismatch fails the run", expected: 12, wantErr: "processed 10 records but raw.events has 12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.ReconcileCounts, mock.Anything, ReconcileCountsInput{DatasetID: "ds-1", Source: "raw.events", Processed: 10, Tolerance: 0}).
+				Return(func(ctx context.Context, input ReconcileCountsInput) (ReconcileCountsResult, error) {
+					result := reconcileCounts(input, tt.expected)
+					if !result.Matched {
+						return result, temporal.NewNonRetryableApplicationError("processed 10 records but raw.events has 12", ErrTypeCountMismatch, nil, result)
+					}
+					return result, nil
+				})
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:    CurrentComplexProcessingInputVersion,
+				DatasetID:  "ds-1",
+				Parameters: Parameters{"reconcile_source": "raw.events"},
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			env.AssertCalled(t, "ReconcileCounts", mock.Anything, mock.Anything)
+			if tt.wantErr != "" {
+				require.Error(t, env.GetWorkflowError())
+				assert.Contains(t, env.GetWorkflowError().Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result ComplexProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, StatusCompleted, result.Status)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeDanglingReferences is returned, without retries, when more child
+// references than the tolerance point at keys missing from the parent.
+// Its details carry the ReferentialIntegrityResult.
+const ErrTypeDanglingReferences = "DanglingReferences"
+
+// ErrTypeParentKeysUnavailable is returned, without retries, when no parent
+// key set can be loaded within the memory bound
+const ErrTypeParentKeysUnavailable = "ParentKeysUnavailable"
+
+const (
+	defaultMaxParentKeys = 1000000
+	// maxDanglingSamples is how many distinct dangling keys are reported
+	maxDanglingSamples = 20
+	// refHeartbeatBytes is how often scanning progress is reported
+	refHeartbeatBytes = 1 << 20
+	// refMaxLineBytes is the longest record line that can be scanned
+	refMaxLineBytes = 16 << 20
+)
+
+// CheckReferentialIntegrityInput represents input for checking that a child
+// dataset's ForeignKey values exist among its parent's keys. Both datasets
+// are JSON lines. The parent key set is read from the result cache under
+// ParentCacheKey, or else from the ParentKeyField of the ParentSourceKey
+// dataset, which is then cached for ParentCacheTTLSeconds. The parent may
+// be a sample of the full dataset; Tolerance then allows for references to
+// keys outside it.
+type CheckReferentialIntegrityInput struct {
+	DatasetID             string  `json:"dataset_id"`
+	ChildSourceKey        string  `json:"child_source_key"`
+	ForeignKey            string  `json:"foreign_key"`
+	ParentCacheKey        string  `json:"parent_cache_key,omitempty"`
+	ParentCacheTTLSeconds int     `json:"parent_cache_ttl_seconds,omitempty"` // default: 1h
+	ParentSourceKey       string  `json:"parent_source_key,omitempty"`
+	ParentKeyField        string  `json:"parent_key_field,omitempty"` // default: id
+	MaxParentKeys         int     `json:"max_parent_keys,omitempty"`  // default: 1000000
+	Tolerance             float64 `json:"tolerance,omitempty"`        // allowed dangling rate, 0-1
+}
+
+// ReferentialIntegrityResult reports the child's references. Records
+// without the foreign key, or with a null or empty one, aren't references.
+type ReferentialIntegrityResult struct {
+	DatasetID       string   `json:"dataset_id"`
+	ParentKeys      int      `json:"parent_keys"`
+	ParentFromCache bool     `json:"parent_from_cache"`
+	Records         int64    `json:"records"`
+	Malformed       int64    `json:"malformed"`
+	References      int64    `json:"references"`
+	Dangling        int64    `json:"dangling"`
+	DanglingRate    float64  `json:"dangling_rate"`
+	DanglingSamples []string `json:"dangling_samples,omitempty"`
+}
+
+// CheckReferentialIntegrity streams the child dataset and counts references
+// to keys missing from the parent. Only the parent key set and a handful of
+// dangling samples are held in memory, however large the child.
+func (a *Activities) CheckReferentialIntegrity(ctx context.Context, input CheckReferentialIntegrityInput) (ReferentialIntegrityResult, error) {
+	if input.ForeignKey == "" {
+		return ReferentialIntegrityResult{}, temporal.NewNonRetryableApplicationError("foreign_key is required", "InvalidInput", nil)
+	}
+	activityLog.Infof("🔗 Checking %s.%s references", input.ChildSourceKey, input.ForeignKey)
+
+	parent, fromCache, err := loadParentKeys(ctx, input)
+	if err != nil {
+		return ReferentialIntegrityResult{}, err
+	}
+
+	src, err := objectStore.Get(ctx, input.ChildSourceKey)
+	if err != nil {
+		return ReferentialIntegrityResult{}, err
+	}
+	defer src.Close()
+
+	result := ReferentialIntegrityResult{DatasetID: input.DatasetID, ParentKeys: len(parent), ParentFromCache: fromCache}
+	sampled := make(map[string]bool)
+	err = scanRecords(&heartbeatReader{ctx: ctx, r: src, every: refHeartbeatBytes}, func(record map[string]json.RawMessage) {
+		result.Records++
+		if record == nil {
+			result.Malformed++
+			return
+		}
+		key, ok := referenceKey(record[input.ForeignKey])
+		if !ok {
+			return
+		}
+		result.References++
+		if _, found := parent[key]; found {
+			return
+		}
+		result.Dangling++
+		if len(result.DanglingSamples) < maxDanglingSamples && !sampled[key] {
+			sampled[key] = true
+			result.DanglingSamples = append(result.DanglingSamples, key)
+		}
+	})
+	if err != nil {
+		return ReferentialIntegrityResult{}, fmt.Errorf("scanning %s: %w", input.ChildSourceKey, err)
+	}
+	if result.References > 0 {
+		result.DanglingRate = float64(result.Dangling) / float64(result.References)
+	}
+
+	if result.Dangling > 0 && result.DanglingRate > input.Tolerance {
+		activityLog.Errorf("❌ %d of %d references in %s are dangling", result.Dangling, result.References, input.ChildSourceKey)
+		return result, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("%d of %d %s references are dangling (rate %.4f, tolerance %.4f)",
+				result.Dangling, result.References, input.ForeignKey, result.DanglingRate, input.Tolerance),
+			ErrTypeDanglingReferences, nil, result)
+	}
+
+	activityLog.Infof("✅ %d references checked, %d dangling", result.References, result.Dangling)
+	return result, nil
+}
+
+// loadParentKeys returns the parent key set and whether it came from the
+// cache. A cache failure falls back to reading the parent dataset.
+func loadParentKeys(ctx context.Context, input CheckReferentialIntegrityInput) (map[string]struct{}, bool, error) {
+	limit := input.MaxParentKeys
+	if limit <= 0 {
+		limit = defaultMaxParentKeys
+	}
+	if input.ParentCacheKey != "" {
+		cached, ok, err := resultCache.Get(ctx, input.ParentCacheKey)
+		switch {
+		case err != nil:
+			activityLog.Errorf("⚠️ Parent key cache lookup failed for %s: %v", input.ParentCacheKey, err)
+		case ok:
+			var keys []string
+			if err := json.Unmarshal(cached, &keys); err == nil && len(keys) <= limit {
+				parent := make(map[string]struct{}, len(keys))
+				for _, key := range keys {
+					parent[key] = struct{}{}
+				}
+				return parent, true, nil
+			}
+		}
+	}
+	if input.ParentSourceKey == "" {
+		return nil, false, temporal.NewNonRetryableApplicationError(
+			"no cached parent keys and no parent_source_key to read them from", ErrTypeParentKeysUnavailable, nil)
+	}
+
+	parent, err := readParentKeys(ctx, input, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	if input.ParentCacheKey != "" {
+		cacheParentKeys(ctx, input, parent)
+	}
+	return parent, false, nil
+}
+
+// readParentKeys streams the parent dataset's key field into a set of at
+// most limit keys
+func readParentKeys(ctx context.Context, input CheckReferentialIntegrityInput, limit int) (map[string]struct{}, error) {
+	field := input.ParentKeyField
+	if field == "" {
+		field = "id"
+	}
+	src, err := objectStore.Get(ctx, input.ParentSourceKey)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	parent := make(map[string]struct{})
+	err = scanRecords(&heartbeatReader{ctx: ctx, r: src, every: refHeartbeatBytes}, func(record map[string]json.RawMessage) {
+		if key, ok := referenceKey(record[field]); ok && len(parent) <= limit {
+			parent[key] = struct{}{}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", input.ParentSourceKey, err)
+	}
+	if len(parent) > limit {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("parent %s has more than %d keys; raise max_parent_keys or check against a sample", input.ParentSourceKey, limit),
+			ErrTypeParentKeysUnavailable, nil)
+	}
+	return parent, nil
+}
+
+// cacheParentKeys stores a parent key set for later checks. Caching is
+// best effort.
+func cacheParentKeys(ctx context.Context, input CheckReferentialIntegrityInput, parent map[string]struct{}) {
+	ttl := time.Hour
+	if input.ParentCacheTTLSeconds > 0 {
+		ttl = time.Duration(input.ParentCacheTTLSeconds) * time.Second
+	}
+	keys := make([]string, 0, len(parent))
+	for key := range parent {
+		keys = append(keys, key)
+	}
+	value, err := json.Marshal(keys)
+	if err == nil {
+		err = resultCache.Set(ctx, input.ParentCacheKey, value, ttl)
+	}
+	if err != nil {
+		activityLog.Errorf("⚠️ Unable to cache parent keys under %s: %v", input.ParentCacheKey, err)
+	}
+}
+
+// scanRecords calls fn with each JSON-lines record, or nil for a line that
+// isn't a JSON object. Blank lines are skipped.
+func scanRecords(r io.Reader, fn func(map[string]json.RawMessage)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), refMaxLineBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(line, &record); err != nil {
+			record = nil
+		}
+		fn(record)
+	}
+	return scanner.Err()
+}
+
+// referenceKey is a key value's text: strings as themselves, numbers and
+// other values as their JSON. Missing, null and empty values are no key.
+func referenceKey(raw json.RawMessage) (string, bool) {
+	if len(raw) == 0 || string(raw) == "null" || string(raw) == `""` {
+		return "", false
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, true
+	}
+	return string(raw), true
+}
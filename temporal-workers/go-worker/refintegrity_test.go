@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestCheckReferentialIntegrity(t *testing.T) {
+	parent := "{\"id\":1}\n{\"id\":\"2\"}\n\n{\"id\":3}\n"
+	child := strings.Join([]string{
+		`{"order":1,"customer_id":1}`,
+		`{"customer_id":"2"}`,
+		`{"customer_id":4}`,
+		`{"customer_id":null}`,
+		`{}`,
+		`not a record`,
+		`{"customer_id":4}`,
+		`{"customer_id":5}`,
+	}, "\n")
+	fromSource := CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentSourceKey: "datasets/customers.jsonl"}
+	tests := []struct {
+		name        string
+		input       CheckReferentialIntegrityInput
+		cached      []string
+		cacheErr    error
+		wantDangle  int64
+		wantSamples []string
+		wantCached  bool
+		wantErrType string
+		wantErr     string
+	}{
+		{name: "dangling references", input: fromSource, wantDangle: 3, wantSamples: []string{"4", "5"}, wantErrType: ErrTypeDanglingReferences},
+		{
+			name:        "within tolerance",
+			input:       CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentSourceKey: "datasets/customers.jsonl", Tolerance: 0.7},
+			wantDangle:  3,
+			wantSamples: []string{"4", "5"},
+		},
+		{
+			name:       "parent keys from the cache",
+			input:      CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentCacheKey: "parents:customers"},
+			cached:     []string{"1", "2", "4", "5"},
+			wantCached: true,
+		},
+		{
+			name:        "cache unavailable",
+			input:       CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentCacheKey: "parents:customers", ParentSourceKey: "datasets/customers.jsonl", Tolerance: 1},
+			cacheErr:    errors.New("cache unreachable"),
+			wantDangle:  3,
+			wantSamples: []string{"4", "5"},
+		},
+		{name: "no parent keys", input: CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentCacheKey: "parents:customers"}, wantErrType: ErrTypeParentKeysUnavailable},
+		{
+			name:        "parent too large",
+			input:       CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentSourceKey: "datasets/customers.jsonl", MaxParentKeys: 2},
+			wantErrType: ErrTypeParentKeysUnavailable,
+		},
+		{name: "no foreign key", input: CheckReferentialIntegrityInput{ParentSourceKey: "datasets/customers.jsonl"}, wantErrType: "InvalidInput"},
+		{
+			name:    "child missing",
+			input:   CheckReferentialIntegrityInput{ForeignKey: "customer_id", ParentSourceKey: "datasets/customers.jsonl", ChildSourceKey: "datasets/missing.jsonl"},
+			wantErr: "no such file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			_, err := store.Put(context.Background(), "datasets/customers.jsonl", strings.NewReader(parent))
+			require.NoError(t, err)
+			_, err = store.Put(context.Background(), "datasets/orders.jsonl", strings.NewReader(child))
+			require.NoError(t, err)
+			prev := resultCache
+			t.Cleanup(func() { resultCache = prev })
+			cache := newMemoryCache()
+			resultCache = failingCache{memoryCache: cache, getErr: tt.cacheErr}
+			if tt.cached != nil {
+				keys, err := json.Marshal(tt.cached)
+				require.NoError(t, err)
+				require.NoError(t, cache.Set(context.Background(), "parents:customers", keys, time.Hour))
+			}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			input := tt.input
+			input.DatasetID = "orders"
+			if input.ChildSourceKey == "" {
+				input.ChildSourceKey = "datasets/orders.jsonl"
+			}
+
+			value, err := env.ExecuteActivity(activities.CheckReferentialIntegrity, input)
+			var result ReferentialIntegrityResult
+			switch {
+			case tt.wantErrType == ErrTypeDanglingReferences:
+				requireApplicationError(t, err, tt.wantErrType)
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr))
+				require.NoError(t, appErr.Details(&result))
+				assert.Contains(t, err.Error(), "3 of 5 customer_id references are dangling")
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			default:
+				require.NoError(t, err)
+				require.NoError(t, value.Get(&result))
+			}
+			assert.Equal(t, "orders", result.DatasetID)
+			assert.Equal(t, int64(8), result.Records)
+			assert.Equal(t, int64(1), result.Malformed)
+			assert.Equal(t, int64(5), result.References)
+			assert.Equal(t, tt.wantDangle, result.Dangling)
+			assert.InDelta(t, float64(tt.wantDangle)/5, result.DanglingRate, 1e-9)
+			assert.Equal(t, tt.wantSamples, result.DanglingSamples)
+			assert.Equal(t, tt.wantCached, result.ParentFromCache)
+		})
+	}
+}
+
+// TestCheckReferentialIntegrityCachesParent checks a parent read from its
+// dataset serves the next check from the cache
+func TestCheckReferentialIntegrityCachesParent(t *testing.T) {
+	store := useCountingStore(t)
+	_, err := store.Put(context.Background(), "datasets/customers.jsonl", strings.NewReader("{\"id\":1}\n{\"id\":2}\n"))
+	require.NoError(t, err)
+	_, err = store.Put(context.Background(), "datasets/orders.jsonl", strings.NewReader("{\"customer_id\":2}\n"))
+	require.NoError(t, err)
+	prev := resultCache
+	t.Cleanup(func() { resultCache = prev })
+	resultCache = newMemoryCache()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(activities)
+	input := CheckReferentialIntegrityInput{
+		DatasetID:       "orders",
+		ChildSourceKey:  "datasets/orders.jsonl",
+		ForeignKey:      "customer_id",
+		ParentCacheKey:  "parents:customers",
+		ParentSourceKey: "datasets/customers.jsonl",
+	}
+	for _, wantCached := range []bool{false, true} {
+		value, err := env.ExecuteActivity(activities.CheckReferentialIntegrity, input)
+		require.NoError(t, err)
+		var result ReferentialIntegrityResult
+		require.NoError(t, value.Get(&result))
+		assert.Equal(t, wantCached, result.ParentFromCache)
+		assert.Equal(t, 2, result.ParentKeys)
+		assert.Zero(t, result.Dangling)
+	}
+}
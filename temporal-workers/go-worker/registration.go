@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Registration check modes, from REGISTRATION_CHECK
+const (
+	RegistrationCheckStrict = "strict"
+	RegistrationCheckWarn   = "warn"
+	RegistrationCheckOff    = "off"
+)
+
+// workflowDependency declares what a workflow schedules. Keep it next to
+// new ExecuteActivity/ExecuteChildWorkflow calls; the boot check trusts it.
+type workflowDependency struct {
+	Workflow       interface{}
+	Activities     []interface{}
+	ChildWorkflows []interface{}
+}
+
+// workflowDependencies lists the activities and child workflows each
+// workflow references. PipelineWorkflow is absent because its stages name
+// activities at run time. TestWorkflowDependencies fails when an entry
+// falls behind the workflow's code.
+var workflowDependencies = []workflowDependency{
+	{Workflow: ComplexProcessingWorkflow, Activities: complexProcessingActivities},
+	{Workflow: ComplexProcessingProtoWorkflow, Activities: complexProcessingActivities},
+	{Workflow: SystemOperationWorkflow, Activities: systemOperationActivities, ChildWorkflows: []interface{}{LockManagerWorkflow}},
+	{Workflow: SystemOperationProtoWorkflow, Activities: systemOperationActivities, ChildWorkflows: []interface{}{LockManagerWorkflow}},
+	{Workflow: HighPerformanceWorkflow, Activities: highPerformanceActivities},
+	{Workflow: HighPerformanceProtoWorkflow, Activities: highPerformanceActivities},
+	{Workflow: RetentionWorkflow, Activities: []interface{}{activities.PurgeExpiredData, activities.AuditLog}},
+	{Workflow: RollupWorkflow, Activities: []interface{}{activities.ComputeRollup}},
+	{Workflow: HealthCheckWorkflow, Activities: []interface{}{activities.SystemHealthCheck}},
+	{Workflow: PromotionWorkflow, Activities: []interface{}{activities.ProcessLargeDataset, activities.LoadStaging, activities.ValidateStaging, activities.PromoteStaging, activities.DropStaging}},
+	{Workflow: BatchProcessingWorkflow, Activities: []interface{}{activities.TerminateWorkflow}, ChildWorkflows: []interface{}{ComplexProcessingWorkflow}},
+	{Workflow: DAGWorkflow, ChildWorkflows: []interface{}{ComplexProcessingWorkflow}},
+	{Workflow: IncrementalProcessingWorkflow, Activities: []interface{}{activities.ReadWatermark, activities.ProcessIncrementalRecords, activities.AdvanceWatermark}},
+	{Workflow: MultiRegionWorkflow, ChildWorkflows: []interface{}{ComplexProcessingWorkflow}},
+	{Workflow: CanaryWorkflow, Activities: []interface{}{activities.ProcessLargeDataset}, ChildWorkflows: []interface{}{ComplexProcessingWorkflow}},
+}
+
+var (
+	complexProcessingActivities = []interface{}{
+		activities.CheckQuota, activities.FetchDatasetMetadata, activities.SystemHealthCheck, activities.CheckSchemaCompatibility, activities.WarmCache, activities.NormalizeEncoding,
+		activities.ProcessLargeDataset, activities.ReconcileCounts, activities.ComputeDataQuality, activities.OptimizePerformance, activities.CacheOperation, activities.CheckDownstreamPressure,
+		activities.Deliver, activities.AuditLog, activities.ExportParquet, activities.IndexResults, activities.RedactAndEncrypt, activities.PersistResult, activities.SendCompletionWebhook,
+	}
+	systemOperationActivities = []interface{}{activities.DatabaseOperation, activities.RequestLock, activities.AuditLog}
+	highPerformanceActivities = []interface{}{activities.ProcessLargeDataset, activities.AuditLog}
+)
+
+// recordingRegistry records registered names without creating a worker.
+// The embedded registry is nil and only completes the interface.
+type recordingRegistry struct {
+	worker.Registry
+	workflows  map[string]bool
+	activities map[string]bool
+}
+
+func newRecordingRegistry() *recordingRegistry {
+	return &recordingRegistry{workflows: map[string]bool{}, activities: map[string]bool{}}
+}
+
+func (r *recordingRegistry) RegisterWorkflow(w interface{}) {
+	r.workflows[functionName(w)] = true
+}
+
+func (r *recordingRegistry) RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions) {
+	name := options.Name
+	if name == "" {
+		name = functionName(w)
+	}
+	r.workflows[name] = true
+}
+
+// RegisterActivity records a function, or each exported method of a struct
+// pointer like the SDK registers them
+func (r *recordingRegistry) RegisterActivity(a interface{}) {
+	if t := reflect.TypeOf(a); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		for i := 0; i < t.NumMethod(); i++ {
+			r.activities[t.Method(i).Name] = true
+		}
+		return
+	}
+	r.activities[functionName(a)] = true
+}
+
+func (r *recordingRegistry) RegisterActivityWithOptions(a interface{}, options activity.RegisterOptions) {
+	name := options.Name
+	if name == "" {
+		name = functionName(a)
+	}
+	r.activities[name] = true
+}
+
+// validateRegistrations runs register against a recording registry and
+// reports every dependency of a registered workflow that isn't registered
+func validateRegistrations(register func(worker.Registry), dependencies []workflowDependency) error {
+	registry := newRecordingRegistry()
+	register(registry)
+
+	var missing []string
+	for _, dep := range dependencies {
+		name := functionName(dep.Workflow)
+		if !registry.workflows[name] {
+			continue
+		}
+		for _, a := range dep.Activities {
+			if !registry.activities[functionName(a)] {
+				missing = append(missing, fmt.Sprintf("activity %s (used by %s)", functionName(a), name))
+			}
+		}
+		for _, w := range dep.ChildWorkflows {
+			if !registry.workflows[functionName(w)] {
+				missing = append(missing, fmt.Sprintf("workflow %s (used by %s)", functionName(w), name))
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("missing registrations: %s", strings.Join(missing, ", "))
+}
+
+// functionName returns the name the SDK registers a function under
+func functionName(fn interface{}) string {
+	fullName := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name := fullName[strings.LastIndex(fullName, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/worker"
+)
+
+// dynamicWorkflows name the activities they run at run time, so their
+// dependencies can't be listed
+var dynamicWorkflows = map[string]bool{"PipelineWorkflow": true}
+
+// packageFuncs parses the package's non-test files and returns each
+// function body by name. Methods are listed under their name alone, since
+// calls to them can't be told apart without type checking.
+func packageFuncs(t *testing.T) map[string][]*ast.FuncDecl {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	fset := token.NewFileSet()
+	funcs := map[string][]*ast.FuncDecl{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		require.NoError(t, err)
+		for _, decl := range parsed.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				funcs[fn.Name.Name] = append(funcs[fn.Name.Name], fn)
+			}
+		}
+	}
+	return funcs
+}
+
+// scheduledBy follows the calls a workflow makes within the package, into
+// the activities it references through the nil *Activities, and returns
+// those activities and the workflows in workflows it starts as children or
+// with signal-with-start
+func scheduledBy(funcs map[string][]*ast.FuncDecl, workflows map[string]bool, workflow string) (activityNames, childNames []string) {
+	activitySet, childSet := map[string]bool{}, map[string]bool{}
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, fn := range funcs[name] {
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.SelectorExpr:
+					if x, ok := n.X.(*ast.Ident); ok && x.Name == "activities" {
+						activitySet[n.Sel.Name] = true
+						visit(n.Sel.Name)
+					}
+				case *ast.CallExpr:
+					var callee string
+					switch fun := n.Fun.(type) {
+					case *ast.Ident:
+						callee = fun.Name
+					case *ast.SelectorExpr:
+						callee = fun.Sel.Name
+					}
+					if callee == "ExecuteChildWorkflow" || callee == "SignalWithStartWorkflow" {
+						for _, arg := range n.Args {
+							if ident, ok := arg.(*ast.Ident); ok && workflows[ident.Name] {
+								childSet[ident.Name] = true
+							}
+						}
+					}
+					visit(callee)
+				}
+				return true
+			})
+		}
+	}
+	visit(workflow)
+	return sortedKeys(activitySet), sortedKeys(childSet)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func names(fns []interface{}) []string {
+	out := make([]string, 0, len(fns))
+	for _, fn := range fns {
+		out = append(out, functionName(fn))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TestWorkflowDependencies fails when a registered workflow schedules an
+// activity or child workflow its workflowDependencies entry doesn't list,
+// or lists one it no longer schedules
+func TestWorkflowDependencies(t *testing.T) {
+	funcs := packageFuncs(t)
+	declared := map[string]workflowDependency{}
+	for _, dep := range workflowDependencies {
+		declared[functionName(dep.Workflow)] = dep
+	}
+	registry := newRecordingRegistry()
+	registerWorkflowsAndActivities(&Activities{})(registry)
+	require.NotEmpty(t, registry.workflows)
+
+	for workflow := range registry.workflows {
+		if dynamicWorkflows[workflow] {
+			continue
+		}
+		t.Run(workflow, func(t *testing.T) {
+			wantActivities, wantChildren := scheduledBy(funcs, registry.workflows, workflow)
+			dep := declared[workflow]
+			assert.Equal(t, wantActivities, names(dep.Activities), "activities")
+			assert.Equal(t, wantChildren, names(dep.ChildWorkflows), "child workflows")
+		})
+	}
+}
+
+func TestValidateRegistrations(t *testing.T) {
+	dependencies := []workflowDependency{
+		{Workflow: ComplexProcessingWorkflow, Activities: []interface{}{activities.ProcessLargeDataset, activities.AuditLog}},
+		{Workflow: BatchProcessingWorkflow, ChildWorkflows: []interface{}{ComplexProcessingWorkflow}},
+		// Not registered, so its dependencies don't matter
+		{Workflow: DAGWorkflow, Activities: []interface{}{activities.Deliver}},
+	}
+	tests := []struct {
+		name     string
+		register func(worker.Registry)
+		wantErr  string
+	}{
+		{name: "all registered", register: func(r worker.Registry) {
+			r.RegisterWorkflow(ComplexProcessingWorkflow)
+			r.RegisterWorkflow(BatchProcessingWorkflow)
+			r.RegisterActivity(&Activities{})
+		}},
+		{
+			name: "missing activity",
+			register: func(r worker.Registry) {
+				r.RegisterWorkflow(BatchProcessingWorkflow)
+				r.RegisterWorkflow(ComplexProcessingWorkflow)
+				r.RegisterActivity(activities.ProcessLargeDataset)
+			},
+			wantErr: "missing registrations: activity AuditLog (used by ComplexProcessingWorkflow)",
+		},
+		{
+			name: "missing child workflow",
+			register: func(r worker.Registry) {
+				r.RegisterWorkflow(BatchProcessingWorkflow)
+			},
+			wantErr: "missing registrations: workflow ComplexProcessingWorkflow (used by BatchProcessingWorkflow)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegistrations(tt.register, dependencies)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
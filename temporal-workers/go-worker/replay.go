@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	sdklog "go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+
+	"temporal-go-worker/internal/payload"
+)
+
+// replayRegistry registers workflows with a replayer. Activities aren't
+// executed on replay, so their registrations are dropped.
+type replayRegistry struct {
+	worker.Registry
+	replayer worker.WorkflowReplayer
+}
+
+func (r replayRegistry) RegisterWorkflow(w interface{}) { r.replayer.RegisterWorkflow(w) }
+
+func (r replayRegistry) RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions) {
+	r.replayer.RegisterWorkflowWithOptions(w, options)
+}
+
+func (replayRegistry) RegisterActivity(interface{}) {}
+
+func (replayRegistry) RegisterActivityWithOptions(interface{}, activity.RegisterOptions) {}
+
+// newReplayer returns a replayer with every workflow this worker serves and
+// the production data converter, including payload decryption when
+// PAYLOAD_ENCRYPTION_KEY is set
+func newReplayer() (worker.WorkflowReplayer, error) {
+	dataConverter, err := payload.DataConverterFromEnv(client.MetricsNopHandler)
+	if err != nil {
+		return nil, err
+	}
+	replayer, err := worker.NewWorkflowReplayerWithOptions(worker.WorkflowReplayerOptions{
+		DataConverter: dataConverter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	registerWorkflowsAndActivities(activities)(replayRegistry{replayer: replayer})
+	return replayer, nil
+}
+
+// runReplay replays exported JSON histories against the current workflow
+// code, reporting nondeterminism. Usage: go run . replay history.json...
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: replay <history.json>...")
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no history files given")
+	}
+
+	replayer, err := newReplayer()
+	if err != nil {
+		return err
+	}
+	logger := sdklog.NewStructuredLogger(slog.Default())
+	failed := 0
+	for _, file := range flags.Args() {
+		if err := replayer.ReplayWorkflowHistoryFromJSONFile(logger, file); err != nil {
+			log.Printf("❌ %s: %v", file, err)
+			failed++
+			continue
+		}
+		log.Printf("✅ %s replayed cleanly", file)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d histories failed to replay", failed, flags.NArg())
+	}
+	return nil
+}
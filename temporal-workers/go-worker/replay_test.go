@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+
+	"temporal-go-worker/internal/payload"
+)
+
+func replayEchoWorkflow(ctx workflow.Context, input ComplexProcessingInput) (string, error) {
+	return input.DatasetID, nil
+}
+
+// echoHistory is the history of a replayEchoWorkflow run that completed in
+// its first workflow task, with payloads encoded by codec when set
+func echoHistory(t *testing.T, codec converter.PayloadCodec) *historypb.History {
+	dc := converter.GetDefaultDataConverter()
+	if codec != nil {
+		dc = converter.NewCodecDataConverter(dc, codec)
+	}
+	input, err := dc.ToPayloads(ComplexProcessingInput{DatasetID: "dataset-1"})
+	require.NoError(t, err)
+	result, err := dc.ToPayloads("dataset-1")
+	require.NoError(t, err)
+
+	events := []*historypb.HistoryEvent{
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, Attributes: &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{
+			WorkflowExecutionStartedEventAttributes: &historypb.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &commonpb.WorkflowType{Name: "replayEchoWorkflow"},
+				TaskQueue:    &taskqueuepb.TaskQueue{Name: "go-workers"},
+				Input:        input,
+			},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_SCHEDULED, Attributes: &historypb.HistoryEvent_WorkflowTaskScheduledEventAttributes{
+			WorkflowTaskScheduledEventAttributes: &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: "go-workers"}},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_STARTED, Attributes: &historypb.HistoryEvent_WorkflowTaskStartedEventAttributes{
+			WorkflowTaskStartedEventAttributes: &historypb.WorkflowTaskStartedEventAttributes{ScheduledEventId: 2},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_COMPLETED, Attributes: &historypb.HistoryEvent_WorkflowTaskCompletedEventAttributes{
+			WorkflowTaskCompletedEventAttributes: &historypb.WorkflowTaskCompletedEventAttributes{ScheduledEventId: 2, StartedEventId: 3},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED, Attributes: &historypb.HistoryEvent_WorkflowExecutionCompletedEventAttributes{
+			WorkflowExecutionCompletedEventAttributes: &historypb.WorkflowExecutionCompletedEventAttributes{Result: result, WorkflowTaskCompletedEventId: 4},
+		}},
+	}
+	for i, event := range events {
+		event.EventId = int64(i + 1)
+	}
+	return &historypb.History{Events: events}
+}
+
+func newTestKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestReplayEncryptedHistory(t *testing.T) {
+	key := newTestKey(t)
+	codec, err := payload.NewEncryptionCodec(key)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		// historyCodec encodes the recorded payloads; replayKey is
+		// PAYLOAD_ENCRYPTION_KEY for the replayer
+		historyCodec converter.PayloadCodec
+		replayKey    []byte
+		wantErr      bool
+	}{
+		{name: "encrypted history with the production key", historyCodec: codec, replayKey: key},
+		{name: "encrypted history without a key", historyCodec: codec, wantErr: true},
+		{name: "encrypted history with another key", historyCodec: codec, replayKey: newTestKey(t), wantErr: true},
+		{name: "plain history with a key", replayKey: key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAYLOAD_ENCRYPTION_KEY", "")
+			if tt.replayKey != nil {
+				t.Setenv("PAYLOAD_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(tt.replayKey))
+			}
+			replayer, err := newReplayer()
+			require.NoError(t, err)
+			replayer.RegisterWorkflow(replayEchoWorkflow)
+
+			err = replayer.ReplayWorkflowHistory(nil, echoHistory(t, tt.historyCodec))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// StoredResult is a workflow's final result as kept in the object store,
+// outliving workflow history retention
+type StoredResult struct {
+	WorkflowID   string          `json:"workflow_id"`
+	RunID        string          `json:"run_id"`
+	WorkflowType string          `json:"workflow_type"`
+	CompletedAt  time.Time       `json:"completed_at"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// resultKey is the object key for a workflow's stored result. Workflow IDs
+// are caller-chosen, so they are escaped into a single path segment.
+func resultKey(workflowID string) string {
+	return "results/" + url.PathEscape(workflowID) + ".json"
+}
+
+// runResultKey is the object key for one run's stored result
+func runResultKey(workflowID, runID string) string {
+	return "results/" + url.PathEscape(workflowID) + "/" + url.PathEscape(runID) + ".json"
+}
+
+// PersistResult stores a workflow result under its run ID, then as the
+// workflow's latest result, which a later run with the same workflow ID
+// overwrites. The run's object is created only if absent, so when a run
+// completes twice, even concurrently, one completion writes and the other
+// changes nothing; stores that notify on writes (S3 events, ...) fire once
+// per run. The latest result is only written again when an earlier attempt
+// created the run's object but failed to update it.
+func (a *Activities) PersistResult(ctx context.Context, stored StoredResult) (ObjectRef, error) {
+	body, err := json.Marshal(stored)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	runKey := runResultKey(stored.WorkflowID, stored.RunID)
+	if _, err := objectStore.Create(ctx, runKey, bytes.NewReader(body)); errors.Is(err, fs.ErrExist) {
+		latest, err := LoadResult(ctx, objectStore, stored.WorkflowID)
+		if err == nil && (latest.RunID == stored.RunID || latest.CompletedAt.After(stored.CompletedAt)) {
+			activityLog.Infof("⏭️ Result of workflow %s run %s already persisted", stored.WorkflowID, stored.RunID)
+			return objectStore.Stat(ctx, resultKey(stored.WorkflowID))
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ObjectRef{}, err
+		}
+	} else if err != nil {
+		return ObjectRef{}, err
+	}
+
+	activityLog.Infof("💾 Persisting result of workflow %s", stored.WorkflowID)
+	ref, err := objectStore.Put(ctx, resultKey(stored.WorkflowID), bytes.NewReader(body))
+	if err != nil {
+		return ObjectRef{}, err
+	}
+
+	activityLog.Infof("✅ Result persisted: %s", ref.URI)
+	return ref, nil
+}
+
+// LoadResult retrieves a persisted workflow result by workflow ID without
+// going through Temporal
+func LoadResult(ctx context.Context, store ObjectStore, workflowID string) (StoredResult, error) {
+	r, err := store.Get(ctx, resultKey(workflowID))
+	if err != nil {
+		return StoredResult{}, fmt.Errorf("loading result of workflow %s: %w", workflowID, err)
+	}
+	defer r.Close()
+
+	var stored StoredResult
+	if err := json.NewDecoder(r).Decode(&stored); err != nil {
+		return StoredResult{}, fmt.Errorf("decoding result of workflow %s: %w", workflowID, err)
+	}
+	return stored, nil
+}
+
+// persistWorkflowResult runs PersistResult for the current workflow's result.
+// Failures are logged rather than failing a run whose work already finished.
+func persistWorkflowResult(ctx workflow.Context, result interface{}) {
+	logger := workflow.GetLogger(ctx)
+	info := workflow.GetInfo(ctx)
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		logger.Error("❌ Failed to encode result for persistence", "error", err)
+		return
+	}
+	stored := StoredResult{
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		WorkflowType: info.WorkflowType.Name,
+		CompletedAt:  workflow.Now(ctx).UTC(),
+		Result:       body,
+	}
+	if err := workflow.ExecuteActivity(ctx, activities.PersistResult, stored).Get(ctx, nil); err != nil {
+		logger.Error("❌ Failed to persist workflow result", "error", err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// countingStore counts the objects written to a store, standing in for the
+// write notifications (S3 events, ...) downstream consumers act on
+type countingStore struct {
+	ObjectStore
+	mu      sync.Mutex
+	writes  map[string]int
+	failPut int
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, r io.Reader) (ObjectRef, error) {
+	s.mu.Lock()
+	if s.failPut > 0 {
+		s.failPut--
+		s.mu.Unlock()
+		return ObjectRef{}, errors.New("store unavailable")
+	}
+	s.mu.Unlock()
+	ref, err := s.ObjectStore.Put(ctx, key, r)
+	if err == nil {
+		s.count(key)
+	}
+	return ref, err
+}
+
+func (s *countingStore) Create(ctx context.Context, key string, r io.Reader) (ObjectRef, error) {
+	ref, err := s.ObjectStore.Create(ctx, key, r)
+	if err == nil {
+		s.count(key)
+	}
+	return ref, err
+}
+
+func (s *countingStore) count(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes[key]++
+}
+
+func useCountingStore(t *testing.T) *countingStore {
+	store := &countingStore{ObjectStore: newFileObjectStore(t.TempDir()), writes: map[string]int{}}
+	prev := objectStore
+	t.Cleanup(func() { objectStore = prev })
+	objectStore = store
+	return store
+}
+
+func TestPersistResultOncePerRun(t *testing.T) {
+	completedAt := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	first := StoredResult{WorkflowID: "wf-1", RunID: "run-1", WorkflowType: "ComplexProcessingWorkflow", CompletedAt: completedAt, Result: []byte(`{"n":1}`)}
+	duplicate := first
+	duplicate.CompletedAt = completedAt.Add(time.Second)
+	duplicate.Result = []byte(`{"n":2}`)
+	nextRun := first
+	nextRun.RunID = "run-2"
+	nextRun.CompletedAt = completedAt.Add(time.Hour)
+	nextRun.Result = []byte(`{"n":3}`)
+
+	tests := []struct {
+		name        string
+		completions []StoredResult
+		concurrent  bool
+		wantWrites  map[string]int
+		wantLatest  StoredResult
+	}{
+		{
+			name:        "completed twice",
+			completions: []StoredResult{first, duplicate},
+			wantWrites:  map[string]int{"results/wf-1/run-1.json": 1, "results/wf-1.json": 1},
+			wantLatest:  first,
+		},
+		{
+			name:        "completed twice concurrently",
+			completions: []StoredResult{first, first},
+			concurrent:  true,
+			// The latest result may be written by both: the loser can't tell
+			// the winner is mid-write from a winner that crashed before it
+			wantWrites: map[string]int{"results/wf-1/run-1.json": 1},
+			wantLatest: first,
+		},
+		{
+			name:        "later run replaces the result",
+			completions: []StoredResult{first, nextRun, duplicate},
+			wantWrites:  map[string]int{"results/wf-1/run-1.json": 1, "results/wf-1/run-2.json": 1, "results/wf-1.json": 2},
+			wantLatest:  nextRun,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			var wg sync.WaitGroup
+			for _, stored := range tt.completions {
+				persist := func(stored StoredResult) {
+					defer wg.Done()
+					_, err := newActivities().PersistResult(context.Background(), stored)
+					assert.NoError(t, err)
+				}
+				wg.Add(1)
+				if tt.concurrent {
+					go persist(stored)
+				} else {
+					persist(stored)
+				}
+			}
+			wg.Wait()
+
+			if tt.concurrent {
+				assert.Equal(t, tt.wantWrites["results/wf-1/run-1.json"], store.writes["results/wf-1/run-1.json"])
+				assert.Contains(t, []int{1, 2}, store.writes["results/wf-1.json"])
+			} else {
+				assert.Equal(t, tt.wantWrites, store.writes)
+			}
+			latest, err := LoadResult(context.Background(), store, "wf-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLatest.RunID, latest.RunID)
+			assert.JSONEq(t, string(tt.wantLatest.Result), string(latest.Result))
+		})
+	}
+}
+
+func TestPersistResultRetryRepairsLatest(t *testing.T) {
+	store := useCountingStore(t)
+	store.failPut = 1
+	stored := StoredResult{WorkflowID: "wf-1", RunID: "run-1", CompletedAt: time.Now(), Result: []byte(`{}`)}
+
+	_, err := newActivities().PersistResult(context.Background(), stored)
+	require.Error(t, err)
+	_, err = newActivities().PersistResult(context.Background(), stored)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"results/wf-1/run-1.json": 1, "results/wf-1.json": 1}, store.writes)
+}
+
+// TestComplexProcessingPersistsResult checks a run asked to persist its
+// result does so under its workflow ID, and still completes when it can't
+func TestComplexProcessingPersistsResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		persistErr error
+	}{
+		{name: "persisted"},
+		{name: "store unavailable", persistErr: temporal.NewNonRetryableApplicationError("store unavailable", "StoreUnavailable", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "wf-1"})
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+			var persisted []StoredResult
+			env.OnActivity(activities.PersistResult, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, stored StoredResult) (ObjectRef, error) {
+					persisted = append(persisted, stored)
+					return ObjectRef{}, tt.persistErr
+				})
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:    CurrentComplexProcessingInputVersion,
+				DatasetID:  "ds-1",
+				Parameters: Parameters{"persist_result": true},
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			require.Len(t, persisted, 1)
+			assert.Equal(t, "wf-1", persisted[0].WorkflowID)
+			assert.Equal(t, "ComplexProcessingWorkflow", persisted[0].WorkflowType)
+			var result ComplexProcessingResult
+			require.NoError(t, json.Unmarshal(persisted[0].Result, &result))
+			assert.Equal(t, "ds-1", result.DatasetID)
+			assert.Equal(t, 10, result.ProcessedItems)
+		})
+	}
+}
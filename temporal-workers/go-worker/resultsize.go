@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// How DatabaseOperation handles rows too large to return, chosen with the
+// oversized_results parameter
+const (
+	OversizedExternalize = "externalize" // store the rows and return a reference
+	OversizedTruncate    = "truncate"    // return the rows that fit
+)
+
+// defaultMaxResultBytes leaves headroom under the server's 2MB default blob
+// size limit for the rest of the result
+const defaultMaxResultBytes = 1 << 20
+
+// oversizedRowsPolicy reads max_result_bytes and oversized_results
+func oversizedRowsPolicy(params Parameters) (int, string, error) {
+	limit := int64(defaultMaxResultBytes)
+	if v, ok := params.Int64("max_result_bytes"); ok && v > 0 {
+		limit = v
+	}
+	mode, _ := params.String("oversized_results")
+	switch mode {
+	case "":
+		mode = OversizedExternalize
+	case OversizedExternalize, OversizedTruncate:
+	default:
+		return 0, "", temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("oversized_results must be %s or %s, got %q", OversizedExternalize, OversizedTruncate, mode),
+			"InvalidInput", nil)
+	}
+	return int(limit), mode, nil
+}
+
+// fittedRows is what is left of a row set after applying the size limit
+type fittedRows struct {
+	Rows      []map[string]interface{}
+	Ref       *ObjectRef
+	Truncated bool
+}
+
+// fitRows returns rows unchanged if their JSON fits in limit bytes.
+// Otherwise it either stores every row under key and returns the reference,
+// or keeps the leading rows that fit and flags the result as truncated.
+func fitRows(ctx context.Context, key string, rows []map[string]interface{}, limit int, mode string) (fittedRows, error) {
+	encoded, err := json.Marshal(rows)
+	if err != nil {
+		return fittedRows{}, err
+	}
+	if len(encoded) <= limit {
+		return fittedRows{Rows: rows}, nil
+	}
+
+	if mode == OversizedTruncate {
+		size := 2 // []
+		kept := 0
+		for _, row := range rows {
+			line, err := json.Marshal(row)
+			if err != nil {
+				return fittedRows{}, err
+			}
+			if size+len(line)+1 > limit {
+				break
+			}
+			size += len(line) + 1
+			kept++
+		}
+		activityLog.Errorf("⚠️ Result of %d rows is %d bytes, over the %d byte limit; returning the first %d", len(rows), len(encoded), limit, kept)
+		return fittedRows{Rows: rows[:kept], Truncated: true}, nil
+	}
+
+	ref, err := objectStore.Put(ctx, key, bytes.NewReader(encoded))
+	if err != nil {
+		return fittedRows{}, fmt.Errorf("externalizing oversized result: %w", err)
+	}
+	activityLog.Infof("📦 Result of %d rows is %d bytes, over the %d byte limit; stored at %s", len(rows), len(encoded), limit, ref.URI)
+	return fittedRows{Ref: &ref}, nil
+}
+
+// externalizedRowsKey names the object an activity's oversized rows are
+// stored under; retries overwrite the same object
+func externalizedRowsKey(ctx context.Context) string {
+	info := activity.GetInfo(ctx)
+	return fmt.Sprintf("rows/%s/%s-%s.json", url.PathEscape(info.WorkflowExecution.ID), info.ActivityType.Name, info.ActivityID)
+}
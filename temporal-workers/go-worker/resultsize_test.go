@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// rowsDB returns rows from every query, or fails them with err
+type rowsDB struct {
+	recordingExecer
+	rows []map[string]interface{}
+}
+
+func (d *rowsDB) QueryRows(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	d.queries = append(d.queries, query)
+	return d.rows, d.err
+}
+
+func TestDatabaseOperationOversizedRows(t *testing.T) {
+	rows := make([]map[string]interface{}, 10)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": i + 1, "data": strings.Repeat("x", 20)}
+	}
+	tests := []struct {
+		name          string
+		parameters    Parameters
+		dbErr         error
+		failPut       int
+		wantRows      int
+		wantRef       bool
+		wantTruncated bool
+		wantErrType   string
+		wantErr       string
+	}{
+		{name: "fits", wantRows: 10},
+		{name: "externalized", parameters: Parameters{"max_result_bytes": 100}, wantRef: true},
+		{name: "truncated", parameters: Parameters{"max_result_bytes": 100, "oversized_results": OversizedTruncate}, wantRows: 2, wantTruncated: true},
+		{name: "unknown mode", parameters: Parameters{"oversized_results": "drop"}, wantErrType: "InvalidInput"},
+		{name: "store unavailable", parameters: Parameters{"max_result_bytes": 100}, failPut: 1, wantErr: "externalizing oversized result: store unavailable"},
+		{name: "query fails", dbErr: stateError{"23503", "foreign key"}, wantErrType: ErrTypeConstraintViolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			store.failPut = tt.failPut
+			db := &rowsDB{recordingExecer: recordingExecer{err: tt.dbErr}, rows: rows}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(&Activities{DB: db})
+
+			value, err := env.ExecuteActivity(activities.DatabaseOperation, DatabaseOperationInput{Operation: "select", Target: "analytics.results", Parameters: tt.parameters})
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var result DatabaseOperationResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, 10, result.RowsAffected)
+			assert.Len(t, result.Rows, tt.wantRows)
+			assert.Equal(t, tt.wantTruncated, result.Truncated)
+			assert.Equal(t, []string{`SELECT * FROM "analytics"."results" WHERE id = $1`}, db.queries)
+			if !tt.wantRef {
+				assert.Nil(t, result.RowsRef)
+				return
+			}
+			require.NotNil(t, result.RowsRef)
+			assert.Equal(t, 1, store.writes[result.RowsRef.Key])
+			r, err := store.Get(context.Background(), result.RowsRef.Key)
+			require.NoError(t, err)
+			defer r.Close()
+			var stored []map[string]interface{}
+			require.NoError(t, json.NewDecoder(r).Decode(&stored))
+			assert.Len(t, stored, 10)
+		})
+	}
+}
+
+func TestFitRowsTruncatesWithinLimit(t *testing.T) {
+	rows := make([]map[string]interface{}, 50)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": fmt.Sprint(i)}
+	}
+	for _, limit := range []int{2, 20, 100, 333} {
+		fitted, err := fitRows(context.Background(), "unused", rows, limit, OversizedTruncate)
+		require.NoError(t, err)
+		encoded, err := json.Marshal(fitted.Rows)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(encoded), limit, "limit %d", limit)
+		assert.True(t, fitted.Truncated)
+	}
+}
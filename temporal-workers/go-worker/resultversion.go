@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeUnsupportedResultVersion is returned, non-retryable, when a caller
+// asks for a result shape this worker can't produce
+const ErrTypeUnsupportedResultVersion = "UnsupportedResultVersion"
+
+// CurrentProcessLargeDatasetResultVersion is the ProcessLargeDatasetResult
+// shape returned unless the caller asks for an older one.
+//
+//	v1: items_processed, processing_time, metrics and results only
+//	v2: adds schema_version, records, quarantined and quarantine
+const CurrentProcessLargeDatasetResultVersion = 2
+
+// processLargeDatasetResultV1 is the v1 result shape
+type processLargeDatasetResultV1 struct {
+	ItemsProcessed int                    `json:"items_processed"`
+	ProcessingTime string                 `json:"processing_time"`
+	Metrics        ProcessingMetrics      `json:"metrics"`
+	Results        map[string]interface{} `json:"results"`
+}
+
+// resolveResultVersion returns the result shape to produce for a requested
+// result_version; zero means the current one
+func resolveResultVersion(requested int) (int, error) {
+	if requested == 0 {
+		return CurrentProcessLargeDatasetResultVersion, nil
+	}
+	if requested < 1 || requested > CurrentProcessLargeDatasetResultVersion {
+		return 0, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unsupported result_version %d (this worker supports 1 to %d)", requested, CurrentProcessLargeDatasetResultVersion),
+			ErrTypeUnsupportedResultVersion, nil)
+	}
+	return requested, nil
+}
+
+// MarshalJSON encodes the shape named by SchemaVersion, so consumers pinned
+// to an older version keep receiving exactly the fields they know. Results
+// without a version (recorded before versioning) encode as they are.
+func (r ProcessLargeDatasetResult) MarshalJSON() ([]byte, error) {
+	if r.SchemaVersion == 1 {
+		return json.Marshal(processLargeDatasetResultV1{
+			ItemsProcessed: r.ItemsProcessed,
+			ProcessingTime: r.ProcessingTime,
+			Metrics:        r.Metrics,
+			Results:        r.Results,
+		})
+	}
+	type plain ProcessLargeDatasetResult
+	return json.Marshal(plain(r))
+}
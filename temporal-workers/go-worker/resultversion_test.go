@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fixedProcessor returns result for every input
+type fixedProcessor struct {
+	result ProcessLargeDatasetResult
+}
+
+func (p fixedProcessor) Process(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+	return p.result, nil
+}
+
+func TestProcessLargeDatasetResultVersion(t *testing.T) {
+	processed := ProcessLargeDatasetResult{
+		ItemsProcessed: 10,
+		ProcessingTime: "1s",
+		Metrics:        ProcessingMetrics{Throughput: 10},
+		Results:        map[string]interface{}{"success_rate": 0.9},
+		Records:        []ProcessedRecord{{ID: "r-1", Status: "ok"}},
+		Quarantined:    1,
+		Quarantine:     &ObjectRef{URI: "file:///quarantine/ds-1.jsonl"},
+	}
+	tests := []struct {
+		name        string
+		version     int
+		wantFields  []string
+		wantVersion int
+	}{
+		{
+			name:        "current by default",
+			wantFields:  []string{"items_processed", "metrics", "processing_time", "quarantine", "quarantined", "records", "results", "schema_version"},
+			wantVersion: CurrentProcessLargeDatasetResultVersion,
+		},
+		{
+			name:        "pinned to v2",
+			version:     2,
+			wantFields:  []string{"items_processed", "metrics", "processing_time", "quarantine", "quarantined", "records", "results", "schema_version"},
+			wantVersion: 2,
+		},
+		{name: "pinned to v1", version: 1, wantFields: []string{"items_processed", "metrics", "processing_time", "results"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			acts := &Activities{Processor: fixedProcessor{result: processed}}
+			env.RegisterActivity(acts)
+
+			value, err := env.ExecuteActivity(acts.ProcessLargeDataset, ProcessLargeDatasetInput{DatasetID: "ds-1", ResultVersion: tt.version})
+			require.NoError(t, err)
+			var fields map[string]json.RawMessage
+			require.NoError(t, value.Get(&fields))
+			var names []string
+			for name := range fields {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tt.wantFields, names)
+			var result ProcessLargeDatasetResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantVersion, result.SchemaVersion)
+			assert.Equal(t, 10, result.ItemsProcessed)
+			assert.Equal(t, 0.9, result.Results["success_rate"])
+		})
+	}
+}
+
+func TestProcessLargeDatasetUnsupportedResultVersion(t *testing.T) {
+	for _, version := range []int{-1, CurrentProcessLargeDatasetResultVersion + 1} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestActivityEnvironment()
+		acts := &Activities{Processor: fixedProcessor{}}
+		env.RegisterActivity(acts)
+
+		_, err := env.ExecuteActivity(acts.ProcessLargeDataset, ProcessLargeDatasetInput{DatasetID: "ds-1", ResultVersion: version})
+		requireApplicationError(t, err, ErrTypeUnsupportedResultVersion)
+		assert.Contains(t, err.Error(), fmt.Sprintf("unsupported result_version %d", version))
+	}
+}
+
+func TestProcessLargeDatasetResultUnversioned(t *testing.T) {
+	// Results recorded before versioning encode every field as they are
+	encoded, err := json.Marshal(ProcessLargeDatasetResult{ItemsProcessed: 3, Quarantined: 1})
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.NotContains(t, fields, "schema_version")
+	assert.JSONEq(t, "1", string(fields["quarantined"]))
+}
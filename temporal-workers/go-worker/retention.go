@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const defaultRetentionDays = 30
+
+// DatasetRecord describes a stored dataset for retention purposes
+type DatasetRecord struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DatasetStore lists and deletes stored datasets (S3 prefix, DB table, ...).
+// DeleteDataset must treat an already-deleted dataset as success so purges
+// can be retried safely.
+type DatasetStore interface {
+	ListDatasets(ctx context.Context, pageToken string, pageSize int) ([]DatasetRecord, string, error)
+	DeleteDataset(ctx context.Context, id string) error
+}
+
+// datasetStore is the store used by PurgeExpiredData
+var datasetStore DatasetStore = newMemoryDatasetStore()
+
+// memoryDatasetStore is an in-process DatasetStore for local runs
+type memoryDatasetStore struct {
+	mu       sync.Mutex
+	datasets map[string]DatasetRecord
+}
+
+func newMemoryDatasetStore(records ...DatasetRecord) *memoryDatasetStore {
+	s := &memoryDatasetStore{datasets: make(map[string]DatasetRecord)}
+	for _, r := range records {
+		s.datasets[r.ID] = r
+	}
+	return s
+}
+
+func (s *memoryDatasetStore) ListDatasets(ctx context.Context, pageToken string, pageSize int) ([]DatasetRecord, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.datasets))
+	for id := range s.datasets {
+		if id > pageToken {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	next := ""
+	if len(ids) > pageSize {
+		ids = ids[:pageSize]
+		next = ids[len(ids)-1]
+	}
+	records := make([]DatasetRecord, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, s.datasets[id])
+	}
+	return records, next, nil
+}
+
+func (s *memoryDatasetStore) DeleteDataset(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.datasets, id)
+	return nil
+}
+
+// PurgeExpiredDataInput represents input for purging expired datasets
+type PurgeExpiredDataInput struct {
+	RetentionDays int `json:"retention_days"`
+	PageSize      int `json:"page_size"`
+}
+
+// PurgeExpiredDataResult represents the result of a retention purge. It
+// holds counts only, so it stays small however many datasets expire; the
+// deleted IDs are audited page by page.
+type PurgeExpiredDataResult struct {
+	Scanned      int `json:"scanned"`
+	DeletedCount int `json:"deleted_count"`
+}
+
+// purgeProgress is heartbeated so a retried purge resumes where it left off
+type purgeProgress struct {
+	PageToken string                 `json:"page_token"`
+	Result    PurgeExpiredDataResult `json:"result"`
+}
+
+// PurgeExpiredData deletes datasets older than the retention window. The
+// expired IDs of each page go to the audit log before they are deleted and
+// the page is checkpointed, so a retried page may audit an ID twice but no
+// dataset is deleted unaudited.
+func (a *Activities) PurgeExpiredData(ctx context.Context, input PurgeExpiredDataInput) (PurgeExpiredDataResult, error) {
+	retentionDays := input.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultRetentionDays
+	}
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	cutoff := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+
+	log.Printf("🧹 Purging datasets older than %d days (before %s)", retentionDays, cutoff.Format(time.RFC3339))
+
+	var progress purgeProgress
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &progress); err != nil {
+			log.Printf("⚠️ Ignoring unreadable purge progress: %v", err)
+			progress = purgeProgress{}
+		}
+	}
+
+	for {
+		records, next, err := datasetStore.ListDatasets(ctx, progress.PageToken, pageSize)
+		if err != nil {
+			return progress.Result, err
+		}
+
+		var expired []string
+		for _, record := range records {
+			if record.CreatedAt.Before(cutoff) {
+				expired = append(expired, record.ID)
+			}
+		}
+		if len(expired) > 0 {
+			err := a.AuditLog(ctx, AuditLogInput{
+				Action:  "retention_purge_deleting",
+				Details: map[string]interface{}{"retention_days": retentionDays, "datasets": expired},
+			})
+			if err != nil {
+				return progress.Result, err
+			}
+		}
+		for _, id := range expired {
+			if err := datasetStore.DeleteDataset(ctx, id); err != nil {
+				return progress.Result, err
+			}
+		}
+		progress.Result.Scanned += len(records)
+		progress.Result.DeletedCount += len(expired)
+
+		progress.PageToken = next
+		activity.RecordHeartbeat(ctx, progress)
+		if ctx.Err() != nil {
+			return progress.Result, ctx.Err()
+		}
+		if next == "" {
+			break
+		}
+	}
+
+	log.Printf("✅ Purge completed: %d scanned, %d deleted", progress.Result.Scanned, progress.Result.DeletedCount)
+	return progress.Result, nil
+}
+
+// RetentionInput represents input for the retention workflow
+type RetentionInput struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+// RetentionWorkflow purges expired datasets and audits the deletions. It is
+// meant to be started from a Temporal Schedule (e.g. daily).
+func RetentionWorkflow(ctx workflow.Context, input RetentionInput) (PurgeExpiredDataResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("🧹 Starting retention workflow", "retention_days", input.RetentionDays)
+	logWorkflowInput(ctx, input)
+
+	activityOptions, err := newHeartbeatActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Hour}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}, purgeHeartbeatInterval)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return PurgeExpiredDataResult{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	var result PurgeExpiredDataResult
+	err = workflow.ExecuteActivity(ctx, activities.PurgeExpiredData, PurgeExpiredDataInput{
+		RetentionDays: input.RetentionDays,
+	}).Get(ctx, &result)
+	if err != nil {
+		logger.Error("❌ Retention purge failed", "error", err)
+		return result, err
+	}
+
+	err = workflow.ExecuteActivity(ctx, activities.AuditLog, AuditLogInput{
+		Action: "retention_purge_completed",
+		Details: map[string]interface{}{
+			"retention_days": input.RetentionDays,
+			"scanned":        result.Scanned,
+			"deleted_count":  result.DeletedCount,
+		},
+	}).Get(ctx, nil)
+	if err != nil {
+		logger.Error("❌ Failed to audit log", "error", err)
+		return result, err
+	}
+
+	logger.Info("✅ Retention workflow completed", "deleted", result.DeletedCount)
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestPurgeExpiredData(t *testing.T) {
+	now := time.Now()
+	var records []DatasetRecord
+	var expired, fresh []string
+	for i := 0; i < 7; i++ {
+		id := fmt.Sprintf("dataset-%d", i)
+		age := 10 * 24 * time.Hour
+		if i%2 == 0 {
+			age = 40 * 24 * time.Hour
+			expired = append(expired, id)
+		} else {
+			fresh = append(fresh, id)
+		}
+		records = append(records, DatasetRecord{ID: id, CreatedAt: now.Add(-age)})
+	}
+
+	tests := []struct {
+		name     string
+		pageSize int
+		// progress, if set, is the checkpoint of an earlier attempt
+		progress *purgeProgress
+		// wantKept are expired datasets before the checkpoint, which
+		// this attempt doesn't revisit
+		wantKept    []string
+		wantScanned int
+		wantDeleted int
+	}{
+		{name: "one page", pageSize: 100, wantScanned: 7, wantDeleted: 4},
+		{name: "several pages", pageSize: 2, wantScanned: 7, wantDeleted: 4},
+		{
+			name:     "resumed after the first page",
+			pageSize: 2,
+			progress: &purgeProgress{PageToken: "dataset-1", Result: PurgeExpiredDataResult{Scanned: 2, DeletedCount: 1}},
+			// The checkpoint says dataset-0 is already deleted
+			wantKept:    []string{"dataset-0"},
+			wantScanned: 7,
+			wantDeleted: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryDatasetStore(records...)
+			prev := datasetStore
+			t.Cleanup(func() { datasetStore = prev })
+			datasetStore = store
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			if tt.progress != nil {
+				env.SetHeartbeatDetails(*tt.progress)
+			}
+			value, err := env.ExecuteActivity(activities.PurgeExpiredData, PurgeExpiredDataInput{RetentionDays: 30, PageSize: tt.pageSize})
+			require.NoError(t, err)
+			var result PurgeExpiredDataResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantScanned, result.Scanned)
+			assert.Equal(t, tt.wantDeleted, result.DeletedCount)
+
+			var remaining []string
+			for id := range store.datasets {
+				remaining = append(remaining, id)
+			}
+			assert.ElementsMatch(t, append(append([]string{}, fresh...), tt.wantKept...), remaining)
+		})
+	}
+}
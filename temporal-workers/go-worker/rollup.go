@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	rollupDateLayout      = "2006-01-02"
+	defaultRollupPageSize = 100
+)
+
+// RollupInput represents input for the rollup workflow. Date defaults to
+// the previous UTC day, WorkflowType to ComplexProcessingWorkflow.
+type RollupInput struct {
+	Date         string `json:"date"`
+	WorkflowType string `json:"workflow_type"`
+	PageSize     int    `json:"page_size"`
+}
+
+// RollupRecord aggregates the results of one day's completed runs
+type RollupRecord struct {
+	Date                string         `json:"date"`
+	WorkflowType        string         `json:"workflow_type"`
+	Runs                int            `json:"runs"`
+	Unavailable         int            `json:"unavailable"` // completed runs whose result couldn't be read
+	TotalItems          int64          `json:"total_items"`
+	AverageItems        float64        `json:"average_items"`
+	TotalOptimization   float64        `json:"total_optimization_gain"`
+	AverageOptimization float64        `json:"average_optimization_gain"`
+	ByProcessType       map[string]int `json:"by_process_type"`
+	Object              *ObjectRef     `json:"object,omitempty"`
+}
+
+// rollupProgress is heartbeated so a retried rollup resumes at the page it
+// reached instead of re-reading every run
+type rollupProgress struct {
+	PageToken []byte       `json:"page_token"`
+	Record    RollupRecord `json:"record"`
+}
+
+// RollupWorkflow aggregates a day's completed ComplexProcessingWorkflow
+// runs into a rollup record. It is meant to be started from a daily
+// Temporal Schedule.
+func RollupWorkflow(ctx workflow.Context, input RollupInput) (RollupRecord, error) {
+	if input.Date == "" {
+		input.Date = workflow.Now(ctx).UTC().AddDate(0, 0, -1).Format(rollupDateLayout)
+	}
+	if input.WorkflowType == "" {
+		input.WorkflowType = "ComplexProcessingWorkflow"
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Info("📊 Starting rollup workflow", "date", input.Date, "workflow_type", input.WorkflowType)
+	logWorkflowInput(ctx, input)
+
+	activityOptions, err := newHeartbeatActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: time.Hour}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}, rollupHeartbeatInterval)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return RollupRecord{}, err
+	}
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+
+	var record RollupRecord
+	if err := workflow.ExecuteActivity(ctx, activities.ComputeRollup, input).Get(ctx, &record); err != nil {
+		logger.Error("❌ Rollup failed", "error", err)
+		return record, err
+	}
+
+	logger.Info("✅ Rollup workflow completed", "runs", record.Runs, "total_items", record.TotalItems)
+	return record, nil
+}
+
+// ComputeRollup pages through completed runs of the workflow type closed on
+// the given day, reads each result and stores the aggregate under rollups/
+func (a *Activities) ComputeRollup(ctx context.Context, input RollupInput) (RollupRecord, error) {
+	day, err := time.Parse(rollupDateLayout, input.Date)
+	if err != nil {
+		return RollupRecord{}, temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid rollup date %q", input.Date), "InvalidInput", err)
+	}
+	if strings.ContainsAny(input.WorkflowType, `'"`) {
+		return RollupRecord{}, temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid workflow type %q", input.WorkflowType), "InvalidInput", nil)
+	}
+	if temporalClient == nil {
+		return RollupRecord{}, temporal.NewNonRetryableApplicationError("no Temporal client configured", "RollupUnavailable", nil)
+	}
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultRollupPageSize
+	}
+
+	progress := rollupProgress{Record: RollupRecord{
+		Date:          input.Date,
+		WorkflowType:  input.WorkflowType,
+		ByProcessType: map[string]int{},
+	}}
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &progress); err == nil {
+			activityLog.Infof("🔁 Resuming rollup for %s after %d runs", input.Date, progress.Record.Runs)
+		}
+	}
+	progress.Record.ByProcessType = mapOrEmpty(progress.Record.ByProcessType)
+	activityLog.Infof("📊 Computing rollup of %s runs for %s", input.WorkflowType, input.Date)
+
+	query := fmt.Sprintf("WorkflowType = '%s' AND ExecutionStatus = 'Completed' AND CloseTime >= '%s' AND CloseTime < '%s'",
+		input.WorkflowType, day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339))
+	namespace := activity.GetInfo(ctx).WorkflowNamespace
+
+	for {
+		resp, err := temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     namespace,
+			PageSize:      int32(pageSize),
+			NextPageToken: progress.PageToken,
+			Query:         query,
+		})
+		if err != nil {
+			return RollupRecord{}, fmt.Errorf("listing completed runs: %w", err)
+		}
+
+		for _, execution := range resp.Executions {
+			result, err := completedRunResult(ctx, execution.Execution.WorkflowId, execution.Execution.RunId)
+			if err != nil {
+				activityLog.Errorf("❌ Unable to read result of %s: %v", execution.Execution.WorkflowId, err)
+				progress.Record.Unavailable++
+				continue
+			}
+			progress.Record.add(result)
+		}
+
+		progress.PageToken = resp.NextPageToken
+		activity.RecordHeartbeat(ctx, progress)
+		if len(progress.PageToken) == 0 {
+			break
+		}
+	}
+
+	record := progress.Record
+	record.finish()
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return RollupRecord{}, err
+	}
+	ref, err := objectStore.Put(ctx, fmt.Sprintf("rollups/%s/%s.json", record.WorkflowType, record.Date), bytes.NewReader(body))
+	if err != nil {
+		return RollupRecord{}, err
+	}
+	record.Object = &ref
+
+	activityLog.Infof("✅ Rollup stored: %d runs, %d items", record.Runs, record.TotalItems)
+	return record, nil
+}
+
+// completedRunResult reads a run's result from history, falling back to a
+// persisted copy once history is gone
+func completedRunResult(ctx context.Context, workflowID, runID string) (ComplexProcessingResult, error) {
+	var result ComplexProcessingResult
+	err := temporalClient.GetWorkflow(ctx, workflowID, runID).Get(ctx, &result)
+	var notFound *serviceerror.NotFound
+	if !errors.As(err, &notFound) {
+		return result, err
+	}
+
+	stored, loadErr := LoadResult(ctx, objectStore, workflowID)
+	if loadErr != nil {
+		return result, err
+	}
+	return result, json.Unmarshal(stored.Result, &result)
+}
+
+func (r *RollupRecord) add(result ComplexProcessingResult) {
+	r.Runs++
+	r.TotalItems += int64(result.ProcessedItems)
+	r.TotalOptimization += result.OptimizationGain
+	processType := string(result.Routing.ProcessType)
+	if processType == "" {
+		processType = "unknown"
+	}
+	r.ByProcessType[processType]++
+}
+
+func (r *RollupRecord) finish() {
+	if r.Runs == 0 {
+		return
+	}
+	r.AverageItems = float64(r.TotalItems) / float64(r.Runs)
+	r.AverageOptimization = r.TotalOptimization / float64(r.Runs)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// rollupClient lists pages of runs and returns each run's result by
+// workflow ID. Runs without a result return their error from Get.
+type rollupClient struct {
+	client.Client
+	pages   [][]string
+	results map[string]ComplexProcessingResult
+	errs    map[string]error
+	listErr error
+	queries []string
+}
+
+func (c *rollupClient) ListWorkflow(ctx context.Context, request *workflowservice.ListWorkflowExecutionsRequest) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	c.queries = append(c.queries, request.Query)
+	if c.listErr != nil {
+		return nil, c.listErr
+	}
+	page := 0
+	if len(request.NextPageToken) > 0 {
+		page = int(request.NextPageToken[0])
+	}
+	resp := &workflowservice.ListWorkflowExecutionsResponse{}
+	for _, id := range c.pages[page] {
+		resp.Executions = append(resp.Executions, &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: id, RunId: id + "-run"},
+		})
+	}
+	if page+1 < len(c.pages) {
+		resp.NextPageToken = []byte{byte(page + 1)}
+	}
+	return resp, nil
+}
+
+func (c *rollupClient) GetWorkflow(ctx context.Context, workflowID, runID string) client.WorkflowRun {
+	return rollupRun{result: c.results[workflowID], err: c.errs[workflowID]}
+}
+
+type rollupRun struct {
+	client.WorkflowRun
+	result ComplexProcessingResult
+	err    error
+}
+
+func (r rollupRun) Get(ctx context.Context, valuePtr interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*valuePtr.(*ComplexProcessingResult) = r.result
+	return nil
+}
+
+func TestComputeRollup(t *testing.T) {
+	parallel := func(items int, gain float64) ComplexProcessingResult {
+		return ComplexProcessingResult{ProcessedItems: items, OptimizationGain: gain, Routing: RoutingDecision{ProcessType: ProcessTypeParallel}}
+	}
+	tests := []struct {
+		name        string
+		input       RollupInput
+		client      *rollupClient
+		noClient    bool
+		want        RollupRecord
+		wantErr     string
+		wantErrType string
+	}{
+		{
+			name:  "aggregated over pages",
+			input: RollupInput{Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow", PageSize: 2},
+			client: &rollupClient{
+				pages:   [][]string{{"wf-1", "wf-2"}, {"wf-3", "wf-4"}},
+				results: map[string]ComplexProcessingResult{"wf-1": parallel(10, 0.25), "wf-2": parallel(30, 0.5), "wf-3": {ProcessedItems: 20}},
+				errs:    map[string]error{"wf-4": errors.New("history unavailable")},
+			},
+			want: RollupRecord{
+				Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow", Runs: 3, Unavailable: 1,
+				TotalItems: 60, AverageItems: 20, TotalOptimization: 0.75, AverageOptimization: 0.25,
+				ByProcessType: map[string]int{"parallel": 2, "unknown": 1},
+			},
+		},
+		{
+			name:   "no runs",
+			input:  RollupInput{Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow"},
+			client: &rollupClient{pages: [][]string{{}}},
+			want:   RollupRecord{Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow", ByProcessType: map[string]int{}},
+		},
+		{name: "invalid date", input: RollupInput{Date: "14/10/2026"}, client: &rollupClient{}, wantErrType: "InvalidInput"},
+		{name: "quote in workflow type", input: RollupInput{Date: "2026-10-14", WorkflowType: "X' OR '1'='1"}, client: &rollupClient{}, wantErrType: "InvalidInput"},
+		{name: "no client", input: RollupInput{Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow"}, noClient: true, wantErrType: "RollupUnavailable"},
+		{
+			name:    "listing fails",
+			input:   RollupInput{Date: "2026-10-14", WorkflowType: "ComplexProcessingWorkflow"},
+			client:  &rollupClient{listErr: serviceerror.NewUnavailable("frontend down")},
+			wantErr: "listing completed runs: frontend down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			prev := temporalClient
+			t.Cleanup(func() { temporalClient = prev })
+			temporalClient = nil
+			if !tt.noClient {
+				temporalClient = tt.client
+			}
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+
+			value, err := env.ExecuteActivity(activities.ComputeRollup, tt.input)
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Empty(t, store.writes)
+				return
+			}
+			require.NoError(t, err)
+			var got RollupRecord
+			require.NoError(t, value.Get(&got))
+			require.NotNil(t, got.Object)
+			assert.Equal(t, "rollups/ComplexProcessingWorkflow/2026-10-14.json", got.Object.Key)
+			got.Object = nil
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, tt.client.queries[0], "CloseTime >= '2026-10-14T00:00:00Z' AND CloseTime < '2026-10-15T00:00:00Z'")
+
+			r, err := store.Get(context.Background(), "rollups/ComplexProcessingWorkflow/2026-10-14.json")
+			require.NoError(t, err)
+			defer r.Close()
+			var stored RollupRecord
+			require.NoError(t, json.NewDecoder(r).Decode(&stored))
+			assert.Equal(t, tt.want, stored)
+		})
+	}
+}
+
+// TestCompletedRunResultFallsBackToStore checks runs whose history is gone
+// are read from their persisted result
+func TestCompletedRunResultFallsBackToStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		persisted bool
+		wantItems int
+		wantErr   bool
+	}{
+		{name: "persisted copy", persisted: true, wantItems: 42},
+		{name: "no copy", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCountingStore(t)
+			prev := temporalClient
+			t.Cleanup(func() { temporalClient = prev })
+			temporalClient = &rollupClient{errs: map[string]error{"wf-1": serviceerror.NewNotFound("workflow execution not found")}}
+			if tt.persisted {
+				body, err := json.Marshal(ComplexProcessingResult{ProcessedItems: 42})
+				require.NoError(t, err)
+				_, err = newActivities().PersistResult(context.Background(), StoredResult{WorkflowID: "wf-1", RunID: "wf-1-run", CompletedAt: time.Now(), Result: body})
+				require.NoError(t, err)
+			}
+
+			result, err := completedRunResult(context.Background(), "wf-1", "wf-1-run")
+			if tt.wantErr {
+				var notFound *serviceerror.NotFound
+				assert.True(t, errors.As(err, &notFound), "want the history error, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantItems, result.ProcessedItems)
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3PartSize is the size of multipart upload parts, and so the most an
+// upload holds in memory. S3 requires at least 5MB for all but the last.
+const s3PartSize = 8 << 20
+
+// s3Credentials are static AWS credentials
+type s3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// s3ObjectStore stores objects in an S3 bucket, or in an S3-compatible
+// store such as MinIO, so every worker sees the same objects. Requests are
+// signed with Signature Version 4 from static credentials. Uploads of
+// unknown length go up in s3PartSize parts, which bounds memory use.
+type s3ObjectStore struct {
+	client   *http.Client
+	bucket   string
+	prefix   string
+	region   string
+	creds    s3Credentials
+	partSize int
+	// endpoint, when set, is an S3-compatible endpoint addressed
+	// path-style (<endpoint>/<bucket>/<key>); otherwise AWS is addressed
+	// virtual-hosted style
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// newS3ObjectStore returns a store for an s3://bucket/prefix URL. endpoint
+// is optional.
+func newS3ObjectStore(storeURL, region, endpoint string, creds s3Credentials) (*s3ObjectStore, error) {
+	u, err := url.Parse(storeURL)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid object store URL %q: want s3://bucket[/prefix]", storeURL)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for an s3:// object store")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	s := &s3ObjectStore{
+		client:   &http.Client{Timeout: 5 * time.Minute},
+		bucket:   u.Host,
+		prefix:   strings.Trim(u.Path, "/"),
+		region:   region,
+		creds:    creds,
+		partSize: s3PartSize,
+		now:      time.Now,
+	}
+	if endpoint != "" {
+		if s.endpoint, err = url.Parse(endpoint); err != nil || s.endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+		}
+	}
+	return s, nil
+}
+
+func (s *s3ObjectStore) objectKey(key string) (string, error) {
+	key = strings.Trim(key, "/")
+	if key == "" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	return key, nil
+}
+
+func (s *s3ObjectStore) uri(objectKey string) string {
+	return "s3://" + s.bucket + "/" + objectKey
+}
+
+// objectURL returns the URL of an object, encoded exactly as it is signed
+func (s *s3ObjectStore) objectURL(objectKey string, query url.Values) *url.URL {
+	u := &url.URL{Scheme: "https", Host: s.bucket + ".s3." + s.region + ".amazonaws.com", Path: "/" + objectKey}
+	if s.endpoint != nil {
+		u = &url.URL{Scheme: s.endpoint.Scheme, Host: s.endpoint.Host, Path: strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket + "/" + objectKey}
+	}
+	u.RawPath = s3URIEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return u
+}
+
+// Put uploads the object. Objects smaller than a part go up in one
+// request; larger ones as a multipart upload, aborted if any part fails.
+func (s *s3ObjectStore) Put(ctx context.Context, key string, r io.Reader) (ObjectRef, error) {
+	return s.put(ctx, key, r, nil)
+}
+
+// Create uploads the object like Put, with If-None-Match so S3 refuses it
+// (412) when the key already exists, which is returned as fs.ErrExist
+func (s *s3ObjectStore) Create(ctx context.Context, key string, r io.Reader) (ObjectRef, error) {
+	return s.put(ctx, key, r, http.Header{"If-None-Match": {"*"}})
+}
+
+// put uploads the object, sending header with the request that creates it:
+// the single PUT or the multipart completion
+func (s *s3ObjectStore) put(ctx context.Context, key string, r io.Reader, header http.Header) (ObjectRef, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	part := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if _, err := s.doWithHeader(ctx, http.MethodPut, objectKey, nil, header, part[:n]); err != nil {
+			return ObjectRef{}, err
+		}
+		return ObjectRef{URI: s.uri(objectKey), Key: key, Size: int64(n)}, nil
+	}
+	if err != nil {
+		return ObjectRef{}, err
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, objectKey)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	size, err := s.uploadParts(ctx, objectKey, uploadID, r, part, header)
+	if err != nil {
+		// Leave nothing billable behind; the upload error is what matters
+		_, _ = s.do(context.Background(), http.MethodDelete, objectKey, url.Values{"uploadId": {uploadID}}, nil)
+		return ObjectRef{}, err
+	}
+	return ObjectRef{URI: s.uri(objectKey), Key: key, Size: size}, nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts uploads first, which is full, then the rest of r, and
+// completes the upload with header
+func (s *s3ObjectStore) uploadParts(ctx context.Context, objectKey, uploadID string, r io.Reader, first []byte, header http.Header) (int64, error) {
+	var parts []s3CompletedPart
+	var size int64
+	buf := first
+	for n := len(first); n > 0; {
+		number := len(parts) + 1
+		resp, err := s.do(ctx, http.MethodPut, objectKey, url.Values{
+			"partNumber": {strconv.Itoa(number)},
+			"uploadId":   {uploadID},
+		}, buf[:n])
+		if err != nil {
+			return 0, fmt.Errorf("uploading part %d: %w", number, err)
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+		size += int64(n)
+
+		if n, err = io.ReadFull(r, buf); err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.doWithHeader(ctx, http.MethodPost, objectKey, url.Values{"uploadId": {uploadID}}, header, body)
+	if err != nil {
+		return 0, err
+	}
+	// Completion can fail after a 200, with the error in the body
+	var complete struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(resp.body, &complete); err == nil && complete.XMLName.Local == "Error" {
+		return 0, fmt.Errorf("completing upload of %s: %s: %s", objectKey, complete.Code, complete.Message)
+	}
+	return size, nil
+}
+
+func (s *s3ObjectStore) createMultipartUpload(ctx context.Context, objectKey string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, objectKey, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp.body, &created); err != nil || created.UploadID == "" {
+		return "", fmt.Errorf("starting upload of %s: no upload ID in response", objectKey)
+	}
+	return created.UploadID, nil
+}
+
+// Get opens the object for reading. A missing object is fs.ErrNotExist.
+func (s *s3ObjectStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, objectKey, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s3Error(http.MethodGet, objectKey, resp)
+	}
+	return resp.Body, nil
+}
+
+// Stat returns a reference to an existing object. A missing object is
+// fs.ErrNotExist.
+func (s *s3ObjectStore) Stat(ctx context.Context, key string) (ObjectRef, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, objectKey, nil, nil)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	return ObjectRef{URI: s.uri(objectKey), Key: key, Size: resp.ContentLength}, nil
+}
+
+// s3Response is a response whose body has been read
+type s3Response struct {
+	Header        http.Header
+	ContentLength int64
+	body          []byte
+}
+
+// do sends a signed request and reads the response, failing on any status
+// other than 2xx
+func (s *s3ObjectStore) do(ctx context.Context, method, objectKey string, query url.Values, body []byte) (s3Response, error) {
+	return s.doWithHeader(ctx, method, objectKey, query, nil, body)
+}
+
+func (s *s3ObjectStore) doWithHeader(ctx context.Context, method, objectKey string, query url.Values, header http.Header, body []byte) (s3Response, error) {
+	req, err := s.newRequest(ctx, method, objectKey, query, header, body)
+	if err != nil {
+		return s3Response{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return s3Response{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s3Response{}, s3Error(method, objectKey, resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return s3Response{}, err
+	}
+	return s3Response{Header: resp.Header, ContentLength: resp.ContentLength, body: data}, nil
+}
+
+func (s *s3ObjectStore) newRequest(ctx context.Context, method, objectKey string, query url.Values, header http.Header, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(objectKey, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.ContentLength = int64(len(body))
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	signS3Request(req, body, s.creds, s.region, s.now())
+	return req, nil
+}
+
+// s3Error describes a failed response. 404s wrap fs.ErrNotExist and 412s
+// (a conditional write found the key) fs.ErrExist; other errors, including
+// throttling, 5xx and 409s from racing conditional writes, are left to the
+// retry policy.
+func s3Error(method, objectKey string, resp *http.Response) error {
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	var parsed struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	message := resp.Status
+	if xml.Unmarshal(detail, &parsed) == nil && parsed.Code != "" {
+		message = fmt.Sprintf("%s: %s: %s", resp.Status, parsed.Code, parsed.Message)
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%s %s: %s: %w", method, objectKey, message, fs.ErrNotExist)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%s %s: %s: %w", method, objectKey, message, fs.ErrExist)
+	}
+	return fmt.Errorf("%s %s: %s", method, objectKey, message)
+}
+
+// signS3Request adds AWS Signature Version 4 headers to req. Every header
+// already set is signed, along with the host and payload hash.
+func signS3Request(req *http.Request, body []byte, creds s3Credentials, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3URIEncode(k, true)+"="+s3URIEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3URIEncode percent-encodes everything but unreserved characters, and
+// slashes unless encodeSlash is set
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
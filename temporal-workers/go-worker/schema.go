@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeSchemaIncompatible is returned, non-retryable, when a dataset's
+// schema breaks the compatibility rules against its registered baseline
+const ErrTypeSchemaIncompatible = "SchemaIncompatible"
+
+// ErrTypeSchemaRegistryNotConfigured is returned, non-retryable, when
+// SCHEMA_REGISTRY_URL isn't set
+const ErrTypeSchemaRegistryNotConfigured = "SchemaRegistryNotConfigured"
+
+// errNoBaselineSchema means the registry has no schema for the subject yet
+var errNoBaselineSchema = errors.New("no baseline schema")
+
+// Compatibility modes, with the same meaning as in common schema registries
+const (
+	CompatibilityBackward = "backward" // consumers on the new schema can read old data
+	CompatibilityForward  = "forward"  // consumers on the old schema can read new data
+	CompatibilityFull     = "full"     // both
+	CompatibilityNone     = "none"
+)
+
+// schemaRegistryURL is the base URL of the schema registry holding baseline
+// schemas. It comes from SCHEMA_REGISTRY_URL.
+var schemaRegistryURL string
+
+var schemaRegistryClient = &http.Client{Timeout: 10 * time.Second}
+
+// DatasetSchema describes the fields of a dataset
+type DatasetSchema struct {
+	Fields []SchemaField `json:"fields"`
+}
+
+// SchemaField is one field of a dataset schema. A field with a default can
+// be filled in for records that lack it.
+type SchemaField struct {
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Required bool            `json:"required"`
+	Default  json.RawMessage `json:"default,omitempty"`
+}
+
+func (f SchemaField) hasDefault() bool {
+	return len(f.Default) > 0 || !f.Required
+}
+
+// typePromotions lists the types each type can be read as without loss
+var typePromotions = map[string][]string{
+	"int":   {"long", "float", "double"},
+	"long":  {"float", "double"},
+	"float": {"double"},
+}
+
+// CheckSchemaCompatibilityInput represents input for schema compatibility
+// checks. Subject defaults to the dataset ID and Mode to backward.
+type CheckSchemaCompatibilityInput struct {
+	DatasetID string        `json:"dataset_id"`
+	Subject   string        `json:"subject,omitempty"`
+	Mode      string        `json:"mode,omitempty"`
+	Schema    DatasetSchema `json:"schema"`
+}
+
+// CheckSchemaCompatibilityResult represents the outcome of a compatibility
+// check. BaselineVersion is 0 when the subject had no baseline to check.
+type CheckSchemaCompatibilityResult struct {
+	Compatible      bool   `json:"compatible"`
+	Mode            string `json:"mode"`
+	BaselineVersion int    `json:"baseline_version"`
+}
+
+// CheckSchemaCompatibility compares a dataset's new schema with the latest
+// baseline in the schema registry. Breaking changes fail with a
+// non-retryable ErrTypeSchemaIncompatible error listing every violation;
+// registry failures are ordinary retryable errors. A subject the registry
+// doesn't know has no baseline yet, so any schema is compatible.
+func (a *Activities) CheckSchemaCompatibility(ctx context.Context, input CheckSchemaCompatibilityInput) (CheckSchemaCompatibilityResult, error) {
+	subject := input.Subject
+	if subject == "" {
+		subject = input.DatasetID
+	}
+	mode := strings.ToLower(input.Mode)
+	if mode == "" {
+		mode = CompatibilityBackward
+	}
+	activityLog.Infof("📐 Checking schema compatibility for %s (mode: %s)", subject, mode)
+
+	baseline, version, err := fetchBaselineSchema(ctx, subject)
+	if errors.Is(err, errNoBaselineSchema) {
+		activityLog.Infof("✅ No baseline schema for %s yet", subject)
+		return CheckSchemaCompatibilityResult{Compatible: true, Mode: mode}, nil
+	}
+	if err != nil {
+		return CheckSchemaCompatibilityResult{}, err
+	}
+
+	violations, err := schemaViolations(baseline, input.Schema, mode)
+	if err != nil {
+		return CheckSchemaCompatibilityResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeSchemaIncompatible, err)
+	}
+	if len(violations) > 0 {
+		activityLog.Errorf("❌ Incompatible schema change for %s: %s", subject, strings.Join(violations, "; "))
+		return CheckSchemaCompatibilityResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("schema for %s is not %s compatible with version %d: %s", subject, mode, version, strings.Join(violations, "; ")),
+			ErrTypeSchemaIncompatible, nil, violations)
+	}
+
+	activityLog.Infof("✅ Schema is %s compatible with version %d", mode, version)
+	return CheckSchemaCompatibilityResult{Compatible: true, Mode: mode, BaselineVersion: version}, nil
+}
+
+// fetchBaselineSchema reads the latest registered schema for subject, using
+// the registry's /subjects/<subject>/versions/latest endpoint. A 404 is
+// errNoBaselineSchema.
+func fetchBaselineSchema(ctx context.Context, subject string) (DatasetSchema, int, error) {
+	if schemaRegistryURL == "" {
+		return DatasetSchema{}, 0, temporal.NewNonRetryableApplicationError("SCHEMA_REGISTRY_URL is not configured", ErrTypeSchemaRegistryNotConfigured, nil)
+	}
+	endpoint := strings.TrimSuffix(schemaRegistryURL, "/") + "/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return DatasetSchema{}, 0, err
+	}
+	resp, err := schemaRegistryClient.Do(req)
+	if err != nil {
+		return DatasetSchema{}, 0, fmt.Errorf("fetching baseline schema for %s: %w", subject, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return DatasetSchema{}, 0, fmt.Errorf("%s: %w", subject, errNoBaselineSchema)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return DatasetSchema{}, 0, fmt.Errorf("fetching baseline schema for %s: registry returned %s", subject, resp.Status)
+	}
+
+	// The registry returns the schema document as a JSON string
+	var body struct {
+		Version int    `json:"version"`
+		Schema  string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return DatasetSchema{}, 0, fmt.Errorf("decoding registry response for %s: %w", subject, err)
+	}
+	var schema DatasetSchema
+	if err := json.Unmarshal([]byte(body.Schema), &schema); err != nil {
+		return DatasetSchema{}, 0, fmt.Errorf("decoding baseline schema for %s: %w", subject, err)
+	}
+	return schema, body.Version, nil
+}
+
+// schemaViolations lists the changes from baseline to updated that break mode
+func schemaViolations(baseline, updated DatasetSchema, mode string) ([]string, error) {
+	switch mode {
+	case CompatibilityNone:
+		return nil, nil
+	case CompatibilityBackward:
+		return readViolations(baseline, updated), nil
+	case CompatibilityForward:
+		return readViolations(updated, baseline), nil
+	case CompatibilityFull:
+		return append(readViolations(baseline, updated), readViolations(updated, baseline)...), nil
+	default:
+		return nil, fmt.Errorf("unsupported compatibility mode %q", mode)
+	}
+}
+
+// readViolations lists why data written with the writer schema can't be
+// read with the reader schema
+func readViolations(writer, reader DatasetSchema) []string {
+	written := make(map[string]SchemaField, len(writer.Fields))
+	for _, f := range writer.Fields {
+		written[f.Name] = f
+	}
+
+	var violations []string
+	for _, rf := range reader.Fields {
+		wf, ok := written[rf.Name]
+		if !ok {
+			if !rf.hasDefault() {
+				violations = append(violations, fmt.Sprintf("field %q is required but missing without a default", rf.Name))
+			}
+			continue
+		}
+		if !canReadAs(wf.Type, rf.Type) {
+			violations = append(violations, fmt.Sprintf("field %q changed type from %s to %s", rf.Name, wf.Type, rf.Type))
+		}
+	}
+	return violations
+}
+
+func canReadAs(writerType, readerType string) bool {
+	if writerType == readerType {
+		return true
+	}
+	for _, t := range typePromotions[writerType] {
+		if t == readerType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// useSchemaRegistry serves baselines by subject as SCHEMA_REGISTRY_URL.
+// Subjects not listed are 404s and "broken" is a 500.
+func useSchemaRegistry(t *testing.T, baselines map[string]DatasetSchema) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions/latest")
+		if subject == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		baseline, ok := baselines[subject]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+			return
+		}
+		schema, err := json.Marshal(baseline)
+		require.NoError(t, err)
+		json.NewEncoder(w).Encode(map[string]interface{}{"version": 3, "schema": string(schema)})
+	}))
+	t.Cleanup(server.Close)
+	prev := schemaRegistryURL
+	t.Cleanup(func() { schemaRegistryURL = prev })
+	schemaRegistryURL = server.URL
+}
+
+func TestCheckSchemaCompatibility(t *testing.T) {
+	baseline := DatasetSchema{Fields: []SchemaField{
+		{Name: "id", Type: "long", Required: true},
+		{Name: "amount", Type: "int", Required: true},
+	}}
+
+	tests := []struct {
+		name         string
+		registry     bool
+		subject      string
+		schema       DatasetSchema
+		wantVersion  int
+		wantErrType  string
+		wantRetry    bool
+		wantErrMatch string
+	}{
+		{
+			name:     "compatible change",
+			registry: true,
+			subject:  "orders",
+			schema: DatasetSchema{Fields: []SchemaField{
+				{Name: "id", Type: "long", Required: true},
+				{Name: "amount", Type: "double", Required: true},
+				{Name: "note", Type: "string"},
+			}},
+			wantVersion: 3,
+		},
+		{
+			name:     "incompatible change",
+			registry: true,
+			subject:  "orders",
+			schema: DatasetSchema{Fields: []SchemaField{
+				{Name: "id", Type: "string", Required: true},
+				{Name: "region", Type: "string", Required: true},
+			}},
+			wantErrType:  ErrTypeSchemaIncompatible,
+			wantErrMatch: `field "region" is required`,
+		},
+		{name: "no baseline yet", registry: true, subject: "new-dataset", schema: baseline},
+		{name: "registry down", registry: true, subject: "broken", schema: baseline, wantRetry: true, wantErrMatch: "500"},
+		{name: "registry not configured", subject: "orders", schema: baseline, wantErrType: ErrTypeSchemaRegistryNotConfigured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := schemaRegistryURL
+			t.Cleanup(func() { schemaRegistryURL = prev })
+			schemaRegistryURL = ""
+			if tt.registry {
+				useSchemaRegistry(t, map[string]DatasetSchema{"orders": baseline})
+			}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			value, err := env.ExecuteActivity(activities.CheckSchemaCompatibility, CheckSchemaCompatibilityInput{Subject: tt.subject, Schema: tt.schema})
+			if tt.wantErrType != "" || tt.wantRetry {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrMatch)
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr), "got %v", err)
+				assert.Equal(t, !tt.wantRetry, appErr.NonRetryable())
+				if tt.wantErrType != "" {
+					assert.Equal(t, tt.wantErrType, appErr.Type())
+				}
+				return
+			}
+			require.NoError(t, err)
+			var result CheckSchemaCompatibilityResult
+			require.NoError(t, value.Get(&result))
+			assert.True(t, result.Compatible)
+			assert.Equal(t, CompatibilityBackward, result.Mode)
+			assert.Equal(t, tt.wantVersion, result.BaselineVersion)
+		})
+	}
+}
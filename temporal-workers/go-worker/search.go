@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+const defaultIndexBatchSize = 500
+
+// IndexFailure names a document the search cluster rejected
+type IndexFailure struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// SearchIndexer bulk-indexes documents by ID. It returns the documents
+// rejected individually; an error means the whole request failed.
+type SearchIndexer interface {
+	Bulk(ctx context.Context, index string, docs []ProcessedRecord) ([]IndexFailure, error)
+}
+
+// IndexResultsInput represents input for indexing record-level output
+type IndexResultsInput struct {
+	DatasetID string            `json:"dataset_id"`
+	Index     string            `json:"index"`
+	Records   []ProcessedRecord `json:"records"`
+	BatchSize int               `json:"batch_size,omitempty"` // default: 500
+}
+
+// IndexResultsResult represents the outcome of indexing
+type IndexResultsResult struct {
+	Index   string         `json:"index"`
+	Indexed int            `json:"indexed"`
+	Failed  []IndexFailure `json:"failed,omitempty"`
+}
+
+// indexProgress is recorded in heartbeat details after each batch
+type indexProgress struct {
+	Offset int            `json:"offset"`
+	Failed []IndexFailure `json:"failed,omitempty"`
+}
+
+// IndexResults bulk-indexes records into a search index in batches. The
+// offset reached is heartbeated after each batch, so a retried attempt
+// resumes where the last one stopped. Documents use the record ID, so a
+// batch replayed after a crash overwrites rather than duplicates.
+// Individually rejected documents are reported, not retried.
+func (a *Activities) IndexResults(ctx context.Context, input IndexResultsInput) (IndexResultsResult, error) {
+	batchSize := input.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultIndexBatchSize
+	}
+
+	var progress indexProgress
+	if activity.HasHeartbeatDetails(ctx) {
+		_ = activity.GetHeartbeatDetails(ctx, &progress)
+		activityLog.Infof("🔁 Resuming indexing of %s at offset %d", input.DatasetID, progress.Offset)
+	}
+	activityLog.Infof("🔎 Indexing %d records of %s into %s", len(input.Records)-progress.Offset, input.DatasetID, input.Index)
+
+	for progress.Offset < len(input.Records) {
+		end := progress.Offset + batchSize
+		if end > len(input.Records) {
+			end = len(input.Records)
+		}
+		failed, err := a.Search.Bulk(ctx, input.Index, input.Records[progress.Offset:end])
+		if err != nil {
+			return IndexResultsResult{}, fmt.Errorf("indexing records %d-%d into %s: %w", progress.Offset, end, input.Index, err)
+		}
+		progress.Failed = append(progress.Failed, failed...)
+		progress.Offset = end
+		activity.RecordHeartbeat(ctx, progress)
+	}
+
+	result := IndexResultsResult{
+		Index:   input.Index,
+		Indexed: len(input.Records) - len(progress.Failed),
+		Failed:  progress.Failed,
+	}
+	if len(result.Failed) > 0 {
+		activityLog.Errorf("⚠️ %d of %d records were rejected by %s", len(result.Failed), len(input.Records), input.Index)
+	}
+	activityLog.Infof("✅ Indexed %d records into %s", result.Indexed, input.Index)
+	return result, nil
+}
+
+// openSearchIndexer uses the OpenSearch/Elasticsearch _bulk API
+type openSearchIndexer struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newOpenSearchIndexer(url, username, password string) *openSearchIndexer {
+	return &openSearchIndexer{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: time.Minute},
+	}
+}
+
+// bulkResponse is the part of a _bulk response used to find rejected
+// documents
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func (s *openSearchIndexer) Bulk(ctx context.Context, index string, docs []ProcessedRecord) ([]IndexFailure, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]map[string]string{"index": {"_index": index, "_id": doc.ID}}
+		if err := enc.Encode(action); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/_bulk", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("bulk request returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), "SearchRequestRejected", err)
+		}
+		return nil, err
+	}
+
+	var parsed bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decoding bulk response: %w", err)
+	}
+	if !parsed.Errors {
+		return nil, nil
+	}
+	var failed []IndexFailure
+	for _, item := range parsed.Items {
+		for _, outcome := range item {
+			if outcome.Error != nil {
+				failed = append(failed, IndexFailure{ID: outcome.ID, Reason: outcome.Error.Type + ": " + outcome.Error.Reason})
+			}
+		}
+	}
+	return failed, nil
+}
+
+// simulatedIndexer stands in for a search cluster in local runs
+type simulatedIndexer struct{}
+
+func (simulatedIndexer) Bulk(ctx context.Context, index string, docs []ProcessedRecord) ([]IndexFailure, error) {
+	time.Sleep(time.Duration(20+rand.Intn(80)) * time.Millisecond)
+	activityLog.Infof("🔎 Indexed %d documents into %s", len(docs), index)
+	return nil, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// fakeIndexer records batch sizes, rejects the documents in reject and
+// fails whole batches starting with an ID in failBatch
+type fakeIndexer struct {
+	batches   []int
+	reject    map[string]bool
+	failBatch map[string]error
+}
+
+func (f *fakeIndexer) Bulk(ctx context.Context, index string, docs []ProcessedRecord) ([]IndexFailure, error) {
+	if err := f.failBatch[docs[0].ID]; err != nil {
+		return nil, err
+	}
+	f.batches = append(f.batches, len(docs))
+	var failed []IndexFailure
+	for _, doc := range docs {
+		if f.reject[doc.ID] {
+			failed = append(failed, IndexFailure{ID: doc.ID, Reason: "mapper_parsing_exception"})
+		}
+	}
+	return failed, nil
+}
+
+func TestIndexResults(t *testing.T) {
+	records := make([]ProcessedRecord, 5)
+	for i := range records {
+		records[i] = ProcessedRecord{ID: fmt.Sprintf("r%d", i)}
+	}
+	tests := []struct {
+		name        string
+		indexer     *fakeIndexer
+		progress    *indexProgress
+		wantBatches []int
+		wantIndexed int
+		wantFailed  []string
+		wantErr     string
+	}{
+		{name: "batched", indexer: &fakeIndexer{}, wantBatches: []int{2, 2, 1}, wantIndexed: 5},
+		{name: "rejected documents reported", indexer: &fakeIndexer{reject: map[string]bool{"r1": true, "r4": true}}, wantBatches: []int{2, 2, 1}, wantIndexed: 3, wantFailed: []string{"r1", "r4"}},
+		{
+			name:        "resumed from heartbeat",
+			indexer:     &fakeIndexer{},
+			progress:    &indexProgress{Offset: 4, Failed: []IndexFailure{{ID: "r0"}}},
+			wantBatches: []int{1},
+			wantIndexed: 4,
+			wantFailed:  []string{"r0"},
+		},
+		{name: "bulk request fails", indexer: &fakeIndexer{failBatch: map[string]error{"r2": errors.New("cluster red")}}, wantErr: "indexing records 2-4 into results: cluster red"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(&Activities{Search: tt.indexer})
+			if tt.progress != nil {
+				env.SetHeartbeatDetails(*tt.progress)
+			}
+
+			value, err := env.ExecuteActivity(activities.IndexResults, IndexResultsInput{DatasetID: "ds-1", Index: "results", Records: records, BatchSize: 2})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var result IndexResultsResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantBatches, tt.indexer.batches)
+			assert.Equal(t, tt.wantIndexed, result.Indexed)
+			var failed []string
+			for _, f := range result.Failed {
+				failed = append(failed, f.ID)
+			}
+			assert.Equal(t, tt.wantFailed, failed)
+		})
+	}
+}
+
+func TestOpenSearchIndexerBulk(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantFailed    []IndexFailure
+		wantErr       string
+		wantRetryable bool
+	}{
+		{name: "indexed", status: http.StatusOK, body: `{"errors":false,"items":[]}`},
+		{
+			name:       "partial failure",
+			status:     http.StatusOK,
+			body:       `{"errors":true,"items":[{"index":{"_id":"r0","status":201}},{"index":{"_id":"r1","status":400,"error":{"type":"mapper_parsing_exception","reason":"bad score"}}}]}`,
+			wantFailed: []IndexFailure{{ID: "r1", Reason: "mapper_parsing_exception: bad score"}},
+		},
+		{name: "request rejected", status: http.StatusBadRequest, body: "bad index name", wantErr: "bulk request returned 400 Bad Request: bad index name"},
+		{name: "cluster unavailable", status: http.StatusServiceUnavailable, body: "busy", wantErr: "503 Service Unavailable", wantRetryable: true},
+		{name: "malformed response", status: http.StatusOK, body: "{", wantErr: "decoding bulk response", wantRetryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody, gotUser string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/_bulk", r.URL.Path)
+				assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+				gotUser, _, _ = r.BasicAuth()
+				body, _ := io.ReadAll(r.Body)
+				gotBody = string(body)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			indexer := newOpenSearchIndexer(server.URL+"/", "worker", "secret")
+			failed, err := indexer.Bulk(context.Background(), "results", []ProcessedRecord{{ID: "r0"}, {ID: "r1"}})
+			assert.Equal(t, "worker", gotUser)
+			assert.Equal(t, 4, strings.Count(gotBody, "\n"))
+			assert.Contains(t, gotBody, `{"index":{"_id":"r1","_index":"results"}}`)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				var appErr *temporal.ApplicationError
+				assert.Equal(t, !tt.wantRetryable, errors.As(err, &appErr) && appErr.NonRetryable())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFailed, failed)
+		})
+	}
+}
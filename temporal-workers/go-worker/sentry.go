@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// sentrySendTimeout bounds one event upload
+const sentrySendTimeout = 10 * time.Second
+
+// SentryEvent is the part of a Sentry event the worker fills in
+type SentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Message     string                 `json:"message"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   *sentryExceptions      `json:"exception,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+// sentryException is one error in the chain; Sentry lists the root cause
+// first
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// SentryTransport delivers events to Sentry
+type SentryTransport interface {
+	Send(ctx context.Context, event SentryEvent) error
+}
+
+// httpSentryTransport posts events to a project's store endpoint
+type httpSentryTransport struct {
+	endpoint string
+	auth     string
+	client   *http.Client
+}
+
+// newHTTPSentryTransport parses a DSN of the form
+// https://<key>@<host>/<project>. Errors never include the DSN, since it
+// carries the project key.
+func newHTTPSentryTransport(dsn, release string) (*httpSentryTransport, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.New("sentry: malformed DSN")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil || u.User.Username() == "" || slash < 0 || path[slash+1:] == "" {
+		return nil, errors.New("sentry: DSN must look like https://<key>@<host>/<project>")
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=temporal-go-worker/%s, sentry_key=%s", release, u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	return &httpSentryTransport{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:slash], path[slash+1:]),
+		auth:     auth,
+		client:   &http.Client{Timeout: sentrySendTimeout},
+	}, nil
+}
+
+func (t *httpSentryTransport) Send(ctx context.Context, event SentryEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", t.auth)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sentry returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// sentryReporter sends events in the background, so a slow or missing
+// Sentry never holds up a task. Flush waits for events still in flight.
+type sentryReporter struct {
+	transport   SentryTransport
+	environment string
+	release     string
+	wg          sync.WaitGroup
+}
+
+func newSentryReporter(transport SentryTransport, environment, release string) *sentryReporter {
+	return &sentryReporter{transport: transport, environment: environment, release: release}
+}
+
+// capture reports err with the given tags and extra context
+func (r *sentryReporter) capture(err error, tags map[string]string, extra map[string]interface{}) {
+	event := SentryEvent{
+		EventID:     newSentryEventID(),
+		Timestamp:   time.Now().UTC(),
+		Level:       "error",
+		Platform:    "go",
+		Logger:      "temporal-go-worker",
+		Environment: r.environment,
+		Release:     r.release,
+		Message:     err.Error(),
+		Tags:        tags,
+		Extra:       extra,
+		Exception:   &sentryExceptions{Values: sentryExceptionChain(err)},
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), sentrySendTimeout)
+		defer cancel()
+		if err := r.transport.Send(ctx, event); err != nil {
+			log.Printf("⚠️ Unable to report failure to Sentry: %v", err)
+		}
+	}()
+}
+
+// Flush waits until queued events are sent or ctx is done
+func (r *sentryReporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newSentryEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// sentryExceptionChain lists err and what it wraps, root cause first
+func sentryExceptionChain(err error) []sentryException {
+	var chain []sentryException
+	for ; err != nil; err = errors.Unwrap(err) {
+		errType := fmt.Sprintf("%T", err)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr == err && appErr.Type() != "" {
+			errType = appErr.Type()
+		}
+		chain = append([]sentryException{{Type: errType, Value: err.Error()}}, chain...)
+	}
+	return chain
+}
+
+// isExpectedFailure reports control flow that ends a run or attempt
+// without anything being wrong: cancellation and continue-as-new
+func isExpectedFailure(err error) bool {
+	return temporal.IsCanceledError(err) || errors.Is(err, context.Canceled) || workflow.IsContinueAsNewError(err)
+}
+
+// datasetIDFromArgs finds a dataset_id field in the first argument that
+// has one, for tagging
+func datasetIDFromArgs(args []interface{}) string {
+	for _, arg := range args {
+		raw, err := json.Marshal(arg)
+		if err != nil {
+			continue
+		}
+		var fields struct {
+			DatasetID string `json:"dataset_id"`
+		}
+		if json.Unmarshal(raw, &fields) == nil && fields.DatasetID != "" {
+			return fields.DatasetID
+		}
+	}
+	return ""
+}
+
+// sentryInterceptor reports failed workflows, and activities that fail
+// non-retryably, to Sentry. Retryable activity failures are left to the
+// workflow: if they end up failing the run, the workflow failure is
+// reported. It is only installed when SENTRY_DSN is set.
+type sentryInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	reporter *sentryReporter
+}
+
+func newSentryInterceptor(reporter *sentryReporter) *sentryInterceptor {
+	return &sentryInterceptor{reporter: reporter}
+}
+
+func (i *sentryInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	a := &sentryActivityInterceptor{root: i}
+	a.Next = next
+	return a
+}
+
+func (i *sentryInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	w := &sentryWorkflowInterceptor{root: i}
+	w.Next = next
+	return w
+}
+
+type sentryActivityInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+	root *sentryInterceptor
+}
+
+func (a *sentryActivityInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	result, err := a.Next.ExecuteActivity(ctx, in)
+	var appErr *temporal.ApplicationError
+	if err == nil || isExpectedFailure(err) || ctx.Err() != nil || !errors.As(err, &appErr) || !appErr.NonRetryable() {
+		return result, err
+	}
+
+	info := activity.GetInfo(ctx)
+	tags := map[string]string{"step": info.ActivityType.Name, "task_queue": info.TaskQueue}
+	if info.WorkflowType != nil {
+		tags["workflow_type"] = info.WorkflowType.Name
+	}
+	if id := datasetIDFromArgs(in.Args); id != "" {
+		tags["dataset_id"] = id
+	}
+	a.root.reporter.capture(err, tags, map[string]interface{}{
+		"workflow_id": info.WorkflowExecution.ID,
+		"run_id":      info.WorkflowExecution.RunID,
+		"activity_id": info.ActivityID,
+		"attempt":     info.Attempt,
+	})
+	return result, err
+}
+
+type sentryWorkflowInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+	root *sentryInterceptor
+}
+
+// ExecuteWorkflow reports the run's failure once; replays of a failed run
+// return the same error and are skipped
+func (w *sentryWorkflowInterceptor) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	result, err := w.Next.ExecuteWorkflow(ctx, in)
+	if err == nil || isExpectedFailure(err) || workflow.IsReplaying(ctx) {
+		return result, err
+	}
+
+	info := workflow.GetInfo(ctx)
+	tags := map[string]string{"workflow_type": info.WorkflowType.Name, "task_queue": info.TaskQueueName}
+	if id := datasetIDFromArgs(in.Args); id != "" {
+		tags["dataset_id"] = id
+	}
+	var activityErr *temporal.ActivityError
+	if errors.As(err, &activityErr) {
+		tags["step"] = activityErr.ActivityType().Name
+	}
+	w.root.reporter.capture(err, tags, map[string]interface{}{
+		"workflow_id": info.WorkflowExecution.ID,
+		"run_id":      info.WorkflowExecution.RunID,
+		"attempt":     info.Attempt,
+	})
+	return result, err
+}
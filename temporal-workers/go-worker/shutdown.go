@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const defaultShutdownHookTimeout = 10 * time.Second
+
+// shutdownHook is one piece of cleanup run when the worker stops
+type shutdownHook struct {
+	name    string
+	timeout time.Duration
+	run     func(ctx context.Context) error
+}
+
+// shutdownHooks runs registered cleanup in reverse registration order, so
+// components are torn down before the things they were built on
+type shutdownHooks struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+// workerShutdown holds the hooks run after the worker has stopped polling
+var workerShutdown = &shutdownHooks{}
+
+// onShutdown registers a hook on the worker's shutdown path. A zero
+// timeout means defaultShutdownHookTimeout.
+func onShutdown(name string, timeout time.Duration, run func(ctx context.Context) error) {
+	workerShutdown.register(name, timeout, run)
+}
+
+func (h *shutdownHooks) register(name string, timeout time.Duration, run func(ctx context.Context) error) {
+	if timeout <= 0 {
+		timeout = defaultShutdownHookTimeout
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, shutdownHook{name: name, timeout: timeout, run: run})
+}
+
+// runAll runs every hook, last registered first. Each gets a context that
+// expires after its timeout; a hook that ignores it is abandoned when the
+// timeout passes, so one stuck hook can't hold up the others or the exit.
+func (h *shutdownHooks) runAll() {
+	h.mu.Lock()
+	hooks := append([]shutdownHook(nil), h.hooks...)
+	h.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		ctx, cancel := context.WithTimeout(context.Background(), hook.timeout)
+		done := make(chan error, 1)
+		start := time.Now()
+		go func() { done <- hook.run(ctx) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				log.Printf("❌ Shutdown hook %s failed: %v", hook.name, err)
+			} else {
+				log.Printf("🧹 Shutdown hook %s done in %s", hook.name, time.Since(start).Round(time.Millisecond))
+			}
+		case <-ctx.Done():
+			log.Printf("⏰ Shutdown hook %s timed out after %s", hook.name, hook.timeout)
+		}
+		cancel()
+	}
+}
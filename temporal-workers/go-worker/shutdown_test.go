@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownHooks(t *testing.T) {
+	logs := captureLog(t)
+	var mu sync.Mutex
+	var ran []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, name)
+	}
+	stuckErr := make(chan error, 1)
+	hooks := &shutdownHooks{}
+	hooks.register("metrics", 0, func(context.Context) error {
+		record("metrics")
+		return nil
+	})
+	hooks.register("stuck", 20*time.Millisecond, func(ctx context.Context) error {
+		record("stuck")
+		<-ctx.Done()
+		stuckErr <- ctx.Err()
+		// Ignores the deadline for a while longer
+		time.Sleep(time.Second)
+		return nil
+	})
+	hooks.register("client", 0, func(context.Context) error {
+		record("client")
+		return errors.New("connection reset")
+	})
+
+	start := time.Now()
+	hooks.runAll()
+
+	assert.Less(t, time.Since(start), time.Second, "a stuck hook must not hold up the rest")
+	mu.Lock()
+	assert.Equal(t, []string{"client", "stuck", "metrics"}, ran)
+	mu.Unlock()
+	assert.ErrorIs(t, <-stuckErr, context.DeadlineExceeded)
+	assert.Equal(t, defaultShutdownHookTimeout, hooks.hooks[0].timeout)
+	assert.Contains(t, logs.String(), "❌ Shutdown hook client failed: connection reset")
+	assert.Contains(t, logs.String(), "⏰ Shutdown hook stuck timed out after 20ms")
+	assert.Contains(t, logs.String(), "🧹 Shutdown hook metrics done")
+}
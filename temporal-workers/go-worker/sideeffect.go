@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// stableRecord is what stableDecision records: the value and a hash of the
+// inputs it was computed from
+type stableRecord[T any] struct {
+	Inputs string `json:"inputs"`
+	Value  T      `json:"value"`
+}
+
+// stableDecision returns the value recorded under id, recomputing it only
+// when inputs differ from the ones it was last computed for. Replays always
+// see the recorded value, so a decision made by a running workflow survives
+// changes to the code that computes it.
+//
+// Use it for decisions derived from workflow state that may be asked for
+// again, e.g. after a signal or update. workflow.SideEffect records a new
+// value on every call, so it suits one-off values like IDs or random
+// choices instead. compute may still run on later calls, but its result is
+// dropped unless the inputs changed.
+func stableDecision[T any](ctx workflow.Context, id string, inputs interface{}, compute func() T) (T, error) {
+	var zero T
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return zero, err
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
+	encoded := workflow.MutableSideEffect(ctx, id,
+		func(workflow.Context) interface{} {
+			return stableRecord[T]{Inputs: key, Value: compute()}
+		},
+		func(a, b interface{}) bool {
+			return a.(stableRecord[T]).Inputs == b.(stableRecord[T]).Inputs
+		})
+	var record stableRecord[T]
+	if err := encoded.Get(&record); err != nil {
+		return zero, err
+	}
+	return record.Value, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestStableDecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  interface{}
+		want    RoutingDecision
+		wantErr string
+	}{
+		{name: "computed", inputs: []interface{}{"batch", 0.95}, want: RoutingDecision{ProcessType: "batch", Reason: "healthy"}},
+		{name: "inputs not encodable", inputs: func() {}, wantErr: "unsupported type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			computed := 0
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) (RoutingDecision, error) {
+				return stableDecision(ctx, "routing", tt.inputs, func() RoutingDecision {
+					computed++
+					return RoutingDecision{ProcessType: "batch", Reason: "healthy"}
+				})
+			}, workflow.RegisterOptions{Name: "stableDecisionWorkflow"})
+
+			env.ExecuteWorkflow("stableDecisionWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			if tt.wantErr != "" {
+				require.Error(t, env.GetWorkflowError())
+				assert.Contains(t, env.GetWorkflowError().Error(), tt.wantErr)
+				assert.Zero(t, computed)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var got RoutingDecision
+			require.NoError(t, env.GetWorkflowResult(&got))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, 1, computed)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/workflow"
+)
+
+// defaultSeenSignalLimit bounds how many signal IDs a workflow remembers.
+// Redeliveries arrive soon after the original, so the oldest IDs can go.
+const defaultSeenSignalLimit = 1000
+
+// seenSignals remembers the IDs of recently applied signals so redelivered
+// copies can be ignored. It is plain workflow state and is carried across
+// continue-as-new with the rest of the input.
+type seenSignals struct {
+	IDs   []string `json:"ids,omitempty"`
+	Limit int      `json:"limit,omitempty"` // defaults to defaultSeenSignalLimit
+}
+
+// firstDelivery records id and reports whether it was new. Signals without
+// an ID are always applied.
+func (s *seenSignals) firstDelivery(id string) bool {
+	if id == "" {
+		return true
+	}
+	for _, seen := range s.IDs {
+		if seen == id {
+			return false
+		}
+	}
+	limit := s.Limit
+	if limit <= 0 {
+		limit = defaultSeenSignalLimit
+	}
+	s.IDs = append(s.IDs, id)
+	if len(s.IDs) > limit {
+		s.IDs = append(s.IDs[:0:0], s.IDs[len(s.IDs)-limit:]...)
+	}
+	return true
+}
+
+// newSignalID returns a unique ID for a signal sent by the calling
+// workflow. It is recorded in history, so a replay or retried send reuses
+// the same ID. Versioned so runs started before it keep the history
+// length suffix, which repeats for signals sent in the same workflow task.
+func newSignalID(ctx workflow.Context, kind string) string {
+	info := workflow.GetInfo(ctx)
+	prefix := fmt.Sprintf("%s/%s/%s", info.WorkflowExecution.ID, info.WorkflowExecution.RunID, kind)
+	if workflow.GetVersion(ctx, "unique-signal-ids", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return fmt.Sprintf("%s#%d", prefix, info.GetCurrentHistoryLength())
+	}
+	var id string
+	err := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return uuid.NewString()
+	}).Get(&id)
+	if err != nil {
+		// Without an ID the signal is always applied, as before dedup
+		workflow.GetLogger(ctx).Warn("⚠️ Unable to record signal ID", "kind", kind, "error", err)
+		return ""
+	}
+	return prefix + "#" + id
+}
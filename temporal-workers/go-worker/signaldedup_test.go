@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestSeenSignalsFirstDelivery(t *testing.T) {
+	seen := seenSignals{Limit: 2}
+	assert.True(t, seen.firstDelivery("a"))
+	assert.False(t, seen.firstDelivery("a"))
+	assert.True(t, seen.firstDelivery(""))
+	assert.True(t, seen.firstDelivery(""))
+	assert.True(t, seen.firstDelivery("b"))
+	assert.True(t, seen.firstDelivery("c"))
+	// Past the limit the oldest ID is forgotten
+	assert.Equal(t, []string{"b", "c"}, seen.IDs)
+	assert.True(t, seen.firstDelivery("a"))
+}
+
+func TestNewSignalID(t *testing.T) {
+	tests := []struct {
+		name    string
+		version workflow.Version
+		// wantSame is whether two IDs minted in one workflow task collide
+		wantSame bool
+	}{
+		{name: "unique", version: 1},
+		{name: "before unique IDs", version: workflow.DefaultVersion, wantSame: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{ID: "sender"})
+			env.OnGetVersion("unique-signal-ids", workflow.DefaultVersion, 1).Return(tt.version)
+
+			env.ExecuteWorkflow(func(ctx workflow.Context) ([]string, error) {
+				return []string{newSignalID(ctx, "release:orders"), newSignalID(ctx, "release:orders")}, nil
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var ids []string
+			require.NoError(t, env.GetWorkflowResult(&ids))
+
+			require.Len(t, ids, 2)
+			for _, id := range ids {
+				assert.True(t, strings.HasPrefix(id, "sender/"), id)
+				assert.Contains(t, id, "/release:orders#")
+			}
+			assert.Equal(t, tt.wantSame, ids[0] == ids[1], "%s and %s", ids[0], ids[1])
+		})
+	}
+}
+
+// TestLockManagerIgnoresRedeliveredSignals has wf-a queue for a lock,
+// withdraw and queue again, then delivers a withdrawal a second time.
+// Applied twice, the old withdrawal would drop wf-a's new request.
+func TestLockManagerIgnoresRedeliveredSignals(t *testing.T) {
+	tests := []struct {
+		name string
+		// lastWithdrawal is the signal ID of the final withdrawal
+		lastWithdrawal string
+		wantGrants     []string
+	}{
+		{name: "redelivered", lastWithdrawal: "a-withdraw-1", wantGrants: []string{"wf-b", "wf-a"}},
+		{name: "new withdrawal", lastWithdrawal: "a-withdraw-2", wantGrants: []string{"wf-b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+
+			var grants []string
+			var leaseID string
+			env.OnSignalExternalWorkflow(mock.Anything, mock.Anything, mock.Anything, LockGrantedSignalPrefix+"orders", mock.Anything).Return(
+				func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+					grants = append(grants, workflowID)
+					leaseID = arg.(LockGrant).LeaseID
+					return nil
+				})
+
+			signals := []struct {
+				name string
+				arg  interface{}
+			}{
+				{AcquireLockSignalName, LockRequest{WorkflowID: "wf-b", LeaseTimeout: time.Hour, SignalID: "b-acquire"}},
+				{AcquireLockSignalName, LockRequest{WorkflowID: "wf-a", LeaseTimeout: time.Hour, SignalID: "a-acquire-1"}},
+				{ReleaseLockSignalName, LockRelease{WorkflowID: "wf-a", SignalID: "a-withdraw-1"}},
+				{AcquireLockSignalName, LockRequest{WorkflowID: "wf-a", LeaseTimeout: time.Hour, SignalID: "a-acquire-2"}},
+				{ReleaseLockSignalName, LockRelease{WorkflowID: "wf-a", SignalID: tt.lastWithdrawal}},
+			}
+			for i, signal := range signals {
+				signal := signal
+				env.RegisterDelayedCallback(func() {
+					env.SignalWorkflow(signal.name, signal.arg)
+				}, time.Duration(i+1)*time.Minute)
+			}
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(ReleaseLockSignalName, LockRelease{WorkflowID: "wf-b", LeaseID: leaseID, SignalID: "b-release"})
+			}, 10*time.Minute)
+
+			env.ExecuteWorkflow(LockManagerWorkflow, LockManagerInput{Resource: "orders"})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			assert.Equal(t, tt.wantGrants, grants)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestSQLStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     DatabaseOperationInput
+		want      string
+		wantError bool
+	}{
+		{name: "select", input: DatabaseOperationInput{Operation: "select", Target: "users"}, want: `SELECT * FROM "users" WHERE id = $1`},
+		{name: "insert", input: DatabaseOperationInput{Operation: "insert", Target: "users"}, want: `INSERT INTO "users" (data) VALUES ($1)`},
+		{name: "update", input: DatabaseOperationInput{Operation: "update", Target: "users"}, want: `UPDATE "users" SET data = $1 WHERE id = $2`},
+		{name: "delete", input: DatabaseOperationInput{Operation: "delete", Target: "users"}, want: `DELETE FROM "users" WHERE id = $1`},
+		{name: "schema qualified", input: DatabaseOperationInput{Operation: "select", Target: "analytics.events"}, want: `SELECT * FROM "analytics"."events" WHERE id = $1`},
+		{name: "unknown operation", input: DatabaseOperationInput{Operation: "backup", Target: "users"}, want: "SELECT 1"},
+		{
+			name: "query parameter ignored",
+			input: DatabaseOperationInput{
+				Operation:  "select",
+				Target:     "users",
+				Parameters: Parameters{"query": "DROP TABLE users"},
+			},
+			want: `SELECT * FROM "users" WHERE id = $1`,
+		},
+		{name: "injection", input: DatabaseOperationInput{Operation: "select", Target: "users; DROP TABLE users"}, wantError: true},
+		{name: "quote", input: DatabaseOperationInput{Operation: "delete", Target: `users" --`}, wantError: true},
+		{name: "empty", input: DatabaseOperationInput{Operation: "insert"}, wantError: true},
+		{name: "too many parts", input: DatabaseOperationInput{Operation: "select", Target: "a.b.c"}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sqlStatement(tt.input)
+			if tt.wantError {
+				var appErr *temporal.ApplicationError
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, ErrTypeInvalidTarget, appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/client"
+)
+
+// Metrics backends selectable with METRICS_BACKEND
+const (
+	MetricsBackendNone       = "none"
+	MetricsBackendPrometheus = "prometheus"
+	MetricsBackendStatsD     = "statsd"
+)
+
+// newMetricsHandler returns the metrics handler for the configured backend
+func newMetricsHandler(backend, statsdAddr string) (client.MetricsHandler, error) {
+	switch backend {
+	case "", MetricsBackendNone:
+		return client.MetricsNopHandler, nil
+	case MetricsBackendPrometheus:
+		return newPrometheusHandler(), nil
+	case MetricsBackendStatsD:
+		return newStatsDHandler(statsdAddr)
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q (want %q, %q or %q)", backend, MetricsBackendNone, MetricsBackendPrometheus, MetricsBackendStatsD)
+	}
+}
+
+// statsdHandler sends SDK and worker metrics to a StatsD agent over UDP,
+// one packet per sample, with tags in the DogStatsD "#key:value" format
+// understood by Datadog, Telegraf and the CloudWatch agent. Sends are fire
+// and forget: a missing agent never slows down or fails task processing.
+type statsdHandler struct {
+	conn net.Conn
+	tags string
+}
+
+func newStatsDHandler(addr string) (*statsdHandler, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	return &statsdHandler{conn: conn}, nil
+}
+
+// Close releases the UDP socket
+func (h *statsdHandler) Close() error {
+	return h.conn.Close()
+}
+
+// WithTags returns a handler that adds tags to every metric
+func (h *statsdHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	if len(tags) == 0 {
+		return h
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(h.tags)
+	for _, k := range keys {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(statsdSanitize(k))
+		b.WriteByte(':')
+		b.WriteString(statsdSanitize(tags[k]))
+	}
+	return &statsdHandler{conn: h.conn, tags: b.String()}
+}
+
+// Counter returns a counter sent as a "c" metric
+func (h *statsdHandler) Counter(name string) client.MetricsCounter {
+	return statsdCounter{h: h, name: name}
+}
+
+// Gauge returns a gauge sent as a "g" metric
+func (h *statsdHandler) Gauge(name string) client.MetricsGauge {
+	return statsdGauge{h: h, name: name}
+}
+
+// Timer returns a timer sent as a "ms" metric
+func (h *statsdHandler) Timer(name string) client.MetricsTimer {
+	return statsdTimer{h: h, name: name}
+}
+
+func (h *statsdHandler) send(name, value, kind string) {
+	packet := statsdSanitize(name) + ":" + value + "|" + kind
+	if h.tags != "" {
+		packet += "|#" + h.tags
+	}
+	_, _ = h.conn.Write([]byte(packet))
+}
+
+type statsdCounter struct {
+	h    *statsdHandler
+	name string
+}
+
+func (c statsdCounter) Inc(delta int64) {
+	c.h.send(c.name, strconv.FormatInt(delta, 10), "c")
+}
+
+type statsdGauge struct {
+	h    *statsdHandler
+	name string
+}
+
+func (g statsdGauge) Update(value float64) {
+	g.h.send(g.name, strconv.FormatFloat(value, 'f', -1, 64), "g")
+}
+
+type statsdTimer struct {
+	h    *statsdHandler
+	name string
+}
+
+func (t statsdTimer) Record(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+	t.h.send(t.name, strconv.FormatFloat(ms, 'f', -1, 64), "ms")
+}
+
+// statsdSanitize replaces characters that delimit the StatsD wire format
+func statsdSanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', ',', '#', '@', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+)
+
+// listenStatsD returns a UDP agent address and a func reading its next packet
+func listenStatsD(t *testing.T) (string, func() string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func() string {
+		buf := make([]byte, 1024)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+}
+
+func TestStatsDHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []map[string]string
+		record func(h client.MetricsHandler)
+		want   string
+	}{
+		{name: "counter", record: func(h client.MetricsHandler) { h.Counter("go_worker_runs").Inc(3) }, want: "go_worker_runs:3|c"},
+		{name: "gauge", record: func(h client.MetricsHandler) { h.Gauge("go_worker_slots").Update(2.5) }, want: "go_worker_slots:2.5|g"},
+		{name: "timer", record: func(h client.MetricsHandler) { h.Timer("go_worker_latency").Record(1500 * time.Microsecond) }, want: "go_worker_latency:1.5|ms"},
+		{
+			name:   "tags sorted and accumulated",
+			tags:   []map[string]string{{"task_queue": "go-workers"}, {"status": "completed", "namespace": "default"}},
+			record: func(h client.MetricsHandler) { h.Counter("go_worker_runs").Inc(1) },
+			want:   "go_worker_runs:1|c|#task_queue:go-workers,namespace:default,status:completed",
+		},
+		{
+			name:   "delimiters sanitized",
+			tags:   []map[string]string{{"reason": "a|b:c,d#e"}},
+			record: func(h client.MetricsHandler) { h.Counter("odd:name|x").Inc(1) },
+			want:   "odd_name_x:1|c|#reason:a_b_c_d_e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, next := listenStatsD(t)
+			statsd, err := newStatsDHandler(addr)
+			require.NoError(t, err)
+			defer statsd.Close()
+
+			var handler client.MetricsHandler = statsd
+			for _, tags := range tt.tags {
+				handler = handler.WithTags(tags)
+			}
+			tt.record(handler)
+			assert.Equal(t, tt.want, next())
+		})
+	}
+}
+
+func TestNewStatsDHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr string
+	}{
+		{name: "no port", addr: "localhost", wantErr: "statsd: "},
+		{name: "bad port", addr: "localhost:notaport", wantErr: "statsd: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newMetricsHandler(MetricsBackendStatsD, tt.addr)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestStatsDHandlerAgentDown checks sends to an agent that isn't listening
+// don't fail or block
+func TestStatsDHandlerAgentDown(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := agent.LocalAddr().String()
+	require.NoError(t, agent.Close())
+
+	statsd, err := newStatsDHandler(addr)
+	require.NoError(t, err)
+	defer statsd.Close()
+	statsd.Counter("go_worker_runs").Inc(1)
+	statsd.Counter("go_worker_runs").Inc(1)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// StepTiming records when a workflow step ran and how it ended
+type StepTiming struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"` // completed or failed
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	// BuildID is the worker build that recorded the step's end
+	BuildID string `json:"build_id,omitempty"`
+}
+
+// StepResultQueryName returns the captured result of a finished step
+const StepResultQueryName = "stepResult"
+
+// StepResultPageQueryName returns a finished step's result in chunks, for
+// results too large for one query response
+const StepResultPageQueryName = "stepResultPage"
+
+// CheckpointQueryName returns the steps a run has completed so far
+const CheckpointQueryName = "checkpoint"
+
+// Checkpoint is where a run stands. Completed steps are never rerun: a run
+// picked up by a new build replays its history and carries on from
+// LastCompletedStep.
+type Checkpoint struct {
+	CompletedSteps    []string `json:"completed_steps"`
+	LastCompletedStep string   `json:"last_completed_step,omitempty"`
+	CurrentStep       string   `json:"current_step"`
+	// StartBuildID ran the run's first workflow task and CurrentBuildID its
+	// latest; they differ once a rolling deploy has moved the run
+	StartBuildID   string `json:"start_build_id,omitempty"`
+	CurrentBuildID string `json:"current_build_id,omitempty"`
+}
+
+// maxStepResultChunkBytes caps a stepResultPage chunk. Chunks are base64 in
+// the JSON response, so a full one stays well under the server's 2MB blob
+// limit.
+const maxStepResultChunkBytes = 1 << 20
+
+// StepResultPageRequest asks for Limit bytes of a step's JSON-encoded result
+// from Offset. Limit defaults to, and is capped at, maxStepResultChunkBytes.
+type StepResultPageRequest struct {
+	Step   string `json:"step"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// StepResultPage is one chunk of a step's JSON-encoded result. Clients
+// concatenate Data from offset 0 until Done and decode the whole as JSON;
+// NextOffset is where the following chunk starts.
+type StepResultPage struct {
+	Step       string `json:"step"`
+	Offset     int    `json:"offset"`
+	NextOffset int    `json:"next_offset"`
+	TotalBytes int    `json:"total_bytes"`
+	Data       []byte `json:"data"`
+	Done       bool   `json:"done"`
+}
+
+// stepRecorder appends step timings to a result as steps finish, so results
+// returned early still carry every step that ran. Times come from
+// workflow.Now and are identical on replay. Each step's raw activity result
+// is kept for the stepResult query.
+type stepRecorder struct {
+	ctx     workflow.Context
+	steps   *[]StepTiming
+	known   map[string]bool
+	results map[string]interface{}
+	// active lists started steps that haven't finished, in start order
+	active []string
+	// cancelledAt is the first step that ended with a cancellation
+	cancelledAt string
+	// onStep, if set, is called after each step is recorded
+	onStep func(StepTiming)
+	// startBuildID ran the first workflow task; buildID recorded the latest
+	// step
+	startBuildID, buildID string
+}
+
+// newStepRecorder records into steps and registers the stepResult query.
+// known lists every step the workflow may run, to tell unknown steps from
+// ones that haven't run yet.
+func newStepRecorder(ctx workflow.Context, steps *[]StepTiming, known ...string) (*stepRecorder, error) {
+	r := &stepRecorder{
+		ctx:     ctx,
+		steps:   steps,
+		known:   make(map[string]bool, len(known)),
+		results: make(map[string]interface{}),
+	}
+	r.startBuildID = workflow.GetInfo(ctx).GetCurrentBuildID()
+	r.buildID = r.startBuildID
+	for _, name := range known {
+		r.known[name] = true
+	}
+	if err := workflow.SetQueryHandler(ctx, StepResultQueryName, r.result); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, StepResultPageQueryName, r.resultPage); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, CheckpointQueryName, r.checkpoint); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *stepRecorder) result(name string) (interface{}, error) {
+	if !r.known[name] {
+		known := make([]string, 0, len(r.known))
+		for k := range r.known {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("unknown step %q (steps: %s)", name, strings.Join(known, ", "))
+	}
+	result, ok := r.results[name]
+	if !ok {
+		return nil, fmt.Errorf("step %q has not finished", name)
+	}
+	return result, nil
+}
+
+// resultPage returns a chunk of a step's result. The result is encoded on
+// every query, so chunks of a finished step always line up.
+func (r *stepRecorder) resultPage(req StepResultPageRequest) (StepResultPage, error) {
+	result, err := r.result(req.Step)
+	if err != nil {
+		return StepResultPage{}, err
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return StepResultPage{}, fmt.Errorf("encoding result of step %q: %w", req.Step, err)
+	}
+	if req.Offset < 0 || req.Offset > len(encoded) {
+		return StepResultPage{}, fmt.Errorf("offset %d outside result of step %q (%d bytes)", req.Offset, req.Step, len(encoded))
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > maxStepResultChunkBytes {
+		limit = maxStepResultChunkBytes
+	}
+	end := req.Offset + limit
+	if end > len(encoded) {
+		end = len(encoded)
+	}
+	return StepResultPage{
+		Step:       req.Step,
+		Offset:     req.Offset,
+		NextOffset: end,
+		TotalBytes: len(encoded),
+		Data:       encoded[req.Offset:end],
+		Done:       end == len(encoded),
+	}, nil
+}
+
+// start begins timing a step; call the returned func with the step's result
+// (nil if it has none) and error when it finishes
+func (r *stepRecorder) start(name string) func(result interface{}, err error) {
+	started := workflow.Now(r.ctx)
+	r.active = append(r.active, name)
+	return func(result interface{}, err error) {
+		for i, active := range r.active {
+			if active == name {
+				r.active = append(r.active[:i], r.active[i+1:]...)
+				break
+			}
+		}
+		if temporal.IsCanceledError(err) && r.cancelledAt == "" {
+			r.cancelledAt = name
+		}
+		r.results[name] = result
+		ended := workflow.Now(r.ctx)
+		step := StepTiming{
+			Name:       name,
+			Status:     "completed",
+			StartedAt:  started,
+			EndedAt:    ended,
+			DurationMs: nonNegative(ended.Sub(started)).Milliseconds(),
+		}
+		if err != nil {
+			step.Status = "failed"
+			step.Error = err.Error()
+		}
+		step.BuildID = workflow.GetInfo(r.ctx).GetCurrentBuildID()
+		if step.BuildID != r.buildID && r.buildID != "" {
+			workflow.GetLogger(r.ctx).Info("🔁 Run moved to a new build", "from_build_id", r.buildID,
+				"build_id", step.BuildID, "step", name, "last_completed_step", r.lastCompleted())
+		}
+		r.buildID = step.BuildID
+		*r.steps = append(*r.steps, step)
+		if r.onStep != nil {
+			r.onStep(step)
+		}
+	}
+}
+
+// current names the step the workflow is at: the step cancellation
+// interrupted, else the oldest running step, else "after" the last one
+func (r *stepRecorder) current() string {
+	switch {
+	case r.cancelledAt != "":
+		return r.cancelledAt
+	case len(r.active) > 0:
+		return r.active[0]
+	case len(*r.steps) > 0:
+		return "after " + (*r.steps)[len(*r.steps)-1].Name
+	}
+	return "start"
+}
+
+// lastCompleted names the latest step that completed, if any
+func (r *stepRecorder) lastCompleted() string {
+	for i := len(*r.steps) - 1; i >= 0; i-- {
+		if (*r.steps)[i].Status == "completed" {
+			return (*r.steps)[i].Name
+		}
+	}
+	return ""
+}
+
+func (r *stepRecorder) checkpoint() (Checkpoint, error) {
+	checkpoint := Checkpoint{
+		CompletedSteps:    []string{},
+		LastCompletedStep: r.lastCompleted(),
+		CurrentStep:       r.current(),
+		StartBuildID:      r.startBuildID,
+		CurrentBuildID:    workflow.GetInfo(r.ctx).GetCurrentBuildID(),
+	}
+	for _, step := range *r.steps {
+		if step.Status == "completed" {
+			checkpoint.CompletedSteps = append(checkpoint.CompletedSteps, step.Name)
+		}
+	}
+	return checkpoint, nil
+}
+
+// emit logs one structured completion event summarizing every step
+func (r *stepRecorder) emit(status string) {
+	info := workflow.GetInfo(r.ctx)
+	started := info.WorkflowStartTime
+	workflow.GetLogger(r.ctx).Info("🏁 Workflow steps summary",
+		"workflow_type", info.WorkflowType.Name,
+		"status", status,
+		"duration_ms", nonNegative(workflow.Now(r.ctx).Sub(started)).Milliseconds(),
+		"steps", *r.steps)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestComplexProcessingStepTimings(t *testing.T) {
+	tests := []struct {
+		name        string
+		optimizeErr error
+		wantStatus  string
+		wantError   string
+	}{
+		{name: "completed", wantStatus: "completed"},
+		{name: "failed step", optimizeErr: errors.New("optimizer unavailable"), wantStatus: "failed", wantError: "optimizer unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			var suite testsuite.WorkflowTestSuite
+			suite.SetLogger(logger)
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).After(2*time.Minute).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "2m"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).After(30*time.Second).Return(OptimizePerformanceResult{}, tt.optimizeErr)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:   CurrentComplexProcessingInputVersion,
+				DatasetID: "ds-1",
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result ComplexProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			steps := make(map[string]StepTiming, len(result.Steps))
+			var names []string
+			for _, step := range result.Steps {
+				steps[step.Name] = step
+				names = append(names, step.Name)
+			}
+			assert.Equal(t, []string{"fetch_metadata", "health_check", "process_dataset", "optimize_performance", "cache_results", "audit_log"}, names)
+			assert.Equal(t, "completed", steps["process_dataset"].Status)
+			assert.Equal(t, int64(2*time.Minute/time.Millisecond), steps["process_dataset"].DurationMs)
+			assert.Equal(t, steps["process_dataset"].StartedAt.Add(2*time.Minute), steps["process_dataset"].EndedAt)
+
+			optimize := steps["optimize_performance"]
+			assert.Equal(t, tt.wantStatus, optimize.Status)
+			assert.Contains(t, optimize.Error, tt.wantError)
+			assert.GreaterOrEqual(t, optimize.DurationMs, int64(30*time.Second/time.Millisecond))
+
+			summary := logger.infos["🏁 Workflow steps summary"]
+			require.NotNil(t, summary, "no summary event")
+			assert.Equal(t, "ComplexProcessingWorkflow", keyvalString(summary, "workflow_type"))
+			assert.Equal(t, "completed", keyvalString(summary, "status"))
+		})
+	}
+}
+
+// TestStepRecorderCurrent checks the step a run reports it is at as steps
+// start and finish
+func TestStepRecorderCurrent(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(func(ctx workflow.Context) ([]string, error) {
+		var timings []StepTiming
+		steps, err := newStepRecorder(ctx, &timings, "first", "second")
+		if err != nil {
+			return nil, err
+		}
+		seen := []string{steps.current()}
+		endFirst := steps.start("first")
+		endSecond := steps.start("second")
+		seen = append(seen, steps.current())
+		endFirst(nil, nil)
+		seen = append(seen, steps.current())
+		endSecond(nil, errors.New("broke"))
+		seen = append(seen, steps.current(), steps.lastCompleted())
+		return seen, nil
+	}, workflow.RegisterOptions{Name: "stepsWorkflow"})
+
+	env.ExecuteWorkflow("stepsWorkflow")
+	require.NoError(t, env.GetWorkflowError())
+	var seen []string
+	require.NoError(t, env.GetWorkflowResult(&seen))
+	assert.Equal(t, []string{"start", "first", "second", "after second", "first"}, seen)
+}
+
+func TestStepResultQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    string
+		want    map[string]int
+		wantErr string
+	}{
+		{name: "finished step", step: "first", want: map[string]int{"items": 10}},
+		{name: "step not finished", step: "second", wantErr: `step "second" has not finished`},
+		{name: "unknown step", step: "third", wantErr: `unknown step "third" (steps: first, second)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) error {
+				var timings []StepTiming
+				steps, err := newStepRecorder(ctx, &timings, "first", "second")
+				if err != nil {
+					return err
+				}
+				steps.start("first")(map[string]int{"items": 10}, nil)
+				endSecond := steps.start("second")
+				if err := workflow.Sleep(ctx, time.Hour); err != nil {
+					return err
+				}
+				endSecond(nil, nil)
+				return nil
+			}, workflow.RegisterOptions{Name: "stepResultWorkflow"})
+
+			env.RegisterDelayedCallback(func() {
+				value, err := env.QueryWorkflow(StepResultQueryName, tt.step)
+				if tt.wantErr != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.wantErr)
+					return
+				}
+				require.NoError(t, err)
+				var got map[string]int
+				require.NoError(t, value.Get(&got))
+				assert.Equal(t, tt.want, got)
+			}, time.Minute)
+
+			env.ExecuteWorkflow("stepResultWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+		})
+	}
+}
+
+func TestStepResultPageQuery(t *testing.T) {
+	large := map[string]string{"blob": strings.Repeat("x", 5*maxStepResultChunkBytes/2)}
+	tests := []struct {
+		name      string
+		request   StepResultPageRequest
+		wantPages int
+		wantErr   string
+	}{
+		{name: "large result in pages", request: StepResultPageRequest{Step: "first"}, wantPages: 3},
+		{name: "limit over the cap", request: StepResultPageRequest{Step: "first", Limit: 4 * maxStepResultChunkBytes}, wantPages: 3},
+		{name: "small pages", request: StepResultPageRequest{Step: "first", Limit: maxStepResultChunkBytes / 2}, wantPages: 6},
+		{name: "offset past the end", request: StepResultPageRequest{Step: "first", Offset: 3 * maxStepResultChunkBytes}, wantErr: "offset 3145728 outside result of step \"first\""},
+		{name: "step not finished", request: StepResultPageRequest{Step: "second"}, wantErr: `step "second" has not finished`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) error {
+				var timings []StepTiming
+				steps, err := newStepRecorder(ctx, &timings, "first", "second")
+				if err != nil {
+					return err
+				}
+				steps.start("first")(large, nil)
+				endSecond := steps.start("second")
+				if err := workflow.Sleep(ctx, time.Hour); err != nil {
+					return err
+				}
+				endSecond(nil, nil)
+				return nil
+			}, workflow.RegisterOptions{Name: "stepResultPageWorkflow"})
+
+			env.RegisterDelayedCallback(func() {
+				var assembled []byte
+				request := tt.request
+				for pages := 1; ; pages++ {
+					value, err := env.QueryWorkflow(StepResultPageQueryName, request)
+					if tt.wantErr != "" {
+						require.Error(t, err)
+						assert.Contains(t, err.Error(), tt.wantErr)
+						return
+					}
+					require.NoError(t, err)
+					var page StepResultPage
+					require.NoError(t, value.Get(&page))
+					require.Equal(t, len(assembled), page.Offset)
+					assert.LessOrEqual(t, len(page.Data), maxStepResultChunkBytes)
+					assembled = append(assembled, page.Data...)
+					require.Equal(t, len(assembled), page.NextOffset)
+					if page.Done {
+						assert.Equal(t, tt.wantPages, pages)
+						assert.Equal(t, page.TotalBytes, len(assembled))
+						break
+					}
+					require.Less(t, pages, tt.wantPages, "too many pages")
+					request.Offset = page.NextOffset
+				}
+				var got map[string]string
+				require.NoError(t, json.Unmarshal(assembled, &got))
+				assert.Equal(t, large, got)
+			}, time.Minute)
+
+			env.ExecuteWorkflow("stepResultPageWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+		})
+	}
+}
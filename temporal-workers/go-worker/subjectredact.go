@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeRedactionFailed is returned, without retries, when a record that
+// mentions the subject can't be parsed. Copying it through would leave the
+// subject's data in place.
+const ErrTypeRedactionFailed = "RedactionFailed"
+
+const (
+	defaultSubjectField = "subject_id"
+	// redactHeartbeatBytes is how often scanning progress is reported
+	redactHeartbeatBytes = 1 << 20
+	// redactMaxLineBytes is the longest record line that can be rewritten
+	redactMaxLineBytes = 16 << 20
+)
+
+// RedactSubjectInput represents input for a data subject redaction. The
+// source is JSON lines; records whose SubjectField equals SubjectID have
+// Fields redacted, or every field but "id" when Fields is empty. RequestID
+// identifies the data subject request in the audit log.
+type RedactSubjectInput struct {
+	DatasetID    string   `json:"dataset_id"`
+	SourceKey    string   `json:"source_key"`
+	SubjectID    string   `json:"subject_id"`
+	SubjectField string   `json:"subject_field,omitempty"`
+	Fields       []string `json:"fields,omitempty"`
+	RequestID    string   `json:"request_id,omitempty"`
+	Key          string   `json:"key,omitempty"`
+}
+
+// RedactSubjectResult represents the redacted copy of a dataset
+type RedactSubjectResult struct {
+	Object   ObjectRef `json:"object"`
+	Records  int       `json:"records"`
+	Redacted int       `json:"redacted"`
+}
+
+// RedactSubject writes a copy of a dataset with a data subject's records
+// redacted and audits the redaction. Records stream from the source to the
+// new object one line at a time, so memory use doesn't grow with the
+// dataset. Other records are copied byte for byte.
+func (a *Activities) RedactSubject(ctx context.Context, input RedactSubjectInput) (RedactSubjectResult, error) {
+	if input.SubjectID == "" {
+		return RedactSubjectResult{}, temporal.NewNonRetryableApplicationError("subject_id is required", ErrTypeRedactionFailed, nil)
+	}
+	if input.SubjectField == "" {
+		input.SubjectField = defaultSubjectField
+	}
+	key := input.Key
+	if key == "" {
+		key = fmt.Sprintf("redacted/%s.jsonl", input.DatasetID)
+	}
+	// The subject ID is personal data itself, so only its hash is logged
+	sum := sha256.Sum256([]byte(input.SubjectID))
+	subjectHash := hex.EncodeToString(sum[:])
+	activityLog.Infof("🕶️ Redacting subject %s… from %s", subjectHash[:12], input.SourceKey)
+
+	src, err := objectStore.Get(ctx, input.SourceKey)
+	if err != nil {
+		return RedactSubjectResult{}, err
+	}
+	defer src.Close()
+
+	var result RedactSubjectResult
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(redactRecords(&heartbeatReader{ctx: ctx, r: src, every: redactHeartbeatBytes}, pw, input, &result))
+	}()
+	ref, err := objectStore.Put(ctx, key, pr)
+	pr.CloseWithError(err)
+	if err != nil {
+		return RedactSubjectResult{}, err
+	}
+	result.Object = ref
+
+	err = a.AuditLog(ctx, AuditLogInput{
+		Action:    "subject_redacted",
+		DatasetID: input.DatasetID,
+		Details: map[string]interface{}{
+			"request_id":   input.RequestID,
+			"subject_hash": subjectHash,
+			"source_key":   input.SourceKey,
+			"object":       ref.Key,
+			"records":      result.Records,
+			"redacted":     result.Redacted,
+		},
+	})
+	if err != nil {
+		return RedactSubjectResult{}, fmt.Errorf("auditing redaction: %w", err)
+	}
+
+	activityLog.Infof("✅ Redacted %d of %d records into %s", result.Redacted, result.Records, ref.Key)
+	return result, nil
+}
+
+// redactRecords copies JSON lines from r to w, redacting the subject's
+// records. result is only read once w has been closed.
+func redactRecords(r io.Reader, w io.Writer, input RedactSubjectInput, result *RedactSubjectResult) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), redactMaxLineBytes)
+	out := bufio.NewWriter(w)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) > 0 {
+			result.Records++
+			redacted, matched, err := redactRecord(line, input)
+			if err != nil {
+				return temporal.NewNonRetryableApplicationError(
+					fmt.Sprintf("record %d mentions the subject but isn't a JSON object", result.Records), ErrTypeRedactionFailed, err)
+			}
+			if matched {
+				result.Redacted++
+				line = redacted
+			}
+		}
+		if _, err := out.Write(line); err != nil {
+			return err
+		}
+		if err := out.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanning %s: %w", input.SourceKey, err)
+	}
+	return out.Flush()
+}
+
+// redactRecord redacts line if it is the subject's record. Unparseable
+// lines are only an error when they contain the subject ID.
+func redactRecord(line []byte, input RedactSubjectInput) ([]byte, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var record map[string]interface{}
+	if err := decoder.Decode(&record); err != nil {
+		if bytes.Contains(line, []byte(input.SubjectID)) {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	if value, ok := record[input.SubjectField]; !ok || fmt.Sprint(value) != input.SubjectID {
+		return nil, false, nil
+	}
+
+	fields := input.Fields
+	if len(fields) == 0 {
+		for name := range record {
+			if name != "id" {
+				fields = append(fields, name)
+			}
+		}
+	}
+	for _, name := range fields {
+		value, ok := record[name]
+		if !ok {
+			continue
+		}
+		// Strings read as redacted; other types become null
+		if _, isString := value.(string); isString {
+			record[name] = redactedValue
+		} else {
+			record[name] = nil
+		}
+	}
+	redacted, err := json.Marshal(record)
+	return redacted, true, err
+}
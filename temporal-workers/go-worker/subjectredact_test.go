@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestRedactSubject(t *testing.T) {
+	dataset := strings.Join([]string{
+		`{"id":1,"subject_id":"u-1","email":"a@example.com","age":30}`,
+		`{"id":2,"subject_id":"u-2","email":"b@example.com"}`,
+		``,
+		`{"id":3,"subject_id":"u-1","email":"c@example.com","note":"hi"}`,
+		`not a record`,
+	}, "\n")
+	tests := []struct {
+		name        string
+		source      string
+		input       RedactSubjectInput
+		failPut     int
+		want        []string
+		wantRecords int
+		wantErrType string
+		wantErr     string
+	}{
+		{
+			name:  "every field but id",
+			input: RedactSubjectInput{SubjectID: "u-1"},
+			want: []string{
+				`{"id":1,"subject_id":"[REDACTED]","email":"[REDACTED]","age":null}`,
+				`{"id":2,"subject_id":"u-2","email":"b@example.com"}`,
+				``,
+				`{"id":3,"subject_id":"[REDACTED]","email":"[REDACTED]","note":"[REDACTED]"}`,
+				`not a record`,
+			},
+			wantRecords: 4,
+		},
+		{
+			name:  "listed fields",
+			input: RedactSubjectInput{SubjectID: "u-1", Fields: []string{"email", "phone"}},
+			want: []string{
+				`{"id":1,"subject_id":"u-1","email":"[REDACTED]","age":30}`,
+				`{"id":2,"subject_id":"u-2","email":"b@example.com"}`,
+				``,
+				`{"id":3,"subject_id":"u-1","email":"[REDACTED]","note":"hi"}`,
+				`not a record`,
+			},
+			wantRecords: 4,
+		},
+		{name: "no subject", input: RedactSubjectInput{}, wantErrType: ErrTypeRedactionFailed},
+		{
+			name:        "unparseable record mentions the subject",
+			source:      `{"id":1,"subject_id":"u-1",` + "\n",
+			input:       RedactSubjectInput{SubjectID: "u-1"},
+			wantErrType: ErrTypeRedactionFailed,
+		},
+		{name: "store unavailable", input: RedactSubjectInput{SubjectID: "u-1"}, failPut: 1, wantErr: "store unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			store := useCountingStore(t)
+			source := tt.source
+			if source == "" {
+				source = dataset
+			}
+			_, err := store.Put(context.Background(), "datasets/ds-1.jsonl", strings.NewReader(source))
+			require.NoError(t, err)
+			store.failPut = tt.failPut
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			input := tt.input
+			input.DatasetID = "ds-1"
+			input.SourceKey = "datasets/ds-1.jsonl"
+
+			value, err := env.ExecuteActivity(activities.RedactSubject, input)
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				assert.NotContains(t, logs.String(), "subject_redacted")
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.NotContains(t, logs.String(), "subject_redacted")
+				return
+			}
+			require.NoError(t, err)
+			var result RedactSubjectResult
+			require.NoError(t, value.Get(&result))
+			assert.Equal(t, tt.wantRecords, result.Records)
+			assert.Equal(t, 2, result.Redacted)
+			assert.Equal(t, "redacted/ds-1.jsonl", result.Object.Key)
+			assert.Contains(t, logs.String(), "📝 Audit log: subject_redacted")
+			assert.NotContains(t, logs.String(), "u-1", "the subject ID is logged")
+
+			r, err := store.Get(context.Background(), result.Object.Key)
+			require.NoError(t, err)
+			defer r.Close()
+			written, err := io.ReadAll(r)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(string(written), "\n"), "\n")
+			require.Len(t, lines, len(tt.want))
+			for i, line := range lines {
+				if strings.HasPrefix(tt.want[i], "{") {
+					assert.JSONEq(t, tt.want[i], line)
+				} else {
+					assert.Equal(t, tt.want[i], line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+)
+
+// workflowTaskQueueHeader carries the scheduling workflow's task queue to
+// its activities, which may run on a different queue
+const workflowTaskQueueHeader = "workflow-task-queue"
+
+type workflowTaskQueueKey struct{}
+
+// workflowTaskQueue returns the task queue of the workflow that scheduled
+// the activity, falling back to the activity's own queue (local
+// activities, or workers without taskQueueInterceptor)
+func workflowTaskQueue(ctx context.Context) string {
+	if q, ok := ctx.Value(workflowTaskQueueKey{}).(string); ok && q != "" {
+		return q
+	}
+	if activity.IsActivity(ctx) {
+		return activity.GetInfo(ctx).TaskQueue
+	}
+	return ""
+}
+
+// taskQueueInterceptor propagates the workflow's task queue to activities
+// in a header, and adds it to activity loggers (WorkflowTaskQueue) and
+// metrics (workflow_task_queue tag)
+type taskQueueInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+func (i *taskQueueInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	w := &taskQueueWorkflowInbound{}
+	w.Next = next
+	return w
+}
+
+func (i *taskQueueInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	a := &taskQueueActivityInbound{}
+	a.Next = next
+	return a
+}
+
+type taskQueueWorkflowInbound struct {
+	interceptor.WorkflowInboundInterceptorBase
+}
+
+func (w *taskQueueWorkflowInbound) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	o := &taskQueueWorkflowOutbound{}
+	o.Next = outbound
+	return w.Next.Init(o)
+}
+
+type taskQueueWorkflowOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+}
+
+func (o *taskQueueWorkflowOutbound) ExecuteActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	if payload, err := converter.GetDefaultDataConverter().ToPayload(workflow.GetInfo(ctx).TaskQueueName); err == nil {
+		interceptor.WorkflowHeader(ctx)[workflowTaskQueueHeader] = payload
+	}
+	return o.Next.ExecuteActivity(ctx, activityType, args...)
+}
+
+type taskQueueActivityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (a *taskQueueActivityInbound) Init(outbound interceptor.ActivityOutboundInterceptor) error {
+	o := &taskQueueActivityOutbound{}
+	o.Next = outbound
+	return a.Next.Init(o)
+}
+
+func (a *taskQueueActivityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	if payload, ok := interceptor.Header(ctx)[workflowTaskQueueHeader]; ok {
+		var q string
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &q); err == nil {
+			ctx = context.WithValue(ctx, workflowTaskQueueKey{}, q)
+		}
+	}
+	return a.Next.ExecuteActivity(ctx, in)
+}
+
+type taskQueueActivityOutbound struct {
+	interceptor.ActivityOutboundInterceptorBase
+}
+
+func (o *taskQueueActivityOutbound) GetLogger(ctx context.Context) log.Logger {
+	return log.With(o.Next.GetLogger(ctx), "WorkflowTaskQueue", workflowTaskQueue(ctx))
+}
+
+func (o *taskQueueActivityOutbound) GetMetricsHandler(ctx context.Context) client.MetricsHandler {
+	return o.Next.GetMetricsHandler(ctx).WithTags(map[string]string{"workflow_task_queue": workflowTaskQueue(ctx)})
+}
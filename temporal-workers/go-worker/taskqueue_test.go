@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestTaskQueueInterceptor(t *testing.T) {
+	tests := []struct {
+		name        string
+		interceptor bool
+		want        string
+	}{
+		{name: "propagated", interceptor: true, want: "workflow-queue"},
+		{name: "falls back to the activity queue", want: "activity-queue"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			metrics := newCounterHandler()
+			var suite testsuite.WorkflowTestSuite
+			suite.SetLogger(logger)
+			suite.SetMetricsHandler(metrics)
+			env := suite.NewTestWorkflowEnvironment()
+			if tt.interceptor {
+				env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{&taskQueueInterceptor{}}})
+			}
+			env.SetStartWorkflowOptions(client.StartWorkflowOptions{TaskQueue: "workflow-queue"})
+			env.RegisterActivityWithOptions(func(ctx context.Context) (string, error) {
+				activity.GetLogger(ctx).Info("queue check")
+				activity.GetMetricsHandler(ctx).Counter("queue_checks").Inc(1)
+				return workflowTaskQueue(ctx), nil
+			}, activity.RegisterOptions{Name: "queueCheck"})
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) (string, error) {
+				ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute, TaskQueue: "activity-queue"})
+				var q string
+				err := workflow.ExecuteActivity(ctx, "queueCheck").Get(ctx, &q)
+				return q, err
+			}, workflow.RegisterOptions{Name: "queueWorkflow"})
+
+			env.ExecuteWorkflow("queueWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var got string
+			require.NoError(t, env.GetWorkflowResult(&got))
+			assert.Equal(t, tt.want, got)
+
+			if tt.interceptor {
+				assert.Equal(t, "workflow-queue", keyvalString(logger.infos["queue check"], "WorkflowTaskQueue"))
+				assert.Equal(t, int64(1), metrics.counts["queue_checks{activity_type=queueCheck,task_queue=activity-queue,workflow_task_queue=workflow-queue,workflow_type=queueWorkflow}"])
+			} else {
+				assert.Empty(t, keyvalString(logger.infos["queue check"], "WorkflowTaskQueue"))
+			}
+		})
+	}
+}
+
+func TestWorkflowTaskQueueOutsideActivity(t *testing.T) {
+	assert.Empty(t, workflowTaskQueue(context.Background()))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/client"
+)
+
+// joinCompatibleBuild adds buildID to the task queue's version set holding
+// compatibleWith and makes it that set's default, so runs in flight on the
+// older build continue on this one from their last completed step. It
+// reports false when buildID is already registered, so restarted workers
+// of the same build don't re-register.
+//
+// Only builds whose workflow changes are guarded by workflow.GetVersion may
+// join an existing set; the others must start a new one.
+func joinCompatibleBuild(ctx context.Context, c client.Client, taskQueue, buildID, compatibleWith string) (bool, error) {
+	sets, err := c.GetWorkerBuildIdCompatibility(ctx, &client.GetWorkerBuildIdCompatibilityOptions{TaskQueue: taskQueue})
+	if err != nil {
+		return false, err
+	}
+	for _, set := range sets.Sets {
+		for _, id := range set.BuildIDs {
+			if id == buildID {
+				return false, nil
+			}
+		}
+	}
+	err = c.UpdateWorkerBuildIdCompatibility(ctx, &client.UpdateWorkerBuildIdCompatibilityOptions{
+		TaskQueue: taskQueue,
+		Operation: &client.BuildIDOpAddNewCompatibleVersion{
+			BuildID:                   buildID,
+			ExistingCompatibleBuildID: compatibleWith,
+			MakeSetDefault:            true,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+)
+
+func checkpointFetch(ctx context.Context) (string, error)    { return "fetched", nil }
+func checkpointValidate(ctx context.Context) (string, error) { return "valid", nil }
+func checkpointProcess(ctx context.Context) (string, error)  { return "processed", nil }
+
+// checkpointWorkflow returns a run of two recorded steps, fetch then
+// process, as the code of one build. change, if set, runs between the
+// steps, standing for what a later build added.
+func checkpointWorkflow(change func(ctx workflow.Context, steps *stepRecorder) error) func(workflow.Context) ([]StepTiming, error) {
+	return func(ctx workflow.Context) ([]StepTiming, error) {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+		var completed []StepTiming
+		steps, err := newStepRecorder(ctx, &completed, "fetch", "validate", "process")
+		if err != nil {
+			return nil, err
+		}
+		var result string
+		end := steps.start("fetch")
+		err = workflow.ExecuteActivity(ctx, checkpointFetch).Get(ctx, &result)
+		end(result, err)
+		if err != nil {
+			return completed, err
+		}
+		if change != nil {
+			if err := change(ctx, steps); err != nil {
+				return completed, err
+			}
+		}
+		end = steps.start("process")
+		err = workflow.ExecuteActivity(ctx, checkpointProcess).Get(ctx, &result)
+		end(result, err)
+		return completed, err
+	}
+}
+
+// validate is the step the second build adds
+func validate(ctx workflow.Context, steps *stepRecorder) error {
+	var result string
+	end := steps.start("validate")
+	err := workflow.ExecuteActivity(ctx, checkpointValidate).Get(ctx, &result)
+	end(result, err)
+	return err
+}
+
+// midFlightHistory is the history of a run on build go-v1 that completed
+// fetch and had just scheduled process when the deploy moved it
+func midFlightHistory(t *testing.T) *historypb.History {
+	dc := converter.GetDefaultDataConverter()
+	fetched, err := dc.ToPayloads("fetched")
+	require.NoError(t, err)
+	taskQueue := &taskqueuepb.TaskQueue{Name: "go-workers"}
+	v1 := &commonpb.WorkerVersionStamp{BuildId: "go-v1", UseVersioning: true}
+
+	workflowTask := func(scheduled int64) []*historypb.HistoryEvent {
+		return []*historypb.HistoryEvent{
+			{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_SCHEDULED, Attributes: &historypb.HistoryEvent_WorkflowTaskScheduledEventAttributes{
+				WorkflowTaskScheduledEventAttributes: &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: taskQueue},
+			}},
+			{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_STARTED, Attributes: &historypb.HistoryEvent_WorkflowTaskStartedEventAttributes{
+				WorkflowTaskStartedEventAttributes: &historypb.WorkflowTaskStartedEventAttributes{ScheduledEventId: scheduled},
+			}},
+			{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_COMPLETED, Attributes: &historypb.HistoryEvent_WorkflowTaskCompletedEventAttributes{
+				WorkflowTaskCompletedEventAttributes: &historypb.WorkflowTaskCompletedEventAttributes{ScheduledEventId: scheduled, StartedEventId: scheduled + 1, WorkerVersion: v1},
+			}},
+		}
+	}
+	scheduleActivity := func(id, name string, completedTask int64) *historypb.HistoryEvent {
+		return &historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED, Attributes: &historypb.HistoryEvent_ActivityTaskScheduledEventAttributes{
+			ActivityTaskScheduledEventAttributes: &historypb.ActivityTaskScheduledEventAttributes{
+				ActivityId: id, ActivityType: &commonpb.ActivityType{Name: name}, TaskQueue: taskQueue, WorkflowTaskCompletedEventId: completedTask,
+			},
+		}}
+	}
+
+	var events []*historypb.HistoryEvent
+	events = append(events, &historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, Attributes: &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{
+		WorkflowExecutionStartedEventAttributes: &historypb.WorkflowExecutionStartedEventAttributes{
+			WorkflowType: &commonpb.WorkflowType{Name: "checkpointWorkflow"},
+			TaskQueue:    taskQueue,
+		},
+	}})
+	events = append(events, workflowTask(2)...)
+	events = append(events,
+		scheduleActivity("5", "checkpointFetch", 4),
+		&historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_ACTIVITY_TASK_STARTED, Attributes: &historypb.HistoryEvent_ActivityTaskStartedEventAttributes{
+			ActivityTaskStartedEventAttributes: &historypb.ActivityTaskStartedEventAttributes{ScheduledEventId: 5},
+		}},
+		&historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED, Attributes: &historypb.HistoryEvent_ActivityTaskCompletedEventAttributes{
+			ActivityTaskCompletedEventAttributes: &historypb.ActivityTaskCompletedEventAttributes{ScheduledEventId: 5, StartedEventId: 6, Result: fetched},
+		}},
+	)
+	events = append(events, workflowTask(8)...)
+	events = append(events, scheduleActivity("11", "checkpointProcess", 10))
+	for i, event := range events {
+		event.EventId = int64(i + 1)
+	}
+	return &historypb.History{Events: events}
+}
+
+// TestReplayAcrossBuilds replays a run started on one build with the code
+// of the next. A run resumes correctly when the new code, given the
+// recorded history, asks for exactly the step the old build asked for next
+// (process) rather than redoing fetch or running a step the run never had.
+func TestReplayAcrossBuilds(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(ctx workflow.Context, steps *stepRecorder) error
+		wantErr string
+	}{
+		{name: "same build"},
+		{
+			name: "new step guarded by GetVersion",
+			change: func(ctx workflow.Context, steps *stepRecorder) error {
+				if workflow.GetVersion(ctx, "add-validate-step", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+					return nil
+				}
+				return validate(ctx, steps)
+			},
+		},
+		{name: "unguarded new step", change: validate, wantErr: "nondeterministic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer, err := newReplayer()
+			require.NoError(t, err)
+			replayer.RegisterWorkflowWithOptions(checkpointWorkflow(tt.change), workflow.RegisterOptions{Name: "checkpointWorkflow"})
+
+			err = replayer.ReplayWorkflowHistory(nil, midFlightHistory(t))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+const defaultWarmCacheMaxKeys = 100000
+
+// WarmCacheInput represents input for warming the result cache with
+// lookup records from a JSON-lines dataset. Each record is cached under
+// KeyPrefix plus its KeyField value; Keys, when set, limits warming to
+// those values. At most MaxKeys records are loaded.
+type WarmCacheInput struct {
+	DatasetID  string   `json:"dataset_id"`
+	SourceKey  string   `json:"source_key"`
+	KeyField   string   `json:"key_field,omitempty"`   // default: id
+	Keys       []string `json:"keys,omitempty"`        // default: every record
+	KeyPrefix  string   `json:"key_prefix,omitempty"`  // default: lookup:<source_key>:
+	TTLSeconds int      `json:"ttl_seconds,omitempty"` // default: 1h
+	MaxKeys    int      `json:"max_keys,omitempty"`    // default: 100000
+}
+
+// WarmCacheResult reports what a warm-up loaded. Records already cached
+// are counted but not rewritten, so warming twice loads nothing new.
+type WarmCacheResult struct {
+	DatasetID     string `json:"dataset_id"`
+	Scanned       int64  `json:"scanned"`
+	Loaded        int    `json:"loaded"`
+	AlreadyCached int    `json:"already_cached"`
+	Failed        int    `json:"failed"`
+	Missing       int    `json:"missing"`   // requested keys the source doesn't have
+	Truncated     bool   `json:"truncated"` // stopped at max_keys
+}
+
+// WarmCache streams a lookup dataset into the result cache so the lookups
+// made during processing hit it. Only the requested key set and the keys
+// loaded so far are held in memory. Cache write failures are counted, not
+// fatal: a cold key is only slower to read.
+func (a *Activities) WarmCache(ctx context.Context, input WarmCacheInput) (WarmCacheResult, error) {
+	if input.SourceKey == "" {
+		return WarmCacheResult{}, temporal.NewNonRetryableApplicationError("source_key is required", "InvalidInput", nil)
+	}
+	field := input.KeyField
+	if field == "" {
+		field = "id"
+	}
+	prefix := input.KeyPrefix
+	if prefix == "" {
+		prefix = "lookup:" + input.SourceKey + ":"
+	}
+	ttl := time.Hour
+	if input.TTLSeconds > 0 {
+		ttl = time.Duration(input.TTLSeconds) * time.Second
+	}
+	limit := input.MaxKeys
+	if limit <= 0 {
+		limit = defaultWarmCacheMaxKeys
+	}
+	if len(input.Keys) > limit {
+		return WarmCacheResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("%d keys requested, more than max_keys %d", len(input.Keys), limit), "InvalidInput", nil)
+	}
+	var wanted map[string]bool
+	if len(input.Keys) > 0 {
+		wanted = make(map[string]bool, len(input.Keys))
+		for _, key := range input.Keys {
+			wanted[key] = true
+		}
+	}
+	activityLog.Infof("🔥 Warming cache from %s (%d keys requested)", input.SourceKey, len(wanted))
+
+	src, err := objectStore.Get(ctx, input.SourceKey)
+	if err != nil {
+		return WarmCacheResult{}, err
+	}
+	defer src.Close()
+
+	result := WarmCacheResult{DatasetID: input.DatasetID}
+	seen := make(map[string]bool)
+	var cacheErr error
+	err = scanRecords(&heartbeatReader{ctx: ctx, r: src, every: refHeartbeatBytes}, func(record map[string]json.RawMessage) {
+		result.Scanned++
+		key, ok := referenceKey(record[field])
+		if !ok || seen[key] || (wanted != nil && !wanted[key]) {
+			return
+		}
+		if len(seen) >= limit {
+			result.Truncated = true
+			return
+		}
+		seen[key] = true
+
+		if _, cached, err := resultCache.Get(ctx, prefix+key); err == nil && cached {
+			result.AlreadyCached++
+			return
+		}
+		value, err := json.Marshal(record)
+		if err == nil {
+			err = resultCache.Set(ctx, prefix+key, value, ttl)
+		}
+		if err != nil {
+			result.Failed++
+			cacheErr = err
+			return
+		}
+		result.Loaded++
+	})
+	if err != nil {
+		return WarmCacheResult{}, fmt.Errorf("scanning %s: %w", input.SourceKey, err)
+	}
+	if wanted != nil {
+		result.Missing = len(wanted) - len(seen)
+	}
+	if cacheErr != nil {
+		activityLog.Errorf("⚠️ %d records couldn't be cached, last error: %v", result.Failed, cacheErr)
+	}
+
+	activityLog.Infof("✅ Cache warmed: %d loaded, %d already cached, %d missing", result.Loaded, result.AlreadyCached, result.Missing)
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestWarmCache(t *testing.T) {
+	lookups := strings.Join([]string{
+		`{"id":1,"name":"north"}`,
+		`{"id":"2","name":"south"}`,
+		`{"name":"no id"}`,
+		`{"id":1,"name":"north again"}`,
+		`{"id":3,"name":"east"}`,
+	}, "\n")
+	tests := []struct {
+		name        string
+		input       WarmCacheInput
+		cached      []string
+		setErr      error
+		want        WarmCacheResult
+		wantKeys    []string
+		wantErrType string
+		wantErr     string
+	}{
+		{
+			name:     "every record",
+			input:    WarmCacheInput{},
+			want:     WarmCacheResult{Scanned: 5, Loaded: 3},
+			wantKeys: []string{"lookup:datasets/regions.jsonl:1", "lookup:datasets/regions.jsonl:2", "lookup:datasets/regions.jsonl:3"},
+		},
+		{
+			name:     "requested keys",
+			input:    WarmCacheInput{Keys: []string{"2", "9"}, KeyPrefix: "region:"},
+			want:     WarmCacheResult{Scanned: 5, Loaded: 1, Missing: 1},
+			wantKeys: []string{"region:2"},
+		},
+		{
+			name:     "warmed before",
+			input:    WarmCacheInput{KeyField: "name", KeyPrefix: "region:", Keys: []string{"north", "south"}},
+			cached:   []string{"region:north"},
+			want:     WarmCacheResult{Scanned: 5, Loaded: 1, AlreadyCached: 1},
+			wantKeys: []string{"region:north", "region:south"},
+		},
+		{
+			name:     "stops at max keys",
+			input:    WarmCacheInput{MaxKeys: 2, KeyPrefix: "region:"},
+			want:     WarmCacheResult{Scanned: 5, Loaded: 2, Truncated: true},
+			wantKeys: []string{"region:1", "region:2"},
+		},
+		{name: "cache writes fail", input: WarmCacheInput{}, setErr: errors.New("cache unreachable"), want: WarmCacheResult{Scanned: 5, Failed: 3}},
+		{name: "no source", input: WarmCacheInput{SourceKey: "-"}, wantErrType: "InvalidInput"},
+		{name: "too many keys", input: WarmCacheInput{Keys: []string{"1", "2", "3"}, MaxKeys: 2}, wantErrType: "InvalidInput"},
+		{name: "source missing", input: WarmCacheInput{SourceKey: "datasets/missing.jsonl"}, wantErr: "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useCountingStore(t)
+			_, err := store.Put(context.Background(), "datasets/regions.jsonl", strings.NewReader(lookups))
+			require.NoError(t, err)
+			prev := resultCache
+			t.Cleanup(func() { resultCache = prev })
+			cache := newMemoryCache()
+			for _, key := range tt.cached {
+				require.NoError(t, cache.Set(context.Background(), key, []byte(`{}`), time.Hour))
+			}
+			resultCache = failingCache{memoryCache: cache, setErr: tt.setErr}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivity(activities)
+			input := tt.input
+			input.DatasetID = "orders"
+			switch input.SourceKey {
+			case "":
+				input.SourceKey = "datasets/regions.jsonl"
+			case "-":
+				input.SourceKey = ""
+			}
+
+			value, err := env.ExecuteActivity(activities.WarmCache, input)
+			switch {
+			case tt.wantErrType != "":
+				requireApplicationError(t, err, tt.wantErrType)
+				return
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var result WarmCacheResult
+			require.NoError(t, value.Get(&result))
+			want := tt.want
+			want.DatasetID = "orders"
+			assert.Equal(t, want, result)
+			for _, key := range tt.wantKeys {
+				_, ok, err := cache.Get(context.Background(), key)
+				require.NoError(t, err)
+				assert.True(t, ok, "%s cached", key)
+			}
+		})
+	}
+}
+
+func TestComplexProcessingWarmCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		warmErr    error
+		wantStatus string
+	}{
+		{name: "warmed", wantStatus: "completed"},
+		{name: "warm-up fails", warmErr: temporal.NewNonRetryableApplicationError("cache unreachable", "CacheUnavailable", nil), wantStatus: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warmed []WarmCacheInput
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.WarmCache, mock.Anything, mock.Anything).Return(func(ctx context.Context, input WarmCacheInput) (WarmCacheResult, error) {
+				warmed = append(warmed, input)
+				return WarmCacheResult{DatasetID: input.DatasetID, Loaded: 3}, tt.warmErr
+			})
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 1000, ProcessingTime: "1s"}, nil)
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(OptimizePerformanceResult{}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:   CurrentComplexProcessingInputVersion,
+				DatasetID: "ds-1",
+				WarmCache: &WarmCacheInput{SourceKey: "datasets/regions.jsonl"},
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError(), "a cold cache doesn't fail the run")
+			var result ComplexProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, StatusCompleted, result.Status)
+			assert.Equal(t, 1000, result.ProcessedItems)
+			require.Len(t, warmed, 1)
+			assert.Equal(t, "ds-1", warmed[0].DatasetID)
+			var step *StepTiming
+			for i := range result.Steps {
+				if result.Steps[i].Name == "warm_cache" {
+					step = &result.Steps[i]
+				}
+			}
+			require.NotNil(t, step)
+			assert.Equal(t, tt.wantStatus, step.Status)
+		})
+	}
+}
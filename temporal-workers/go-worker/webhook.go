@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Completion webhook events
+const (
+	WebhookEventCompleted = "run.completed"
+	WebhookEventFailed    = "run.failed"
+)
+
+// Application error types returned by SendCompletionWebhook. Neither is
+// retried.
+const (
+	// ErrTypeWebhookRejected: the URL isn't an https URL of a public host,
+	// or the webhook answered with a client error other than 408 or 429
+	ErrTypeWebhookRejected = "WebhookRejected"
+	// ErrTypeWebhookNotConfigured: WEBHOOK_SECRET is unset, so the request
+	// couldn't be signed
+	ErrTypeWebhookNotConfigured = "WebhookNotConfigured"
+)
+
+// Webhook request headers. The signature is the hex HMAC-SHA256, keyed by
+// WEBHOOK_SECRET, of "<timestamp>.<body>"; receivers should also reject
+// stale timestamps. The ID is the same on every delivery of a run's
+// completion, for receivers to deduplicate retries.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookIDHeader        = "X-Webhook-ID"
+)
+
+// webhookSecret signs completion webhooks; they aren't sent without it
+var webhookSecret string
+
+// webhookHostAllowed reports whether webhooks may be sent to an address.
+// Webhook URLs come from callers and requests leave from inside the worker
+// network, so only public addresses are allowed.
+var webhookHostAllowed = publicAddress
+
+var webhookClient = newWebhookClient()
+
+// newWebhookClient returns a client that checks every address it connects
+// to, after DNS resolution, so a name can't be rebound to an internal
+// address between the check and the request. It uses no proxy and doesn't
+// follow redirects, which could lead anywhere.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !webhookHostAllowed(ip) {
+				return &blockedAddressError{host}
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// blockedAddressError is returned when a webhook resolves to an address
+// that isn't allowed
+type blockedAddressError struct {
+	address string
+}
+
+func (e *blockedAddressError) Error() string {
+	return fmt.Sprintf("webhook address %s isn't public", e.address)
+}
+
+// sharedAddressSpace is carrier-grade NAT space (RFC 6598), which
+// net.IP.IsPrivate doesn't cover
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicAddress reports whether ip is a public unicast address: not
+// loopback, private, link-local (which includes cloud metadata endpoints),
+// shared, multicast or unspecified
+func publicAddress(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// validateWebhookURL checks that a webhook URL is https and that its host
+// resolves only to allowed addresses. Lookup failures are retried; a URL
+// that isn't allowed isn't.
+func validateWebhookURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("webhook URL %q must be an https URL", raw), ErrTypeWebhookRejected, err)
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("resolving webhook host %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !webhookHostAllowed(addr.IP) {
+			blocked := &blockedAddressError{addr.IP.String()}
+			return temporal.NewNonRetryableApplicationError(blocked.Error(), ErrTypeWebhookRejected, nil)
+		}
+	}
+	return nil
+}
+
+// CompletionWebhookPayload summarizes a finished run
+type CompletionWebhookPayload struct {
+	Event            string  `json:"event"`
+	WorkflowID       string  `json:"workflow_id"`
+	RunID            string  `json:"run_id"`
+	DatasetID        string  `json:"dataset_id"`
+	Status           string  `json:"status"`
+	ProcessedItems   int     `json:"processed_items"`
+	ProcessingTime   string  `json:"processing_time,omitempty"`
+	OptimizationGain float64 `json:"optimization_gain,omitempty"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// SendCompletionWebhookInput represents input for a completion webhook
+type SendCompletionWebhookInput struct {
+	URL     string                   `json:"url"`
+	Payload CompletionWebhookPayload `json:"payload"`
+}
+
+// SendCompletionWebhook POSTs a run's signed completion to the caller's
+// webhook, which must be an https URL of a public host. Server errors, 408
+// and 429 are retried; other client errors, redirects, disallowed URLs and
+// a missing WEBHOOK_SECRET aren't.
+func (a *Activities) SendCompletionWebhook(ctx context.Context, input SendCompletionWebhookInput) error {
+	if webhookSecret == "" {
+		return temporal.NewNonRetryableApplicationError("WEBHOOK_SECRET is unset, so webhooks can't be signed", ErrTypeWebhookNotConfigured, nil)
+	}
+	if err := validateWebhookURL(ctx, input.URL); err != nil {
+		return err
+	}
+	body, err := json.Marshal(input.Payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, input.URL, bytes.NewReader(body))
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid webhook URL: %v", err), ErrTypeWebhookRejected, nil)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, completionSignalID(input.Payload.WorkflowID, input.Payload.RunID))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(webhookSecret, timestamp, body))
+
+	activityLog.Infof("📨 Sending %s webhook for %s", input.Payload.Event, input.Payload.WorkflowID)
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		var blocked *blockedAddressError
+		if errors.As(err, &blocked) {
+			return temporal.NewNonRetryableApplicationError(blocked.Error(), ErrTypeWebhookRejected, nil)
+		}
+		return fmt.Errorf("sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode >= 300 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeWebhookRejected, nil)
+	}
+	return err
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyCompletion sends the completion webhook, if the run asked for one,
+// once the run has finished either way. It runs in a disconnected context
+// so cancelled runs are reported too. Delivery failures are logged and
+// don't change the run's outcome.
+func notifyCompletion(ctx workflow.Context, url string, result ComplexProcessingResult, runErr error) {
+	if url == "" {
+		return
+	}
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	logger := workflow.GetLogger(ctx)
+	options, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 30 * time.Second}, &temporal.RetryPolicy{
+		InitialInterval:    2 * time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return
+	}
+
+	info := workflow.GetInfo(ctx)
+	payload := CompletionWebhookPayload{
+		Event:            WebhookEventCompleted,
+		WorkflowID:       info.WorkflowExecution.ID,
+		RunID:            info.WorkflowExecution.RunID,
+		DatasetID:        result.DatasetID,
+		Status:           string(result.Status),
+		ProcessedItems:   result.ProcessedItems,
+		ProcessingTime:   result.ProcessingTime,
+		OptimizationGain: result.OptimizationGain,
+	}
+	if runErr != nil {
+		payload.Event = WebhookEventFailed
+		payload.Status = string(StatusFailed)
+		if temporal.IsCanceledError(runErr) {
+			payload.Status = "cancelled"
+		}
+		payload.Error = runErr.Error()
+	}
+	err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, options), activities.SendCompletionWebhook, SendCompletionWebhookInput{
+		URL:     url,
+		Payload: payload,
+	}).Get(ctx, nil)
+	if err != nil {
+		logger.Error("❌ Unable to deliver completion webhook", "error", err)
+	}
+}
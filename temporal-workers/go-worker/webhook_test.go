@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+)
+
+// useWebhookServer points SendCompletionWebhook at a local TLS server,
+// allowing loopback addresses for the duration of the test
+func useWebhookServer(t *testing.T, secret string, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	prevSecret, prevAllowed, prevClient := webhookSecret, webhookHostAllowed, webhookClient
+	t.Cleanup(func() { webhookSecret, webhookHostAllowed, webhookClient = prevSecret, prevAllowed, prevClient })
+	webhookSecret = secret
+	webhookHostAllowed = func(ip net.IP) bool { return ip.IsLoopback() }
+	webhookClient = newWebhookClient()
+	webhookClient.Transport.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+	return server
+}
+
+func TestSendCompletionWebhook(t *testing.T) {
+	payload := CompletionWebhookPayload{Event: WebhookEventCompleted, WorkflowID: "wf-1", RunID: "run-1", Status: "completed", ProcessedItems: 3}
+
+	tests := []struct {
+		name   string
+		secret string
+		status int
+		url    func(server *httptest.Server) string
+		// wantType is the application error type, "" for success or a
+		// retryable error
+		wantErr      bool
+		wantType     string
+		nonRetryable bool
+	}{
+		{name: "delivered", secret: "s3cret", status: http.StatusNoContent},
+		{name: "server error is retried", secret: "s3cret", status: http.StatusBadGateway, wantErr: true},
+		{name: "rate limit is retried", secret: "s3cret", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "client error isn't retried", secret: "s3cret", status: http.StatusNotFound, wantErr: true, wantType: ErrTypeWebhookRejected, nonRetryable: true},
+		{name: "redirect isn't followed", secret: "s3cret", status: http.StatusFound, wantErr: true, wantType: ErrTypeWebhookRejected, nonRetryable: true},
+		{name: "no secret", status: http.StatusNoContent, wantErr: true, wantType: ErrTypeWebhookNotConfigured, nonRetryable: true},
+		{
+			name:   "plain http",
+			secret: "s3cret",
+			status: http.StatusNoContent,
+			url: func(server *httptest.Server) string {
+				return "http://" + server.Listener.Addr().String()
+			},
+			wantErr: true, wantType: ErrTypeWebhookRejected, nonRetryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			server := useWebhookServer(t, tt.secret, func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "https://169.254.169.254/")
+				}
+				w.WriteHeader(tt.status)
+			})
+			url := server.URL
+			if tt.url != nil {
+				url = tt.url(server)
+			}
+
+			err := newActivities().SendCompletionWebhook(context.Background(), SendCompletionWebhookInput{URL: url, Payload: payload})
+			if !tt.wantErr {
+				require.NoError(t, err)
+				require.NotNil(t, received)
+				assert.Equal(t, "wf-1/run-1/completed", received.Header.Get(WebhookIDHeader))
+				timestamp := received.Header.Get(WebhookTimestampHeader)
+				assert.Equal(t, "sha256="+signWebhook(tt.secret, timestamp, body), received.Header.Get(WebhookSignatureHeader))
+				var got CompletionWebhookPayload
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, payload, got)
+				return
+			}
+			require.Error(t, err)
+			var appErr *temporal.ApplicationError
+			if tt.wantType == "" {
+				assert.False(t, errors.As(err, &appErr), err)
+				return
+			}
+			require.True(t, errors.As(err, &appErr), err)
+			assert.Equal(t, tt.wantType, appErr.Type())
+			assert.Equal(t, tt.nonRetryable, appErr.NonRetryable())
+		})
+	}
+}
+
+func TestSendCompletionWebhookRejectsInternalAddresses(t *testing.T) {
+	prev := webhookSecret
+	t.Cleanup(func() { webhookSecret = prev })
+	webhookSecret = "s3cret"
+
+	for _, url := range []string{
+		"https://127.0.0.1/hook",
+		"https://localhost/hook",
+		"https://10.0.0.5/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https://100.64.0.1/hook",
+	} {
+		t.Run(url, func(t *testing.T) {
+			err := newActivities().SendCompletionWebhook(context.Background(), SendCompletionWebhookInput{URL: url})
+			var appErr *temporal.ApplicationError
+			require.True(t, errors.As(err, &appErr), err)
+			assert.Equal(t, ErrTypeWebhookRejected, appErr.Type())
+			assert.True(t, appErr.NonRetryable())
+		})
+	}
+}
+
+// TestWebhookClientChecksDialedAddress covers names that resolve to a
+// public address when checked and an internal one when dialed
+func TestWebhookClientChecksDialedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := newWebhookClient().Get(server.URL)
+	var blocked *blockedAddressError
+	require.True(t, errors.As(err, &blocked), err)
+	assert.Equal(t, "127.0.0.1", blocked.address)
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "127.0.0.1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "169.254.169.254"},
+		{ip: "100.100.100.200"},
+		{ip: "0.0.0.0"},
+		{ip: "224.0.0.1"},
+		{ip: "::1"},
+		{ip: "fd00::1"},
+		{ip: "fe80::1"},
+		{ip: "::ffff:127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, publicAddress(net.ParseIP(tt.ip)))
+		})
+	}
+}
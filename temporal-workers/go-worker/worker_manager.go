@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+// workerManager owns the Temporal worker so polling can be stopped and
+// restarted without exiting the process. A stopped worker.Worker cannot be
+// started again, so resuming creates a fresh worker with the same options.
+type workerManager struct {
+	mu        sync.Mutex
+	client    client.Client
+	taskQueue string
+	options   worker.Options
+	register  func(worker.Registry)
+	// startWorker creates and starts a worker with the given options;
+	// newStartedWorker unless replaced in tests
+	startWorker func(worker.Options) (worker.Worker, error)
+	worker      worker.Worker
+	paused      bool
+	stopped     bool
+}
+
+// errWorkerStopped is returned by Resume once the worker has been stopped
+// for good
+var errWorkerStopped = errors.New("worker is shutting down")
+
+func newWorkerManager(c client.Client, taskQueue string, options worker.Options, register func(worker.Registry)) *workerManager {
+	m := &workerManager{
+		client:    c,
+		taskQueue: taskQueue,
+		options:   options,
+		register:  register,
+	}
+	m.startWorker = m.newStartedWorker
+	return m
+}
+
+// Start creates the worker and begins polling the task queue
+func (m *workerManager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.startLocked()
+}
+
+func (m *workerManager) startLocked() error {
+	w, err := m.startWorker(m.options)
+	if err != nil {
+		return err
+	}
+	m.worker = w
+	m.paused = false
+	return nil
+}
+
+func (m *workerManager) newStartedWorker(options worker.Options) (worker.Worker, error) {
+	w := worker.New(m.client, m.taskQueue, options)
+	m.register(w)
+	if err := w.Start(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// maxConcurrencySlots bounds limits accepted at runtime
+const maxConcurrencySlots = 10000
+
+// ConcurrencyLimits are the worker's task slot counts. Zero fields are left
+// unchanged by Resize.
+type ConcurrencyLimits struct {
+	Activities    int `json:"activities"`
+	WorkflowTasks int `json:"workflow_tasks"`
+}
+
+// Validate rejects limits the SDK can't run with
+func (l ConcurrencyLimits) Validate() error {
+	if l.Activities < 0 || l.Activities > maxConcurrencySlots {
+		return fmt.Errorf("activities must be between 1 and %d, or 0 to leave it unchanged", maxConcurrencySlots)
+	}
+	// The SDK refuses a single workflow task slot
+	if l.WorkflowTasks < 0 || l.WorkflowTasks == 1 || l.WorkflowTasks > maxConcurrencySlots {
+		return fmt.Errorf("workflow_tasks must be between 2 and %d, or 0 to leave it unchanged", maxConcurrencySlots)
+	}
+	return nil
+}
+
+// Limits returns the slot counts the worker runs with
+func (m *workerManager) Limits() ConcurrencyLimits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return ConcurrencyLimits{
+		Activities:    m.options.MaxConcurrentActivityExecutionSize,
+		WorkflowTasks: m.options.MaxConcurrentWorkflowTaskExecutionSize,
+	}
+}
+
+// Resize applies new slot counts. A worker's limits are fixed when it is
+// created, so a replacement worker with the new limits starts polling first
+// and only then is the old one stopped. Its in-flight activities get up to
+// WorkerStopTimeout to finish; any still running then are cancelled and
+// retried under their retry policy, so long activities can lose an attempt.
+// While paused, the limits apply on resume.
+func (m *workerManager) Resize(limits ConcurrencyLimits) (ConcurrencyLimits, error) {
+	if err := limits.Validate(); err != nil {
+		return ConcurrencyLimits{}, err
+	}
+
+	m.mu.Lock()
+	options := m.options
+	if limits.Activities > 0 {
+		options.MaxConcurrentActivityExecutionSize = limits.Activities
+	}
+	if limits.WorkflowTasks > 0 {
+		options.MaxConcurrentWorkflowTaskExecutionSize = limits.WorkflowTasks
+	}
+	applied := ConcurrencyLimits{
+		Activities:    options.MaxConcurrentActivityExecutionSize,
+		WorkflowTasks: options.MaxConcurrentWorkflowTaskExecutionSize,
+	}
+
+	old := m.worker
+	if old != nil {
+		replacement, err := m.startWorker(options)
+		if err != nil {
+			m.mu.Unlock()
+			return ConcurrencyLimits{}, err
+		}
+		m.worker = replacement
+	}
+	m.options = options
+	m.mu.Unlock()
+
+	// Draining can take a while; don't hold the lock for it
+	if old != nil {
+		old.Stop()
+	}
+	return applied, nil
+}
+
+// DrainTimeout is how long a stopped or replaced worker's in-flight
+// activities may keep running before they are cancelled
+func (m *workerManager) DrainTimeout() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.options.WorkerStopTimeout
+}
+
+// Pause stops polling; in-flight tasks are drained by worker.Stop. The
+// worker is stopped after the lock is released, so Paused, which /healthz
+// calls, doesn't wait for the drain.
+func (m *workerManager) Pause() {
+	m.mu.Lock()
+	if m.paused || m.worker == nil {
+		m.mu.Unlock()
+		return
+	}
+	old := m.worker
+	m.worker = nil
+	m.paused = true
+	m.mu.Unlock()
+
+	old.Stop()
+}
+
+// Resume restarts polling after a Pause. Once Stop has been called it
+// returns errWorkerStopped.
+func (m *workerManager) Resume() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return errWorkerStopped
+	}
+	if !m.paused {
+		return nil
+	}
+	return m.startLocked()
+}
+
+// Paused reports whether polling is currently paused
+func (m *workerManager) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+// Stop shuts the worker down for good; a paused worker stays down rather
+// than waiting for a Resume. Like Pause, it drains the worker without
+// holding the lock.
+func (m *workerManager) Stop() {
+	m.mu.Lock()
+	old := m.worker
+	m.worker = nil
+	m.paused = false
+	m.stopped = true
+	m.mu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/worker"
+)
+
+// blockingWorker is a worker whose Stop takes until release is closed, like
+// one draining long activities
+type blockingWorker struct {
+	worker.Worker
+	stopping chan struct{}
+	release  chan struct{}
+}
+
+func (w *blockingWorker) Stop() {
+	close(w.stopping)
+	<-w.release
+}
+
+func TestWorkerManagerDrainsWithoutHoldingLock(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(m *workerManager)
+	}{
+		{name: "pause", stop: (*workerManager).Pause},
+		{name: "stop", stop: (*workerManager).Stop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &blockingWorker{stopping: make(chan struct{}), release: make(chan struct{})}
+			m := &workerManager{worker: w}
+			done := make(chan struct{})
+			go func() {
+				tt.stop(m)
+				close(done)
+			}()
+			<-w.stopping
+
+			paused := make(chan bool)
+			go func() { paused <- m.Paused() }()
+			select {
+			case <-paused:
+			case <-time.After(time.Second):
+				t.Fatal("Paused blocked while the worker was draining")
+			}
+
+			close(w.release)
+			<-done
+			assert.Nil(t, m.worker)
+		})
+	}
+}
+
+// fakeWorker is a started worker that records being stopped
+type fakeWorker struct {
+	worker.Worker
+	options worker.Options
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (w *fakeWorker) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+}
+
+func (w *fakeWorker) Stopped() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stopped
+}
+
+// fakeWorkerManager returns a workerManager whose workers are fakes, and
+// the workers it has started so far
+func fakeWorkerManager(options worker.Options) (*workerManager, func() []*fakeWorker) {
+	var mu sync.Mutex
+	var started []*fakeWorker
+	m := newWorkerManager(nil, "go-workers", options, nil)
+	m.startWorker = func(options worker.Options) (worker.Worker, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		w := &fakeWorker{options: options}
+		started = append(started, w)
+		return w, nil
+	}
+	return m, func() []*fakeWorker {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeWorker(nil), started...)
+	}
+}
+
+func TestWorkerManagerResume(t *testing.T) {
+	tests := []struct {
+		name string
+		// before runs between Start and Resume
+		before      func(m *workerManager)
+		wantErr     error
+		wantStarted int
+	}{
+		{name: "after pause", before: (*workerManager).Pause, wantStarted: 2},
+		{name: "not paused", before: func(*workerManager) {}, wantStarted: 1},
+		{name: "after stop", before: (*workerManager).Stop, wantErr: errWorkerStopped, wantStarted: 1},
+		{
+			name:        "paused, then stopped",
+			before:      func(m *workerManager) { m.Pause(); m.Stop() },
+			wantErr:     errWorkerStopped,
+			wantStarted: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, started := fakeWorkerManager(worker.Options{})
+			require.NoError(t, m.Start())
+			tt.before(m)
+
+			err := m.Resume()
+			assert.ErrorIs(t, err, tt.wantErr)
+			workers := started()
+			require.Len(t, workers, tt.wantStarted)
+			assert.False(t, m.Paused())
+			if tt.wantErr != nil {
+				assert.Nil(t, m.worker)
+				return
+			}
+			// The latest worker polls; any before it were stopped
+			latest := workers[len(workers)-1]
+			assert.Same(t, latest, m.worker)
+			assert.False(t, latest.Stopped())
+			for _, w := range workers[:len(workers)-1] {
+				assert.True(t, w.Stopped())
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimitsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  ConcurrencyLimits
+		wantErr string
+	}{
+		{name: "unchanged", limits: ConcurrencyLimits{}},
+		{name: "valid", limits: ConcurrencyLimits{Activities: 1, WorkflowTasks: 2}},
+		{name: "negative activities", limits: ConcurrencyLimits{Activities: -1}, wantErr: "activities must be between 1 and 10000, or 0 to leave it unchanged"},
+		{name: "too many activities", limits: ConcurrencyLimits{Activities: maxConcurrencySlots + 1}, wantErr: "activities must be between 1 and 10000, or 0 to leave it unchanged"},
+		{name: "single workflow task slot", limits: ConcurrencyLimits{WorkflowTasks: 1}, wantErr: "workflow_tasks must be between 2 and 10000, or 0 to leave it unchanged"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/workflow"
+)
+
+// otherTagValue replaces tag values outside the allowlist
+const otherTagValue = "other"
+
+// workflowMetricTagValues is the allowlist of per-run metric tags and their
+// permitted values. Input fields are free-form, so anything unexpected is
+// reported as "other" to keep series cardinality bounded.
+var workflowMetricTagValues = map[string]map[string]bool{
+	"process_type": {"parallel": true, "standard": true, "sequential": true, "batch": true},
+	"priority":     {"low": true, "normal": true, "medium": true, "high": true, "critical": true},
+}
+
+// workflowMetricTags returns allowlisted tags built from input fields.
+// Unknown tag names are dropped, unknown values become "other" and empty
+// values become "none".
+func workflowMetricTags(fields map[string]string) map[string]string {
+	tags := make(map[string]string, len(fields))
+	for name, value := range fields {
+		allowed, ok := workflowMetricTagValues[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case value == "":
+			tags[name] = "none"
+		case allowed[value]:
+			tags[name] = value
+		default:
+			tags[name] = otherTagValue
+		}
+	}
+	return tags
+}
+
+// workflowMetrics records started/completed counters and a latency timer
+// for one workflow run. The SDK's workflow metrics handler skips recording
+// during replay, so these are emitted once per run.
+type workflowMetrics struct {
+	ctx     workflow.Context
+	handler client.MetricsHandler
+	prefix  string
+	start   time.Time
+}
+
+func startWorkflowMetrics(ctx workflow.Context, prefix string, fields map[string]string) *workflowMetrics {
+	m := &workflowMetrics{
+		ctx:     ctx,
+		handler: workflow.GetMetricsHandler(ctx).WithTags(workflowMetricTags(fields)),
+		prefix:  prefix,
+		start:   workflow.Now(ctx),
+	}
+	m.handler.Counter(prefix + "_started").Inc(1)
+	return m
+}
+
+// finish records the outcome; status is tagged as "completed" or "failed"
+func (m *workflowMetrics) finish(status string) {
+	if status != "completed" {
+		status = "failed"
+	}
+	handler := m.handler.WithTags(map[string]string{"status": status})
+	handler.Counter(m.prefix + "_finished").Inc(1)
+	handler.Timer(m.prefix + "_latency").Record(nonNegative(workflow.Now(m.ctx).Sub(m.start)))
+}
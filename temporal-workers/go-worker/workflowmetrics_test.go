@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestWorkflowMetricTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   map[string]string
+	}{
+		{name: "allowed", fields: map[string]string{"process_type": "batch", "priority": "high"}, want: map[string]string{"process_type": "batch", "priority": "high"}},
+		{name: "unknown value", fields: map[string]string{"process_type": "turbo", "priority": "HIGH"}, want: map[string]string{"process_type": "other", "priority": "other"}},
+		{name: "empty value", fields: map[string]string{"process_type": ""}, want: map[string]string{"process_type": "none"}},
+		{name: "unknown tag dropped", fields: map[string]string{"tenant": "acme", "priority": "low"}, want: map[string]string{"priority": "low"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, workflowMetricTags(tt.fields))
+		})
+	}
+}
+
+func TestWorkflowMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		wantStatus string
+	}{
+		{name: "completed", status: "completed", wantStatus: "completed"},
+		{name: "failed", status: "failed", wantStatus: "failed"},
+		{name: "other statuses count as failed", status: "cancelled", wantStatus: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := newCounterHandler()
+			var suite testsuite.WorkflowTestSuite
+			suite.SetMetricsHandler(metrics)
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflowWithOptions(func(ctx workflow.Context) error {
+				m := startWorkflowMetrics(ctx, "test_workflow", map[string]string{"process_type": "turbo", "priority": "high", "tenant": "acme"})
+				if err := workflow.Sleep(ctx, time.Minute); err != nil {
+					return err
+				}
+				m.finish(tt.status)
+				if tt.status != "completed" {
+					return errors.New(tt.status)
+				}
+				return nil
+			}, workflow.RegisterOptions{Name: "metricsWorkflow"})
+
+			env.ExecuteWorkflow("metricsWorkflow")
+			require.True(t, env.IsWorkflowCompleted())
+
+			assert.Equal(t, int64(1), metrics.counts["test_workflow_started{priority=high,process_type=other}"])
+			assert.Equal(t, int64(1), metrics.counts["test_workflow_finished{priority=high,process_type=other,status="+tt.wantStatus+"}"])
+			for key := range metrics.counts {
+				assert.NotContains(t, key, "acme")
+			}
+		})
+	}
+}
@@ -1,44 +1,150 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
 // ComplexProcessingInput represents input for complex processing workflow
 type ComplexProcessingInput struct {
-	DatasetID    string                 `json:"dataset_id"`
-	ProcessType  string                 `json:"process_type"`
-	Parameters   map[string]interface{} `json:"parameters"`
-	Priority     string                 `json:"priority"`
+	// Version is the input shape; see CurrentComplexProcessingInputVersion
+	Version     int         `json:"version,omitempty"`
+	DatasetID   string      `json:"dataset_id"`
+	ProcessType ProcessType `json:"process_type"`
+	Parameters  Parameters  `json:"parameters"`
+	Priority    Priority    `json:"priority"`
+	// HealthThreshold is the health score below which the host counts as
+	// degraded and processing takes the "standard" path
+	HealthThreshold float64 `json:"health_threshold,omitempty"`
+	// Schema, when set, is checked against the registered baseline before
+	// any processing; SchemaCompatibility defaults to backward
+	Schema              *DatasetSchema `json:"schema,omitempty"`
+	SchemaCompatibility string         `json:"schema_compatibility,omitempty"`
+	// Sinks, when set, receive the processing results after caching
+	Sinks []SinkConfig `json:"sinks,omitempty"`
+	// Fields, when set, limits the returned result to these JSON fields
+	Fields []string `json:"fields,omitempty"`
+	// Quality, when set, scores these fields of the source_key dataset
+	Quality []QualityField `json:"quality,omitempty"`
+	// WarmCache, when set, primes the result cache with lookup records
+	// before processing
+	WarmCache *WarmCacheInput `json:"warm_cache,omitempty"`
+	// Tenant, when set, is charged DatasetSize bytes (default: the size of
+	// the source_key object) against its quota before anything else runs
+	Tenant      string `json:"tenant,omitempty"`
+	DatasetSize int64  `json:"dataset_size,omitempty"`
+	// WebhookURL, when set, is POSTed a summary once the run completes or
+	// fails; see notifyCompletion
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// ActivityTimeout, when set, replaces the default timeout (10 minutes
+	// per attempt) of the processing activities
+	ActivityTimeout *ActivityTimeoutConfig `json:"activity_timeout,omitempty"`
 }
 
 // ComplexProcessingResult represents the result of complex processing
 type ComplexProcessingResult struct {
-	DatasetID       string                 `json:"dataset_id"`
-	Status          string                 `json:"status"`
-	ProcessedItems  int                    `json:"processed_items"`
-	ProcessingTime  string                 `json:"processing_time"`
-	OptimizationGain float64               `json:"optimization_gain"`
-	Results         map[string]interface{} `json:"results"`
-	Message         string                 `json:"message"`
+	DatasetID        string                 `json:"dataset_id"`
+	Status           ProcessingStatus       `json:"status"`
+	ProcessedItems   int                    `json:"processed_items"`
+	ProcessingTime   string                 `json:"processing_time"`
+	OptimizationGain float64                `json:"optimization_gain"`
+	Results          map[string]interface{} `json:"results"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	Routing          RoutingDecision        `json:"routing"`
+	Export           *ObjectRef             `json:"export,omitempty"`
+	Source           *ObjectRef             `json:"source,omitempty"`
+	Quality          *DataQualityReport     `json:"quality,omitempty"`
+	Steps            []StepTiming           `json:"steps"`
+	Deliveries       []SinkStatus           `json:"deliveries,omitempty"`
+	Message          string                 `json:"message"`
+
+	// projection is set from ComplexProcessingInput.Fields; see MarshalJSON
+	projection []string
 }
 
 // ComplexProcessingWorkflow handles high-performance data processing
 func ComplexProcessingWorkflow(ctx workflow.Context, input ComplexProcessingInput) (ComplexProcessingResult, error) {
+	result, err := complexProcessing(ctx, input)
+	notifyCompletion(ctx, input.WebhookURL, result, err)
+	return result, err
+}
+
+// complexProcessing runs ComplexProcessingWorkflow up to its result
+func complexProcessing(ctx workflow.Context, input ComplexProcessingInput) (ComplexProcessingResult, error) {
+	input.Parameters = mapOrEmpty(input.Parameters)
+
 	logger := workflow.GetLogger(ctx)
+	input, err := migrateComplexProcessingInput(input)
+	if err != nil {
+		logger.Error("❌ Unsupported workflow input", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
+	if err := validateComplexProcessingEnums(input); err != nil {
+		logger.Error("❌ Invalid workflow input", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
+	if input.Parameters, err = withDefaultParameters(ctx, input.Parameters); err != nil {
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
 	logger.Info("🚀 Starting complex processing workflow", "dataset_id", input.DatasetID, "process_type", input.ProcessType)
+	logWorkflowInput(ctx, input)
 
-	// Configure activity options
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 10 * time.Minute,
-		RetryPolicy: &workflow.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    30 * time.Second,
-			MaximumAttempts:    3,
+	// Operators can merge new parameters into the run between steps; only
+	// steps scheduled after the update see them.
+	err = workflow.SetUpdateHandlerWithOptions(ctx, UpdateParametersName,
+		func(ctx workflow.Context, update Parameters) (Parameters, error) {
+			input.Parameters = mergeParameters(input.Parameters, update)
+			logger.Info("🔧 Processing parameters updated", "parameters", input.Parameters)
+			return input.Parameters, nil
 		},
+		workflow.UpdateHandlerOptions{Validator: validateParameterUpdate},
+	)
+	if err != nil {
+		return ComplexProcessingResult{}, err
+	}
+
+	// Fail fast on a bad predicate rather than after processing
+	if _, err := parseCondition(input.Parameters, optimizeWhenParameter); err != nil {
+		logger.Error("❌ Invalid optimize predicate", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
+
+	// Configure activity options
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    30 * time.Second,
+		MaximumAttempts:    3,
+	}
+	timeouts := ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 10 * time.Minute}
+	if input.ActivityTimeout != nil {
+		timeouts = *input.ActivityTimeout
+	}
+	activityOptions, err := newActivityOptions(timeouts, retryPolicy)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
+	normalizeOptions, err := newHeartbeatActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 30 * time.Minute},
+		retryPolicy, normalizeEncodingHeartbeatInterval)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
+	processOptions, err := newHeartbeatActivityOptions(timeouts, retryPolicy, processDatasetHeartbeatInterval)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
+	}
+	indexOptions, err := newHeartbeatActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 30 * time.Minute},
+		retryPolicy, indexResultsHeartbeatInterval)
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return ComplexProcessingResult{DatasetID: input.DatasetID, Status: "failed", Message: err.Error()}, err
 	}
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
@@ -46,65 +152,357 @@ func ComplexProcessingWorkflow(ctx workflow.Context, input ComplexProcessingInpu
 	result.DatasetID = input.DatasetID
 	result.Status = "processing"
 
-	// Step 1: Process large dataset
+	steps, err := newStepRecorder(ctx, &result.Steps, "check_quota", "fetch_metadata", "health_check", "schema_check", "warm_cache",
+		"normalize_encoding", "process_dataset", "reconcile_counts", "data_quality", "optimize_performance", "cache_results", "deliver_results",
+		"audit_log", "export_parquet", "index_results", "encrypt_fields")
+	if err != nil {
+		return result, err
+	}
+	metrics := startWorkflowMetrics(ctx, "go_worker_complex_processing", map[string]string{
+		"process_type": string(input.ProcessType),
+		"priority":     string(input.Priority),
+	})
+	dashboardID, _ := input.Parameters.String(dashboardWorkflowParameter)
+	progress := newProgressReporter(ctx, dashboardID, input.DatasetID, plannedComplexProcessingSteps(input))
+	steps.onStep = progress.stepFinished
+	cancellation := auditCancellation(ctx, input.DatasetID, steps.current)
+	defer func() {
+		cancellation.record()
+		metrics.finish(string(result.Status))
+		steps.emit(string(result.Status))
+		progress.finish(string(result.Status))
+	}()
+
+	// Gate: charge the tenant's quota before doing any work
+	if input.Tenant != "" {
+		logger.Info("🎫 Checking tenant quota...", "tenant", input.Tenant)
+		sourceKey, _ := input.Parameters.String("source_key")
+		var quota CheckQuotaResult
+		endQuota := steps.start("check_quota")
+		err := workflow.ExecuteActivity(ctx, activities.CheckQuota, CheckQuotaInput{
+			Tenant:    input.Tenant,
+			DatasetID: input.DatasetID,
+			Size:      input.DatasetSize,
+			SourceKey: sourceKey,
+		}).Get(ctx, &quota)
+		endQuota(quota, err)
+		if err != nil {
+			logger.Error("❌ Quota check failed", "tenant", input.Tenant, "error", err)
+			result.Status = "failed"
+			result.Message = "Quota check failed: " + err.Error()
+			return result, err
+		}
+	}
+
+	// Independent steps are gathered with one selector; see gather
+	order := complexProcessingOrder(ctx)
+	gathered := newGatherer(ctx)
+	var healthResult SystemHealthCheckResult
+	var healthErr error
+	var metadataResult FetchDatasetMetadataResult
+	checkHealth := func(checkType string) workflow.Future {
+		endHealth := steps.start("health_check")
+		health := workflow.ExecuteActivity(ctx, activities.SystemHealthCheck, SystemHealthCheckInput{
+			CheckType: checkType,
+			DatasetID: input.DatasetID,
+		})
+		gathered.add(health, func(f workflow.Future) {
+			healthErr = f.Get(ctx, &healthResult)
+			endHealth(healthResult, healthErr)
+			if healthErr != nil {
+				logger.Error("❌ System health check failed", "error", healthErr)
+			}
+		})
+		return health
+	}
+	fetchMetadata := func() {
+		endMetadata := steps.start("fetch_metadata")
+		gathered.add(workflow.ExecuteActivity(ctx, activities.FetchDatasetMetadata, FetchDatasetMetadataInput{
+			DatasetID: input.DatasetID,
+		}), func(f workflow.Future) {
+			err := f.Get(ctx, &metadataResult)
+			endMetadata(metadataResult, err)
+			if err != nil {
+				logger.Error("❌ Failed to fetch dataset metadata", "error", err)
+			}
+		})
+	}
+	if order == orderHealthFirst {
+		// Step 1: Check system health. The metadata fetch doesn't depend
+		// on anything, so it is dispatched first and runs alongside health
+		// checking and processing.
+		logger.Info("🔍 Checking system health and fetching metadata...")
+		fetchMetadata()
+		gathered.wait(checkHealth("pre_processing"))
+
+		// Step 2: Route to the lightweight path when the host is degraded
+		route := func() RoutingDecision {
+			return routeProcessing(input.ProcessType, healthResult, healthErr, input.HealthThreshold)
+		}
+		// The decision is recorded so changes to the routing rules don't
+		// alter it for running workflows; versioned so older histories
+		// replay
+		if workflow.GetVersion(ctx, "stable-routing", workflow.DefaultVersion, 1) == 1 {
+			result.Routing, err = stableDecision(ctx, "routing", []interface{}{
+				input.ProcessType, healthResult.HealthScore, healthErr != nil, input.HealthThreshold,
+			}, route)
+			if err != nil {
+				result.Status = "failed"
+				return result, err
+			}
+		} else {
+			result.Routing = route()
+		}
+	} else {
+		// Runs started before health routing process what was requested
+		result.Routing = requestedRouting(input.ProcessType)
+	}
+	logger.Info("🧭 Processing path selected", "process_type", result.Routing.ProcessType, "reason", result.Routing.Reason)
+
+	if input.Schema != nil {
+		logger.Info("📐 Checking schema compatibility...")
+		var schemaResult CheckSchemaCompatibilityResult
+		endSchema := steps.start("schema_check")
+		err := workflow.ExecuteActivity(ctx, activities.CheckSchemaCompatibility, CheckSchemaCompatibilityInput{
+			DatasetID: input.DatasetID,
+			Mode:      input.SchemaCompatibility,
+			Schema:    *input.Schema,
+		}).Get(ctx, &schemaResult)
+		endSchema(schemaResult, err)
+		if err != nil {
+			logger.Error("❌ Schema compatibility check failed", "error", err)
+			result.Status = "failed"
+			result.Message = "Schema compatibility check failed: " + err.Error()
+			return result, err
+		}
+	}
+
+	// Optional: prime the cache for lookups made while processing. A cold
+	// cache only slows processing down, so failures don't fail the run.
+	if input.WarmCache != nil {
+		logger.Info("🔥 Warming cache...", "source_key", input.WarmCache.SourceKey)
+		warm := *input.WarmCache
+		if warm.DatasetID == "" {
+			warm.DatasetID = input.DatasetID
+		}
+		var warmed WarmCacheResult
+		endWarm := steps.start("warm_cache")
+		err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, normalizeOptions), activities.WarmCache, warm).Get(ctx, &warmed)
+		endWarm(warmed, err)
+		if err != nil {
+			logger.Warn("⚠️ Cache warm-up failed, processing with a cold cache", "error", err)
+		}
+	}
+
+	// Step 3: Process large dataset
 	logger.Info("⚙️ Processing large dataset...")
 	var processResult ProcessLargeDatasetResult
-	err := workflow.ExecuteActivity(ctx, ProcessLargeDataset, ProcessLargeDatasetInput{
+	exportParquet, _ := input.Parameters.Bool("export_parquet")
+	searchIndex, _ := input.Parameters.String("search_index")
+	processParameters := input.Parameters
+	if exportParquet || searchIndex != "" {
+		processParameters = mergeParameters(input.Parameters, Parameters{"emit_records": true})
+	}
+	var source *ObjectRef
+	if sourceKey, ok := input.Parameters.String("source_key"); ok && sourceKey != "" {
+		normalizeCtx := workflow.WithActivityOptions(ctx, normalizeOptions)
+		encoding, _ := input.Parameters.String("source_encoding")
+		var normalized NormalizeEncodingResult
+		endNormalize := steps.start("normalize_encoding")
+		err := workflow.ExecuteActivity(normalizeCtx, activities.NormalizeEncoding, NormalizeEncodingInput{
+			DatasetID: input.DatasetID,
+			SourceKey: sourceKey,
+			Encoding:  encoding,
+		}).Get(ctx, &normalized)
+		endNormalize(normalized, err)
+		if err != nil {
+			logger.Error("❌ Encoding normalization failed", "error", err)
+			result.Status = "failed"
+			return result, err
+		}
+		source = &normalized.Object
+		result.Source = source
+	}
+	endProcess := steps.start("process_dataset")
+	exhaustion := exhaustionPolicyFromParameters(input.Parameters)
+	processCtx := withExhaustionPolicy(workflow.WithActivityOptions(ctx, processOptions), exhaustion)
+	var processErr error
+	gathered.add(workflow.ExecuteActivity(processCtx, activities.ProcessLargeDataset, ProcessLargeDatasetInput{
 		DatasetID:   input.DatasetID,
-		ProcessType: input.ProcessType,
-		Parameters:  input.Parameters,
-	}).Get(ctx, &processResult)
-	if err != nil {
-		logger.Error("❌ Failed to process dataset", "error", err)
+		ProcessType: result.Routing.ProcessType,
+		Parameters:  processParameters,
+		Source:      source,
+	}), func(f workflow.Future) {
+		processErr = f.Get(ctx, &processResult)
+		endProcess(processResult, processErr)
+	})
+	if order == orderScatterGather {
+		// Runs started before health routing check health and fetch
+		// metadata while processing
+		checkHealth("processing")
+		fetchMetadata()
+	}
+	gathered.all()
+	processErr = handleExhaustion(processCtx, exhaustion, processErr)
+
+	if processErr != nil {
+		logger.Error("❌ Failed to process dataset", "error", processErr)
 		result.Status = "failed"
-		result.Message = "Dataset processing failed: " + err.Error()
-		return result, err
+		result.Message = "Dataset processing failed: " + processErr.Error()
+		return result, processErr
 	}
 
 	result.ProcessedItems = processResult.ItemsProcessed
 	result.ProcessingTime = processResult.ProcessingTime
+	result.Metadata = metadataResult.Metadata
 
-	// Step 2: Optimize performance
-	logger.Info("🚀 Optimizing performance...")
-	var optimizeResult OptimizePerformanceResult
-	err = workflow.ExecuteActivity(ctx, OptimizePerformance, OptimizePerformanceInput{
-		DatasetID: input.DatasetID,
-		Algorithm: "advanced_optimization",
-		Metrics:   processResult.Metrics,
-	}).Get(ctx, &optimizeResult)
+	// Optional: check nothing was silently dropped
+	if reconcileSource, _ := input.Parameters.String("reconcile_source"); reconcileSource != "" {
+		tolerance, _ := input.Parameters.Float64("reconcile_tolerance")
+		var reconciled ReconcileCountsResult
+		endReconcile := steps.start("reconcile_counts")
+		err = workflow.ExecuteActivity(ctx, activities.ReconcileCounts, ReconcileCountsInput{
+			DatasetID: input.DatasetID,
+			Source:    reconcileSource,
+			Processed: int64(processResult.ItemsProcessed),
+			Tolerance: tolerance,
+		}).Get(ctx, &reconciled)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.HasDetails() {
+			_ = appErr.Details(&reconciled)
+		}
+		endReconcile(reconciled, err)
+		if err != nil {
+			logger.Error("❌ Count reconciliation failed", "error", err, "discrepancy", reconciled.Discrepancy)
+			result.Status = "failed"
+			result.Message = "Count reconciliation failed: " + err.Error()
+			return result, err
+		}
+	}
+
+	// Optional: score the source dataset. A failed score doesn't fail the
+	// run.
+	if len(input.Quality) > 0 {
+		if source == nil {
+			logger.Warn("⚠️ Data quality needs a source_key dataset, skipping")
+		} else {
+			logger.Info("🧮 Computing data quality...", "fields", len(input.Quality))
+			var quality DataQualityReport
+			endQuality := steps.start("data_quality")
+			err = workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, normalizeOptions), activities.ComputeDataQuality, ComputeDataQualityInput{
+				DatasetID: input.DatasetID,
+				SourceKey: source.Key,
+				Fields:    input.Quality,
+			}).Get(ctx, &quality)
+			endQuality(quality, err)
+			if err != nil {
+				logger.Error("❌ Failed to compute data quality", "error", err)
+			} else {
+				result.Quality = &quality
+			}
+		}
+	}
+
+	// Step 4: Optimize performance (depends on the processing metrics),
+	// unless the optimize_when predicate says otherwise
+	optimizeWhen, err := parseCondition(input.Parameters, optimizeWhenParameter)
 	if err != nil {
-		logger.Error("❌ Failed to optimize performance", "error", err)
-		// Continue without optimization
-		result.OptimizationGain = 0.0
+		result.Status = "failed"
+		result.Message = err.Error()
+		return result, err
+	}
+	runOptimize, err := evalCondition(optimizeWhen, processResult.Metrics.vars())
+	if err != nil {
+		logger.Error("❌ Unable to evaluate optimize predicate", "error", err)
+		result.Status = "failed"
+		result.Message = err.Error()
+		return result, err
+	}
+	if runOptimize {
+		logger.Info("🚀 Optimizing performance...")
+		var optimizeResult OptimizePerformanceResult
+		algorithm, _ := input.Parameters.String("algorithm")
+		if algorithm == "" {
+			algorithm = "advanced_optimization"
+		}
+		endOptimize := steps.start("optimize_performance")
+		err = workflow.ExecuteActivity(ctx, activities.OptimizePerformance, OptimizePerformanceInput{
+			DatasetID: input.DatasetID,
+			Algorithm: algorithm,
+			Metrics:   processResult.Metrics,
+		}).Get(ctx, &optimizeResult)
+		endOptimize(optimizeResult, err)
+		if err != nil {
+			logger.Error("❌ Failed to optimize performance", "error", err)
+			// Continue without optimization
+			result.OptimizationGain = 0.0
+		} else {
+			result.OptimizationGain = optimizeResult.PerformanceGain
+		}
 	} else {
-		result.OptimizationGain = optimizeResult.PerformanceGain
+		logger.Info("⏭️ Skipping optimization", "predicate", optimizeWhen.String())
 	}
 
-	// Step 3: System health check
-	logger.Info("🔍 Performing system health check...")
-	var healthResult SystemHealthCheckResult
-	err = workflow.ExecuteActivity(ctx, SystemHealthCheck, SystemHealthCheckInput{
-		CheckType: "post_processing",
-		DatasetID: input.DatasetID,
-	}).Get(ctx, &healthResult)
-	if err != nil {
-		logger.Error("❌ System health check failed", "error", err)
+	if order == orderSequential {
+		logger.Info("🔍 Performing system health check...")
+		endHealth := steps.start("health_check")
+		healthErr = workflow.ExecuteActivity(ctx, activities.SystemHealthCheck, SystemHealthCheckInput{
+			CheckType: "post_processing",
+			DatasetID: input.DatasetID,
+		}).Get(ctx, &healthResult)
+		endHealth(healthResult, healthErr)
+		if healthErr != nil {
+			logger.Error("❌ System health check failed", "error", healthErr)
+		}
 	}
 
-	// Step 4: Cache results
+	// Step 5: Cache results
 	logger.Info("💾 Caching results...")
-	err = workflow.ExecuteActivity(ctx, CacheOperation, CacheOperationInput{
+	endCache := steps.start("cache_results")
+	err = workflow.ExecuteActivity(ctx, activities.CacheOperation, CacheOperationInput{
 		Operation: "store",
 		Key:       "dataset_" + input.DatasetID,
 		Data:      processResult.Results,
 		TTL:       3600, // 1 hour
 	}).Get(ctx, nil)
+	endCache(nil, err)
 	if err != nil {
 		logger.Error("❌ Failed to cache results", "error", err)
 	}
 
-	// Step 5: Audit log
-	err = workflow.ExecuteActivity(ctx, AuditLog, AuditLogInput{
+	// Deliver results to configured sinks; only required sinks can fail
+	// the run
+	if len(input.Sinks) > 0 {
+		// Versioned so executions started before backpressure existed
+		// replay unchanged
+		if workflow.GetVersion(ctx, "downstream-pressure", workflow.DefaultVersion, 1) == 1 {
+			awaitDownstreamCapacity(ctx, input.Sinks, input.Parameters)
+		}
+		logger.Info("📬 Delivering results...", "sinks", len(input.Sinks))
+		var deliverResult DeliverResult
+		endDeliver := steps.start("deliver_results")
+		err = workflow.ExecuteActivity(ctx, activities.Deliver, DeliverInput{
+			DatasetID: input.DatasetID,
+			Result:    processResult.Results,
+			Sinks:     input.Sinks,
+		}).Get(ctx, &deliverResult)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.HasDetails() {
+			_ = appErr.Details(&deliverResult)
+		}
+		endDeliver(deliverResult, err)
+		result.Deliveries = deliverResult.Sinks
+		if err != nil {
+			logger.Error("❌ Failed to deliver results", "error", err)
+			result.Status = "failed"
+			result.Message = "Result delivery failed: " + err.Error()
+			return result, err
+		}
+	}
+
+	// Step 6: Audit log
+	endAudit := steps.start("audit_log")
+	err = workflow.ExecuteActivity(ctx, activities.AuditLog, AuditLogInput{
 		Action:    "complex_processing_completed",
 		DatasetID: input.DatasetID,
 		Details: map[string]interface{}{
@@ -114,47 +512,347 @@ func ComplexProcessingWorkflow(ctx workflow.Context, input ComplexProcessingInpu
 			"health_status":     healthResult.Status,
 		},
 	}).Get(ctx, nil)
+	endAudit(nil, err)
 	if err != nil {
 		logger.Error("❌ Failed to audit log", "error", err)
 	}
 
-	result.Status = "completed"
+	// Optional final step: export record-level output to Parquet
+	if exportParquet {
+		logger.Info("📦 Exporting records to Parquet...")
+		var exportResult ExportParquetResult
+		endExport := steps.start("export_parquet")
+		err = workflow.ExecuteActivity(ctx, activities.ExportParquet, ExportParquetInput{
+			DatasetID: input.DatasetID,
+			Records:   processResult.Records,
+		}).Get(ctx, &exportResult)
+		endExport(exportResult, err)
+		if err != nil {
+			logger.Error("❌ Failed to export Parquet", "error", err)
+		} else {
+			result.Export = &exportResult.Object
+		}
+	}
+
+	// Optional: index record-level output for search
+	if searchIndex != "" {
+		logger.Info("🔎 Indexing records...", "index", searchIndex)
+		indexCtx := workflow.WithActivityOptions(ctx, indexOptions)
+		var indexResult IndexResultsResult
+		endIndex := steps.start("index_results")
+		err = workflow.ExecuteActivity(indexCtx, activities.IndexResults, IndexResultsInput{
+			DatasetID: input.DatasetID,
+			Index:     searchIndex,
+			Records:   processResult.Records,
+		}).Get(ctx, &indexResult)
+		endIndex(indexResult, err)
+		if err != nil {
+			logger.Error("❌ Failed to index records", "error", err)
+		} else if len(indexResult.Failed) > 0 {
+			logger.Warn("⚠️ Some records were not indexed", "index", searchIndex, "failed", len(indexResult.Failed))
+		}
+	}
+
 	result.Results = processResult.Results
+
+	// PII fields are encrypted before the result is persisted or returned
+	if piiFields, _ := input.Parameters.Strings("pii_fields"); len(piiFields) > 0 {
+		logger.Info("🔐 Encrypting PII fields...", "fields", piiFields)
+		var encrypted RedactAndEncryptResult
+		endEncrypt := steps.start("encrypt_fields")
+		err = workflow.ExecuteActivity(ctx, activities.RedactAndEncrypt, RedactAndEncryptInput{
+			Data:  map[string]interface{}{"results": result.Results, "metadata": result.Metadata},
+			Paths: piiFields,
+		}).Get(ctx, &encrypted)
+		endEncrypt(encrypted.Encrypted, err)
+		if err != nil {
+			logger.Error("❌ Failed to encrypt PII fields", "error", err)
+			result.Results = nil
+			result.Status = "failed"
+			result.Message = "PII encryption failed: " + err.Error()
+			return result, err
+		}
+		result.Results, _ = encrypted.Data["results"].(map[string]interface{})
+		result.Metadata, _ = encrypted.Data["metadata"].(map[string]interface{})
+	}
+
+	result.Status = "completed"
 	result.Message = "Complex processing completed successfully"
 
+	if persist, _ := input.Parameters.Bool("persist_result"); persist {
+		persistWorkflowResult(ctx, result)
+	}
+
 	logger.Info("✅ Complex processing workflow completed", "result", result)
+	projectResult(ctx, &result, input.Fields)
 	return result, nil
 }
 
+// plannedComplexProcessingSteps estimates how many steps a run will take,
+// for progress reporting
+func plannedComplexProcessingSteps(input ComplexProcessingInput) int {
+	// fetch_metadata, health_check, process_dataset, optimize_performance,
+	// cache_results and audit_log
+	planned := 6
+	if input.Tenant != "" {
+		planned++
+	}
+	if input.Schema != nil {
+		planned++
+	}
+	if input.WarmCache != nil {
+		planned++
+	}
+	if sourceKey, _ := input.Parameters.String("source_key"); sourceKey != "" {
+		planned++
+		if len(input.Quality) > 0 {
+			planned++
+		}
+	}
+	if reconcileSource, _ := input.Parameters.String("reconcile_source"); reconcileSource != "" {
+		planned++
+	}
+	if len(input.Sinks) > 0 {
+		planned++
+	}
+	if exportParquet, _ := input.Parameters.Bool("export_parquet"); exportParquet {
+		planned++
+	}
+	if searchIndex, _ := input.Parameters.String("search_index"); searchIndex != "" {
+		planned++
+	}
+	if piiFields, _ := input.Parameters.Strings("pii_fields"); len(piiFields) > 0 {
+		planned++
+	}
+	return planned
+}
+
+const defaultHealthThreshold = 0.8
+
+// processingOrder is how ComplexProcessingWorkflow orders its first steps.
+// A run keeps the order it started with; see complexProcessingOrder.
+type processingOrder int
+
+const (
+	// orderSequential processes what was requested, optimizes and then
+	// checks health, one step at a time
+	orderSequential processingOrder = iota
+	// orderScatterGather processes what was requested while checking
+	// health and fetching metadata
+	orderScatterGather
+	// orderHealthFirst fetches metadata while checking health, and then
+	// while processing on the path the health check routed to
+	orderHealthFirst
+)
+
+// complexProcessingOrder returns the order of the run's first steps.
+// Versioned so runs started before the scatter-gather, or before health
+// routing, replay unchanged.
+func complexProcessingOrder(ctx workflow.Context) processingOrder {
+	if workflow.GetVersion(ctx, "scatter-gather", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return orderSequential
+	}
+	if workflow.GetVersion(ctx, "health-routing", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return orderScatterGather
+	}
+	return orderHealthFirst
+}
+
+// requestedRouting is the routing of runs that don't route on health: they
+// process what was requested
+func requestedRouting(requested ProcessType) RoutingDecision {
+	return RoutingDecision{RequestedProcessType: requested, ProcessType: requested, Reason: "requested"}
+}
+
+// gatherer waits on activities started together through one selector, so
+// each result is handled as soon as it is ready rather than in the order the
+// activities were started
+type gatherer struct {
+	ctx      workflow.Context
+	selector workflow.Selector
+	pending  map[workflow.Future]bool
+}
+
+func newGatherer(ctx workflow.Context) *gatherer {
+	return &gatherer{ctx: ctx, selector: workflow.NewSelector(ctx), pending: map[workflow.Future]bool{}}
+}
+
+// add waits on f; done handles its result
+func (g *gatherer) add(f workflow.Future, done func(workflow.Future)) {
+	g.pending[f] = true
+	g.selector.AddFuture(f, func(f workflow.Future) {
+		delete(g.pending, f)
+		done(f)
+	})
+}
+
+// wait handles results as they are ready until f's has been handled
+func (g *gatherer) wait(f workflow.Future) {
+	for g.pending[f] {
+		g.selector.Select(g.ctx)
+	}
+}
+
+// all handles the results of everything added
+func (g *gatherer) all() {
+	for len(g.pending) > 0 {
+		g.selector.Select(g.ctx)
+	}
+}
+
+// RoutingDecision records which processing path a run took and why
+type RoutingDecision struct {
+	RequestedProcessType ProcessType `json:"requested_process_type"`
+	ProcessType          ProcessType `json:"process_type"`
+	HealthScore          float64     `json:"health_score"`
+	Threshold            float64     `json:"threshold"`
+	Reason               string      `json:"reason"`
+}
+
+// routeProcessing picks "parallel" on a healthy host and the lighter
+// "standard" path when the health score is below the threshold or the health
+// check failed. It only looks at recorded activity results, so it is
+// deterministic on replay.
+func routeProcessing(requested ProcessType, health SystemHealthCheckResult, healthErr error, threshold float64) RoutingDecision {
+	if threshold <= 0 {
+		threshold = defaultHealthThreshold
+	}
+	decision := RoutingDecision{
+		RequestedProcessType: requested,
+		HealthScore:          health.HealthScore,
+		Threshold:            threshold,
+	}
+	switch {
+	case healthErr != nil:
+		decision.ProcessType = ProcessTypeStandard
+		decision.Reason = "health_check_failed"
+	case health.HealthScore < threshold:
+		decision.ProcessType = ProcessTypeStandard
+		decision.Reason = "degraded"
+	default:
+		decision.ProcessType = ProcessTypeParallel
+		decision.Reason = "healthy"
+	}
+	return decision
+}
+
+// UpdateParametersName is the update that merges parameters into a running
+// ComplexProcessingWorkflow
+const UpdateParametersName = "updateParameters"
+
+// validateParameterUpdate rejects updates before they are written to history
+func validateParameterUpdate(ctx workflow.Context, update Parameters) error {
+	if len(update) == 0 {
+		return errors.New("parameter update must not be empty")
+	}
+	if algorithm, ok := update["algorithm"]; ok {
+		if s, isString := algorithm.(string); !isString || s == "" {
+			return fmt.Errorf("algorithm must be a non-empty string, got %v", algorithm)
+		}
+	}
+	if _, err := parseCondition(update, optimizeWhenParameter); err != nil {
+		return err
+	}
+	return nil
+}
+
+// mergeParameters returns a copy of current with update applied on top
+func mergeParameters(current, update Parameters) Parameters {
+	merged := make(Parameters, len(current)+len(update))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range update {
+		merged[k] = v
+	}
+	return merged
+}
+
 // SystemOperationInput represents input for system operations
 type SystemOperationInput struct {
-	Operation   string                 `json:"operation"`
-	Target      string                 `json:"target"`
-	Parameters  map[string]interface{} `json:"parameters"`
-	Timeout     int                    `json:"timeout"`
+	Operation  string     `json:"operation"`
+	Target     string     `json:"target"`
+	Parameters Parameters `json:"parameters"`
+	Timeout    int        `json:"timeout"`
 }
 
+// defaultSystemOperationTimeoutSeconds applies when callers omit Timeout
+const defaultSystemOperationTimeoutSeconds = 60
+
 // SystemOperationWorkflow handles system-level operations
 func SystemOperationWorkflow(ctx workflow.Context, input SystemOperationInput) (map[string]interface{}, error) {
+	input.Parameters = mapOrEmpty(input.Parameters)
+	if input.Timeout <= 0 {
+		input.Timeout = defaultSystemOperationTimeoutSeconds
+	}
+
 	logger := workflow.GetLogger(ctx)
 	logger.Info("🔧 Starting system operation workflow", "operation", input.Operation, "target", input.Target)
+	logWorkflowInput(ctx, input)
 
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Duration(input.Timeout) * time.Second,
-		RetryPolicy: &workflow.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    10 * time.Second,
-			MaximumAttempts:    2,
-		},
+	var err error
+	if input.Parameters, err = withDefaultParameters(ctx, input.Parameters); err != nil {
+		return nil, err
+	}
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{
+		Strategy: TimeoutBoundedAttempt,
+		Timeout:  time.Duration(input.Timeout) * time.Second,
+	}, &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    10 * time.Second,
+		// Deadlocks are retried quickly (see classifySQLError); constraint
+		// violations fail the operation immediately
+		MaximumAttempts:        5,
+		NonRetryableErrorTypes: []string{ErrTypeConstraintViolation},
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return nil, err
 	}
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 
 	result := make(map[string]interface{})
+	step := "start"
+	defer auditCancellation(ctx, input.Target, func() string { return step }).record()
+
+	// Sensitive operations wait for a human decision first. Versioned so
+	// executions started before the gate existed replay unchanged.
+	if workflow.GetVersion(ctx, "approval-gate", workflow.DefaultVersion, 1) == 1 {
+		step = "approval"
+		approval, err := awaitApproval(ctx, input)
+		if approval.Required {
+			result["approval"] = approval
+		}
+		if err != nil {
+			result["status"] = approval.Status
+			result["error"] = err.Error()
+			return result, err
+		}
+	}
+
+	// Mutations of the same target are serialized across workflows
+	if mutatingOperation(input.Operation) && workflow.GetVersion(ctx, "resource-lock", workflow.DefaultVersion, 1) == 1 {
+		var requested time.Duration
+		if seconds, ok := input.Parameters.Int64("lock_lease_seconds"); ok && seconds > 0 {
+			requested = time.Duration(seconds) * time.Second
+		}
+		lease := lockLeaseFor(activityOptions, requested)
+		step = "lock"
+		lock, err := acquireResourceLock(ctx, input.Target, lease, defaultLockWait)
+		if err != nil {
+			logger.Error("❌ Unable to lock target", "target", input.Target, "error", err)
+			result["status"] = "failed"
+			result["error"] = err.Error()
+			return result, err
+		}
+		defer lock.release(ctx)
+	}
 
 	// Execute database operation
+	step = "database_operation"
 	var dbResult DatabaseOperationResult
-	err := workflow.ExecuteActivity(ctx, DatabaseOperation, DatabaseOperationInput{
+	err = workflow.ExecuteActivity(ctx, activities.DatabaseOperation, DatabaseOperationInput{
 		Operation:  input.Operation,
 		Target:     input.Target,
 		Parameters: input.Parameters,
@@ -174,6 +872,11 @@ func SystemOperationWorkflow(ctx workflow.Context, input SystemOperationInput) (
 	return result, nil
 }
 
+// maxHighPerformanceChunks caps how many chunks HighPerformanceWorkflow
+// processes at once. Every chunk is an activity and its events in the
+// run's history, and a worker runs 10 activities at a time.
+const maxHighPerformanceChunks = 32
+
 // HighPerformanceInput represents input for high-performance workflows
 type HighPerformanceInput struct {
 	TaskType    string                 `json:"task_type"`
@@ -181,44 +884,104 @@ type HighPerformanceInput struct {
 	Data        map[string]interface{} `json:"data"`
 }
 
-// HighPerformanceWorkflow handles high-performance parallel processing
+// HighPerformanceWorkflow handles high-performance parallel processing. The
+// dataset is split into Concurrency chunks, at most
+// maxHighPerformanceChunks, each processed by its own ProcessLargeDataset
+// activity at the same time, and their results are combined.
 func HighPerformanceWorkflow(ctx workflow.Context, input HighPerformanceInput) (map[string]interface{}, error) {
+	input.Data = mapOrEmpty(input.Data)
+
 	logger := workflow.GetLogger(ctx)
 	logger.Info("⚡ Starting high-performance workflow", "task_type", input.TaskType, "concurrency", input.Concurrency)
+	logWorkflowInput(ctx, input)
 
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 5 * time.Minute,
-		RetryPolicy: &workflow.RetryPolicy{
-			InitialInterval:    500 * time.Millisecond,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    5 * time.Second,
-			MaximumAttempts:    3,
-		},
+	activityOptions, err := newActivityOptions(ActivityTimeoutConfig{Strategy: TimeoutBoundedAttempt, Timeout: 5 * time.Minute}, &temporal.RetryPolicy{
+		InitialInterval:    500 * time.Millisecond,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    5 * time.Second,
+		MaximumAttempts:    3,
+	})
+	if err != nil {
+		logger.Error("❌ Invalid activity options", "error", err)
+		return nil, err
 	}
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	defer auditCancellation(ctx, "high_perf_"+input.TaskType, func() string { return "process_dataset" }).record()
 
-	// Execute parallel processing
-	var processResult ProcessLargeDatasetResult
-	err := workflow.ExecuteActivity(ctx, ProcessLargeDataset, ProcessLargeDatasetInput{
-		DatasetID:   "high_perf_" + input.TaskType,
-		ProcessType: "parallel",
-		Parameters: map[string]interface{}{
-			"concurrency": input.Concurrency,
-			"data":        input.Data,
-		},
-	}).Get(ctx, &processResult)
-	if err != nil {
-		logger.Error("❌ High-performance processing failed", "error", err)
-		return nil, err
+	// Split the dataset into one chunk per unit of concurrency and process
+	// them in parallel. Versioned so runs started with a single activity
+	// replay unchanged: version 1 added the chunks and version 2 the cap.
+	version := workflow.GetVersion(ctx, "high-perf-fan-out", workflow.DefaultVersion, 2)
+	chunks := 1
+	if version >= 1 && input.Concurrency > 1 {
+		chunks = input.Concurrency
+	}
+	if version >= 2 && chunks > maxHighPerformanceChunks {
+		logger.Warn("⚠️ Concurrency capped", "concurrency", input.Concurrency, "chunks", maxHighPerformanceChunks)
+		chunks = maxHighPerformanceChunks
+	}
+	chunkCtx, cancelChunks := workflow.WithCancel(ctx)
+	defer cancelChunks()
+	selector := workflow.NewSelector(ctx)
+	futures := make([]workflow.Future, chunks)
+	chunkResults := make([]ProcessLargeDatasetResult, chunks)
+	var chunkErr error
+	for i := range futures {
+		i := i
+		futures[i] = workflow.ExecuteActivity(chunkCtx, activities.ProcessLargeDataset, ProcessLargeDatasetInput{
+			DatasetID:   "high_perf_" + input.TaskType,
+			ProcessType: ProcessTypeParallel,
+			Parameters: Parameters{
+				"concurrency": input.Concurrency,
+				"data":        input.Data,
+				"chunk":       i,
+				"chunks":      chunks,
+			},
+		})
+		selector.AddFuture(futures[i], func(f workflow.Future) {
+			if err := f.Get(ctx, &chunkResults[i]); err != nil && chunkErr == nil {
+				chunkErr = fmt.Errorf("chunk %d: %w", i, err)
+			}
+		})
+	}
+	// The first chunk to fail fails the run and cancels the others
+	for range futures {
+		selector.Select(ctx)
+		if chunkErr != nil {
+			logger.Error("❌ High-performance processing failed", "error", chunkErr)
+			return nil, chunkErr
+		}
+	}
+
+	// Chunks run side by side, so the run takes as long as the slowest
+	itemsProcessed := 0
+	var throughput float64
+	var processingTime time.Duration
+	results := make([]map[string]interface{}, chunks)
+	for i, chunk := range chunkResults {
+		itemsProcessed += chunk.ItemsProcessed
+		throughput += chunk.Metrics.Throughput
+		if d, err := time.ParseDuration(chunk.ProcessingTime); err == nil && d > processingTime {
+			processingTime = d
+		}
+		results[i] = chunk.Results
+	}
+
+	// Throughput is the mean items per second of a chunk. Runs started
+	// before the chunks reported items processed divided by 60 instead.
+	throughput /= float64(chunks)
+	if version == workflow.DefaultVersion {
+		throughput = float64(itemsProcessed) / 60.0
 	}
 
 	result := map[string]interface{}{
-		"status":           "completed",
-		"items_processed":  processResult.ItemsProcessed,
-		"processing_time":  processResult.ProcessingTime,
-		"throughput":       float64(processResult.ItemsProcessed) / 60.0, // items per minute
-		"results":          processResult.Results,
-		"message":          "High-performance processing completed",
+		"status":          "completed",
+		"chunks":          chunks,
+		"items_processed": itemsProcessed,
+		"processing_time": processingTime.String(),
+		"throughput":      throughput,
+		"results":         results,
+		"message":         "High-performance processing completed",
 	}
 
 	logger.Info("✅ High-performance workflow completed", "result", result)
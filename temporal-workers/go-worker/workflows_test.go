@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestHighPerformanceWorkflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		// legacy runs as if started before the chunks were added
+		legacy         bool
+		wantChunks     int
+		wantThroughput float64
+	}{
+		{name: "single activity", concurrency: 1, wantChunks: 1, wantThroughput: 100},
+		{name: "four chunks", concurrency: 4, wantChunks: 4, wantThroughput: 250},
+		{name: "capped", concurrency: 100, wantChunks: maxHighPerformanceChunks, wantThroughput: 1650},
+		{name: "started before the chunks", concurrency: 4, legacy: true, wantChunks: 1, wantThroughput: 100.0 / 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			if tt.legacy {
+				env.OnGetVersion("high-perf-fan-out", workflow.DefaultVersion, 2).Return(workflow.DefaultVersion)
+			}
+
+			// Chunk i processes 100*(i+1) items at 100*(i+1) a second
+			var mu sync.Mutex
+			var calls []int
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+					c, _ := input.Parameters.Int64("chunk")
+					chunk := int(c)
+					mu.Lock()
+					calls = append(calls, chunk)
+					mu.Unlock()
+					n := 100 * (chunk + 1)
+					return ProcessLargeDatasetResult{
+						ItemsProcessed: n,
+						ProcessingTime: "1s",
+						Metrics:        ProcessingMetrics{Throughput: float64(n)},
+						Results:        map[string]interface{}{"chunk": chunk},
+					}, nil
+				})
+
+			env.ExecuteWorkflow(HighPerformanceWorkflow, HighPerformanceInput{TaskType: "etl", Concurrency: tt.concurrency})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result struct {
+				Chunks         int                      `json:"chunks"`
+				ItemsProcessed int                      `json:"items_processed"`
+				Throughput     float64                  `json:"throughput"`
+				Results        []map[string]interface{} `json:"results"`
+			}
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			sort.Ints(calls)
+			wantCalls := make([]int, tt.wantChunks)
+			wantResults := make([]map[string]interface{}, tt.wantChunks)
+			wantItems := 0
+			for i := range wantCalls {
+				wantCalls[i] = i
+				wantResults[i] = map[string]interface{}{"chunk": float64(i)}
+				wantItems += 100 * (i + 1)
+			}
+			assert.Equal(t, wantCalls, calls)
+			assert.Equal(t, tt.wantChunks, result.Chunks)
+			assert.Equal(t, wantItems, result.ItemsProcessed)
+			assert.InDelta(t, tt.wantThroughput, result.Throughput, 1e-9)
+			assert.Equal(t, wantResults, result.Results)
+		})
+	}
+}
+
+func TestComplexProcessingWorkflowOrder(t *testing.T) {
+	metadata := map[string]interface{}{"owner": "data-team"}
+	tests := []struct {
+		name string
+		// order is the order the run started with
+		order        processingOrder
+		wantOrder    []string
+		wantHealth   string
+		wantRouting  RoutingDecision
+		wantMetadata map[string]interface{}
+	}{
+		{
+			name:         "health first",
+			order:        orderHealthFirst,
+			wantOrder:    []string{"FetchDatasetMetadata", "SystemHealthCheck", "ProcessLargeDataset", "OptimizePerformance", "CacheOperation", "AuditLog"},
+			wantHealth:   "pre_processing",
+			wantRouting:  RoutingDecision{RequestedProcessType: ProcessTypeBatch, ProcessType: ProcessTypeParallel, HealthScore: 0.95, Threshold: defaultHealthThreshold, Reason: "healthy"},
+			wantMetadata: metadata,
+		},
+		{
+			name:         "started before health routing",
+			order:        orderScatterGather,
+			wantOrder:    []string{"ProcessLargeDataset", "SystemHealthCheck", "FetchDatasetMetadata", "OptimizePerformance", "CacheOperation", "AuditLog"},
+			wantHealth:   "processing",
+			wantRouting:  requestedRouting(ProcessTypeBatch),
+			wantMetadata: metadata,
+		},
+		{
+			name:        "started before the scatter-gather",
+			order:       orderSequential,
+			wantOrder:   []string{"ProcessLargeDataset", "OptimizePerformance", "SystemHealthCheck", "CacheOperation", "AuditLog"},
+			wantHealth:  "post_processing",
+			wantRouting: requestedRouting(ProcessTypeBatch),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			switch tt.order {
+			case orderSequential:
+				env.OnGetVersion("scatter-gather", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+			case orderScatterGather:
+				env.OnGetVersion("health-routing", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+			}
+
+			var mu sync.Mutex
+			var order []string
+			env.SetOnActivityStartedListener(func(info *activity.Info, ctx context.Context, args converter.EncodedValues) {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, info.ActivityType.Name)
+			})
+			var healthCheck string
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input SystemHealthCheckInput) (SystemHealthCheckResult, error) {
+					healthCheck = input.CheckType
+					return SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil
+				})
+			// The metadata fetch only finishes once processing has started,
+			// so it fails unless the two run at the same time
+			processing := make(chan struct{})
+			var startProcessing sync.Once
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input FetchDatasetMetadataInput) (FetchDatasetMetadataResult, error) {
+					select {
+					case <-processing:
+						return FetchDatasetMetadataResult{Metadata: metadata}, nil
+					case <-time.After(5 * time.Second):
+						return FetchDatasetMetadataResult{}, errors.New("processing never started")
+					}
+				})
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input ProcessLargeDatasetInput) (ProcessLargeDatasetResult, error) {
+					startProcessing.Do(func() { close(processing) })
+					return ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil
+				})
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(
+				OptimizePerformanceResult{PerformanceGain: 0.25, OptimizationApplied: true}, nil)
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:     CurrentComplexProcessingInputVersion,
+				DatasetID:   "ds-1",
+				ProcessType: ProcessTypeBatch,
+				Priority:    PriorityNormal,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result ComplexProcessingResult
+			require.NoError(t, env.GetWorkflowResult(&result))
+
+			assert.Equal(t, tt.wantOrder, order)
+			assert.Equal(t, tt.wantHealth, healthCheck)
+			assert.Equal(t, tt.wantRouting, result.Routing)
+			assert.Equal(t, tt.wantMetadata, result.Metadata)
+			assert.Equal(t, 10, result.ProcessedItems)
+			assert.Equal(t, 0.25, result.OptimizationGain)
+		})
+	}
+}
+
+// firstTaskHistory is the history of a ComplexProcessingWorkflow run whose
+// first workflow task recorded versions (change ID to version) and
+// scheduled activities, in that order, with none of them finished yet
+func firstTaskHistory(t *testing.T, versions [][2]interface{}, activities ...string) *historypb.History {
+	dc := converter.GetDefaultDataConverter()
+	input, err := dc.ToPayloads(ComplexProcessingInput{
+		Version:     CurrentComplexProcessingInputVersion,
+		DatasetID:   "ds-1",
+		ProcessType: ProcessTypeParallel,
+		Priority:    PriorityNormal,
+	})
+	require.NoError(t, err)
+	taskQueue := &taskqueuepb.TaskQueue{Name: "go-workers"}
+
+	events := []*historypb.HistoryEvent{
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, Attributes: &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{
+			WorkflowExecutionStartedEventAttributes: &historypb.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &commonpb.WorkflowType{Name: "ComplexProcessingWorkflow"},
+				TaskQueue:    taskQueue,
+				Input:        input,
+			},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_SCHEDULED, Attributes: &historypb.HistoryEvent_WorkflowTaskScheduledEventAttributes{
+			WorkflowTaskScheduledEventAttributes: &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: taskQueue},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_STARTED, Attributes: &historypb.HistoryEvent_WorkflowTaskStartedEventAttributes{
+			WorkflowTaskStartedEventAttributes: &historypb.WorkflowTaskStartedEventAttributes{ScheduledEventId: 2},
+		}},
+		{EventType: enumspb.EVENT_TYPE_WORKFLOW_TASK_COMPLETED, Attributes: &historypb.HistoryEvent_WorkflowTaskCompletedEventAttributes{
+			WorkflowTaskCompletedEventAttributes: &historypb.WorkflowTaskCompletedEventAttributes{ScheduledEventId: 2, StartedEventId: 3},
+		}},
+	}
+	for _, version := range versions {
+		changeID, err := dc.ToPayloads(version[0])
+		require.NoError(t, err)
+		number, err := dc.ToPayloads(version[1])
+		require.NoError(t, err)
+		events = append(events, &historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_MARKER_RECORDED, Attributes: &historypb.HistoryEvent_MarkerRecordedEventAttributes{
+			MarkerRecordedEventAttributes: &historypb.MarkerRecordedEventAttributes{
+				MarkerName:                   "Version",
+				Details:                      map[string]*commonpb.Payloads{"change-id": changeID, "version": number},
+				WorkflowTaskCompletedEventId: 4,
+			},
+		}})
+		// GetVersion also adds the version to the TemporalChangeVersion
+		// search attribute
+		changeVersions, err := dc.ToPayload([]string{fmt.Sprintf("%s-%d", version[0], version[1])})
+		require.NoError(t, err)
+		events = append(events, &historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_UPSERT_WORKFLOW_SEARCH_ATTRIBUTES, Attributes: &historypb.HistoryEvent_UpsertWorkflowSearchAttributesEventAttributes{
+			UpsertWorkflowSearchAttributesEventAttributes: &historypb.UpsertWorkflowSearchAttributesEventAttributes{
+				SearchAttributes:             &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{"TemporalChangeVersion": changeVersions}},
+				WorkflowTaskCompletedEventId: 4,
+			},
+		}})
+	}
+	// Activity IDs are the IDs of the events scheduling them
+	for _, name := range activities {
+		id := strconv.Itoa(len(events) + 1)
+		events = append(events, &historypb.HistoryEvent{EventType: enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED, Attributes: &historypb.HistoryEvent_ActivityTaskScheduledEventAttributes{
+			ActivityTaskScheduledEventAttributes: &historypb.ActivityTaskScheduledEventAttributes{
+				ActivityId: id, ActivityType: &commonpb.ActivityType{Name: name}, TaskQueue: taskQueue, WorkflowTaskCompletedEventId: 4,
+			},
+		}})
+	}
+	for i, event := range events {
+		event.EventId = int64(i + 1)
+	}
+	return &historypb.History{Events: events}
+}
+
+// TestReplayComplexProcessingOrder replays runs started with each step
+// order against the current code, which must schedule exactly what each
+// run's first workflow task scheduled
+func TestReplayComplexProcessingOrder(t *testing.T) {
+	scatterGather := [2]interface{}{"scatter-gather", 1}
+	healthRouting := [2]interface{}{"health-routing", 1}
+	tests := []struct {
+		name       string
+		versions   [][2]interface{}
+		activities []string
+		wantErr    string
+	}{
+		{name: "sequential", activities: []string{"ProcessLargeDataset"}},
+		{
+			name:       "scatter-gather",
+			versions:   [][2]interface{}{scatterGather},
+			activities: []string{"ProcessLargeDataset", "SystemHealthCheck", "FetchDatasetMetadata"},
+		},
+		{
+			name:       "health first",
+			versions:   [][2]interface{}{scatterGather, healthRouting},
+			activities: []string{"FetchDatasetMetadata", "SystemHealthCheck"},
+		},
+		{
+			name:       "scatter-gather without its version",
+			activities: []string{"ProcessLargeDataset", "SystemHealthCheck", "FetchDatasetMetadata"},
+			wantErr:    "TMPRL1100", // nondeterminism
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer, err := newReplayer()
+			require.NoError(t, err)
+
+			err = replayer.ReplayWorkflowHistory(nil, firstTaskHistory(t, tt.versions, tt.activities...))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// updateCallbacks records the outcome of an update sent in a test
+type updateCallbacks struct {
+	accepted bool
+	rejected error
+	result   interface{}
+	err      error
+}
+
+func (u *updateCallbacks) Accept()          { u.accepted = true }
+func (u *updateCallbacks) Reject(err error) { u.rejected = err }
+func (u *updateCallbacks) Complete(success interface{}, err error) {
+	u.result, u.err = success, err
+}
+
+func TestComplexProcessingWorkflowUpdateParameters(t *testing.T) {
+	tests := []struct {
+		name          string
+		update        Parameters
+		wantRejected  string
+		wantAlgorithm string
+	}{
+		{name: "merged before optimizing", update: Parameters{"algorithm": "fast_path"}, wantAlgorithm: "fast_path"},
+		{name: "empty", update: Parameters{}, wantRejected: "parameter update must not be empty", wantAlgorithm: "advanced_optimization"},
+		{name: "blank algorithm", update: Parameters{"algorithm": ""}, wantRejected: "algorithm must be a non-empty string", wantAlgorithm: "advanced_optimization"},
+		{name: "bad predicate", update: Parameters{optimizeWhenParameter: "throughput >"}, wantRejected: `predicate "throughput >"`, wantAlgorithm: "advanced_optimization"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			env.OnActivity(activities.SystemHealthCheck, mock.Anything, mock.Anything).Return(SystemHealthCheckResult{Status: "healthy", HealthScore: 0.95}, nil)
+			env.OnActivity(activities.FetchDatasetMetadata, mock.Anything, mock.Anything).Return(FetchDatasetMetadataResult{}, nil)
+			env.OnActivity(activities.ProcessLargeDataset, mock.Anything, mock.Anything).Return(ProcessLargeDatasetResult{ItemsProcessed: 10, ProcessingTime: "1s"}, nil)
+			var algorithm string
+			env.OnActivity(activities.OptimizePerformance, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input OptimizePerformanceInput) (OptimizePerformanceResult, error) {
+					algorithm = input.Algorithm
+					return OptimizePerformanceResult{PerformanceGain: 0.25, OptimizationApplied: true}, nil
+				})
+			env.OnActivity(activities.CacheOperation, mock.Anything, mock.Anything).Return(nil)
+			env.OnActivity(activities.AuditLog, mock.Anything, mock.Anything).Return(nil)
+
+			callbacks := &updateCallbacks{}
+			env.RegisterDelayedCallback(func() {
+				env.UpdateWorkflow(UpdateParametersName, "update-1", callbacks, tt.update)
+			}, 0)
+
+			env.ExecuteWorkflow(ComplexProcessingWorkflow, ComplexProcessingInput{
+				Version:     CurrentComplexProcessingInputVersion,
+				DatasetID:   "ds-1",
+				ProcessType: ProcessTypeBatch,
+				Priority:    PriorityNormal,
+			})
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			if tt.wantRejected != "" {
+				require.Error(t, callbacks.rejected)
+				assert.Contains(t, callbacks.rejected.Error(), tt.wantRejected)
+				assert.False(t, callbacks.accepted)
+			} else {
+				require.NoError(t, callbacks.rejected)
+				assert.True(t, callbacks.accepted)
+				require.NoError(t, callbacks.err)
+				merged, ok := callbacks.result.(Parameters)
+				require.True(t, ok, "want the merged parameters, got %T", callbacks.result)
+				assert.Equal(t, tt.update["algorithm"], merged["algorithm"])
+			}
+			assert.Equal(t, tt.wantAlgorithm, algorithm)
+		})
+	}
+}
+
+// TestSystemOperationWorkflowNilParameters checks a run started without
+// parameters passes its activity a map it can write to, whether or not the
+// operation succeeds
+func TestSystemOperationWorkflowNilParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{name: "completed"},
+		{name: "operation fails", err: temporal.NewNonRetryableApplicationError("relation does not exist", ErrTypeConstraintViolation, nil), wantErr: "relation does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(activities)
+			var got DatabaseOperationInput
+			env.OnActivity(activities.DatabaseOperation, mock.Anything, mock.Anything).Return(
+				func(ctx context.Context, input DatabaseOperationInput) (DatabaseOperationResult, error) {
+					got = input
+					return DatabaseOperationResult{}, tt.err
+				})
+
+			env.ExecuteWorkflow(SystemOperationWorkflow, SystemOperationInput{Operation: "select", Target: "users"})
+			require.True(t, env.IsWorkflowCompleted())
+
+			assert.NotNil(t, got.Parameters)
+			if tt.wantErr != "" {
+				require.Error(t, env.GetWorkflowError())
+				assert.Contains(t, env.GetWorkflowError().Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, env.GetWorkflowError())
+			var result map[string]interface{}
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, "completed", result["status"])
+		})
+	}
+}